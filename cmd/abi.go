@@ -0,0 +1,269 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/spf13/cobra"
+
+	jarviscommon "github.com/tranvictor/jarvis/common"
+	"github.com/tranvictor/jarvis/config"
+	"github.com/tranvictor/jarvis/ui"
+	"github.com/tranvictor/jarvis/util"
+	"github.com/tranvictor/jarvis/util/abistore"
+	"github.com/tranvictor/jarvis/util/addrbook"
+)
+
+var abiRefreshForce bool
+
+var abiCmd = &cobra.Command{
+	Use:   "abi",
+	Short: "Manage the ABIs jarvis decodes contracts with",
+	Long: `abi manages the ABIs jarvis keeps for the contracts it reads, calls and
+decodes txs of, one store per network under ~/.jarvis/abis/<network>/.
+
+ABIs are fetched from the block explorer of the network the first time a
+contract needs one and kept with where and when they were fetched. A
+pinned ABI is never replaced by a fetch. An ABI set with 'jarvis abi set',
+e.g. from a Foundry artifact, is pinned and overrides the explorer's for
+the address everywhere: contract read and tx, info, msig and tx decoding,
+proxies included.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := config.SetNetwork(config.NetworkString); err != nil {
+			return err
+		}
+		appUI.Info("Network: %s", config.Network().GetName())
+		return nil
+	},
+}
+
+var abiListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the stored ABIs of the network",
+	Run: func(cmd *cobra.Command, args []string) {
+		network := config.Network()
+		entries, err := util.ABIStore().List(network.GetName())
+		if err != nil {
+			appUI.Error("Couldn't list the abis: %s", err)
+			os.Exit(1)
+		}
+		if len(entries) == 0 {
+			appUI.Warn("No abi stored on %s yet.", network.GetName())
+			return
+		}
+		book := addrbook.NewDefault(network)
+		t := &ui.Table{Headers: []string{"Address", "Origin", "Source", "Fetched", "Pinned", "Functions"}}
+		for _, e := range entries {
+			functions := "invalid"
+			if a, err := util.GetABIFromBytes(e.ABI); err == nil {
+				functions = fmt.Sprintf("%d", len(a.Methods))
+			}
+			t.AddRow(
+				ui.TC(jarviscommon.VerboseAddress(book.Resolve(e.Address))),
+				abiOriginCell(e),
+				ui.TC(e.Source),
+				ui.TC(e.FetchedAt.Local().Format("2006-01-02 15:04")),
+				ui.TC(abiPinnedText(e)),
+				ui.TC(functions),
+			)
+		}
+		appUI.PrintTable(t)
+	},
+}
+
+var abiShowCmd = &cobra.Command{
+	Use:   "show <address>",
+	Short: "Show the stored ABI of a contract",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		address := abiAddress(args[0])
+		e, found := util.StoredABI(address, config.Network())
+		if !found {
+			appUI.Warn("No abi of %s is stored on %s.", address, config.Network().GetName())
+			return
+		}
+		printABIEntry(e)
+
+		a, err := util.GetABIFromBytes(e.ABI)
+		if err != nil {
+			appUI.Error("The abi is invalid: %s", err)
+			os.Exit(1)
+		}
+		t := &ui.Table{Headers: []string{"Kind", "Signature"}}
+		for _, m := range sortedSignatures(a) {
+			t.AddRow(ui.TC(m[0]), ui.TC(m[1]))
+		}
+		appUI.PrintTable(t)
+	},
+}
+
+var abiSetCmd = &cobra.Command{
+	Use:   "set <address> <file, url or address>",
+	Short: "Set the ABI of a contract over the block explorer's",
+	Long: `Sets the ABI of <address> to the one read from an ABI file, a Foundry or
+Hardhat artifact, an url or another contract whose ABI is stored or on the
+block explorer. The ABI is pinned and used instead of the explorer's, also
+when the contract is a proxy, until it is removed with 'jarvis abi rm'.`,
+	Example: `  jarvis abi set 0xVault out/Vault.sol/Vault.json
+  jarvis abi set vault 0xVaultImplementation -k base`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		address := abiAddress(args[0])
+		source := args[1]
+		if addr, _, err := util.GetAddressFromString(source); err == nil {
+			source = addr
+		}
+		e, err := util.SetABI(address, source, config.Network())
+		if err != nil {
+			appUI.Error("Couldn't set the abi of %s: %s", address, err)
+			os.Exit(1)
+		}
+		appUI.Success("The abi of %s is set from %s", address, e.Source)
+	},
+}
+
+var abiPinCmd = &cobra.Command{
+	Use:   "pin <address>",
+	Short: "Keep the stored ABI of a contract from being replaced",
+	Long: `Pins the stored ABI of <address> so it isn't replaced when jarvis fetches
+the ABI again, e.g. after the block explorer changed it. The ABI is fetched
+first if none is stored.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		setABIPinned(args[0], true)
+	},
+}
+
+var abiUnpinCmd = &cobra.Command{
+	Use:   "unpin <address>",
+	Short: "Let the stored ABI of a contract be replaced again",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		setABIPinned(args[0], false)
+	},
+}
+
+var abiRefreshCmd = &cobra.Command{
+	Use:   "refresh <address>",
+	Short: "Fetch the ABI of a contract again from the block explorer",
+	Long: `Fetches the ABI of <address> from the block explorer again and stores it.
+A pinned ABI, which a set ABI is, is only replaced with --force.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		address := abiAddress(args[0])
+		stop := appUI.Spinner(fmt.Sprintf("Fetching the abi of %s...", address))
+		e, err := util.RefreshABI(address, config.Network(), abiRefreshForce)
+		stop()
+		if err != nil {
+			appUI.Error("Couldn't refresh the abi of %s: %s", address, err)
+			os.Exit(1)
+		}
+		appUI.Success("The abi of %s is refreshed from %s", address, e.Source)
+	},
+}
+
+var abiRmCmd = &cobra.Command{
+	Use:   "rm <address>",
+	Short: "Remove the stored ABI of a contract",
+	Long: `Removes the stored ABI of <address>. The block explorer's is fetched again
+the next time the contract needs one.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		address := abiAddress(args[0])
+		if err := util.ABIStore().Remove(config.Network().GetName(), address); err != nil {
+			appUI.Error("%s", err)
+			os.Exit(1)
+		}
+		appUI.Success("Removed the abi of %s", address)
+	},
+}
+
+// abiAddress resolves an address, an address book name or an ENS name.
+func abiAddress(str string) string {
+	address, _, err := util.GetAddressFromString(str)
+	if err != nil {
+		appUI.Error("Couldn't find address %s: %s", str, err)
+		os.Exit(1)
+	}
+	return address
+}
+
+func setABIPinned(str string, pinned bool) {
+	address := abiAddress(str)
+	e, err := util.PinABI(address, config.Network(), pinned)
+	if err != nil {
+		appUI.Error("%s", err)
+		os.Exit(1)
+	}
+	appUI.Success("The abi of %s (%s, from %s) is %s", address, e.Origin, e.Source, abiPinnedText(e))
+}
+
+func abiPinnedText(e *abistore.Entry) string {
+	if e.Pinned {
+		return "pinned"
+	}
+	return "unpinned"
+}
+
+func abiOriginCell(e *abistore.Entry) ui.TableCell {
+	switch e.Origin {
+	case abistore.FromUser:
+		return ui.TCS(e.Origin, ui.SeveritySuccess)
+	case abistore.FromCache:
+		// carried over from a cache that didn't tell the networks apart
+		return ui.TCS(e.Origin, ui.SeverityWarn)
+	}
+	return ui.TC(e.Origin)
+}
+
+func printABIEntry(e *abistore.Entry) {
+	appUI.Info("Contract: %s", jarviscommon.VerboseAddress(addrbook.NewDefault(config.Network()).Resolve(e.Address)))
+	appUI.Info("Origin: %s", e.Origin)
+	appUI.Info("Source: %s", e.Source)
+	appUI.Info("Fetched: %s", e.FetchedAt.Local().Format("2006-01-02 15:04:05"))
+	appUI.Info("Pinned: %t", e.Pinned)
+	if e.Overrides() {
+		appUI.Info("Overrides the block explorer's abi")
+	}
+}
+
+// sortedSignatures returns the kind and signature of the functions, events
+// and errors of a, by kind and signature.
+func sortedSignatures(a *abi.ABI) [][2]string {
+	sigs := [][2]string{}
+	for _, m := range a.Methods {
+		kind := "function"
+		if m.IsConstant() {
+			kind = "view"
+		}
+		sigs = append(sigs, [2]string{kind, m.Sig})
+	}
+	for _, e := range a.Events {
+		sigs = append(sigs, [2]string{"event", e.Sig})
+	}
+	for _, e := range a.Errors {
+		sigs = append(sigs, [2]string{"error", e.Sig})
+	}
+	sort.Slice(sigs, func(i, j int) bool {
+		if sigs[i][0] != sigs[j][0] {
+			return sigs[i][0] < sigs[j][0]
+		}
+		return strings.ToLower(sigs[i][1]) < strings.ToLower(sigs[j][1])
+	})
+	return sigs
+}
+
+func init() {
+	abiRefreshCmd.Flags().BoolVar(&abiRefreshForce, "force", false, "replace the abi even if it is pinned")
+	abiCmd.AddCommand(abiListCmd)
+	abiCmd.AddCommand(abiShowCmd)
+	abiCmd.AddCommand(abiSetCmd)
+	abiCmd.AddCommand(abiPinCmd)
+	abiCmd.AddCommand(abiUnpinCmd)
+	abiCmd.AddCommand(abiRefreshCmd)
+	abiCmd.AddCommand(abiRmCmd)
+	rootCmd.AddCommand(abiCmd)
+}
@@ -0,0 +1,328 @@
+package cmd
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/spf13/cobra"
+
+	cmdutil "github.com/tranvictor/jarvis/cmd/util"
+	jarviscommon "github.com/tranvictor/jarvis/common"
+	"github.com/tranvictor/jarvis/config"
+	"github.com/tranvictor/jarvis/networks"
+	"github.com/tranvictor/jarvis/safe"
+	"github.com/tranvictor/jarvis/ui"
+	"github.com/tranvictor/jarvis/util"
+	utilreader "github.com/tranvictor/jarvis/util/reader"
+)
+
+var (
+	deriveCount           uint64
+	deriveSafeOwners      string
+	deriveSafeThreshold   uint64
+	deriveSafeSaltNonce   string
+	deriveSafeVersion     string
+	deriveSafeFactory     string
+	deriveSafeSingleton   string
+	deriveSafeL2          string
+	deriveSafeFallback    string
+	deriveSafeInitializer string
+)
+
+var deriveAddressCmd = &cobra.Command{
+	Use:   "derive",
+	Short: "Compute where a contract will be deployed: CREATE, CREATE2 and Safe proxies",
+	Long: `Compute the address a contract will be deployed at before it is, and
+tell whether something is already deployed there on the selected network.
+
+  jarvis addr derive create <deployer> [nonce]
+  jarvis addr derive create2 <factory> <salt> <init code | init code hash | artifact>
+  jarvis addr derive safe --owners <a,b,c> --threshold 2 --salt-nonce 0`,
+}
+
+var deriveCreateCmd = &cobra.Command{
+	Use:   "create <deployer> [nonce]",
+	Short: "Address of the contracts deployed by <deployer> with plain CREATE, from its next nonce by default",
+	Args:  cobra.RangeArgs(1, 2),
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return cmdutil.CommonNetworkPreprocess(appUI, cmd, args)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		tc, _ := cmdutil.TxContextFrom(cmd)
+
+		deployer, _, err := util.GetAddressFromString(args[0])
+		if err != nil {
+			appUI.Error("Couldn't find address %s: %s", args[0], err)
+			return
+		}
+
+		var nonce uint64
+		if len(args) == 2 {
+			nonce, err = strconv.ParseUint(args[1], 10, 64)
+			if err != nil {
+				appUI.Error("Invalid nonce %s: %s", args[1], err)
+				return
+			}
+		} else {
+			nonce, err = tc.Reader.GetPendingNonce(deployer)
+			if err != nil {
+				appUI.Error("Couldn't get the nonce of %s: %s", deployer, err)
+				return
+			}
+			appUI.Info("Next nonce of %s: %d", deployer, nonce)
+		}
+		if deriveCount == 0 {
+			deriveCount = 1
+		}
+
+		t := &ui.Table{Headers: []string{"Nonce", "Address", "Code"}}
+		for i := uint64(0); i < deriveCount; i++ {
+			addr := crypto.CreateAddress(common.HexToAddress(deployer), nonce+i).Hex()
+			t.AddRow(ui.TC(fmt.Sprintf("%d", nonce+i)), ui.TC(addr), codeCell(tc.Reader, addr))
+		}
+		appUI.PrintTable(t)
+	},
+}
+
+var deriveCreate2Cmd = &cobra.Command{
+	Use:   "create2 <factory> <salt> <init code | init code hash | artifact>",
+	Short: "Address a factory deploys init code at with CREATE2",
+	Long: `Compute the CREATE2 address keccak256(0xff ++ factory ++ salt ++
+keccak256(init code))[12:].
+
+The salt is hex of up to 32 bytes, left padded, or a decimal number. The
+last param is the keccak256 hash of the init code (32 bytes of hex), the
+init code itself as hex, or a Foundry/Hardhat artifact file or URL. The init
+code of a contract is its creation bytecode followed by the abi encoded
+constructor params, so an artifact only gives the right address for a
+constructor without params.
+
+Use "deterministic" as the factory for the deterministic deployer jarvis
+contract deploy --create2 goes through.`,
+	Args: cobra.ExactArgs(3),
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return cmdutil.CommonNetworkPreprocess(appUI, cmd, args)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		tc, _ := cmdutil.TxContextFrom(cmd)
+
+		factory := jarviscommon.DeterministicDeployer.Hex()
+		if !strings.EqualFold(args[0], "deterministic") {
+			var err error
+			factory, _, err = util.GetAddressFromString(args[0])
+			if err != nil {
+				appUI.Error("Couldn't find address %s: %s", args[0], err)
+				return
+			}
+		}
+		salt, err := jarviscommon.ParseSalt(args[1])
+		if err != nil {
+			appUI.Error("Invalid salt: %s", err)
+			return
+		}
+		codeHash, err := initCodeHash(args[2])
+		if err != nil {
+			appUI.Error("%s", err)
+			return
+		}
+
+		addr := crypto.CreateAddress2(common.HexToAddress(factory), salt, codeHash).Hex()
+		appUI.Info("Factory: %s", jarviscommon.VerboseAddress(util.GetJarvisAddress(factory, config.Network())))
+		appUI.Info("Salt: 0x%s", common.Bytes2Hex(salt[:]))
+		appUI.Info("Init code hash: 0x%s", common.Bytes2Hex(codeHash))
+		t := &ui.Table{Headers: []string{"Address", "Code"}}
+		t.AddRow(ui.TC(addr), codeCell(tc.Reader, addr))
+		appUI.PrintTable(t)
+	},
+}
+
+var deriveSafeCmd = &cobra.Command{
+	Use:   "safe",
+	Short: "Counterfactual address of a Safe created with SafeProxyFactory.createProxyWithNonce",
+	Long: `Compute the address a Safe gets when it is created through
+SafeProxyFactory.createProxyWithNonce(singleton, initializer, saltNonce),
+the way Safe{Wallet} creates them.
+
+The initializer is Safe.setup(owners, threshold, 0x0, 0x, fallbackHandler,
+0x0, 0, 0x0) unless --initializer is given. The canonical deployment of
+--safe-version is used for the factory, the singleton and the fallback
+handler; each can be overridden. Like Safe{Wallet}, the L2 singleton is used
+on every chain but Ethereum mainnet unless --l2 says otherwise.
+
+The proxy bytecode is read from the factory, so the factory must be
+deployed on the selected network.`,
+	Args: cobra.NoArgs,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return cmdutil.CommonNetworkPreprocess(appUI, cmd, args)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		tc, _ := cmdutil.TxContextFrom(cmd)
+
+		d, found := safe.Deployments[deriveSafeVersion]
+		if !found {
+			appUI.Error("Unknown --safe-version %s, known: 1.4.1, 1.3.0", deriveSafeVersion)
+			return
+		}
+		factory, err := addressOrDefault(deriveSafeFactory, d.ProxyFactory)
+		if err != nil {
+			appUI.Error("Invalid --factory: %s", err)
+			return
+		}
+		useL2 := config.Network().GetChainID() != networks.EthereumMainnet.GetChainID()
+		if deriveSafeL2 != "" {
+			if useL2, err = strconv.ParseBool(deriveSafeL2); err != nil {
+				appUI.Error("Invalid --l2: %s", err)
+				return
+			}
+		}
+		singletonDefault := d.Singleton
+		if useL2 {
+			singletonDefault = d.SingletonL2
+		}
+		singleton, err := addressOrDefault(deriveSafeSingleton, singletonDefault)
+		if err != nil {
+			appUI.Error("Invalid --singleton: %s", err)
+			return
+		}
+		saltNonce, ok := new(big.Int).SetString(deriveSafeSaltNonce, 0)
+		if !ok || saltNonce.Sign() < 0 {
+			appUI.Error("Invalid --salt-nonce %s", deriveSafeSaltNonce)
+			return
+		}
+
+		var initializer []byte
+		if deriveSafeInitializer != "" {
+			initializer = common.FromHex(deriveSafeInitializer)
+		} else {
+			fallback, err := addressOrDefault(deriveSafeFallback, d.FallbackHandler)
+			if err != nil {
+				appUI.Error("Invalid --fallback-handler: %s", err)
+				return
+			}
+			owners := []common.Address{}
+			for _, o := range strings.Split(deriveSafeOwners, ",") {
+				if strings.TrimSpace(o) == "" {
+					continue
+				}
+				addr, _, err := util.GetAddressFromString(strings.TrimSpace(o))
+				if err != nil {
+					appUI.Error("Couldn't find owner %s: %s", o, err)
+					return
+				}
+				owners = append(owners, common.HexToAddress(addr))
+			}
+			initializer, err = safe.SetupData(owners, deriveSafeThreshold, fallback)
+			if err != nil {
+				appUI.Error("%s", err)
+				return
+			}
+			appUI.Info("Owners (%d of %d):", deriveSafeThreshold, len(owners))
+			for _, o := range owners {
+				appUI.Info("  %s", jarviscommon.VerboseAddress(util.GetJarvisAddress(o.Hex(), config.Network())))
+			}
+			appUI.Info("Fallback handler: %s", fallback.Hex())
+		}
+
+		proxyCode, err := proxyCreationCode(tc.Reader, factory)
+		if err != nil {
+			appUI.Error("Couldn't read the proxy bytecode from the factory %s: %s", factory.Hex(), err)
+			return
+		}
+
+		addr := safe.PredictAddress(factory, singleton, proxyCode, initializer, saltNonce).Hex()
+		appUI.Info("Factory: %s", factory.Hex())
+		appUI.Info("Singleton: %s", singleton.Hex())
+		appUI.Info("Salt nonce: %s", saltNonce)
+		t := &ui.Table{Headers: []string{"Safe", "Code"}}
+		t.AddRow(ui.TC(addr), codeCell(tc.Reader, addr))
+		appUI.PrintTable(t)
+	},
+}
+
+// initCodeHash reads the last param of derive create2.
+func initCodeHash(param string) ([]byte, error) {
+	if strings.HasPrefix(param, "0x") {
+		code := common.FromHex(param)
+		if len(code) == 32 {
+			return code, nil
+		}
+		if len(code) == 0 {
+			return nil, fmt.Errorf("%s is neither an init code nor its hash", param)
+		}
+		return crypto.Keccak256(code), nil
+	}
+	art, err := util.ReadContractArtifact(param)
+	if err != nil {
+		return nil, err
+	}
+	if art.ABI != nil && len(art.ABI.Constructor.Inputs) > 0 {
+		appUI.Warn("The constructor takes params, the address is only right with the init code including them.")
+	}
+	return crypto.Keccak256(art.Bytecode), nil
+}
+
+func proxyCreationCode(r utilreader.Reader, factory common.Address) ([]byte, error) {
+	a := safe.GetSafeSetupABI()
+	data, err := r.ReadContractToBytes(-1, utilreader.DEFAULT_ADDRESS, factory.Hex(), a, "proxyCreationCode")
+	if err != nil {
+		return nil, err
+	}
+	values, err := a.Methods["proxyCreationCode"].Outputs.UnpackValues(data)
+	if err != nil {
+		return nil, err
+	}
+	code, _ := values[0].([]byte)
+	if len(code) == 0 {
+		return nil, fmt.Errorf("the factory returned no bytecode")
+	}
+	return code, nil
+}
+
+func addressOrDefault(s string, def common.Address) (common.Address, error) {
+	if s == "" {
+		return def, nil
+	}
+	addr, _, err := util.GetAddressFromString(s)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return common.HexToAddress(addr), nil
+}
+
+// codeCell tells whether addr already has code on the current network.
+func codeCell(r utilreader.Reader, addr string) ui.TableCell {
+	code, err := r.GetCode(addr)
+	if err != nil {
+		return ui.TCS(fmt.Sprintf("couldn't check: %s", err), ui.SeverityWarn)
+	}
+	if len(code) == 0 {
+		return ui.TCS("none, free", ui.SeveritySuccess)
+	}
+	if _, ok := jarviscommon.DelegateOf(code); ok {
+		return ui.TCS("EIP-7702 delegation", ui.SeverityWarn)
+	}
+	return ui.TCS(fmt.Sprintf("deployed (%d bytes)", len(code)), ui.SeverityCritical)
+}
+
+func init() {
+	deriveCreateCmd.Flags().Uint64Var(&deriveCount, "count", 1, "How many consecutive nonces to derive from")
+
+	deriveSafeCmd.Flags().StringVar(&deriveSafeOwners, "owners", "", "Comma separated owners, addresses or names from the address book")
+	deriveSafeCmd.Flags().Uint64Var(&deriveSafeThreshold, "threshold", 1, "Number of owner signatures a tx needs")
+	deriveSafeCmd.Flags().StringVar(&deriveSafeSaltNonce, "salt-nonce", "0", "saltNonce of createProxyWithNonce")
+	deriveSafeCmd.Flags().StringVar(&deriveSafeVersion, "safe-version", "1.4.1", "Safe release whose canonical factory, singleton and fallback handler are used: 1.4.1 or 1.3.0")
+	deriveSafeCmd.Flags().StringVar(&deriveSafeFactory, "factory", "", "SafeProxyFactory, overrides the one of --safe-version")
+	deriveSafeCmd.Flags().StringVar(&deriveSafeSingleton, "singleton", "", "Safe singleton (mastercopy), overrides the one of --safe-version")
+	deriveSafeCmd.Flags().StringVar(&deriveSafeL2, "l2", "", "true to use the SafeL2 singleton, false for the Safe one. Default: SafeL2 everywhere but Ethereum mainnet")
+	deriveSafeCmd.Flags().StringVar(&deriveSafeFallback, "fallback-handler", "", "Fallback handler, overrides the one of --safe-version")
+	deriveSafeCmd.Flags().StringVar(&deriveSafeInitializer, "initializer", "", "Hex initializer calldata to use instead of the setup built from --owners and --threshold")
+
+	deriveAddressCmd.AddCommand(deriveCreateCmd)
+	deriveAddressCmd.AddCommand(deriveCreate2Cmd)
+	deriveAddressCmd.AddCommand(deriveSafeCmd)
+	addressCmd.AddCommand(deriveAddressCmd)
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/spf13/cobra"
+
+	cmdutil "github.com/tranvictor/jarvis/cmd/util"
+	jarviscommon "github.com/tranvictor/jarvis/common"
+	"github.com/tranvictor/jarvis/config"
+	"github.com/tranvictor/jarvis/networks"
+	"github.com/tranvictor/jarvis/util"
+)
+
+var broadcastCmd = &cobra.Command{
+	Use:   "broadcast <file|rawhex>",
+	Short: "Broadcast a tx signed offline and wait for it to be mined",
+	Long: `Push a signed tx to the network's nodes and monitor it until it is mined.
+
+The argument is either a tx file signed by jarvis sign, or a raw signed tx in
+0x-prefixed hex (the form eth_sendRawTransaction takes). When --network is not
+given, the network is taken from the file, or from the chain id of the raw tx.`,
+	Args: cobra.ExactArgs(1),
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 0 && !cmd.Flags().Changed("network") {
+			if _, chainID, err := readSignedTxArg(args[0]); err == nil && chainID != 0 {
+				if n, err := networks.GetNetworkByID(chainID); err == nil {
+					config.NetworkString = n.GetName()
+				}
+			}
+		}
+		return cmdutil.CommonSendPreprocess(appUI, cmd, args)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		tc, _ := cmdutil.TxContextFrom(cmd)
+		if tc.Reader == nil || tc.Broadcaster == nil {
+			appUI.Error("Couldn't establish connection to node.")
+			return
+		}
+
+		tx, chainID, err := readSignedTxArg(args[0])
+		if err != nil {
+			appUI.Error("%s", err)
+			return
+		}
+		if chainID != 0 && chainID != config.Network().GetChainID() {
+			appUI.Error(
+				"The tx is signed for chain %d but the network is %s (chain %d).",
+				chainID, config.Network().GetName(), config.Network().GetChainID(),
+			)
+			return
+		}
+
+		from, err := jarviscommon.GetSignerAddressFromTx(tx, new(big.Int).SetUint64(config.Network().GetChainID()))
+		if err != nil {
+			appUI.Error("Couldn't recover the signer of the tx: %s", err)
+			return
+		}
+		if err := cmdutil.PromptTxConfirmation(
+			appUI, tc.Analyzer,
+			util.GetJarvisAddress(from.Hex(), config.Network()),
+			tx, nil, config.Network(),
+		); err != nil {
+			appUI.Error("Aborted!")
+			return
+		}
+
+		if broadcasted, err := cmdutil.HandlePostSign(appUI, tx, tc.Reader, tc.Analyzer, nil, tc.Broadcaster); err != nil && !broadcasted {
+			appUI.Error("Failed to broadcast the tx: %s", err)
+		}
+	},
+}
+
+// readSignedTxArg accepts either a signed tx file or a raw signed tx hex and
+// returns the decoded tx with the chain id it is bound to (0 for a pre-EIP-155
+// legacy tx, which carries none).
+func readSignedTxArg(arg string) (*types.Transaction, uint64, error) {
+	if strings.HasPrefix(arg, "0x") {
+		if _, statErr := os.Stat(arg); statErr != nil {
+			tx, err := util.DecodeSignedTx(arg)
+			if err != nil {
+				return nil, 0, err
+			}
+			if !tx.Protected() {
+				return tx, 0, nil
+			}
+			return tx, tx.ChainId().Uint64(), nil
+		}
+	}
+
+	f, err := util.ReadTxFile(arg)
+	if err != nil {
+		return nil, 0, err
+	}
+	tx, err := f.SignedTransaction()
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", arg, err)
+	}
+	return tx, f.ChainID, nil
+}
+
+func init() {
+	broadcastCmd.Flags().BoolVarP(&config.DontWaitToBeMined, "no-wait", "F", false, "Will not wait the tx to be mined.")
+	broadcastCmd.Flags().BoolVarP(&config.RetryBroadcast, "retry-broadcast", "r", false, "Retry broadcasting as soon as possible.")
+	broadcastCmd.Flags().StringVarP(&config.JSONOutputFile, "json-output", "o", "", "write the broadcasted transaction info to json file")
+	rootCmd.AddCommand(broadcastCmd)
+}
@@ -18,7 +18,9 @@ func networkFlag() string {
 
 func AddCommonFlagsToTransactionalCmds(c *cobra.Command) {
 	c.PersistentFlags().
-		Float64VarP(&config.GasPrice, "gasprice", "p", 0, "Gas price in gwei. If default value is used, we will use https://ethgasstation.info/ to get fast gas price. The gas price to be used in the tx is gas price + extra gas price")
+		Float64VarP(&config.GasPrice, "gasprice", "p", 0, "Gas price in gwei. If default value is used, we will use the --speed tier of the fee suggestions from the node's fee history. The gas price to be used in the tx is gas price + extra gas price")
+	c.PersistentFlags().
+		StringVar(&config.GasSpeed, "speed", "normal", "Fee tier used when --gasprice or --tipgas isn't set: slow, normal or fast. See jarvis network gas")
 	c.PersistentFlags().
 		Float64VarP(&config.TipGas, "tipgas", "s", 0, "tip in gwei, will be use in dynamic fee tx, default value get from node.")
 	c.PersistentFlags().
@@ -41,6 +43,20 @@ func AddCommonFlagsToTransactionalCmds(c *cobra.Command) {
 		BoolVarP(&config.RetryBroadcast, "retry-broadcast", "r", false, "Retry broadcasting as soon as possible.")
 	c.PersistentFlags().
 		BoolVarP(&config.ForceLegacy, "legacy-tx", "L", false, "Force using legacy transaction")
+	c.PersistentFlags().
+		BoolVar(&config.AccessList, "access-list", false, "Attach the access list computed by the node (eth_createAccessList) to the tx when it lowers the gas used. Dynamic fee txs only")
+	c.PersistentFlags().
+		BoolVar(&config.PrivateTx, "private", false, "Send the tx only to the private relays of the network (see jarvis node relay) so it never reaches the public mempool. Waits until the tx is mined or --relay-blocks blocks passed")
+	c.PersistentFlags().
+		Uint64Var(&config.RelayBlocks, "relay-blocks", 25, "With --private, how many blocks the relays have to get the tx mined before it is given up")
+	c.PersistentFlags().
+		Uint64Var(&config.ScheduleAtBlock, "at-block", 0, "Hold the tx back and broadcast it so it lands in this block at the earliest")
+	c.PersistentFlags().
+		StringVar(&config.ScheduleAtTime, "at-time", "", "Hold the tx back and broadcast it at this time, RFC3339 (e.g. 2025-01-02T15:04:05Z) or unix timestamp")
+	c.PersistentFlags().
+		StringVar(&config.ScheduleWhen, "when", "", `Hold the tx back and broadcast it once a contract read holds, e.g. "vault.paused() == false" or "<token>.balanceOf(<you>) >= 1e18". Keystore txs are signed right away, hw wallet txs are signed when it holds. The tx is dropped if its nonce gets used in the meantime`)
 	c.PersistentFlags().
 		StringVarP(&config.JSONOutputFile, "json-output", "o", "", "write signed transaction info to json file. It will not create or write the file if the transaction wasn't signed")
+	c.PersistentFlags().
+		StringVar(&config.UnsignedTxFile, "unsigned-out", "", "Don't sign the tx, write it unsigned to this file instead so it can be signed on an offline machine with jarvis sign and pushed with jarvis broadcast")
 }
@@ -164,6 +164,7 @@ var txContractCmd = &cobra.Command{
 			gasLimit, err = reader.EstimateExactGas(tc.From, tc.To, 0, tc.Value, data)
 			if err != nil {
 				appUI.Error("Couldn't estimate gas limit: %s", err)
+				cmdutil.ShowRevertReason(appUI, err, tc.To, map[string]*abi.ABI{strings.ToLower(tc.To): a})
 				return
 			}
 		}
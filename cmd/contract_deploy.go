@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/spf13/cobra"
+
+	cmdutil "github.com/tranvictor/jarvis/cmd/util"
+	jarviscommon "github.com/tranvictor/jarvis/common"
+	"github.com/tranvictor/jarvis/config"
+	"github.com/tranvictor/jarvis/util"
+)
+
+var deployContractCmd = &cobra.Command{
+	Use:   "deploy <artifact|bytecode file|url>",
+	Short: "Deploy a contract from a Foundry/Hardhat artifact or a bytecode file",
+	Long: `Deploy a contract. The contract is read from a Foundry artifact
+(out/<File>.sol/<Contract>.json), a Hardhat artifact
+(artifacts/contracts/<File>.sol/<Contract>.json), or a file holding the hex
+creation bytecode, either local or at an http(s) URL. The constructor params
+are prompted for, or taken from --prefills, with the ABI of the artifact or
+the one given with --abi.
+
+With --create2 the contract is deployed through the deterministic deployer
+(` + jarviscommon.DeterministicDeployer.Hex() + `) with --salt, so the
+same artifact, constructor params and salt give the same address on every
+chain the deployer exists on.
+
+The address the contract will be created at is shown before signing and
+checked once the tx is mined.`,
+	Args:             cobra.ExactArgs(1),
+	TraverseChildren: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// the artifact isn't a contract address, the tx creates one
+		return cmdutil.CommonTxPreprocess(appUI, cmd, nil)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		tc, _ := cmdutil.TxContextFrom(cmd)
+
+		reader := tc.Reader
+		if reader == nil {
+			appUI.Error("Couldn't connect to blockchain.")
+			return
+		}
+
+		art, err := util.ReadContractArtifact(args[0])
+		if err != nil {
+			appUI.Error("Couldn't read the contract: %s", err)
+			return
+		}
+		a := art.ABI
+		knownABI := a != nil || config.CustomABI != ""
+		if config.CustomABI != "" {
+			abiStr, err := util.ReadCustomABIString("", config.CustomABI, config.Network())
+			if err != nil {
+				appUI.Error("Couldn't read the abi: %s", err)
+				return
+			}
+			if a, err = util.GetABIFromString(abiStr); err != nil {
+				appUI.Error("Couldn't decode the abi: %s", err)
+				return
+			}
+		}
+		if a == nil {
+			appUI.Warn("No abi in %s and no --abi given, deploying without constructor params.", args[0])
+			a = &abi.ABI{}
+		}
+		if art.Name != "" {
+			appUI.Info("Contract: %s", art.Name)
+		}
+		if tc.Value.Sign() > 0 && knownABI && !a.Constructor.Payable {
+			appUI.Error("The constructor isn't payable, it can't take --amount.")
+			return
+		}
+
+		var salt [32]byte
+		var deployer string
+		if config.Create2 {
+			salt, err = jarviscommon.ParseSalt(config.Create2Salt)
+			if err != nil {
+				appUI.Error("Invalid --salt: %s", err)
+				return
+			}
+			deployer = jarviscommon.DeterministicDeployer.Hex()
+			code, err := reader.GetCode(deployer)
+			if err != nil {
+				appUI.Error("Couldn't check the deterministic deployer: %s", err)
+				return
+			}
+			if len(code) == 0 {
+				appUI.Error("The deterministic deployer %s isn't deployed on %s.", deployer, config.Network().GetName())
+				return
+			}
+		}
+
+		// the CREATE2 address depends on the constructor params
+		createAddress := crypto.CreateAddress(jarviscommon.HexToAddress(tc.From), tc.Nonce).Hex()
+		promptAddress := createAddress
+		if config.Create2 {
+			promptAddress = "a deterministic address"
+		}
+
+		initCode := art.Bytecode
+		if len(a.Constructor.Inputs) > 0 {
+			params, err := cmdutil.PromptTxData(
+				appUI,
+				tc.Analyzer,
+				promptAddress,
+				cmdutil.CONSTRUCTOR_METHOD_INDEX,
+				tc.PrefillParams,
+				tc.PrefillMode,
+				a,
+				nil,
+				config.Network(),
+			)
+			if err != nil {
+				appUI.Error("Couldn't pack constructor data: %s", err)
+				return
+			}
+			initCode = append(append([]byte{}, art.Bytecode...), params...)
+		}
+
+		to, data, predicted := "", initCode, createAddress
+		if config.Create2 {
+			to = deployer
+			data = jarviscommon.DeterministicDeployData(salt, initCode)
+			predicted = jarviscommon.Create2Address(jarviscommon.DeterministicDeployer, salt, initCode).Hex()
+			code, err := reader.GetCode(predicted)
+			if err != nil {
+				appUI.Error("Couldn't check %s: %s", predicted, err)
+				return
+			}
+			if len(code) > 0 {
+				appUI.Error("A contract is already deployed at %s with this bytecode, params and salt.", predicted)
+				return
+			}
+			appUI.Info("Salt: 0x%s", common.Bytes2Hex(salt[:]))
+		}
+		appUI.Critical("The contract will be deployed at: %s", predicted)
+
+		gasLimit := config.GasLimit
+		if gasLimit == 0 {
+			gasLimit, err = reader.EstimateExactGas(tc.From, to, 0, tc.Value, data)
+			if err != nil {
+				appUI.Error("Couldn't estimate gas limit: %s", err)
+				if to != "" {
+					cmdutil.ShowRevertReason(appUI, err, to, nil)
+				}
+				return
+			}
+		}
+
+		var tx *types.Transaction
+		if config.Create2 {
+			tx = jarviscommon.BuildExactTx(
+				tc.TxType,
+				tc.Nonce,
+				to,
+				tc.Value,
+				gasLimit+config.ExtraGasLimit,
+				tc.GasPrice+config.ExtraGasPrice,
+				tc.TipGas+config.ExtraTipGas,
+				data,
+				config.Network().GetChainID(),
+			)
+		} else {
+			tx = jarviscommon.BuildContractCreationTx(
+				tc.TxType,
+				tc.Nonce,
+				tc.Value,
+				gasLimit+config.ExtraGasLimit,
+				tc.GasPrice+config.ExtraGasPrice,
+				tc.TipGas+config.ExtraTipGas,
+				data,
+				config.Network().GetChainID(),
+			)
+		}
+
+		customABIs := map[string]*abi.ABI{
+			strings.ToLower(predicted): a,
+		}
+		broadcasted, err := cmdutil.SignAndBroadcast(
+			appUI, tc.FromAcc, tx, customABIs,
+			reader, tc.Analyzer, nil, tc.Broadcaster,
+		)
+		if err != nil && !broadcasted {
+			appUI.Error("Failed to proceed after signing the tx: %s. Aborted.", err)
+			return
+		}
+		if !broadcasted || config.DontWaitToBeMined {
+			return
+		}
+
+		code, err := reader.GetCode(predicted)
+		if err != nil {
+			appUI.Warn("Couldn't check the code at %s: %s", predicted, err)
+			return
+		}
+		if len(code) == 0 {
+			appUI.Error("There is no code at %s, the deployment failed.", predicted)
+			return
+		}
+		appUI.Success("Contract deployed at %s", predicted)
+	},
+}
+
+func init() {
+	AddCommonFlagsToTransactionalCmds(deployContractCmd)
+	deployContractCmd.PersistentFlags().StringVarP(&config.PrefillStr, "prefills", "I", "", "Prefill constructor params string. Each param is separated by | char. If the param is \"?\", user input will be prompted.")
+	deployContractCmd.PersistentFlags().StringVarP(&config.CustomABI, "abi", "c", "", "ABI of the contract, to prompt for the constructor params when the artifact has none. It can be either an address, a path to an abi file or an url to an abi.")
+	deployContractCmd.PersistentFlags().BoolVar(&config.Create2, "create2", false, "Deploy through the deterministic deployer with CREATE2 so the address only depends on the bytecode, the constructor params and --salt")
+	deployContractCmd.PersistentFlags().StringVar(&config.Create2Salt, "salt", "0", "CREATE2 salt, hex of up to 32 bytes or a decimal number. Only used with --create2")
+	deployContractCmd.Flags().StringVarP(&config.RawValue, "amount", "v", "0", "Amount of eth to send to a payable constructor. It is in eth value, not wei.")
+	deployContractCmd.MarkFlagRequired("from")
+	contractCmd.AddCommand(deployContractCmd)
+}
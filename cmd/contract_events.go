@@ -0,0 +1,425 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/spf13/cobra"
+
+	cmdutil "github.com/tranvictor/jarvis/cmd/util"
+	"github.com/tranvictor/jarvis/config"
+	"github.com/tranvictor/jarvis/networks"
+	"github.com/tranvictor/jarvis/txanalyzer"
+	"github.com/tranvictor/jarvis/util"
+	utilreader "github.com/tranvictor/jarvis/util/reader"
+)
+
+var (
+	eventsName       string
+	eventsFromBlock  int64
+	eventsToBlock    int64
+	eventsBlockRange uint64
+	eventsCSVOutput  string
+	eventsFollow     bool
+)
+
+var eventsContractCmd = &cobra.Command{
+	Use:   "events <address>",
+	Short: "List the events a contract emitted, decoded",
+	Long: `List the logs of one event of a contract, decoded with its ABI.
+
+The event is picked from the ABI, or given with --event as its name, its
+signature or its index in the list. Its indexed params can be filtered on with
+--prefills, one value per indexed param separated by |. "*" or an empty value
+matches anything and "?" prompts for the value, e.g.
+
+	jarvis contract events usdc --event Transfer -I "* | 0xabc..."
+
+lists the USDC transfers to 0xabc...
+
+The logs are queried from --from-block, the block the contract was created at
+by default, to --to-block, the latest block by default, --block-range blocks
+at a time. The range is shrunk when the nodes refuse to answer that many
+logs at once.
+
+With --follow the events are listed as they are mined, from --from-block or
+the next block, until Ctrl+C. They are pushed by a ws:// or wss:// node of
+the network when there is one (jarvis node add <network> <name> wss://...),
+and polled for otherwise. Blocks mined while the connection is down are
+caught up on once it is back.`,
+	Args:             cobra.ExactArgs(1),
+	TraverseChildren: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return cmdutil.CommonFunctionCallPreprocess(appUI, cmd, args)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		tc, _ := cmdutil.TxContextFrom(cmd)
+		network := config.Network()
+
+		ethReader, err := util.EthReader(network)
+		if err != nil {
+			appUI.Error("Couldn't connect to blockchain: %s", err)
+			return
+		}
+
+		a, err := tc.Resolver.ConfigToABI(tc.To, false, config.CustomABI, network)
+		if err != nil {
+			appUI.Error("Couldn't get abi for %s: %s", tc.To, err)
+			return
+		}
+		event, err := promptEvent(a, eventsName)
+		if err != nil {
+			appUI.Error("%s", err)
+			return
+		}
+		appUI.Info("Event: %s", event.Sig)
+
+		topics, err := promptEventTopics(event, tc.PrefillParams, tc.PrefillMode)
+		if err != nil {
+			appUI.Error("%s", err)
+			return
+		}
+
+		result := contractEventsJSON{Event: event.Sig, Logs: []contractEventJSON{}}
+		if config.JSONOutputFile != "" {
+			defer result.Write(config.JSONOutputFile)
+		}
+		if eventsCSVOutput != "" {
+			defer result.WriteCSV(eventsCSVOutput, event)
+		}
+
+		customABIs := map[string]*abi.ABI{strings.ToLower(tc.To): a}
+		onLog := func(l types.Log) error {
+			if l.Removed {
+				appUI.Warn("Log %d of tx %s in block %d was removed by a reorg.", l.Index, l.TxHash.Hex(), l.BlockNumber)
+				result.remove(l)
+				return nil
+			}
+			logResult, err := tc.Analyzer.AnalyzeLog(nil, customABIs, &l)
+			if err != nil {
+				appUI.Warn("Couldn't decode log %d of tx %s: %s", l.Index, l.TxHash.Hex(), err)
+				return nil
+			}
+			appUI.Info("Block %d, tx %s", l.BlockNumber, l.TxHash.Hex())
+			display := util.DisplayLog(appUI, len(result.Logs), logResult)
+			result.Logs = append(result.Logs, contractEventJSON{
+				Block:    l.BlockNumber,
+				TxHash:   l.TxHash.Hex(),
+				LogIndex: l.Index,
+				Log:      display,
+			})
+			return nil
+		}
+		q := ethereum.FilterQuery{
+			Addresses: []common.Address{common.HexToAddress(tc.To)},
+			Topics:    append([][]common.Hash{{event.ID}}, topics...),
+		}
+
+		if eventsFollow {
+			followEvents(ethReader, q, onLog)
+			appUI.Success("Found %d %s events.", len(result.Logs), event.Name)
+			return
+		}
+
+		from, to, err := eventsBlockSpan(ethReader, tc.To)
+		if err != nil {
+			appUI.Error("%s", err)
+			return
+		}
+		appUI.Info("Blocks: %d to %d", from, to)
+		err = utilreader.PageLogs(ethReader, q, from, to, eventsBlockRange, func(start, end uint64, logs []types.Log) error {
+			for _, l := range logs {
+				if err := onLog(l); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			appUI.Error("Couldn't get the logs: %s", err)
+			return
+		}
+		appUI.Success("Found %d %s events.", len(result.Logs), event.Name)
+	},
+}
+
+// followEvents reports the logs matching q as they are mined until the user
+// interrupts it, from --from-block when it is given.
+func followEvents(r *utilreader.EthReader, q ethereum.FilterQuery, onLog func(types.Log) error) {
+	from := uint64(eventsFromBlock)
+	if eventsFromBlock < 0 {
+		latest, err := r.CurrentBlock()
+		if err != nil {
+			appUI.Error("Couldn't get the latest block: %s", err)
+			return
+		}
+		from = latest + 1
+	}
+
+	follower := &utilreader.LogFollower{
+		Source:     r,
+		Subscriber: r.LogSubscriber(),
+		Query:      q,
+		MaxRange:   eventsBlockRange,
+		Logf:       appUI.Warn,
+	}
+	if follower.Subscriber == nil {
+		appUI.Info("No websocket node for %s, polling for new events every %s.", config.Network().GetName(), utilreader.DefaultFollowPollInterval)
+	}
+	appUI.Info("Following from block %d, press Ctrl+C to stop.", from)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := follower.Follow(ctx, from, onLog); err != nil {
+		appUI.Error("%s", err)
+	}
+}
+
+// promptEvent picks the event named by name, which is its name, signature or
+// index in the sorted event list, or lets the user choose one.
+func promptEvent(a *abi.ABI, name string) (*abi.Event, error) {
+	events := []abi.Event{}
+	for _, e := range a.Events {
+		events = append(events, e)
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("the abi has no events")
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Sig < events[j].Sig })
+
+	if name == "" {
+		appUI.Info("Events:")
+		for i, e := range events {
+			appUI.Info("%d. %s", i+1, e.Sig)
+		}
+		index := cmdutil.PromptIndex(appUI, fmt.Sprintf("Please choose event index [%d, %d]", 1, len(events)), 1, len(events))
+		return &events[index-1], nil
+	}
+	if index, err := strconv.Atoi(name); err == nil {
+		if index < 1 || index > len(events) {
+			return nil, fmt.Errorf("the contract doesn't have %d(th) event", index)
+		}
+		return &events[index-1], nil
+	}
+	for i := range events {
+		if events[i].Name == name || events[i].Sig == name {
+			return &events[i], nil
+		}
+	}
+	return nil, fmt.Errorf("the contract has no event %s", name)
+}
+
+// promptEventTopics builds the topic filter of the indexed params of event
+// from prefills, prompting for the ones that aren't prefilled. A nil topic
+// matches any value.
+func promptEventTopics(event *abi.Event, prefills []string, prefillMode bool) ([][]common.Hash, error) {
+	indexed, _ := txanalyzer.SplitEventArguments(event.Inputs)
+	if prefillMode && len(prefills) != len(indexed) {
+		return nil, fmt.Errorf("%s has %d indexed params, you must give a value or * for each", event.Name, len(indexed))
+	}
+	if len(indexed) == 0 {
+		return nil, nil
+	}
+
+	appUI.Info("Filter, empty or * to match any value:")
+	paramUI := appUI.Indent()
+	topics := make([][]common.Hash, len(indexed))
+	for i := 0; i < len(indexed); {
+		input := indexed[i]
+		paramUI.Info("%d. %s (%s)", i+1, input.Name, input.Type.String())
+
+		prefill := "?"
+		if prefillMode {
+			prefill = prefills[i]
+		}
+		if prefill == "?" {
+			prefill = strings.TrimSpace(paramUI.Ask(nil))
+		}
+		if prefill == "" || prefill == "*" {
+			i++
+			continue
+		}
+		value, err := cmdutil.PromptParam(paramUI, false, input, prefill, config.Network())
+		if err == nil {
+			var hashes [][]common.Hash
+			hashes, err = abi.MakeTopics([]interface{}{value})
+			if err == nil {
+				topics[i] = hashes[0]
+			}
+		}
+		if err != nil {
+			paramUI.Error("your input is not valid: %s", err)
+			if prefillMode && prefills[i] != "?" {
+				return nil, fmt.Errorf("your input is not valid: %w", err)
+			}
+			continue
+		}
+		i++
+	}
+	// trailing wildcards can be left out
+	for len(topics) > 0 && topics[len(topics)-1] == nil {
+		topics = topics[:len(topics)-1]
+	}
+	return topics, nil
+}
+
+// eventsBlockSpan is the block range to query the logs of contract in,
+// starting at the block the contract was created at when --from-block isn't
+// given and the explorer knows it.
+func eventsBlockSpan(r *utilreader.EthReader, contract string) (from, to uint64, err error) {
+	latest, err := r.CurrentBlock()
+	if err != nil {
+		return 0, 0, fmt.Errorf("couldn't get the latest block: %w", err)
+	}
+	to = latest
+	if eventsToBlock >= 0 && uint64(eventsToBlock) < latest {
+		to = uint64(eventsToBlock)
+	}
+
+	if eventsFromBlock >= 0 {
+		from = uint64(eventsFromBlock)
+	} else if created, err := creationBlock(r, contract); err == nil {
+		from = created
+	} else {
+		appUI.Warn("Couldn't find the block %s was created at, querying from block 0: %s", contract, err)
+	}
+	if from > to {
+		return 0, 0, fmt.Errorf("--from-block %d is after --to-block %d", from, to)
+	}
+	return from, to, nil
+}
+
+func creationBlock(r *utilreader.EthReader, contract string) (uint64, error) {
+	explorer, ok := networks.SourceVerifierOf(config.Network())
+	if !ok {
+		return 0, fmt.Errorf("the explorer of %s doesn't tell contract creations", config.Network().GetName())
+	}
+	creation, err := explorer.GetContractCreation(contract)
+	if err != nil {
+		return 0, err
+	}
+	txinfo, err := r.TxInfoFromHash(creation.TxHash)
+	if err != nil {
+		return 0, err
+	}
+	if txinfo.Receipt == nil {
+		return 0, fmt.Errorf("the creation tx %s isn't mined", creation.TxHash)
+	}
+	return txinfo.Receipt.BlockNumber.Uint64(), nil
+}
+
+type contractEventJSON struct {
+	Block    uint64          `json:"block"`
+	TxHash   string          `json:"tx_hash"`
+	LogIndex uint            `json:"log_index"`
+	Log      util.LogDisplay `json:"log"`
+}
+
+type contractEventsJSON struct {
+	Event string              `json:"event"`
+	Logs  []contractEventJSON `json:"logs"`
+}
+
+// remove drops the log a reorg removed.
+func (c *contractEventsJSON) remove(l types.Log) {
+	for i, e := range c.Logs {
+		if e.TxHash == l.TxHash.Hex() && e.LogIndex == l.Index {
+			c.Logs = append(c.Logs[:i], c.Logs[i+1:]...)
+			return
+		}
+	}
+}
+
+func (c *contractEventsJSON) Write(filepath string) {
+	data, _ := json.MarshalIndent(c, "", "  ")
+	if err := os.WriteFile(filepath, data, 0644); err != nil {
+		appUI.Error("Writing to json file failed: %s", err)
+	}
+}
+
+// WriteCSV writes one row per log with a column per param of event. Tuple
+// and array params are written as JSON.
+func (c *contractEventsJSON) WriteCSV(filepath string, event *abi.Event) {
+	indexed, nonIndexed := txanalyzer.SplitEventArguments(event.Inputs)
+	headers := []string{"block", "tx_hash", "log_index"}
+	for i, input := range indexed {
+		headers = append(headers, argumentName(input, fmt.Sprintf("topic%d", i+1)))
+	}
+	for i, input := range nonIndexed {
+		headers = append(headers, argumentName(input, fmt.Sprintf("data%d", i+1)))
+	}
+
+	rows := [][]string{headers}
+	for _, l := range c.Logs {
+		row := []string{strconv.FormatUint(l.Block, 10), l.TxHash, strconv.FormatUint(uint64(l.LogIndex), 10)}
+		for _, topic := range l.Log.Topics {
+			row = append(row, topic.Verbose.Text)
+		}
+		for _, param := range l.Log.Data {
+			row = append(row, csvParamValue(param))
+		}
+		rows = append(rows, row)
+	}
+
+	f, err := os.Create(filepath)
+	if err != nil {
+		appUI.Error("Writing to csv file failed: %s", err)
+		return
+	}
+	defer f.Close()
+	w := csv.NewWriter(f)
+	if err := w.WriteAll(rows); err != nil {
+		appUI.Error("Writing to csv file failed: %s", err)
+	}
+}
+
+func argumentName(input abi.Argument, fallback string) string {
+	if input.Name == "" {
+		return fallback
+	}
+	return input.Name
+}
+
+func csvParamValue(p util.ParamDisplay) string {
+	if p.Values != nil {
+		texts := []string{}
+		for _, v := range p.Values {
+			texts = append(texts, v.Text)
+		}
+		if len(texts) == 1 {
+			return texts[0]
+		}
+		data, _ := json.Marshal(texts)
+		return string(data)
+	}
+	if p.Tuples != nil {
+		data, _ := json.Marshal(p.Tuples)
+		return string(data)
+	}
+	data, _ := json.Marshal(p.Arrays)
+	return string(data)
+}
+
+func init() {
+	eventsContractCmd.PersistentFlags().StringVar(&eventsName, "event", "", "Event to list, its name, signature or index in the event list. Default: prompted")
+	eventsContractCmd.PersistentFlags().StringVarP(&config.PrefillStr, "prefills", "I", "", "Values to filter the indexed params on, separated by | char. \"*\" matches any value, \"?\" prompts for it.")
+	eventsContractCmd.PersistentFlags().Int64Var(&eventsFromBlock, "from-block", -1, "First block to list the events from. Default: the block the contract was created at")
+	eventsContractCmd.PersistentFlags().Int64Var(&eventsToBlock, "to-block", -1, "Last block to list the events to. Default: the latest block")
+	eventsContractCmd.PersistentFlags().Uint64Var(&eventsBlockRange, "block-range", 50000, "Most blocks to query the logs of at once. It shrinks when the nodes refuse that many")
+	eventsContractCmd.PersistentFlags().BoolVarP(&eventsFollow, "follow", "f", false, "Keep listing the events as they are mined, through a websocket node when there is one")
+	eventsContractCmd.PersistentFlags().StringVarP(&config.CustomABI, "abi", "c", "", "Custom abi. It can be either an address, a path to an abi file or an url to an abi. If it is an address, the abi of that address from etherscan will be queried.")
+	eventsContractCmd.PersistentFlags().StringVarP(&config.JSONOutputFile, "json-output", "o", "", "write the decoded events to json file")
+	eventsContractCmd.PersistentFlags().StringVar(&eventsCSVOutput, "csv-output", "", "write the decoded events to csv file, one row per event")
+	contractCmd.AddCommand(eventsContractCmd)
+}
@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+
+	cmdutil "github.com/tranvictor/jarvis/cmd/util"
+	jarviscommon "github.com/tranvictor/jarvis/common"
+	"github.com/tranvictor/jarvis/config"
+	"github.com/tranvictor/jarvis/ui"
+	"github.com/tranvictor/jarvis/util"
+	"github.com/tranvictor/jarvis/util/storage"
+)
+
+var (
+	storageLayoutSource string
+	storageContractName string
+)
+
+var storageContractCmd = &cobra.Command{
+	Use:   "storage <address> [variable...]",
+	Short: "Read the state variables of a contract by name from its storage layout",
+	Long: `Read the state variables of a contract straight from its storage, located
+with the storageLayout solc outputs for it.
+
+The layout is read from --layout: a Foundry artifact compiled with
+--extra-output storageLayout, a Hardhat build-info or solc output with the
+storageLayout output selected, a layout file, or the address of a contract
+verified on Sourcify. By default it is the layout Sourcify has of the
+contract itself; give the implementation's with --layout for a proxy.
+
+Variables are named like in solidity, with mapping keys and array indexes:
+
+	jarvis contract storage vault owner 'balances[0xabc...]' 'positions[alice][0].amount'
+
+Without variables every state variable is listed, and then more can be
+read one at a time. --block reads them as they were at a past block.`,
+	Args: cobra.MinimumNArgs(1),
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return cmdutil.CommonNetworkPreprocess(appUI, cmd, args)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		network := config.Network()
+		address, _, err := util.GetAddressFromString(args[0])
+		if err != nil {
+			appUI.Error("Couldn't find address %s: %s", args[0], err)
+			return
+		}
+		appUI.Info("Contract: %s", jarviscommon.VerboseAddress(util.GetJarvisAddress(address, network)))
+
+		source := storageLayoutSource
+		if source == "" {
+			source = address
+		}
+		layout, err := util.ReadStorageLayout(source, storageContractName, network)
+		if err != nil {
+			appUI.Error("%s", err)
+			appUI.Info("Give the layout with --layout, e.g. a Foundry artifact compiled with --extra-output storageLayout.")
+			return
+		}
+		if len(layout.Storage) == 0 {
+			appUI.Info("The contract has no state variables.")
+			return
+		}
+
+		r, err := util.EthReader(network)
+		if err != nil {
+			appUI.Error("Couldn't connect to blockchain: %s", err)
+			return
+		}
+		if config.AtBlock > 0 {
+			appUI.Info("At block: %d", config.AtBlock)
+		}
+		// packed variables share their slots
+		slots := map[common.Hash]common.Hash{}
+		read := func(slot common.Hash) (common.Hash, error) {
+			if word, found := slots[slot]; found {
+				return word, nil
+			}
+			data, err := r.StorageAt(config.AtBlock, address, slot.Hex())
+			if err != nil {
+				return common.Hash{}, fmt.Errorf("couldn't read slot %s: %w", slot.Hex(), err)
+			}
+			slots[slot] = common.BytesToHash(data)
+			return slots[slot], nil
+		}
+
+		values := []storage.Value{}
+		if config.JSONOutputFile != "" {
+			defer func() {
+				data, _ := json.MarshalIndent(values, "", "  ")
+				if err := os.WriteFile(config.JSONOutputFile, data, 0644); err != nil {
+					appUI.Error("Writing to json file failed: %s", err)
+				}
+			}()
+		}
+		show := func(paths ...string) {
+			table := &ui.Table{Headers: []string{"Variable", "Type", "Slot", "Value"}}
+			for _, path := range paths {
+				loc, err := layout.Resolve(path, storageKeyEncoder)
+				if err == nil {
+					var v storage.Value
+					if v, err = layout.Read(loc, read); err == nil {
+						values = append(values, v)
+						addStorageRows(table, v, "", v.Path)
+						continue
+					}
+				}
+				table.AddRow(ui.TC(path), ui.TC(""), ui.TC(""), ui.TCS(err.Error(), ui.SeverityError))
+			}
+			appUI.PrintTable(table)
+		}
+
+		if len(args) > 1 {
+			show(args[1:]...)
+			return
+		}
+		all := []string{}
+		for _, v := range layout.Storage {
+			all = append(all, v.Label)
+		}
+		show(all...)
+		if config.JSONOutputFile != "" {
+			return
+		}
+		for {
+			appUI.Info("Variable to read, e.g. %s, empty to stop:", storageExample(layout))
+			path := strings.TrimSpace(appUI.Ask(nil))
+			if path == "" {
+				return
+			}
+			show(path)
+		}
+	},
+}
+
+// storageKeyEncoder lets address keys be names from the address book.
+func storageKeyEncoder(keyType *storage.Type, key string) ([]byte, error) {
+	if strings.HasPrefix(keyType.Label, "address") || strings.HasPrefix(keyType.Label, "contract ") {
+		if addr, _, err := util.GetAddressFromString(key); err == nil {
+			key = addr
+		}
+	}
+	return storage.EncodeKey(keyType, key)
+}
+
+// storageExample is a path of the layout to hint at how to name variables.
+func storageExample(layout *storage.Layout) string {
+	for _, v := range layout.Storage {
+		switch layout.Types[v.Type].Encoding {
+		case "mapping":
+			return v.Label + "[<key>]"
+		case "dynamic_array":
+			return v.Label + "[0]"
+		}
+	}
+	return layout.Storage[0].Label
+}
+
+func addStorageRows(table *ui.Table, v storage.Value, indent, name string) {
+	slot := v.Slot
+	if n := common.HexToHash(v.Slot).Big(); n.Cmp(big.NewInt(1<<32)) < 0 {
+		slot = n.String()
+	}
+	if v.Offset > 0 {
+		slot = fmt.Sprintf("%s +%d", slot, v.Offset)
+	}
+	table.AddRow(ui.TC(indent+name), ui.TC(v.Type), ui.TC(slot), storageValueCell(v))
+	for _, m := range v.Members {
+		addStorageRows(table, m, indent+"  ", strings.TrimPrefix(m.Path, v.Path))
+	}
+}
+
+func storageValueCell(v storage.Value) ui.TableCell {
+	if (strings.HasPrefix(v.Type, "address") || strings.HasPrefix(v.Type, "contract ")) && common.IsHexAddress(v.Value) {
+		styled := util.StyledAddress(util.GetJarvisAddress(v.Value, config.Network()))
+		return ui.TCS(styled.Text, styled.Severity)
+	}
+	return ui.TC(v.Value)
+}
+
+func init() {
+	storageContractCmd.PersistentFlags().StringVar(&storageLayoutSource, "layout", "", "Storage layout: a Foundry artifact, a build-info, solc output or layout file, an url to one, or the address of a contract verified on Sourcify. Default: the contract's layout on Sourcify")
+	storageContractCmd.PersistentFlags().StringVar(&storageContractName, "contract", "", "Contract whose layout to use when --layout holds several, e.g. src/Vault.sol:Vault")
+	storageContractCmd.PersistentFlags().Int64VarP(&config.AtBlock, "block", "b", -1, "Specify the block to read at. Default value indicates reading at latest state of the chain.")
+	storageContractCmd.PersistentFlags().StringVarP(&config.JSONOutputFile, "json-output", "o", "", "write the values read to json file")
+	contractCmd.AddCommand(storageContractCmd)
+}
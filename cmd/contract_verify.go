@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+
+	cmdutil "github.com/tranvictor/jarvis/cmd/util"
+	jarviscommon "github.com/tranvictor/jarvis/common"
+	"github.com/tranvictor/jarvis/config"
+	"github.com/tranvictor/jarvis/networks"
+	"github.com/tranvictor/jarvis/util"
+	"github.com/tranvictor/jarvis/util/explorers"
+	utilreader "github.com/tranvictor/jarvis/util/reader"
+)
+
+var (
+	verifyInput           string
+	verifyContractName    string
+	verifyCompiler        string
+	verifyConstructorArgs string
+	verifyProxy           bool
+	verifyImplementation  string
+	verifyTimeout         time.Duration
+)
+
+var verifyContractCmd = &cobra.Command{
+	Use:   "verify <address>",
+	Short: "Verify the source of a contract on the network's explorer",
+	Long: `Submit the source of a deployed contract to the Etherscan-like explorer
+of the network and wait for the verdict.
+
+--input is the solc standard-JSON input the contract was compiled from, or
+a Hardhat/Foundry build-info file holding it. --contract is the fully
+qualified name of the contract, e.g. src/Vault.sol:Vault. The compiler
+version is taken from the build-info or the metadata of the deployed code
+unless --compiler is given, and the constructor params are read from the tx
+that created the contract unless --constructor-args is given.
+
+With --proxy the contract is also verified as a proxy so the explorer shows
+the ABI of its implementation on it. --proxy alone only does that.
+
+The explorer API key is read from the env var the network is configured
+with, e.g. ETHERSCAN_API_KEY.`,
+	Args: cobra.ExactArgs(1),
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return cmdutil.CommonNetworkPreprocess(appUI, cmd, args)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		tc, _ := cmdutil.TxContextFrom(cmd)
+		network := config.Network()
+
+		if verifyInput == "" && !verifyProxy {
+			appUI.Error("Nothing to verify, give the source with --input or use --proxy.")
+			return
+		}
+		verifier, ok := networks.SourceVerifierOf(network)
+		if !ok {
+			appUI.Error("The explorer of %s doesn't support source verification.", network.GetName())
+			return
+		}
+		if v := network.GetBlockExplorerAPIKeyVariableName(); v != "" && strings.TrimSpace(os.Getenv(v)) == "" {
+			appUI.Warn("%s is not set, explorers refuse verifications without an API key.", v)
+		}
+
+		address, _, err := util.GetAddressFromString(args[0])
+		if err != nil {
+			appUI.Error("Couldn't find address %s: %s", args[0], err)
+			return
+		}
+		appUI.Info("Contract: %s", jarviscommon.VerboseAddress(util.GetJarvisAddress(address, network)))
+
+		if verifyInput != "" {
+			req, err := buildVerifyRequest(tc.Reader, verifier, address)
+			if err != nil {
+				appUI.Error("%s", err)
+				return
+			}
+			guid, err := verifier.VerifySourceCode(*req)
+			if err != nil {
+				appUI.Error("%s", err)
+				return
+			}
+			appUI.Info("Submitted, waiting for the explorer (guid %s)...", guid)
+			if !reportVerification(verifier, guid, false) {
+				return
+			}
+		}
+
+		if verifyProxy {
+			guid, err := verifier.VerifyProxy(address, verifyImplementation)
+			if err != nil {
+				appUI.Error("%s", err)
+				return
+			}
+			appUI.Info("Proxy verification submitted, waiting for the explorer (guid %s)...", guid)
+			reportVerification(verifier, guid, true)
+		}
+	},
+}
+
+func reportVerification(verifier explorers.SourceVerifier, guid string, proxy bool) bool {
+	status, err := explorers.WaitVerified(verifier, guid, proxy, 5*time.Second, verifyTimeout)
+	if err != nil {
+		appUI.Error("%s", err)
+		return false
+	}
+	if !status.OK {
+		appUI.Error("Verification failed: %s", status.Message)
+		return false
+	}
+	appUI.Success("%s", status.Message)
+	return true
+}
+
+// buildVerifyRequest reads --input and fills in the compiler version and the
+// constructor params the flags leave out.
+func buildVerifyRequest(r utilreader.Reader, verifier explorers.SourceVerifier, address string) (*explorers.VerifyRequest, error) {
+	if verifyContractName == "" || !strings.Contains(verifyContractName, ":") {
+		return nil, fmt.Errorf("--contract must be the fully qualified name of the contract, e.g. src/Vault.sol:Vault")
+	}
+	data, err := os.ReadFile(verifyInput)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read %s: %w", verifyInput, err)
+	}
+	input, buildCompiler, err := standardJSONInput(data)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &explorers.VerifyRequest{
+		Address:           address,
+		StandardJSONInput: string(input),
+		ContractName:      verifyContractName,
+		CompilerVersion:   verifyCompiler,
+		ConstructorArgs:   verifyConstructorArgs,
+	}
+
+	var code []byte
+	if req.CompilerVersion == "" {
+		req.CompilerVersion = buildCompiler
+	}
+	if req.CompilerVersion == "" {
+		code, err = r.GetCode(address)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't get the code of %s: %w", address, err)
+		}
+		version, ok := jarviscommon.SolcVersion(code)
+		if !ok {
+			return nil, fmt.Errorf("the code has no solc metadata, give the compiler version with --compiler")
+		}
+		if req.CompilerVersion, err = explorers.SolcLongVersion(version); err != nil {
+			return nil, fmt.Errorf("couldn't find the commit of solc %s, give it with --compiler: %w", version, err)
+		}
+	}
+	if !strings.HasPrefix(req.CompilerVersion, "v") {
+		req.CompilerVersion = "v" + req.CompilerVersion
+	}
+	appUI.Info("Compiler: %s", req.CompilerVersion)
+
+	if verifyConstructorArgs == "" {
+		args, err := creationConstructorArgs(r, verifier, address)
+		if err != nil {
+			return nil, fmt.Errorf("%w. Give them with --constructor-args", err)
+		}
+		req.ConstructorArgs = common.Bytes2Hex(args)
+	}
+	if req.ConstructorArgs == "" {
+		appUI.Info("Constructor params: none")
+	} else {
+		appUI.Info("Constructor params: 0x%s", strings.TrimPrefix(req.ConstructorArgs, "0x"))
+	}
+	return req, nil
+}
+
+// standardJSONInput returns the solc standard-JSON input of data, which is
+// either the input itself or a build-info file wrapping it, with the long
+// compiler version of the build-info.
+func standardJSONInput(data []byte) (input []byte, compiler string, err error) {
+	file := struct {
+		Language        string                     `json:"language"`
+		Sources         map[string]json.RawMessage `json:"sources"`
+		Input           json.RawMessage            `json:"input"`
+		SolcLongVersion string                     `json:"solcLongVersion"`
+	}{}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, "", fmt.Errorf("%s isn't JSON: %w", verifyInput, err)
+	}
+	if len(file.Input) > 0 {
+		input, compiler = file.Input, file.SolcLongVersion
+		if err := json.Unmarshal(input, &file); err != nil {
+			return nil, "", fmt.Errorf("couldn't decode the input of the build-info: %w", err)
+		}
+	} else {
+		input = data
+	}
+	if len(file.Sources) == 0 {
+		return nil, "", fmt.Errorf("%s has no sources, it isn't a standard-JSON input", verifyInput)
+	}
+	path := verifyContractName[:strings.LastIndex(verifyContractName, ":")]
+	if _, found := file.Sources[path]; !found {
+		return nil, "", fmt.Errorf("%s isn't one of the sources of %s", path, verifyInput)
+	}
+	return input, compiler, nil
+}
+
+// creationConstructorArgs reads the constructor params of the contract at
+// address from the init code of the tx that created it. Only contracts
+// created by a tx or through the deterministic deployer carry their init
+// code in the tx data.
+func creationConstructorArgs(r utilreader.Reader, verifier explorers.SourceVerifier, address string) ([]byte, error) {
+	creation, err := verifier.GetContractCreation(address)
+	if err != nil {
+		return nil, err
+	}
+	txinfo, err := r.TxInfoFromHash(creation.TxHash)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get the creation tx %s: %w", creation.TxHash, err)
+	}
+	initCode := txinfo.Tx.Data()
+	if to := txinfo.Tx.To(); to != nil {
+		if *to != jarviscommon.DeterministicDeployer || len(initCode) < 32 {
+			return nil, fmt.Errorf("%s was created by the contract %s, its constructor params aren't in the tx", address, to.Hex())
+		}
+		initCode = initCode[32:]
+	}
+	appUI.Info("Created by %s in tx %s", creation.Creator, creation.TxHash)
+	return jarviscommon.ConstructorArgsOf(initCode)
+}
+
+func init() {
+	verifyContractCmd.Flags().StringVar(&verifyInput, "input", "", "solc standard-JSON input, or a Hardhat/Foundry build-info file")
+	verifyContractCmd.Flags().StringVar(&verifyContractName, "contract", "", "Fully qualified name of the contract in the input, e.g. src/Vault.sol:Vault")
+	verifyContractCmd.Flags().StringVar(&verifyCompiler, "compiler", "", "solc version, e.g. v0.8.24+commit.e11b9ed9. Default: from the build-info or the deployed code")
+	verifyContractCmd.Flags().StringVar(&verifyConstructorArgs, "constructor-args", "", "Hex abi encoded constructor params. Default: read from the creation tx")
+	verifyContractCmd.Flags().BoolVar(&verifyProxy, "proxy", false, "Also verify the contract as a proxy of its implementation")
+	verifyContractCmd.Flags().StringVar(&verifyImplementation, "implementation", "", "Implementation the proxy is expected to point to, with --proxy. Default: detected by the explorer")
+	verifyContractCmd.Flags().DurationVar(&verifyTimeout, "timeout", 3*time.Minute, "How long to wait for the explorer to verify")
+	contractCmd.AddCommand(verifyContractCmd)
+}
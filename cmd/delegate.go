@@ -0,0 +1,308 @@
+package cmd
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/holiman/uint256"
+	"github.com/spf13/cobra"
+
+	"github.com/tranvictor/jarvis/accounts"
+	cmdutil "github.com/tranvictor/jarvis/cmd/util"
+	jarviscommon "github.com/tranvictor/jarvis/common"
+	"github.com/tranvictor/jarvis/config"
+	"github.com/tranvictor/jarvis/util"
+)
+
+var (
+	delegateSponsor  string
+	delegateCallData string
+)
+
+var delegateCmd = &cobra.Command{
+	Use:   "delegate <contract>",
+	Short: "Delegate a wallet's code to a contract with EIP-7702",
+	Long: `Sign an EIP-7702 authorization with the --from wallet and send it in a
+type-4 tx, so the wallet runs the code of the given contract (e.g. a batching
+or smart-account implementation) while keeping its address and its key.
+
+  jarvis delegate <contract> -f <wallet>   sets or changes the delegation
+  jarvis delegate revoke -f <wallet>       clears it
+
+The delegate can do anything with the wallet's funds, only delegate to
+contracts you have reviewed. The tx is sent by the wallet itself unless
+--sponsor names another wallet to pay for the gas. Use --data to call the
+wallet right after the delegation is set, e.g. to initialize it.
+
+Only keystore wallets can sign authorizations.`,
+	Args: cobra.ExactArgs(1),
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return cmdutil.CommonSendPreprocess(appUI, cmd, args)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		tc, _ := cmdutil.TxContextFrom(cmd)
+		if tc.Reader == nil {
+			appUI.Error("Couldn't establish connection to node.")
+			return
+		}
+		delegate, _, err := tc.Resolver.GetAddressFromString(args[0])
+		if err != nil {
+			appUI.Error("Couldn't find the contract to delegate to with keyword %s: %s", args[0], err)
+			return
+		}
+		isContract, err := util.IsContract(delegate, config.Network())
+		if err != nil {
+			appUI.Error("Couldn't read the code of %s: %s", delegate, err)
+			return
+		}
+		if !isContract {
+			appUI.Error("%s has no code, delegating to it would leave the wallet without any.", delegate)
+			return
+		}
+		handleDelegate(tc, ethcommon.HexToAddress(delegate))
+	},
+}
+
+var revokeDelegationCmd = &cobra.Command{
+	Use:   "revoke",
+	Short: "Clear the EIP-7702 delegation of a wallet",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		tc, _ := cmdutil.TxContextFrom(cmd)
+		if tc.Reader == nil {
+			appUI.Error("Couldn't establish connection to node.")
+			return
+		}
+		handleDelegate(tc, ethcommon.Address{})
+	},
+}
+
+// handleDelegate sends a type-4 tx carrying the --from wallet's authorization
+// to delegate to delegate, or to revoke its delegation when delegate is the
+// zero address.
+func handleDelegate(tc cmdutil.TxContext, delegate ethcommon.Address) {
+	if config.UnsignedTxFile != "" {
+		appUI.Error("EIP-7702 authorizations can't be signed offline.")
+		return
+	}
+	if cmdutil.ScheduleSet() {
+		appUI.Error("--at-block, --at-time and --when can't be used to delegate, the authorization is signed and sent at once.")
+		return
+	}
+	if config.From == "" {
+		appUI.Error("Please specify the wallet to delegate with --from.")
+		return
+	}
+	authorityAcc, _, err := cmdutil.ResolveAccount(tc.Resolver, config.From)
+	if err != nil {
+		appUI.Error("Couldn't find the wallet %s: %s", config.From, err)
+		return
+	}
+	if authorityAcc.Kind != "keystore" {
+		appUI.Error("%s wallets can't sign EIP-7702 authorizations, use a keystore wallet.", authorityAcc.Kind)
+		return
+	}
+	authority := authorityAcc.Address
+
+	senderAcc := authorityAcc
+	if delegateSponsor != "" {
+		senderAcc, _, err = cmdutil.ResolveAccount(tc.Resolver, delegateSponsor)
+		if err != nil {
+			appUI.Error("Couldn't find the sponsor wallet %s: %s", delegateSponsor, err)
+			return
+		}
+	}
+	selfSponsored := strings.EqualFold(senderAcc.Address, authority)
+
+	current, delegated, err := util.GetDelegate(authority, config.Network())
+	if err != nil {
+		appUI.Error("Couldn't read the code of %s: %s", authority, err)
+		return
+	}
+	authorityStyled := appUI.Style(util.StyledAddress(util.GetJarvisAddress(authority, config.Network())))
+	if delegated {
+		currentStyled := appUI.Style(util.StyledAddress(util.GetJarvisAddress(current, config.Network())))
+		appUI.Info("%s currently delegates to %s", authorityStyled, currentStyled)
+	} else if delegate == (ethcommon.Address{}) {
+		appUI.Error("%s has no delegation to revoke.", authority)
+		return
+	}
+	if delegated && strings.EqualFold(current, delegate.Hex()) && delegateCallData == "" {
+		appUI.Warn("%s already delegates to %s.", authority, current)
+		return
+	}
+
+	txType, err := cmdutil.ValidTxType(tc.Reader, config.Network())
+	if err != nil {
+		appUI.Error("Couldn't determine proper tx type: %s", err)
+		return
+	}
+	if txType != types.DynamicFeeTxType {
+		appUI.Error("EIP-7702 txs need dynamic fees, which %s doesn't support or --legacy-tx disabled.", config.Network().GetName())
+		return
+	}
+
+	gasPrice := config.GasPrice
+	if gasPrice == 0 {
+		gasPrice, err = tc.Reader.RecommendedGasPrice()
+		if err != nil {
+			appUI.Error("Couldn't get recommended gas price: %s", err)
+			return
+		}
+	}
+	tipGas := config.TipGas
+	if tipGas == 0 {
+		tipGas, err = tc.Reader.GetSuggestedGasTipCap()
+		if err != nil {
+			appUI.Error("Couldn't get recommended tip: %s", err)
+			return
+		}
+	}
+
+	nonce := config.Nonce
+	if nonce == 0 {
+		nonce, err = cmdutil.NextNonce(appUI, tc.Reader, senderAcc.Address)
+		if err != nil {
+			appUI.Error("Couldn't get nonce of %s: %s", senderAcc.Address, err)
+			return
+		}
+	}
+	// Authorizations are processed after the sender's nonce is bumped, so a
+	// wallet delegating in its own tx signs for the nonce after the tx's.
+	authNonce := nonce + 1
+	if !selfSponsored {
+		authNonce, err = tc.Reader.GetPendingNonce(authority)
+		if err != nil {
+			appUI.Error("Couldn't get nonce of %s: %s", authority, err)
+			cmdutil.ReleaseNonce(tc.Reader, senderAcc.Address, nonce)
+			return
+		}
+		mined, err := tc.Reader.GetMinedNonce(authority)
+		if err != nil {
+			appUI.Error("Couldn't get nonce of %s: %s", authority, err)
+			cmdutil.ReleaseNonce(tc.Reader, senderAcc.Address, nonce)
+			return
+		}
+		if mined != authNonce {
+			appUI.Warn(
+				"%s has %d pending tx(s). The authorization is signed for nonce %d and is skipped if they aren't mined first.",
+				authority, authNonce-mined, authNonce,
+			)
+		}
+	}
+
+	callData := cmdutil.StringParamToBytes(delegateCallData)
+	gasLimit := config.GasLimit
+	if gasLimit == 0 {
+		if len(callData) == 0 {
+			gasLimit, err = tc.Reader.EstimateExactGas(senderAcc.Address, authority, 0, big.NewInt(0), callData)
+		} else {
+			// the call runs on the code the tx sets, which isn't there yet
+			var code []byte
+			if delegate != (ethcommon.Address{}) {
+				code = types.AddressToDelegation(delegate)
+			}
+			gasLimit, err = tc.Reader.EstimateGasWithCode(
+				senderAcc.Address, authority, big.NewInt(0), callData,
+				map[string][]byte{authority: code},
+			)
+			if err != nil {
+				err = fmt.Errorf("%w. The node can't run the --data call on the delegated code, give its gas with --gas", err)
+			}
+		}
+		if err != nil {
+			appUI.Error("Couldn't estimate gas: %s", err)
+			cmdutil.ReleaseNonce(tc.Reader, senderAcc.Address, nonce)
+			return
+		}
+		gasLimit += jarviscommon.AuthorizationGas
+	}
+
+	chainID := config.Network().GetChainID()
+	buildTx := func(auth types.SetCodeAuthorization) *types.Transaction {
+		return jarviscommon.BuildExactSetCodeTx(
+			nonce,
+			authority,
+			big.NewInt(0),
+			gasLimit+config.ExtraGasLimit,
+			gasPrice+config.ExtraGasPrice,
+			tipGas+config.ExtraTipGas,
+			callData,
+			[]types.SetCodeAuthorization{auth},
+			chainID,
+		)
+	}
+
+	if delegate != (ethcommon.Address{}) {
+		appUI.Warn("The code at %s will be able to move every asset of %s.", delegate.Hex(), authorityStyled)
+	}
+	// The tx shown for confirmation carries the authorization unsigned: it is
+	// only signed once the user agreed to it.
+	unsignedAuth := types.SetCodeAuthorization{
+		ChainID: *uint256.NewInt(chainID),
+		Address: delegate,
+		Nonce:   authNonce,
+	}
+	if err := cmdutil.PromptTxConfirmation(
+		appUI, tc.Analyzer,
+		util.GetJarvisAddress(senderAcc.Address, config.Network()),
+		buildTx(unsignedAuth), nil, config.Network(),
+	); err != nil {
+		appUI.Error("Aborted!")
+		cmdutil.ReleaseNonce(tc.Reader, senderAcc.Address, nonce)
+		return
+	}
+
+	appUI.Info("Unlock %s and sign the authorization now...", authority)
+	authorityAccount, err := accounts.UnlockAccount(authorityAcc)
+	if err != nil {
+		appUI.Error("Couldn't unlock wallet: %s", err)
+		cmdutil.ReleaseUnsettledNonces()
+		os.Exit(126)
+	}
+	auth, err := authorityAccount.SignSetCodeAuthorization(chainID, delegate, authNonce)
+	if err != nil {
+		appUI.Error("%s", err)
+		cmdutil.ReleaseNonce(tc.Reader, senderAcc.Address, nonce)
+		return
+	}
+
+	senderAccount := authorityAccount
+	if !selfSponsored {
+		appUI.Info("Unlock the sponsor %s and sign the tx now...", senderAcc.Address)
+		senderAccount, err = accounts.UnlockAccount(senderAcc)
+		if err != nil {
+			appUI.Error("Couldn't unlock wallet: %s", err)
+			cmdutil.ReleaseUnsettledNonces()
+			os.Exit(126)
+		}
+	}
+	signedTx, err := cmdutil.SignTxAs(senderAccount, senderAcc.Address, buildTx(auth))
+	if err != nil {
+		appUI.Error("%s", err)
+		cmdutil.ReleaseNonce(tc.Reader, senderAcc.Address, nonce)
+		return
+	}
+
+	broadcasted, err := cmdutil.HandlePostSign(appUI, signedTx, tc.Reader, tc.Analyzer, nil, tc.Broadcaster)
+	if err != nil && !broadcasted {
+		appUI.Error("Failed to proceed after signing the tx: %s. Aborted.", err)
+	}
+	if broadcasted {
+		// the authorization bumps the nonce of authority too, so the next tx
+		// of authority can't take authNonce
+		_ = cmdutil.NonceManager(tc.Reader).MarkBroadcasted(authority, authNonce, signedTx.Hash().Hex())
+	}
+}
+
+func init() {
+	AddCommonFlagsToTransactionalCmds(delegateCmd)
+	delegateCmd.PersistentFlags().StringVar(&delegateSponsor, "sponsor", "", "Wallet that sends the tx and pays for its gas instead of the delegating wallet")
+	delegateCmd.PersistentFlags().StringVarP(&delegateCallData, "data", "D", "", "Calldata of a call to the wallet made right after the delegation is set, in hex format. Its gas is estimated with the delegation overridden on the node, give it with --gas where the node can't.")
+	delegateCmd.AddCommand(revokeDelegationCmd)
+	rootCmd.AddCommand(delegateCmd)
+}
@@ -1112,6 +1112,7 @@ the Safe, so the positional Safe address and --network become optional.`,
 			gasLimit, err = reader.EstimateExactGas(tc.From, tc.To, 0, tc.Value, txdata)
 			if err != nil {
 				appUI.Error("Couldn't estimate gas limit: %s", err)
+				cmdutil.ShowRevertReason(appUI, err, tc.To, nil)
 				return
 			}
 		}
@@ -12,8 +12,12 @@ import (
 	"github.com/spf13/cobra"
 
 	cmdutil "github.com/tranvictor/jarvis/cmd/util"
+	jarviscommon "github.com/tranvictor/jarvis/common"
+	"github.com/tranvictor/jarvis/config"
 	"github.com/tranvictor/jarvis/networks"
 	"github.com/tranvictor/jarvis/util"
+	"github.com/tranvictor/jarvis/util/explorers"
+	"github.com/tranvictor/jarvis/util/reader"
 )
 
 var (
@@ -96,8 +100,8 @@ func PromptNetwork() networks.Network {
 			return fmt.Errorf("default node urls cannot be empty")
 		}
 		for _, node := range strings.Split(defaultNodes, ",") {
-			if _, err := url.Parse(node); err != nil {
-				return fmt.Errorf("default node url %s is not a valid url", node)
+			if err := util.ValidateNodeURL(node); err != nil {
+				return err
 			}
 		}
 		return nil
@@ -108,26 +112,11 @@ func PromptNetwork() networks.Network {
 		defaultNodes[nodeURL.Host] = strings.TrimSpace(node)
 	}
 
-	blockExplorerAPIKeyVariableName := cmdutil.PromptInputWithValidation(appUI, "Please enter the block explorer API key variable name of the network", func(v string) error {
-		if v == "" {
-			return fmt.Errorf("block explorer API key variable name cannot be empty")
-		}
-		return nil
-	})
+	explorerConfigs := PromptExplorers()
 
-	blockExplorerAPIURL := cmdutil.PromptInputWithValidation(appUI, "Please enter the block explorer API URL of the network", func(v string) error {
+	multiCallContractAddress := cmdutil.PromptInputWithValidation(appUI, fmt.Sprintf("Please enter the Multicall3 contract address of the network (leave empty for %s)", reader.Multicall3Address), func(v string) error {
 		if v == "" {
-			return fmt.Errorf("block explorer API URL cannot be empty")
-		}
-		if _, err := url.Parse(v); err != nil {
-			return fmt.Errorf("block explorer API URL %s is not a valid url", v)
-		}
-		return nil
-	})
-
-	multiCallContractAddress := cmdutil.PromptInputWithValidation(appUI, "Please enter the multi call contract address of the network", func(v string) error {
-		if v == "" {
-			return fmt.Errorf("multi call contract address cannot be empty")
+			return nil
 		}
 		if !common.IsHexAddress(v) {
 			return fmt.Errorf("multi call contract address %s is not a valid address", v)
@@ -146,23 +135,82 @@ func PromptNetwork() networks.Network {
 	})
 
 	networkConfig := networks.GenericEtherscanNetworkConfig{
-		Name:                            name,
-		AlternativeNames:                alternativeNames,
-		ChainID:                         uint64(chainID),
-		NativeTokenSymbol:               nativeTokenSymbol,
-		NativeTokenDecimal:              uint64(nativeTokenDecimal),
-		BlockTime:                       uint64(blockTime),
-		NodeVariableName:                nodeVariableName,
-		DefaultNodes:                    defaultNodes,
-		BlockExplorerAPIKeyVariableName: blockExplorerAPIKeyVariableName,
-		BlockExplorerAPIURL:             blockExplorerAPIURL,
-		MultiCallContractAddress:        common.HexToAddress(multiCallContractAddress),
-		SafeTxServiceURL:                strings.TrimSpace(safeTxServiceURL),
+		Name:                     name,
+		AlternativeNames:         alternativeNames,
+		ChainID:                  uint64(chainID),
+		NativeTokenSymbol:        nativeTokenSymbol,
+		NativeTokenDecimal:       uint64(nativeTokenDecimal),
+		BlockTime:                uint64(blockTime),
+		NodeVariableName:         nodeVariableName,
+		DefaultNodes:             defaultNodes,
+		MultiCallContractAddress: common.HexToAddress(multiCallContractAddress),
+		SafeTxServiceURL:         strings.TrimSpace(safeTxServiceURL),
+	}
+	// an etherscan-like explorer first goes where the bundled networks keep
+	// theirs
+	if explorerConfigs[0].Type == networks.EtherscanExplorer {
+		networkConfig.BlockExplorerAPIKeyVariableName = explorerConfigs[0].APIKeyVariableName
+		networkConfig.BlockExplorerAPIURL = explorerConfigs[0].APIURL
+		explorerConfigs = explorerConfigs[1:]
+	}
+	if len(explorerConfigs) > 0 {
+		networkConfig.Explorers = explorerConfigs
 	}
 
 	return networks.NewGenericEtherscanNetwork(networkConfig)
 }
 
+// PromptExplorers asks for the block explorers of a network, the ones
+// contracts are looked up on when they aren't verified on the first one
+// coming after it.
+func PromptExplorers() []networks.ExplorerConfig {
+	types := strings.Join(networks.ExplorerTypes, ", ")
+	result := []networks.ExplorerConfig{}
+	for {
+		question := fmt.Sprintf("Please enter the type of the block explorer of the network (%s)", types)
+		if len(result) > 0 {
+			question = fmt.Sprintf("Please enter the type of another block explorer to look contracts up on when they aren't verified on the ones before (%s, leave empty if there is none)", types)
+		}
+		explorerType := cmdutil.PromptInputWithValidation(appUI, question, func(v string) error {
+			if v == "" && len(result) > 0 {
+				return nil
+			}
+			for _, t := range networks.ExplorerTypes {
+				if v == t {
+					return nil
+				}
+			}
+			return fmt.Errorf("block explorer type must be one of %s", types)
+		})
+		if explorerType == "" {
+			return result
+		}
+
+		c := networks.ExplorerConfig{Type: explorerType}
+		switch explorerType {
+		case networks.EtherscanExplorer:
+			c.APIKeyVariableName = cmdutil.PromptInputWithValidation(appUI, "Please enter the block explorer API key variable name of the network", func(v string) error {
+				if v == "" {
+					return fmt.Errorf("block explorer API key variable name cannot be empty")
+				}
+				return nil
+			})
+			c.APIURL = cmdutil.PromptInputWithValidation(appUI, "Please enter the block explorer API URL of the network", func(v string) error {
+				return networks.ExplorerConfig{Type: explorerType, APIURL: v}.Validate()
+			})
+		case networks.BlockscoutExplorer:
+			c.APIURL = cmdutil.PromptInputWithValidation(appUI, "Please enter the URL of the Blockscout instance, e.g. https://eth.blockscout.com", func(v string) error {
+				return networks.ExplorerConfig{Type: explorerType, APIURL: v}.Validate()
+			})
+		case networks.SourcifyExplorer:
+			c.APIURL = cmdutil.PromptInputWithValidation(appUI, fmt.Sprintf("Please enter the URL of the Sourcify server (leave empty for %s)", explorers.SourcifyServerURL), func(v string) error {
+				return networks.ExplorerConfig{Type: explorerType, APIURL: v}.Validate()
+			})
+		}
+		result = append(result, c)
+	}
+}
+
 var addNetworkCmd = &cobra.Command{
 	Use:   "add",
 	Short: "Add a new network to the supported networks list locally",
@@ -182,9 +230,23 @@ var addNetworkCmd = &cobra.Command{
 		"block_explorer_api_key_variable_name": "JARVIS_ETHERSCAN_API_KEY",
 		"block_explorer_api_url": "https://api.etherscan.io/api",
 		"multi_call_contract_address": "0x5394753688800000000000000000000000000000000000000000000000000000",
-		"safe_tx_service_url": "https://safe-transaction.example.com"
+		"safe_tx_service_url": "https://safe-transaction.example.com",
+		"explorers": [
+			{"type": "blockscout", "api_url": "https://explorer.example.com"},
+			{"type": "sourcify"}
+		]
 	}
 
+"explorers" is optional too. It lists more block explorers, tried in order when a
+contract's ABI or name isn't found on the one before: "etherscan" (with "api_url"
+and "api_key_variable_name"), "blockscout" (with the instance url as "api_url") or
+"sourcify" ("api_url" defaults to the public server). For a chain without an
+Etherscan-like explorer, leave "block_explorer_api_url" empty and list its
+explorers there only.
+
+"multi_call_contract_address" must be a Multicall3. Leave it out when Multicall3 is
+deployed at its usual address, ` + reader.Multicall3Address + `, on the chain.
+
 "safe_tx_service_url" is optional. Set it for chains Safe doesn't list in its own
 registry so 'jarvis msig' can propose, approve and execute through a (usually
 self-hosted) Safe Transaction Service without exporting SAFE_TX_SERVICE_URL_<chainID>
@@ -282,6 +344,40 @@ var listNetworkCmd = &cobra.Command{
 	},
 }
 
+var gasNetworkCmd = &cobra.Command{
+	Use:   "gas",
+	Short: "Show the slow, normal and fast fee suggestions of a network",
+	Long: `Show the fee suggestions for the next block of the --network, computed
+from the eth_feeHistory of its latest blocks. Pick a tier for a tx with
+--speed slow|normal|fast instead of passing --gasprice and --tipgas.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return cmdutil.CommonNetworkPreprocess(appUI, cmd, args)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		r, err := util.EthReader(config.Network())
+		if err != nil {
+			appUI.Error("Couldn't connect to blockchain: %s", err)
+			return
+		}
+		fees, err := r.SuggestedFees()
+		if err != nil {
+			appUI.Error("Couldn't get the fee history of %s: %s", config.Network().GetName(), err)
+			return
+		}
+		appUI.Info("Next base fee: %.4f gwei", jarviscommon.BigToFloat(fees.BaseFee, 9))
+		rows := [][]string{}
+		for _, speed := range []reader.GasSpeed{reader.GasSpeedSlow, reader.GasSpeedNormal, reader.GasSpeedFast} {
+			tier := fees.Tier(speed)
+			rows = append(rows, []string{
+				string(speed),
+				fmt.Sprintf("%.4f", jarviscommon.BigToFloat(tier.MaxFee, 9)),
+				fmt.Sprintf("%.4f", jarviscommon.BigToFloat(tier.TipCap, 9)),
+			})
+		}
+		appUI.Table([]string{"Speed", "Max fee (gwei)", "Tip (gwei)"}, rows)
+	},
+}
+
 var networkCmd = &cobra.Command{
 	Use:   "network",
 	Short: "Manage all networks that jarvis supports",
@@ -294,5 +390,6 @@ func init() {
 
 	networkCmd.AddCommand(listNetworkCmd)
 	networkCmd.AddCommand(addNetworkCmd)
+	networkCmd.AddCommand(gasNetworkCmd)
 	rootCmd.AddCommand(networkCmd)
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"sync"
 
@@ -12,10 +13,15 @@ import (
 	"github.com/tranvictor/jarvis/networks"
 	"github.com/tranvictor/jarvis/ui"
 	"github.com/tranvictor/jarvis/util"
+	"github.com/tranvictor/jarvis/util/broadcaster"
+	"github.com/tranvictor/jarvis/util/reader"
 )
 
 var nodeOutputFile string
 var nodeListIncludeDefaults bool
+var relayMethod string
+var relayFlashbotsAuth bool
+var relayHeaders []string
 
 var nodeCmd = &cobra.Command{
 	Use:   "node",
@@ -317,6 +323,10 @@ var nodeAddCmd = &cobra.Command{
 		if _, err := networks.GetNetwork(networkName); err != nil {
 			appUI.Warn("Network %q is not in the built-in list, but adding the node anyway.", networkName)
 		}
+		if err := util.ValidateNodeURL(nodeURL); err != nil {
+			appUI.Error("%s", err)
+			return
+		}
 		cfg, err := util.LoadNodeConfig(networkName)
 		if err != nil {
 			cfg = util.NodeConfig{Nodes: map[string]string{}, UseDefaults: true}
@@ -327,6 +337,9 @@ var nodeAddCmd = &cobra.Command{
 			return
 		}
 		appUI.Success("Added node %q (%s) for network %q.", name, nodeURL, networkName)
+		if reader.IsWebSocketURL(nodeURL) {
+			appUI.Info("It is a websocket node, jarvis contract events --follow subscribes to logs through it.")
+		}
 		if cfg.UseDefaults {
 			appUI.Info("Built-in default nodes are still included. To disable: jarvis node defaults %s off", networkName)
 		}
@@ -580,7 +593,7 @@ replace them with the current built-in defaults.`,
 
 		created, skipped, failed := 0, 0, 0
 		for _, n := range targets {
-			_, cfgErr := util.LoadNodeConfig(n.GetName())
+			existing, cfgErr := util.LoadNodeConfig(n.GetName())
 			if cfgErr == nil && !nodeBootstrapOverwrite {
 				appUI.Info("  %-30s already exists — skipped (use --overwrite to replace)", n.GetName())
 				skipped++
@@ -591,7 +604,8 @@ replace them with the current built-in defaults.`,
 			for k, v := range n.GetDefaultNodes() {
 				nodes[k] = v
 			}
-			cfg := util.NodeConfig{Nodes: nodes, UseDefaults: false}
+			// only the nodes are bootstrapped, the relays are kept
+			cfg := util.NodeConfig{Nodes: nodes, UseDefaults: false, Relays: existing.Relays}
 			if err := util.SaveNodeConfig(n.GetName(), cfg); err != nil {
 				appUI.Error("  %-30s failed: %s", n.GetName(), err)
 				failed++
@@ -640,10 +654,133 @@ var nodeDefaultsCmd = &cobra.Command{
 	},
 }
 
+// ── relay ────────────────────────────────────────────────────────────────────
+
+var nodeRelayCmd = &cobra.Command{
+	Use:   "relay",
+	Short: "Manage the private relays transactional commands use with --private",
+	Long: `Manage the private tx relays of a network. With --private, transactional
+commands send the signed tx only to these relays instead of the public nodes,
+so it never shows up in the public mempool.
+
+A relay takes txs either with eth_sendPrivateTransaction (--method private),
+or as one tx bundles with eth_sendBundle (--method bundle), resubmitted for
+every block until the tx is mined or --relay-blocks blocks passed.
+
+Relays authenticating searchers with the X-Flashbots-Signature header need
+--flashbots-auth: requests are then signed with a reputation key generated in
+~/.jarvis/relay_auth.key on first use. The key never holds funds.`,
+}
+
+var nodeRelayListCmd = &cobra.Command{
+	Use:   "list <network>",
+	Short: "List the private relays of a network",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		network, err := networks.GetNetwork(args[0])
+		if err != nil {
+			appUI.Error("Network %q not found: %s", args[0], err)
+			return
+		}
+		relays := util.GetRelays(network)
+		if len(relays) == 0 {
+			appUI.Warn("No private relay configured for %q.", args[0])
+			appUI.Info("  Add one: jarvis node relay add %s <name> <url> --method private", args[0])
+			return
+		}
+		names := make([]string, 0, len(relays))
+		for name := range relays {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		t := &ui.Table{Headers: []string{"Relay Name", "URL", "Method", "Auth"}, MaxCellWidth: 55}
+		for _, name := range names {
+			r := relays[name]
+			auth := "—"
+			if r.FlashbotsAuth {
+				auth = "flashbots"
+			}
+			t.AddRow(ui.TC(name), ui.TC(r.URL), ui.TC(r.Method), ui.TC(auth))
+		}
+		appUI.PrintTable(t)
+	},
+}
+
+var nodeRelayAddCmd = &cobra.Command{
+	Use:   "add <network> <name> <url>",
+	Short: "Add a private relay for a network",
+	Args:  cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		networkName, name, relayURL := args[0], args[1], args[2]
+		if relayMethod != broadcaster.RelayMethodPrivate && relayMethod != broadcaster.RelayMethodBundle {
+			appUI.Error("--method must be %q or %q.", broadcaster.RelayMethodPrivate, broadcaster.RelayMethodBundle)
+			return
+		}
+		headers := map[string]string{}
+		for _, h := range relayHeaders {
+			k, v, ok := strings.Cut(h, "=")
+			if !ok || strings.TrimSpace(k) == "" {
+				appUI.Error("Invalid header %q, use Name=value.", h)
+				return
+			}
+			headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+		if _, err := networks.GetNetwork(networkName); err != nil {
+			appUI.Warn("Network %q is not in the built-in list, but adding the relay anyway.", networkName)
+		}
+		cfg, err := util.LoadNodeConfig(networkName)
+		if err != nil {
+			cfg = util.NodeConfig{Nodes: map[string]string{}, UseDefaults: true}
+		}
+		if cfg.Relays == nil {
+			cfg.Relays = map[string]util.RelayConfig{}
+		}
+		relay := util.RelayConfig{URL: relayURL, Method: relayMethod, FlashbotsAuth: relayFlashbotsAuth}
+		if len(headers) > 0 {
+			relay.Headers = headers
+		}
+		cfg.Relays[name] = relay
+		if err := util.SaveNodeConfig(networkName, cfg); err != nil {
+			appUI.Error("Couldn't save node config: %s", err)
+			return
+		}
+		appUI.Success("Added %s relay %q (%s) for network %q.", relayMethod, name, relayURL, networkName)
+		appUI.Info("Use it with --private on transactional commands.")
+	},
+}
+
+var nodeRelayRemoveCmd = &cobra.Command{
+	Use:   "remove <network> <name>",
+	Short: "Remove a private relay of a network",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		networkName, name := args[0], args[1]
+		cfg, err := util.LoadNodeConfig(networkName)
+		if err != nil {
+			appUI.Error("No custom node configuration found for %q.", networkName)
+			return
+		}
+		if _, exists := cfg.Relays[name]; !exists {
+			appUI.Error("Relay %q not found in configuration for %q.", name, networkName)
+			return
+		}
+		delete(cfg.Relays, name)
+		if err := util.SaveNodeConfig(networkName, cfg); err != nil {
+			appUI.Error("Couldn't save node config: %s", err)
+			return
+		}
+		appUI.Success("Removed relay %q from network %q.", name, networkName)
+	},
+}
+
 func init() {
 	nodeListCmd.Flags().BoolVar(&nodeListIncludeDefaults, "include-defaults", false, "Show built-in default nodes alongside custom ones, and probe each node for connectivity")
 	nodeExportCmd.Flags().StringVarP(&nodeOutputFile, "output", "o", "", "Write output to this file instead of stdout")
 	nodeBootstrapCmd.Flags().BoolVar(&nodeBootstrapOverwrite, "overwrite", false, "Replace existing config files with the current built-in defaults")
-	nodeCmd.AddCommand(nodeListCmd, nodeAddCmd, nodeRemoveCmd, nodeTestCmd, nodeExportCmd, nodeImportCmd, nodeDefaultsCmd, nodeBootstrapCmd)
+	nodeRelayAddCmd.Flags().StringVar(&relayMethod, "method", broadcaster.RelayMethodPrivate, "How the relay takes txs: private (eth_sendPrivateTransaction) or bundle (eth_sendBundle)")
+	nodeRelayAddCmd.Flags().BoolVar(&relayFlashbotsAuth, "flashbots-auth", false, "Sign requests in the X-Flashbots-Signature header with the relay reputation key")
+	nodeRelayAddCmd.Flags().StringArrayVar(&relayHeaders, "header", nil, "Extra HTTP header sent to the relay, as Name=value. Can be repeated")
+	nodeRelayCmd.AddCommand(nodeRelayListCmd, nodeRelayAddCmd, nodeRelayRemoveCmd)
+	nodeCmd.AddCommand(nodeRelayCmd, nodeListCmd, nodeAddCmd, nodeRemoveCmd, nodeTestCmd, nodeExportCmd, nodeImportCmd, nodeDefaultsCmd, nodeBootstrapCmd)
 	rootCmd.AddCommand(nodeCmd)
 }
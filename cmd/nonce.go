@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/spf13/cobra"
+
+	cmdutil "github.com/tranvictor/jarvis/cmd/util"
+	jarviscommon "github.com/tranvictor/jarvis/common"
+	"github.com/tranvictor/jarvis/config"
+	"github.com/tranvictor/jarvis/util/nonce"
+)
+
+// nonceCmd groups the commands inspecting and fixing the nonces jarvis
+// tracks locally for the wallets sending txs back to back.
+var nonceCmd = &cobra.Command{
+	Use:   "nonce",
+	Short: "Inspect and fix the nonces jarvis hands out to your wallets",
+	Long: `Jarvis keeps the nonces it hands out to txs that aren't mined yet under
+~/.jarvis/nonces, per network and wallet, so several commands sending from the
+same wallet back to back, even at the same time, never reuse a nonce.
+
+  jarvis nonce status <wallet>  shows the mined and pending nonces, the nonces
+                                held by jarvis commands and the gaps
+  jarvis nonce fill <wallet>    fills every gap with a 0-value self-transfer
+  jarvis nonce reset <wallet>   forgets the nonces held by jarvis commands
+
+A gap is a nonce no tx uses below the nonce of a broadcasted tx, e.g. because
+the tx using it was dropped: the later txs won't be mined until it is filled.`,
+	TraverseChildren: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return cmdutil.CommonSendPreprocess(appUI, cmd, args)
+	},
+}
+
+var nonceStatusCmd = &cobra.Command{
+	Use:   "status <wallet>",
+	Short: "Show the nonces of a wallet and their gaps",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		tc, addr, ok := nonceCmdContext(cmd, args[0])
+		if !ok {
+			return
+		}
+		status, err := cmdutil.NonceManager(tc.Reader).Status(addr)
+		if err != nil {
+			appUI.Error("Couldn't get the nonces of %s: %s", addr, err)
+			return
+		}
+		printNonceStatus(addr, status)
+	},
+}
+
+var nonceFillCmd = &cobra.Command{
+	Use:   "fill <wallet>",
+	Short: "Fill the nonce gaps of a wallet with 0-value self-transfers",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		tc, addr, ok := nonceCmdContext(cmd, args[0])
+		if !ok {
+			return
+		}
+		fromAcc, _, err := cmdutil.ResolveAccount(tc.Resolver, addr)
+		if err != nil {
+			appUI.Error("You don't have the wallet %s. Please run `jarvis wallet add` first.", addr)
+			return
+		}
+
+		manager := cmdutil.NonceManager(tc.Reader)
+		status, err := manager.Status(addr)
+		if err != nil {
+			appUI.Error("Couldn't get the nonces of %s: %s", addr, err)
+			return
+		}
+		if len(status.Gaps) == 0 {
+			appUI.Success("%s has no nonce gap.", addr)
+			return
+		}
+		printNonceStatus(addr, status)
+
+		txType, err := cmdutil.ValidTxType(tc.Reader, config.Network())
+		if err != nil {
+			appUI.Error("Couldn't determine proper tx type: %s", err)
+			return
+		}
+		gasPrice := config.GasPrice
+		if gasPrice == 0 {
+			gasPrice, err = tc.Reader.RecommendedGasPrice()
+			if err != nil {
+				appUI.Error("Couldn't get recommended gas price: %s", err)
+				return
+			}
+		}
+		tipGas := config.TipGas
+		if tipGas == 0 && txType == types.DynamicFeeTxType {
+			tipGas, err = tc.Reader.GetSuggestedGasTipCap()
+			if err != nil {
+				appUI.Error("Couldn't get recommended tip: %s", err)
+				return
+			}
+		}
+
+		for range status.Gaps {
+			// Reserve hands out the lowest free nonce, which is the first
+			// gap left unless another command took it in the meantime.
+			n, current, err := manager.Reserve(addr)
+			if err != nil {
+				appUI.Error("Couldn't reserve a nonce of %s: %s", addr, err)
+				return
+			}
+			if len(current.Gaps) == 0 || n != current.Gaps[0] {
+				cmdutil.ReleaseNonce(tc.Reader, addr, n)
+				break
+			}
+			appUI.Info("Filling nonce %d of %s", n, addr)
+			tx := jarviscommon.BuildExactTx(
+				txType, n, addr, big.NewInt(0), 21000,
+				gasPrice, tipGas, nil, config.Network().GetChainID(),
+			)
+			if broadcasted, err := cmdutil.SignAndBroadcast(appUI, fromAcc, tx, nil, tc.Reader, tc.Analyzer, nil, tc.Broadcaster); err != nil && !broadcasted {
+				if errors.Is(err, cmdutil.ErrWalletUnlock) {
+					cmdutil.ReleaseUnsettledNonces()
+					os.Exit(126)
+				}
+				appUI.Error("Failed to fill nonce %d: %s. Aborted.", n, err)
+				return
+			}
+		}
+	},
+}
+
+var nonceResetCmd = &cobra.Command{
+	Use:   "reset <wallet>",
+	Short: "Forget the nonces jarvis holds for a wallet",
+	Long: `Forget every nonce jarvis handed out to a wallet's txs that aren't mined
+yet, e.g. after a tx was dropped and won't be sent again. The next tx then
+takes its nonce from the node again.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		tc, addr, ok := nonceCmdContext(cmd, args[0])
+		if !ok {
+			return
+		}
+		if err := cmdutil.NonceManager(tc.Reader).Reset(addr); err != nil {
+			appUI.Error("Couldn't reset the nonces of %s: %s", addr, err)
+			return
+		}
+		appUI.Success("Forgot the nonces held for %s on %s.", addr, config.Network().GetName())
+	},
+}
+
+func nonceCmdContext(cmd *cobra.Command, arg string) (cmdutil.TxContext, string, bool) {
+	tc, _ := cmdutil.TxContextFrom(cmd)
+	if tc.Reader == nil {
+		appUI.Error("Couldn't establish connection to node.")
+		return tc, "", false
+	}
+	addr, _, err := tc.Resolver.GetAddressFromString(arg)
+	if err != nil {
+		appUI.Error("Couldn't interpret %s as a wallet: %s", arg, err)
+		return tc, "", false
+	}
+	return tc, addr, true
+}
+
+func printNonceStatus(addr string, status nonce.Status) {
+	appUI.Section(fmt.Sprintf("Nonces of %s on %s", addr, config.Network().GetName()))
+	appUI.KeyValue([][2]string{
+		{"Mined", fmt.Sprintf("%d", status.Mined)},
+		{"Pending", fmt.Sprintf("%d", status.Pending)},
+	})
+	if len(status.Reservations) > 0 {
+		rows := [][]string{}
+		for _, r := range status.Reservations {
+			state := "reserved " + time.Since(r.ReservedAt).Round(time.Second).String() + " ago"
+			if r.TxHash != "" {
+				state = "broadcasted " + r.TxHash
+			}
+			rows = append(rows, []string{fmt.Sprintf("%d", r.Nonce), state})
+		}
+		appUI.Table([]string{"Nonce", "Held by"}, rows)
+	}
+	if len(status.Gaps) == 0 {
+		return
+	}
+	gaps := make([]string, 0, len(status.Gaps))
+	for _, g := range status.Gaps {
+		gaps = append(gaps, fmt.Sprintf("%d", g))
+	}
+	appUI.Warn("Gaps: %s. Txs with later nonces won't be mined until they are filled, see jarvis nonce fill.", strings.Join(gaps, ", "))
+}
+
+func init() {
+	nonceCmd.PersistentFlags().
+		Float64VarP(&config.GasPrice, "gasprice", "p", 0, "Gas price (max fee for dynamic fee txs) in gwei of the txs filling the gaps.")
+	nonceCmd.PersistentFlags().
+		Float64VarP(&config.TipGas, "tipgas", "s", 0, "Tip in gwei of the txs filling the gaps, dynamic fee txs only.")
+	nonceCmd.PersistentFlags().
+		BoolVarP(&config.DontWaitToBeMined, "no-wait", "F", false, "Will not wait the txs to be mined.")
+	nonceCmd.PersistentFlags().
+		BoolVarP(&config.ForceLegacy, "legacy-tx", "L", false, "Force using legacy transaction")
+
+	nonceCmd.AddCommand(nonceStatusCmd)
+	nonceCmd.AddCommand(nonceFillCmd)
+	nonceCmd.AddCommand(nonceResetCmd)
+	rootCmd.AddCommand(nonceCmd)
+}
@@ -0,0 +1,502 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+
+	"github.com/tranvictor/jarvis/accounts"
+	cmdutil "github.com/tranvictor/jarvis/cmd/util"
+	jarviscommon "github.com/tranvictor/jarvis/common"
+	"github.com/tranvictor/jarvis/config"
+	"github.com/tranvictor/jarvis/networks"
+	"github.com/tranvictor/jarvis/ui"
+	"github.com/tranvictor/jarvis/util"
+	"github.com/tranvictor/jarvis/util/portfolio"
+	utilreader "github.com/tranvictor/jarvis/util/reader"
+	"github.com/tranvictor/jarvis/util/schedule"
+)
+
+var (
+	portfolioNetworks  []string
+	portfolioTokens    []string
+	portfolioBlock     int64
+	portfolioTime      string
+	portfolioShowZero  bool
+	portfolioCSVOutput string
+)
+
+var portfolioCmd = &cobra.Command{
+	Use:   "portfolio [accounts|addresses...]",
+	Short: "Show what wallets hold across networks",
+	Long: `Show the balances of wallets in the native token and the major tokens of
+every network, with their USD values, grouped by network and token.
+
+The wallets are the given accounts and addresses, every wallet added to jarvis
+by default. The networks are the ones of --networks, or -k when it is given,
+every supported network by default. The balances of a network are read in one
+multicall. Tokens jarvis doesn't know are read too with --tokens, on the
+networks they are deployed on, e.g.
+
+	jarvis portfolio --networks mainnet,arbitrum --tokens 0x6982508145454ce325ddbe47a25d4ec3d2311933
+
+Prices are CoinGecko's. Holdings CoinGecko doesn't price are shown without a
+USD value.
+
+The balances are read at the latest block, at --block of the one network
+given, or at the last block mined at or before --time on every network, an
+RFC3339 time or a unix timestamp. Snapshots are priced at the prices of their
+day, except for the tokens CoinGecko only prices by address.
+
+Zero balances are left out unless --show-zero is given. The holdings can be
+exported with --json-output and --csv-output.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		wallets, names, err := portfolioWallets(args)
+		if err != nil {
+			appUI.Error("%s", err)
+			return
+		}
+		if len(wallets) == 0 {
+			appUI.Error("You have no wallets. Add them with jarvis wallet add, or give the addresses to read.")
+			return
+		}
+		nets, err := portfolioNetworkList(cmd)
+		if err != nil {
+			appUI.Error("%s", err)
+			return
+		}
+		tokens, err := portfolioExtraTokens()
+		if err != nil {
+			appUI.Error("%s", err)
+			return
+		}
+
+		var at time.Time
+		if portfolioTime != "" {
+			if portfolioBlock >= 0 {
+				appUI.Error("--block and --time can't be used together.")
+				return
+			}
+			at, err = schedule.ParseTime(portfolioTime)
+			if err != nil {
+				appUI.Error("%s", err)
+				return
+			}
+		}
+		if portfolioBlock >= 0 && len(nets) != 1 {
+			appUI.Error("--block is a block of one network, pick it with --networks or use --time.")
+			return
+		}
+
+		stop := appUI.Spinner(fmt.Sprintf("Reading %d wallets on %d networks...", len(wallets), len(nets)))
+		holdings := readPortfolio(nets, wallets, tokens, at)
+		stop()
+		if len(holdings) == 0 {
+			appUI.Error("Couldn't read the wallets on any of the networks.")
+			return
+		}
+
+		if portfolioBlock >= 0 {
+			// the block is priced at the prices of its day
+			at, err = blockTime(nets[0], holdings[0].Block)
+			if err != nil {
+				appUI.Warn("Couldn't read when block %d was mined, the holdings are priced at the latest prices: %s", holdings[0].Block, err)
+			}
+		}
+		stop = appUI.Spinner("Pricing the holdings on CoinGecko...")
+		err = portfolio.PriceHoldings(holdings, at)
+		stop()
+		if err != nil {
+			appUI.Warn("Couldn't price every holding: %s", err)
+		}
+
+		shown := []portfolio.Holding{}
+		for _, h := range holdings {
+			if h.Balance != nil && (portfolioShowZero || h.Balance.Sign() != 0) {
+				shown = append(shown, h)
+			}
+		}
+		if !at.IsZero() {
+			appUI.Info("Snapshot at %s", at.Local().Format(time.RFC3339))
+		}
+		if len(shown) == 0 {
+			appUI.Info("The wallets hold nothing on these networks.")
+		} else {
+			printPortfolio(shown, names)
+		}
+
+		if config.JSONOutputFile != "" {
+			writePortfolioJSON(config.JSONOutputFile, shown, names, at)
+		}
+		if portfolioCSVOutput != "" {
+			writePortfolioCSV(portfolioCSVOutput, shown, names)
+		}
+	},
+}
+
+// portfolioWallets resolves the accounts and addresses of args, every wallet
+// added to jarvis when there is none. names are the descriptions of the
+// wallets by lower-case address.
+func portfolioWallets(args []string) (wallets []string, names map[string]string, err error) {
+	accs := accounts.GetAccounts()
+	names = map[string]string{}
+	for addr, acc := range accs {
+		names[strings.ToLower(addr)] = acc.Desc
+	}
+
+	if len(args) == 0 {
+		for addr := range accs {
+			wallets = append(wallets, common.HexToAddress(addr).Hex())
+		}
+		sort.Slice(wallets, func(i, j int) bool {
+			return names[strings.ToLower(wallets[i])] < names[strings.ToLower(wallets[j])]
+		})
+		return wallets, names, nil
+	}
+
+	seen := map[string]bool{}
+	for _, arg := range args {
+		addr, name := "", ""
+		if common.IsHexAddress(arg) {
+			addr = arg
+		} else if acc, resolved, err := cmdutil.ResolveAccount(nil, arg); err == nil {
+			addr, name = resolved, acc.Desc
+		} else if addr, name, err = util.GetAddressFromString(arg); err != nil {
+			return nil, nil, fmt.Errorf("%s is neither one of your wallets nor an address: %w", arg, err)
+		}
+		addr = common.HexToAddress(addr).Hex()
+		if seen[addr] {
+			continue
+		}
+		seen[addr] = true
+		wallets = append(wallets, addr)
+		if names[strings.ToLower(addr)] == "" {
+			names[strings.ToLower(addr)] = name
+		}
+	}
+	return wallets, names, nil
+}
+
+// portfolioNetworkList returns the networks of --networks, the one of -k when
+// it is given, every supported network otherwise, sorted by name.
+func portfolioNetworkList(cmd *cobra.Command) ([]networks.Network, error) {
+	names := portfolioNetworks
+	if len(names) == 0 && cmd.Flags().Changed("network") {
+		names = []string{config.NetworkString}
+	}
+	nets := []networks.Network{}
+	if len(names) == 0 {
+		nets = networks.GetSupportedNetworks()
+	}
+	for _, name := range names {
+		n, err := networks.GetNetwork(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, n)
+	}
+	sort.Slice(nets, func(i, j int) bool { return nets[i].GetName() < nets[j].GetName() })
+	return nets, nil
+}
+
+// portfolioExtraTokens resolves the tokens of --tokens to addresses.
+func portfolioExtraTokens() ([]string, error) {
+	tokens := []string{}
+	for _, t := range portfolioTokens {
+		addr, _, err := util.GetAddressFromString(strings.TrimSpace(t))
+		if err != nil {
+			return nil, fmt.Errorf("couldn't find token %s: %w", t, err)
+		}
+		tokens = append(tokens, addr)
+	}
+	return tokens, nil
+}
+
+// readPortfolio reads the holdings of wallets on every network at once. A
+// network that couldn't be read, or only partly, is warned about and the
+// holdings read are returned in the order of nets.
+func readPortfolio(nets []networks.Network, wallets []string, extraTokens []string, at time.Time) []portfolio.Holding {
+	holdings := make([][]portfolio.Holding, len(nets))
+	errs := make([]error, len(nets))
+	wg := sync.WaitGroup{}
+	for i, network := range nets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			holdings[i], errs[i] = readNetworkPortfolio(network, wallets, extraTokens, at)
+		}()
+	}
+	wg.Wait()
+
+	result := []portfolio.Holding{}
+	for i, network := range nets {
+		if errs[i] != nil {
+			appUI.Warn("%s: %s", network.GetName(), errs[i])
+		}
+		result = append(result, holdings[i]...)
+	}
+	return result
+}
+
+func readNetworkPortfolio(network networks.Network, wallets []string, extraTokens []string, at time.Time) ([]portfolio.Holding, error) {
+	atBlock := portfolioBlock
+	if !at.IsZero() {
+		r, err := util.EthReader(network)
+		if err != nil {
+			return nil, err
+		}
+		block, err := utilreader.BlockAtTime(r, at)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't find the block at %s: %w", at.Format(time.RFC3339), err)
+		}
+		atBlock = int64(block)
+	}
+
+	tokens := portfolio.KnownTokens(network)
+	for _, addr := range extraTokens {
+		known := false
+		for _, t := range tokens {
+			known = known || strings.EqualFold(t.Address, addr)
+		}
+		if known {
+			continue
+		}
+		// tokens that aren't deployed on the network are left out
+		if t, err := portfolio.Lookup(network, addr); err == nil {
+			tokens = append(tokens, t)
+		}
+	}
+	return portfolio.Read(network, atBlock, wallets, tokens)
+}
+
+func blockTime(network networks.Network, block int64) (time.Time, error) {
+	r, err := util.EthReader(network)
+	if err != nil {
+		return time.Time{}, err
+	}
+	header, err := r.HeaderByNumber(block)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(int64(header.Time), 0), nil
+}
+
+func portfolioWalletText(wallet string, names map[string]string) string {
+	if name := names[strings.ToLower(wallet)]; name != "" {
+		return jarviscommon.VerboseAddress(jarviscommon.Address{Address: wallet, Desc: name})
+	}
+	return wallet
+}
+
+func formatUSD(value float64) string {
+	return fmt.Sprintf("$%.2f", value)
+}
+
+// printPortfolio prints the holdings in a group per network and token, with
+// the total of the token when several wallets hold it, then the value of
+// every network.
+func printPortfolio(holdings []portfolio.Holding, names map[string]string) {
+	t := &ui.Table{Headers: []string{"Network", "Token", "Wallet", "Balance", "USD"}}
+	networkValues := map[string]float64{}
+	networkPriced := map[string]bool{}
+	networkOrder := []string{}
+	total := 0.0
+
+	for start := 0; start < len(holdings); {
+		end := start
+		for end < len(holdings) && holdings[end].Network == holdings[start].Network && holdings[end].Token.Address == holdings[start].Token.Address {
+			end++
+		}
+		group := holdings[start:end]
+		first := group[0]
+		firstOfNetwork := false
+		if _, found := networkValues[first.Network]; !found {
+			networkOrder = append(networkOrder, first.Network)
+			networkValues[first.Network] = 0
+			firstOfNetwork = true
+		}
+
+		rows := [][]ui.TableCell{}
+		balance := big.NewInt(0)
+		value, priced := 0.0, false
+		for i, h := range group {
+			network, token := "", ""
+			if i == 0 {
+				if firstOfNetwork {
+					network = fmt.Sprintf("%s (block %d)", h.Network, h.Block)
+				}
+				token = jarviscommon.VerboseAddress(jarviscommon.Address{Address: h.Token.Address, Desc: h.Token.Symbol})
+				if h.Token.IsNative() {
+					token = h.Token.Symbol
+				}
+			}
+			usd := ui.TC("-")
+			if v, ok := h.USDValue(); ok {
+				usd = ui.TC(formatUSD(v))
+				value += v
+				priced = true
+			}
+			rows = append(rows, []ui.TableCell{
+				ui.TC(network),
+				ui.TC(token),
+				ui.TC(portfolioWalletText(h.Wallet, names)),
+				ui.TC(jarviscommon.BigToFloatString(h.Balance, h.Token.Decimals)),
+				usd,
+			})
+			balance.Add(balance, h.Balance)
+		}
+		if len(group) > 1 {
+			usd := ui.TC("-")
+			if priced {
+				usd = ui.TCS(formatUSD(value), ui.SeveritySuccess)
+			}
+			rows = append(rows, []ui.TableCell{
+				ui.TC(""),
+				ui.TC(""),
+				ui.TCS("Total", ui.SeveritySuccess),
+				ui.TCS(jarviscommon.BigToFloatString(balance, first.Token.Decimals), ui.SeveritySuccess),
+				usd,
+			})
+		}
+		t.Groups = append(t.Groups, rows)
+		networkValues[first.Network] += value
+		networkPriced[first.Network] = networkPriced[first.Network] || priced
+		total += value
+		start = end
+	}
+	appUI.PrintTable(t)
+
+	values := [][]string{}
+	for _, network := range networkOrder {
+		value := "-"
+		if networkPriced[network] {
+			value = formatUSD(networkValues[network])
+		}
+		values = append(values, []string{network, value})
+	}
+	values = append(values, []string{"Total", formatUSD(total)})
+	appUI.Table([]string{"Network", "USD"}, values)
+}
+
+type portfolioHoldingJSON struct {
+	Network    string   `json:"network"`
+	ChainID    uint64   `json:"chain_id"`
+	Block      int64    `json:"block"`
+	Wallet     string   `json:"wallet"`
+	WalletName string   `json:"wallet_name,omitempty"`
+	Token      string   `json:"token"`
+	Symbol     string   `json:"symbol"`
+	Decimals   uint64   `json:"decimals"`
+	Balance    string   `json:"balance"`
+	RawBalance string   `json:"raw_balance"`
+	USDPrice   *float64 `json:"usd_price,omitempty"`
+	USDValue   *float64 `json:"usd_value,omitempty"`
+}
+
+type portfolioJSON struct {
+	Time     *time.Time             `json:"time,omitempty"`
+	Holdings []portfolioHoldingJSON `json:"holdings"`
+	USDValue float64                `json:"usd_value"`
+}
+
+func portfolioHoldingsJSON(holdings []portfolio.Holding, names map[string]string) []portfolioHoldingJSON {
+	result := []portfolioHoldingJSON{}
+	for _, h := range holdings {
+		j := portfolioHoldingJSON{
+			Network:    h.Network,
+			ChainID:    h.ChainID,
+			Block:      h.Block,
+			Wallet:     h.Wallet,
+			WalletName: names[strings.ToLower(h.Wallet)],
+			Token:      h.Token.Address,
+			Symbol:     h.Token.Symbol,
+			Decimals:   h.Token.Decimals,
+			Balance:    jarviscommon.BigToFloatString(h.Balance, h.Token.Decimals),
+			RawBalance: h.Balance.String(),
+			USDPrice:   h.USDPrice,
+		}
+		if v, ok := h.USDValue(); ok {
+			j.USDValue = &v
+		}
+		result = append(result, j)
+	}
+	return result
+}
+
+func writePortfolioJSON(filepath string, holdings []portfolio.Holding, names map[string]string, at time.Time) {
+	result := portfolioJSON{Holdings: portfolioHoldingsJSON(holdings, names)}
+	if !at.IsZero() {
+		result.Time = &at
+	}
+	for _, h := range result.Holdings {
+		if h.USDValue != nil {
+			result.USDValue += *h.USDValue
+		}
+	}
+	data, _ := json.MarshalIndent(result, "", "  ")
+	if err := os.WriteFile(filepath, data, 0644); err != nil {
+		appUI.Error("Writing to json file failed: %s", err)
+	}
+}
+
+// writePortfolioCSV writes one row per holding. The USD columns are empty
+// for the holdings without a price.
+func writePortfolioCSV(filepath string, holdings []portfolio.Holding, names map[string]string) {
+	rows := [][]string{{
+		"network", "chain_id", "block", "wallet", "wallet_name", "token", "symbol",
+		"balance", "raw_balance", "usd_price", "usd_value",
+	}}
+	formatFloat := func(f *float64) string {
+		if f == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*f, 'f', -1, 64)
+	}
+	for _, h := range portfolioHoldingsJSON(holdings, names) {
+		rows = append(rows, []string{
+			h.Network,
+			strconv.FormatUint(h.ChainID, 10),
+			strconv.FormatInt(h.Block, 10),
+			h.Wallet,
+			h.WalletName,
+			h.Token,
+			h.Symbol,
+			h.Balance,
+			h.RawBalance,
+			formatFloat(h.USDPrice),
+			formatFloat(h.USDValue),
+		})
+	}
+
+	f, err := os.Create(filepath)
+	if err != nil {
+		appUI.Error("Writing to csv file failed: %s", err)
+		return
+	}
+	defer f.Close()
+	w := csv.NewWriter(f)
+	if err := w.WriteAll(rows); err != nil {
+		appUI.Error("Writing to csv file failed: %s", err)
+	}
+}
+
+func init() {
+	portfolioCmd.Flags().StringSliceVar(&portfolioNetworks, "networks", nil, "Networks to read the wallets on, separated by commas. Default: every supported network")
+	portfolioCmd.Flags().StringSliceVar(&portfolioTokens, "tokens", nil, "More tokens to read the balances of, separated by commas, on the networks they are deployed on")
+	portfolioCmd.Flags().Int64Var(&portfolioBlock, "block", -1, "Block to read the balances at, of the one network given. Default: the latest block")
+	portfolioCmd.Flags().StringVar(&portfolioTime, "time", "", "Read the balances at the last block mined at or before this time on every network, an RFC3339 time or a unix timestamp")
+	portfolioCmd.Flags().BoolVar(&portfolioShowZero, "show-zero", false, "Show the zero balances too")
+	portfolioCmd.Flags().StringVarP(&config.JSONOutputFile, "json-output", "o", "", "write the holdings to json file")
+	portfolioCmd.Flags().StringVar(&portfolioCSVOutput, "csv-output", "", "write the holdings to csv file, one row per holding")
+	rootCmd.AddCommand(portfolioCmd)
+}
@@ -26,6 +26,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	cmdutil "github.com/tranvictor/jarvis/cmd/util"
 	"github.com/tranvictor/jarvis/config"
 	"github.com/tranvictor/jarvis/networks"
 	"github.com/tranvictor/jarvis/ui"
@@ -150,7 +151,9 @@ func Execute() {
 		"print debug logs to screen, helpful to diagnose performance issues",
 	)
 
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.Execute()
+	cmdutil.ReleaseUnsettledNonces()
+	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
@@ -0,0 +1,960 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/spf13/cobra"
+
+	types2 "github.com/tranvictor/jarvis/accounts/types"
+	cmdutil "github.com/tranvictor/jarvis/cmd/util"
+	jarviscommon "github.com/tranvictor/jarvis/common"
+	"github.com/tranvictor/jarvis/config"
+	"github.com/tranvictor/jarvis/msig"
+	"github.com/tranvictor/jarvis/playbook"
+	"github.com/tranvictor/jarvis/safe"
+	"github.com/tranvictor/jarvis/txanalyzer"
+	"github.com/tranvictor/jarvis/util"
+	utilreader "github.com/tranvictor/jarvis/util/reader"
+)
+
+var (
+	runDryRun  bool
+	runState   string
+	runRestart bool
+)
+
+// playbookRun is one run of a playbook: the scope its steps share and the
+// connections to the network of the current step.
+type playbookRun struct {
+	pb     *playbook.Playbook
+	scope  *playbook.Scope
+	dryRun bool
+
+	network  string
+	reader   utilreader.Reader
+	analyzer util.TxAnalyzer
+	bc       cmdutil.TxBroadcaster
+	resolver cmdutil.ABIResolver
+
+	// dryNonces are the next nonces of the accounts sending txs in a dry
+	// run, by network and account, so consecutive txs of an account don't
+	// show the same nonce.
+	dryNonces map[string]uint64
+
+	// state and stepID are where the tx of the running step is recorded
+	// before it is broadcasted. state is nil in a dry run.
+	state  *playbook.State
+	stepID string
+}
+
+// errTxNotMined is the error of a step whose tx was broadcasted but isn't
+// known to be mined. The step stays broadcasted in the state.
+var errTxNotMined = errors.New("the tx isn't known to be mined")
+
+// resolvedCall is a playbook call with its references interpolated and its
+// contract, method and params resolved.
+type resolvedCall struct {
+	to     string
+	abi    *abi.ABI
+	method *abi.Method
+	params []interface{}
+	value  *big.Int
+}
+
+var runCmd = &cobra.Command{
+	Use:   "run <playbook.yaml>",
+	Short: "Run the steps of a playbook: contract reads and txs, sends, multisig proposals and approvals",
+	Long: `Run the steps of a YAML playbook in order. A step is one of:
+
+  read          read a contract:    {contract, method, params}
+  tx            call a contract:    {contract, method, params, value}
+  send          send native tokens or ERC20 tokens: {to, amount}, amount as in
+                jarvis send --amount, e.g. "0.5" or "100 usdc"
+  msig_init     propose a call through a Gnosis Classic multisig or a Safe:
+                {msig, contract, method, params, value}
+  msig_approve  approve a multisig tx: {msig, tx}, tx is the Classic txid or
+                its init tx hash, or the safeTxHash of a Safe
+
+Every step runs on the playbook's network and signs with the playbook's from
+account unless it sets its own network and from. Values can refer to
+${vars.<name>} of the playbook, ${env.<NAME>} and ${steps.<id>.<output>}, the
+outputs of an earlier step:
+
+  read          the returned values, by name and by position (0, 1, ...)
+  tx, send      tx_hash
+  msig_init     tx_hash and txid for Classic multisigs, safe_tx_hash for Safes
+  msig_approve  tx_hash and txid for Classic multisigs, status for Safes
+
+Example:
+
+  network: mainnet
+  from: ops-hot
+  vars:
+    feed: 0x...
+  steps:
+    - id: current
+      read: {contract: oracle-registry, method: getOracle, params: ["${vars.feed}"]}
+    - id: propose
+      msig_init:
+        msig: treasury-safe
+        contract: oracle-registry
+        method: setOracle
+        params: ["${vars.feed}", "${steps.current.0}"]
+
+Every tx is reviewed and confirmed before it is signed, as with the other
+commands. With --dry-run nothing is signed: reads are run and every tx is
+built and analyzed for review, the outputs of the txs being unknown.
+
+The outcome of each step is saved next to the playbook (playbook.state.json).
+Running a playbook again skips the steps done by the earlier runs and
+continues from the step that failed. The tx of a step is saved before it is
+broadcasted: when a run stops before the tx is mined, the next run follows
+that tx, broadcasting it again if the nodes dropped it, rather than sending
+the step again. Use --restart to run it from the start.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		pb, err := playbook.Load(args[0])
+		if err != nil {
+			appUI.Error("Couldn't load playbook %s: %s", args[0], err)
+			return
+		}
+
+		var st *playbook.State
+		if !runDryRun {
+			statePath := runState
+			if statePath == "" {
+				statePath = playbook.DefaultStatePath(args[0])
+			}
+			if runRestart {
+				if err := os.Remove(statePath); err != nil && !os.IsNotExist(err) {
+					appUI.Error("Couldn't remove state %s: %s", statePath, err)
+					return
+				}
+			}
+			st, err = playbook.LoadState(statePath, pb)
+			if err != nil {
+				appUI.Error("%s", err)
+				return
+			}
+		}
+
+		r := &playbookRun{
+			pb:        pb,
+			scope:     playbook.NewScope(pb.Vars, os.Getenv),
+			dryRun:    runDryRun,
+			resolver:  cmdutil.DefaultABIResolver{},
+			dryNonces: map[string]uint64{},
+		}
+		r.run(args[0], st)
+	},
+}
+
+func (r *playbookRun) run(path string, st *playbook.State) {
+	name := r.pb.Name
+	if name == "" {
+		name = path
+	}
+	if r.dryRun {
+		appUI.Section(fmt.Sprintf("Dry run of %s: %d step(s), nothing is signed", name, len(r.pb.Steps)))
+	} else {
+		appUI.Section(fmt.Sprintf("Running %s: %d step(s)", name, len(r.pb.Steps)))
+	}
+
+	previewed := 0
+	for i := range r.pb.Steps {
+		step := &r.pb.Steps[i]
+		title := fmt.Sprintf("Step %d/%d: %s (%s)", i+1, len(r.pb.Steps), step.ID, step.Kind())
+		if step.Name != "" {
+			title += " - " + step.Name
+		}
+
+		if st != nil && st.Done(step.ID) {
+			appUI.Info("%s: done in an earlier run, skipped", title)
+			outputs := st.Steps[step.ID].Outputs
+			if outputs == nil {
+				outputs = map[string]string{}
+			}
+			r.scope.Outputs[step.ID] = outputs
+			continue
+		}
+
+		appUI.Section(title)
+		r.state, r.stepID = st, step.ID
+		var outputs map[string]string
+		var err error
+		if s, ok := st.Broadcasted(step.ID); ok && !r.dryRun {
+			outputs, err = r.resumeStep(step, s)
+		} else {
+			outputs, err = r.runStep(step)
+		}
+
+		if r.dryRun {
+			switch {
+			case errors.Is(err, playbook.ErrNotAvailable):
+				appUI.Warn("Step %s can't be previewed, it depends on a tx of an earlier step: %s", step.ID, err)
+				r.scope.Unavailable[step.ID] = true
+			case err != nil:
+				appUI.Error("Step %s would fail: %s", step.ID, err)
+				r.scope.Unavailable[step.ID] = true
+			case step.SendsTx():
+				// the outputs of a tx are only known once it is sent
+				r.scope.Unavailable[step.ID] = true
+				previewed++
+			default:
+				r.scope.Outputs[step.ID] = outputs
+				showStepOutputs(outputs)
+				previewed++
+			}
+			continue
+		}
+
+		if errors.Is(err, errTxNotMined) {
+			appUI.Error("Step %s isn't done: %s", step.ID, err)
+			appUI.Info("Its tx is kept in the state. Run jarvis run %s again to follow it rather than sending the step again.", path)
+			return
+		}
+		if err != nil {
+			appUI.Error("Step %s failed: %s", step.ID, err)
+			if recErr := st.Record(step.ID, playbook.StatusFailed, outputs, err); recErr != nil {
+				appUI.Error("Couldn't save the state: %s", recErr)
+			}
+			if errors.Is(err, cmdutil.ErrWalletUnlock) {
+				cmdutil.ReleaseUnsettledNonces()
+				os.Exit(126)
+			}
+			appUI.Info("Fix the cause and run jarvis run %s again to continue from this step.", path)
+			return
+		}
+		r.scope.Outputs[step.ID] = outputs
+		showStepOutputs(outputs)
+		if err := st.Record(step.ID, playbook.StatusDone, outputs, nil); err != nil {
+			appUI.Error("Couldn't save the state, the run stops here so it can resume from the next step: %s", err)
+			return
+		}
+	}
+
+	if r.dryRun {
+		appUI.Success("Dry run done: %d of %d step(s) previewed.", previewed, len(r.pb.Steps))
+		return
+	}
+	appUI.Success("All %d step(s) of %s are done.", len(r.pb.Steps), name)
+}
+
+func showStepOutputs(outputs map[string]string) {
+	if len(outputs) == 0 {
+		return
+	}
+	pairs := [][2]string{}
+	for _, k := range stepOutputKeys(outputs) {
+		pairs = append(pairs, [2]string{k, outputs[k]})
+	}
+	appUI.KeyValue(pairs)
+}
+
+// stepOutputKeys lists the positional outputs first, in order, then the named
+// ones.
+func stepOutputKeys(outputs map[string]string) []string {
+	keys := []string{}
+	for i := 0; ; i++ {
+		if _, ok := outputs[strconv.Itoa(i)]; !ok {
+			break
+		}
+		keys = append(keys, strconv.Itoa(i))
+	}
+	named := []string{}
+	for k := range outputs {
+		if _, err := strconv.Atoi(k); err != nil {
+			named = append(named, k)
+		}
+	}
+	sort.Strings(named)
+	return append(keys, named...)
+}
+
+func (r *playbookRun) runStep(step *playbook.Step) (map[string]string, error) {
+	if err := r.connect(r.pb.NetworkOf(step)); err != nil {
+		return nil, err
+	}
+	switch {
+	case step.Read != nil:
+		return r.read(step.Read)
+	case step.Tx != nil:
+		return r.tx(step)
+	case step.Send != nil:
+		return r.send(step)
+	case step.MsigInit != nil:
+		return r.msigInit(step)
+	case step.MsigApprove != nil:
+		return r.msigApprove(step)
+	}
+	return nil, fmt.Errorf("the step has no action")
+}
+
+// connect switches the run to network, connecting to it the first time a
+// step runs on it after a step on another network.
+func (r *playbookRun) connect(network string) error {
+	if r.reader != nil && r.network == network {
+		return nil
+	}
+	if err := config.SetNetwork(network); err != nil {
+		return fmt.Errorf("not supported network %s: %w", network, err)
+	}
+	appUI.Info("Network: %s", config.Network().GetName())
+
+	reader, err := cmdutil.NewTxReader(config.Network())
+	if err != nil {
+		return fmt.Errorf("couldn't connect to blockchain: %w", err)
+	}
+	bc, err := cmdutil.NewTxBroadcaster(appUI, config.Network())
+	if err != nil {
+		return fmt.Errorf("couldn't connect to broadcaster: %w", err)
+	}
+	r.network = network
+	r.reader = reader
+	r.analyzer = txanalyzer.NewGenericAnalyzer(reader, config.Network())
+	r.bc = bc
+	return nil
+}
+
+// account resolves the wallet step signs with.
+func (r *playbookRun) account(step *playbook.Step) (types2.AccDesc, error) {
+	keyword, err := r.scope.Interpolate(r.pb.FromOf(step))
+	if err != nil {
+		return types2.AccDesc{}, err
+	}
+	acc, _, err := cmdutil.ResolveAccount(r.resolver, keyword)
+	if err != nil {
+		return types2.AccDesc{}, fmt.Errorf("couldn't find wallet %s: %w", keyword, err)
+	}
+	return acc, nil
+}
+
+func (r *playbookRun) address(keyword string) (string, error) {
+	keyword, err := r.scope.Interpolate(keyword)
+	if err != nil {
+		return "", err
+	}
+	addr, _, err := r.resolver.GetAddressFromString(keyword)
+	if err != nil {
+		return "", fmt.Errorf("couldn't find address %s: %w", keyword, err)
+	}
+	return addr, nil
+}
+
+// resolveCall interpolates c and resolves its contract, ABI, method and
+// params.
+func (r *playbookRun) resolveCall(c *playbook.Call) (*resolvedCall, error) {
+	to, err := r.address(c.Contract)
+	if err != nil {
+		return nil, err
+	}
+	customABI, err := r.scope.Interpolate(c.ABI)
+	if err != nil {
+		return nil, err
+	}
+	a, err := r.resolver.ConfigToABI(to, false, customABI, config.Network())
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get abi for %s: %w", to, err)
+	}
+
+	var method *abi.Method
+	for name := range a.Methods {
+		m := a.Methods[name]
+		if m.Name == c.Method || m.Sig == c.Method {
+			method = &m
+			break
+		}
+	}
+	if method == nil {
+		return nil, fmt.Errorf("%s has no method %s", to, c.Method)
+	}
+	if len(c.Params) != len(method.Inputs) {
+		return nil, fmt.Errorf("%s takes %d param(s), %d given", method.Sig, len(method.Inputs), len(c.Params))
+	}
+
+	appUI.Info("Contract: %s", jarviscommon.VerboseAddress(util.GetJarvisAddress(to, config.Network())))
+	appUI.Info("Method: %s", method.Sig)
+
+	rc := &resolvedCall{to: to, abi: a, method: method, params: []interface{}{}, value: big.NewInt(0)}
+	for i, input := range method.Inputs {
+		p, err := r.scope.InterpolateParam(c.Params[i])
+		if err != nil {
+			return nil, fmt.Errorf("param %d (%s): %w", i+1, input.Name, err)
+		}
+		// PromptParam asks for the value when it is empty
+		if p.String() == "" {
+			return nil, fmt.Errorf("param %d (%s) is empty", i+1, input.Name)
+		}
+		param, err := cmdutil.PromptParam(appUI, false, input, p.String(), config.Network())
+		if err != nil {
+			return nil, fmt.Errorf("param %d (%s) is not valid: %w", i+1, input.Name, err)
+		}
+		rc.params = append(rc.params, param)
+	}
+
+	value, err := r.scope.Interpolate(c.Value)
+	if err != nil {
+		return nil, err
+	}
+	if value != "" {
+		rc.value, err = jarviscommon.FloatStringToBig(value, config.Network().GetNativeTokenDecimal())
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read value %s: %w", value, err)
+		}
+	}
+	return rc, nil
+}
+
+func (rc *resolvedCall) data() ([]byte, error) {
+	data, err := rc.abi.Pack(rc.method.Name, rc.params...)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't pack data: %w", err)
+	}
+	return data, nil
+}
+
+func (r *playbookRun) read(c *playbook.Call) (map[string]string, error) {
+	rc, err := r.resolveCall(c)
+	if err != nil {
+		return nil, err
+	}
+	response, err := r.reader.ReadContractToBytes(-1, utilreader.DEFAULT_ADDRESS, rc.to, rc.abi, rc.method.Name, rc.params...)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s failed: %w", rc.method.Name, err)
+	}
+	if len(response) == 0 && len(rc.method.Outputs) > 0 {
+		return nil, fmt.Errorf("%s reverted", rc.method.Name)
+	}
+	values, err := rc.method.Outputs.UnpackValues(response)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't unpack the response: %w", err)
+	}
+
+	outputs := map[string]string{}
+	for i, output := range rc.method.Outputs {
+		util.DisplayParam(appUI, r.analyzer.ParamAsJarvisParamResult(output.Name, output.Type, values[i]))
+		outputs[strconv.Itoa(i)] = playbook.FormatValue(values[i])
+		if output.Name != "" {
+			outputs[output.Name] = outputs[strconv.Itoa(i)]
+		}
+	}
+	return outputs, nil
+}
+
+func (r *playbookRun) tx(step *playbook.Step) (map[string]string, error) {
+	rc, err := r.resolveCall(step.Tx)
+	if err != nil {
+		return nil, err
+	}
+	data, err := rc.data()
+	if err != nil {
+		return nil, err
+	}
+	fromAcc, err := r.account(step)
+	if err != nil {
+		return nil, err
+	}
+	abis := map[string]*abi.ABI{strings.ToLower(rc.to): rc.abi}
+	txHash, _, err := r.sendTx(fromAcc, rc.to, rc.value, data, rc.abi, abis)
+	return txOutputs(txHash), err
+}
+
+func (r *playbookRun) send(step *playbook.Step) (map[string]string, error) {
+	to, err := r.address(step.Send.To)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := r.scope.Interpolate(step.Send.Amount)
+	if err != nil {
+		return nil, err
+	}
+	amountStr, currency, err := util.ValueToAmountAndCurrency(amount)
+	if err != nil {
+		return nil, err
+	}
+	if amountStr == "ALL" {
+		return nil, fmt.Errorf("ALL can't be used in a playbook, send an exact amount")
+	}
+	if currency == util.ETH_ADDR {
+		currency = ""
+	}
+	token, err := resolveBatchToken(r.resolver, currency)
+	if err != nil {
+		return nil, err
+	}
+	group, err := newBatchTokenTotal(r.reader, token, currency)
+	if err != nil {
+		return nil, err
+	}
+	value, err := jarviscommon.FloatStringToBig(amountStr, group.decimals)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read amount %s: %w", amountStr, err)
+	}
+
+	fromAcc, err := r.account(step)
+	if err != nil {
+		return nil, fmt.Errorf("%w. Send from a multisig with msig_init", err)
+	}
+	appUI.Info("Send %s %s to %s", amountStr, group.symbol, jarviscommon.VerboseAddress(util.GetJarvisAddress(to, config.Network())))
+
+	if token == util.ETH_ADDR {
+		txHash, _, err := r.sendTx(fromAcc, to, value, nil, nil, nil)
+		return txOutputs(txHash), err
+	}
+	transfer, err := jarviscommon.PackERC20Data("transfer", jarviscommon.HexToAddress(to), value)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't pack ERC20 transfer data: %w", err)
+	}
+	erc20 := jarviscommon.GetERC20ABI()
+	txHash, _, err := r.sendTx(fromAcc, token, big.NewInt(0), transfer, erc20, map[string]*abi.ABI{strings.ToLower(token): erc20})
+	return txOutputs(txHash), err
+}
+
+func (r *playbookRun) msigInit(step *playbook.Step) (map[string]string, error) {
+	m := step.MsigInit
+	msigAddr, err := r.address(m.Msig)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := r.resolveCall(&m.Call)
+	if err != nil {
+		return nil, err
+	}
+	data, err := rc.data()
+	if err != nil {
+		return nil, err
+	}
+	fromAcc, err := r.account(step)
+	if err != nil {
+		return nil, err
+	}
+	abis := map[string]*abi.ABI{strings.ToLower(rc.to): rc.abi}
+
+	typ, err := cmdutil.DetectMultisigType(config.Network(), msigAddr)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't detect the multisig type of %s: %w", msigAddr, err)
+	}
+	switch typ {
+	case cmdutil.MultisigSafe:
+		safeContract, err := r.safeOwnedBy(msigAddr, fromAcc)
+		if err != nil {
+			return nil, err
+		}
+		tcView := cmdutil.TxContext{
+			Reader:   r.reader,
+			Analyzer: r.analyzer,
+			Resolver: r.resolver,
+			Safe:     safeContract,
+		}
+		to := ethcommon.HexToAddress(rc.to)
+		if r.dryRun {
+			if _, _, _, _, ok := prepareSafeTx(&tcView, safeContract, to, rc.value, data, safe.OpCall, abis); !ok {
+				return nil, fmt.Errorf("couldn't build the Safe tx")
+			}
+			return nil, nil
+		}
+		hash, ok := proposeSendSafeTx(&tcView, safeContract, fromAcc, to, rc.value, data, safe.OpCall, abis)
+		if !ok {
+			return nil, fmt.Errorf("the Safe tx was not proposed")
+		}
+		return map[string]string{"safe_tx_hash": "0x" + ethcommon.Bytes2Hex(hash[:])}, nil
+
+	case cmdutil.MultisigClassic:
+		msigABI := cmdutil.ClassicMsigABI(r.resolver, msigAddr, config.Network())
+		submit, err := msigABI.Pack("submitTransaction", jarviscommon.HexToAddress(rc.to), rc.value, data)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't pack tx data: %w", err)
+		}
+		abis[strings.ToLower(msigAddr)] = msigABI
+		txHash, info, err := r.sendTx(fromAcc, msigAddr, big.NewInt(0), submit, msigABI, abis)
+		if err != nil || info == nil {
+			return txOutputs(txHash), err
+		}
+		txid := cmdutil.MsigTxIDFromReceipt(info.Receipt, msigAddr)
+		if txid == nil {
+			return txOutputs(txHash), fmt.Errorf("tx %s didn't submit a tx to %s", txHash, msigAddr)
+		}
+		return map[string]string{"tx_hash": txHash, "txid": txid.String()}, nil
+	}
+	return nil, fmt.Errorf("%s is not a Gnosis Classic multisig nor a Safe", msigAddr)
+}
+
+func (r *playbookRun) msigApprove(step *playbook.Step) (map[string]string, error) {
+	m := step.MsigApprove
+	msigAddr, err := r.address(m.Msig)
+	if err != nil {
+		return nil, err
+	}
+	ref, err := r.scope.Interpolate(m.Tx)
+	if err != nil {
+		return nil, err
+	}
+	fromAcc, err := r.account(step)
+	if err != nil {
+		return nil, err
+	}
+
+	typ, err := cmdutil.DetectMultisigType(config.Network(), msigAddr)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't detect the multisig type of %s: %w", msigAddr, err)
+	}
+	switch typ {
+	case cmdutil.MultisigSafe:
+		hashes := util.ScanForTxs(ref)
+		if len(hashes) == 0 {
+			return nil, fmt.Errorf("%s is not a safeTxHash", ref)
+		}
+		var safeTxHash [32]byte
+		copy(safeTxHash[:], ethcommon.FromHex(hashes[0]))
+		if r.dryRun {
+			return nil, r.previewSafeApproval(msigAddr, safeTxHash)
+		}
+
+		// approveSafeRef signs with config.From when several local wallets
+		// own the Safe
+		from := config.From
+		config.From = fromAcc.Address
+		defer func() { config.From = from }()
+		res := approveSafeRef(safeRefInput{
+			original: ref,
+			ref: &safe.SafeAppRef{
+				ChainID:     config.Network().GetChainID(),
+				SafeAddress: ethcommon.HexToAddress(msigAddr),
+				SafeTxHash:  safeTxHash,
+			},
+		})
+		switch {
+		case res.status == "approved" || res.status == "executed":
+		case res.status == "skipped" && strings.Contains(res.reason, "already"):
+		default:
+			return nil, fmt.Errorf("the Safe tx was not approved: %s", res.reason)
+		}
+		return map[string]string{"status": res.status}, nil
+
+	case cmdutil.MultisigClassic:
+		txid, err := r.classicTxID(msigAddr, ref)
+		if err != nil {
+			return nil, err
+		}
+		multisigContract, err := msig.NewMultisigContract(msigAddr, config.Network())
+		if err != nil {
+			return nil, fmt.Errorf("couldn't interact with the contract: %w", err)
+		}
+		_, _, _, executed := cmdutil.AnalyzeAndShowMsigTxInfo(appUI, multisigContract, txid, config.Network(), r.resolver, r.analyzer)
+		if executed {
+			appUI.Warn("This transaction has already been executed. Nothing to do.")
+			return map[string]string{"txid": txid.String()}, nil
+		}
+
+		msigABI := cmdutil.ClassicMsigABI(r.resolver, msigAddr, config.Network())
+		confirm, err := msigABI.Pack("confirmTransaction", txid)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't pack data: %w", err)
+		}
+		abis := map[string]*abi.ABI{strings.ToLower(msigAddr): msigABI}
+		txHash, _, err := r.sendTx(fromAcc, msigAddr, big.NewInt(0), confirm, msigABI, abis)
+		if txHash == "" {
+			return nil, err
+		}
+		return map[string]string{"tx_hash": txHash, "txid": txid.String()}, err
+	}
+	return nil, fmt.Errorf("%s is not a Gnosis Classic multisig nor a Safe", msigAddr)
+}
+
+// classicTxID reads ref as the id of a tx of the Gnosis Classic multisig
+// msigAddr, or as the hash of the tx that submitted it.
+func (r *playbookRun) classicTxID(msigAddr string, ref string) (*big.Int, error) {
+	hashes := util.ScanForTxs(ref)
+	if len(hashes) == 0 {
+		txid, err := util.ParamToBigInt(ref)
+		if err != nil {
+			return nil, fmt.Errorf("%s is neither a txid nor an init tx hash", ref)
+		}
+		return txid, nil
+	}
+	info, err := r.reader.TxInfoFromHash(hashes[0])
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get tx info from the blockchain: %w", err)
+	}
+	if info.Receipt == nil {
+		return nil, fmt.Errorf("can't get receipt of the init tx %s, it might still be pending", hashes[0])
+	}
+	txid := cmdutil.MsigTxIDFromReceipt(info.Receipt, msigAddr)
+	if txid == nil {
+		return nil, fmt.Errorf("%s is not a gnosis multisig init tx or with a different multisig", hashes[0])
+	}
+	return txid, nil
+}
+
+// safeOwnedBy returns the Safe at addr, checking that fromAcc owns it.
+func (r *playbookRun) safeOwnedBy(addr string, fromAcc types2.AccDesc) (*safe.SafeContract, error) {
+	safeContract, err := safe.NewSafeContract(addr, config.Network())
+	if err != nil {
+		return nil, fmt.Errorf("couldn't init safe reader: %w", err)
+	}
+	owners, err := safeContract.Owners()
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read safe owners: %w", err)
+	}
+	for _, o := range owners {
+		if strings.EqualFold(o, fromAcc.Address) {
+			return safeContract, nil
+		}
+	}
+	return nil, fmt.Errorf("%s is not an owner of the Safe %s", fromAcc.Address, addr)
+}
+
+// previewSafeApproval shows the pending Safe tx a dry run would approve.
+func (r *playbookRun) previewSafeApproval(addr string, safeTxHash [32]byte) error {
+	safeContract, err := safe.NewSafeContract(addr, config.Network())
+	if err != nil {
+		return fmt.Errorf("couldn't init safe reader: %w", err)
+	}
+	collector, err := safe.NewTxServiceCollector(config.Network().GetChainID())
+	if err != nil {
+		return fmt.Errorf("couldn't init safe tx service: %w", err)
+	}
+	pending, err := collector.Get(safeTxHash)
+	if err != nil {
+		return fmt.Errorf("couldn't fetch the pending tx: %w", err)
+	}
+	if pending.IsExecuted {
+		appUI.Warn("The Safe tx is already executed.")
+	}
+	tcView := cmdutil.TxContext{
+		Reader:   r.reader,
+		Analyzer: r.analyzer,
+		Resolver: r.resolver,
+		Safe:     safeContract,
+	}
+	showSafeTxToConfirm(pending.SafeTx, pending.SafeTxHash, &tcView)
+	showSafeAssetChanges(safeContract.Address, pending.SafeTx, config.Network())
+	showSafeSigners("Existing signatures", pending.Sigs)
+	return nil
+}
+
+// sendTx builds a tx of fromAcc and, in a dry run, shows it analyzed for
+// review. Otherwise it signs it after the usual confirmation, broadcasts it
+// and waits for it to be mined: later steps may read what it changed. A tx
+// that doesn't succeed is an error. The hash is returned as soon as the tx
+// is broadcasted, with the tx info once it is mined.
+func (r *playbookRun) sendTx(
+	fromAcc types2.AccDesc,
+	to string,
+	value *big.Int,
+	data []byte,
+	a *abi.ABI,
+	abis map[string]*abi.ABI,
+) (string, *jarviscommon.TxInfo, error) {
+	from := fromAcc.Address
+	txType, err := cmdutil.ValidTxType(r.reader, config.Network())
+	if err != nil {
+		return "", nil, fmt.Errorf("couldn't determine proper tx type: %w", err)
+	}
+	gasPrice := config.GasPrice
+	if gasPrice == 0 {
+		gasPrice, err = r.reader.RecommendedGasPrice()
+		if err != nil {
+			return "", nil, fmt.Errorf("couldn't estimate recommended gas price: %w", err)
+		}
+	}
+	tipGas := config.TipGas
+	if txType == types.DynamicFeeTxType && tipGas == 0 {
+		tipGas, err = r.reader.GetSuggestedGasTipCap()
+		if err != nil {
+			return "", nil, fmt.Errorf("couldn't estimate recommended gas tip: %w", err)
+		}
+	}
+
+	var nonce uint64
+	if r.dryRun {
+		nonce, err = r.dryNonce(from)
+	} else {
+		nonce, err = cmdutil.NextNonce(appUI, r.reader, from)
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("couldn't get nonce: %w", err)
+	}
+
+	gasLimit, err := r.reader.EstimateExactGas(from, to, 0, value, data)
+	if err != nil {
+		if !r.dryRun {
+			cmdutil.ReleaseNonce(r.reader, from, nonce)
+			cmdutil.ShowRevertReason(appUI, err, to, abis)
+			return "", nil, fmt.Errorf("couldn't estimate gas limit: %w", err)
+		}
+		appUI.Warn("Couldn't estimate gas limit: %s. The tx may depend on a tx of an earlier step.", err)
+	}
+
+	tx := jarviscommon.BuildExactTx(
+		txType,
+		nonce,
+		to,
+		value,
+		gasLimit+config.ExtraGasLimit,
+		gasPrice+config.ExtraGasPrice,
+		tipGas+config.ExtraTipGas,
+		data,
+		config.Network().GetChainID(),
+	)
+
+	if r.dryRun {
+		return "", nil, cmdutil.ShowTxInfo(appUI, r.analyzer, util.GetJarvisAddress(from, config.Network()), tx, abis, config.Network())
+	}
+
+	signedTx, err := cmdutil.PromptAndSignTx(appUI, fromAcc, tx, abis, r.analyzer)
+	if err != nil {
+		cmdutil.ReleaseNonce(r.reader, from, nonce)
+		return "", nil, err
+	}
+	rawTx, err := signedTx.MarshalBinary()
+	if err == nil {
+		err = r.state.RecordBroadcast(r.stepID, signedTx.Hash().Hex(), hexutil.Encode(rawTx))
+	}
+	if err != nil {
+		cmdutil.ReleaseNonce(r.reader, from, nonce)
+		return "", nil, fmt.Errorf("couldn't save the tx in the state, it was not broadcasted: %w", err)
+	}
+	broadcasted, err := cmdutil.HandlePostSign(appUI, signedTx, r.reader, r.analyzer, a, r.bc)
+	if !broadcasted {
+		if err == nil {
+			err = fmt.Errorf("the tx was not broadcasted")
+		}
+		return "", nil, err
+	}
+	return r.followTx(signedTx.Hash().Hex())
+}
+
+// followTx returns the info of the broadcasted tx txHash once it is mined.
+// A tx that isn't known to be mined is an errTxNotMined.
+func (r *playbookRun) followTx(txHash string) (string, *jarviscommon.TxInfo, error) {
+	info, err := r.reader.TxInfoFromHash(txHash)
+	if err != nil {
+		return txHash, nil, fmt.Errorf("%w: couldn't get the receipt of %s: %s", errTxNotMined, txHash, err)
+	}
+	switch info.Status {
+	case "done":
+		return txHash, &info, nil
+	case "reverted":
+		return txHash, &info, fmt.Errorf("tx %s is reverted", txHash)
+	}
+	return txHash, &info, fmt.Errorf("%w: tx %s is %s", errTxNotMined, txHash, info.Status)
+}
+
+// resumeStep follows the tx an earlier run broadcasted for step instead of
+// sending the step again. The tx is broadcasted again when the nodes don't
+// know it, unless its nonce was used by another tx since. The outputs of the
+// step are read from the tx as runStep would.
+func (r *playbookRun) resumeStep(step *playbook.Step, s *playbook.StepState) (map[string]string, error) {
+	if err := r.connect(r.pb.NetworkOf(step)); err != nil {
+		return nil, err
+	}
+	appUI.Info("Tx %s of this step was broadcasted by an earlier run, following it.", s.TxHash)
+
+	_, err := r.reader.TxInfoFromHash(s.TxHash)
+	if errors.Is(err, utilreader.ErrTxNotFound) {
+		tx := new(types.Transaction)
+		raw, err := hexutil.Decode(s.RawTx)
+		if err == nil {
+			err = tx.UnmarshalBinary(raw)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: the nodes don't know tx %s and the state has no valid raw tx of it: %s", errTxNotMined, s.TxHash, err)
+		}
+		sender, err := jarviscommon.GetSignerAddressFromTx(tx, tx.ChainId())
+		if err != nil {
+			return nil, fmt.Errorf("%w: couldn't read the sender of tx %s: %s", errTxNotMined, s.TxHash, err)
+		}
+		mined, err := r.reader.GetMinedNonce(sender.Hex())
+		if err != nil {
+			return nil, fmt.Errorf("%w: couldn't read the nonce of %s: %s", errTxNotMined, sender.Hex(), err)
+		}
+		if mined > tx.Nonce() {
+			// the tx was dropped and another tx took its nonce, the step
+			// is sent again by the next run
+			return nil, fmt.Errorf("tx %s was dropped and its nonce %d was used by another tx", s.TxHash, tx.Nonce())
+		}
+		appUI.Info("The nodes don't know it, broadcasting it again.")
+		broadcasted, err := cmdutil.HandlePostSign(appUI, tx, r.reader, r.analyzer, nil, r.bc)
+		if !broadcasted {
+			return nil, fmt.Errorf("%w: couldn't broadcast tx %s again: %v", errTxNotMined, s.TxHash, err)
+		}
+	}
+
+	txHash, info2, err := r.followTx(s.TxHash)
+	if err != nil {
+		return txOutputs(txHash), err
+	}
+	outputs := txOutputs(txHash)
+	switch {
+	case step.MsigInit != nil:
+		msigAddr, err := r.address(step.MsigInit.Msig)
+		if err != nil {
+			return outputs, err
+		}
+		txid := cmdutil.MsigTxIDFromReceipt(info2.Receipt, msigAddr)
+		if txid == nil {
+			return outputs, fmt.Errorf("tx %s didn't submit a tx to %s", txHash, msigAddr)
+		}
+		outputs["txid"] = txid.String()
+	case step.MsigApprove != nil:
+		msigAddr, err := r.address(step.MsigApprove.Msig)
+		if err != nil {
+			return outputs, err
+		}
+		ref, err := r.scope.Interpolate(step.MsigApprove.Tx)
+		if err != nil {
+			return outputs, err
+		}
+		txid, err := r.classicTxID(msigAddr, ref)
+		if err != nil {
+			return outputs, err
+		}
+		outputs["txid"] = txid.String()
+	}
+	return outputs, nil
+}
+
+func (r *playbookRun) dryNonce(from string) (uint64, error) {
+	key := r.network + ":" + strings.ToLower(from)
+	n, ok := r.dryNonces[key]
+	if !ok {
+		var err error
+		if n, err = r.reader.GetPendingNonce(from); err != nil {
+			return 0, err
+		}
+	}
+	r.dryNonces[key] = n + 1
+	return n, nil
+}
+
+func txOutputs(txHash string) map[string]string {
+	if txHash == "" {
+		return nil
+	}
+	return map[string]string{"tx_hash": txHash}
+}
+
+func init() {
+	runCmd.Flags().BoolVar(&runDryRun, "dry-run", false, "Don't sign anything: run the reads and show every tx analyzed for review")
+	runCmd.Flags().StringVar(&runState, "state", "", "State file of the run. Defaults to the playbook path with a .state.json extension")
+	runCmd.Flags().BoolVar(&runRestart, "restart", false, "Forget the state of earlier runs and run every step again")
+	runCmd.Flags().Float64VarP(&config.GasPrice, "gasprice", "p", 0, "Gas price in gwei of every tx. If default value is used, we will use the --speed tier of the fee suggestions of the network of each tx")
+	runCmd.Flags().StringVar(&config.GasSpeed, "speed", "normal", "Fee tier used when --gasprice or --tipgas isn't set: slow, normal or fast. See jarvis network gas")
+	runCmd.Flags().Float64VarP(&config.TipGas, "tipgas", "s", 0, "tip in gwei, will be use in dynamic fee tx, default value get from node.")
+	runCmd.Flags().Float64VarP(&config.ExtraGasPrice, "extraprice", "P", 0, "Extra gas price in gwei. The gas price to be used in the tx is gas price + extra gas price")
+	runCmd.Flags().Float64VarP(&config.ExtraTipGas, "extratip", "Q", 0, "Extra tip gas in gwei. The tip gas to be used in the tx is tip_gas_from_node + extra_tip_gas. This param will be ignored if dynamic tx is not possible.")
+	runCmd.Flags().Uint64VarP(&config.ExtraGasLimit, "extragas", "G", 250000, "Extra gas limit for every tx. The gas limit to be used in the tx is the estimated gas limit + extra gas limit")
+	runCmd.Flags().BoolVarP(&config.ForceLegacy, "legacy-tx", "L", false, "Force using legacy transaction")
+	rootCmd.AddCommand(runCmd)
+}
@@ -618,6 +618,7 @@ over an off-chain signature store. Other owners' off-chain signatures
 
 		if pending.SafeTx != nil {
 			showSafeTxToConfirm(pending.SafeTx, pending.SafeTxHash, &tc)
+			showSafeAssetChanges(safeContract.Address, pending.SafeTx, config.Network())
 		} else {
 			appUI.Info("safeTxHash: 0x%s (no SafeTx body available; only approving the hash on-chain)", ethcommon.Bytes2Hex(pending.SafeTxHash[:]))
 		}
@@ -1413,6 +1414,7 @@ func approveSafeRef(in safeRefInput) approveSafeRefResult {
 	}
 
 	showSafeTxToConfirm(pending.SafeTx, pending.SafeTxHash, &tc)
+	showSafeAssetChanges(safeContract.Address, pending.SafeTx, network)
 	showSafeSigners("Existing signatures", pending.Sigs)
 
 	if safeApproveOnChain {
@@ -1501,11 +1503,11 @@ func buildTxContextForBatch(
 	safeContract *safe.SafeContract,
 	collector safe.SignatureCollector,
 ) (cmdutil.TxContext, error) {
-	r, err := util.EthReader(network)
+	r, err := cmdutil.NewTxReader(network)
 	if err != nil {
 		return cmdutil.TxContext{}, fmt.Errorf("connect to blockchain: %w", err)
 	}
-	bc, err := util.EthBroadcaster(network)
+	bc, err := cmdutil.NewTxBroadcaster(appUI, network)
 	if err != nil {
 		return cmdutil.TxContext{}, fmt.Errorf("connect to broadcaster: %w", err)
 	}
@@ -1889,6 +1891,33 @@ func showSafeTxToConfirmWithABIs(
 	)
 }
 
+// showSafeAssetChanges previews what executing stx would change for the
+// Safe and everyone else. A delegatecall runs the code of its target as the
+// Safe, so it is traced as a call to the Safe with the Safe's code replaced
+// by the target's.
+func showSafeAssetChanges(safeAddress string, stx *safe.SafeTx, network jarvisnetworks.Network) {
+	to := stx.To.Hex()
+	var overrides map[string][]byte
+	if stx.Operation == safe.OpDelegateCall {
+		r, err := util.EthReader(network)
+		if err != nil {
+			appUI.Warn("Asset changes: couldn't connect to the nodes: %s", err)
+			return
+		}
+		code, err := r.GetCode(to)
+		if err != nil {
+			appUI.Warn("Asset changes: couldn't read the code of %s: %s", to, err)
+			return
+		}
+		overrides = map[string][]byte{safeAddress: code}
+		to = safeAddress
+	}
+	util.PreviewAssetChanges(
+		appUI, safeAddress, to, stx.Value, stx.Data, overrides,
+		util.NewEnrichedResolver(network), network,
+	)
+}
+
 // showSafeSigners renders the list of owners that have already signed,
 // resolving each address through the jarvis address book so names show up
 // the same way `jarvis msig` displays confirmation lists. Entries produced
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"errors"
+	"fmt"
 	"math/big"
 	"os"
 	"strings"
@@ -9,6 +10,7 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	ethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/spf13/cobra"
 
 	"github.com/tranvictor/jarvis/accounts"
@@ -37,6 +39,10 @@ type sendTxParams struct {
 	gasLimit uint64 // already includes ExtraGasLimit
 	gasPrice float64 // already includes ExtraGasPrice
 	tipGas   float64 // already includes ExtraTipGas
+
+	// set only for blob txs
+	blobFeeCap *big.Int
+	sidecar    *types.BlobTxSidecar
 }
 
 func handleMsigSend(
@@ -62,6 +68,7 @@ func handleMsigSend(
 
 	if broadcasted, err := cmdutil.SignAndBroadcast(appUI, from, t, nil, reader, analyzer, nil, bc); err != nil && !broadcasted {
 		if errors.Is(err, cmdutil.ErrWalletUnlock) {
+			cmdutil.ReleaseUnsettledNonces()
 			os.Exit(126)
 		}
 		appUI.Error("Failed to proceed after signing the tx: %s. Aborted.", err)
@@ -86,7 +93,20 @@ func handleSend(
 		a *abi.ABI
 	)
 
-	if tokenAddr == util.ETH_ADDR {
+	if sp.sidecar != nil {
+		t = jarviscommon.BuildExactBlobTx(
+			sp.nonce,
+			toAddr,
+			amountWei,
+			sp.gasLimit,
+			sp.gasPrice,
+			sp.tipGas,
+			sp.blobFeeCap,
+			cmdutil.StringParamToBytes(extraData),
+			sp.sidecar,
+			config.Network().GetChainID(),
+		)
+	} else if tokenAddr == util.ETH_ADDR {
 		t = jarviscommon.BuildExactTx(
 			sp.txType,
 			sp.nonce,
@@ -124,6 +144,7 @@ func handleSend(
 		reader, analyzer, a, bc,
 	); err != nil && !broadcasted {
 		if errors.Is(err, cmdutil.ErrWalletUnlock) {
+			cmdutil.ReleaseUnsettledNonces()
 			os.Exit(126)
 		}
 		appUI.Error("Failed to proceed after signing the tx: %s. Aborted.", err)
@@ -293,7 +314,7 @@ func sendFromMsig(reader utilreader.Reader, analyzer util.TxAnalyzer, resolver c
 
 	nonce := config.Nonce
 	if nonce == 0 {
-		nonce, err = reader.GetMinedNonce(fromAddr)
+		nonce, err = cmdutil.NextNonce(appUI, reader, fromAddr)
 		if err != nil {
 			appUI.Error("Couldn't get nonce of %s: %s", fromAddr, err)
 			return
@@ -303,6 +324,7 @@ func sendFromMsig(reader utilreader.Reader, analyzer util.TxAnalyzer, resolver c
 	txType, err := cmdutil.ValidTxType(reader, config.Network())
 	if err != nil {
 		appUI.Error("Couldn't determine proper tx type: %s", err)
+		cmdutil.ReleaseNonce(reader, fromAddr, nonce)
 		return
 	}
 
@@ -345,6 +367,19 @@ exact addresses start with 0x.`,
 			extraGasLimit = 0
 		}
 
+		if sendBatchFile != "" {
+			if cmd.Flags().Changed("to") || cmd.Flags().Changed("amount") {
+				appUI.Error("--to and --amount can't be used with --batch, the batch file gives them.")
+				return
+			}
+			handleBatchSend(tc, extraGasLimit)
+			return
+		}
+		if !cmd.Flags().Changed("to") || !cmd.Flags().Changed("amount") {
+			appUI.Error("Please specify --to and --amount, or a --batch file.")
+			return
+		}
+
 		// Resolve --from via the address-resolver (ENS, address book,
 		// hex scan) as a fallback to the fuzzy wallet lookup: this
 		// keeps `--from alice.eth` working when alice.eth resolves to a
@@ -352,6 +387,10 @@ exact addresses start with 0x.`,
 		// config.From to the hex form so downstream multisig detection
 		// doesn't re-interpret the original keyword.
 		acc, resolvedFrom, err := cmdutil.ResolveAccount(resolver, config.From)
+		if err != nil && len(config.BlobFiles) > 0 {
+			appUI.Error("Blob txs can only be sent from a keystore wallet, %s is not one.", config.From)
+			return
+		}
 		if err != nil {
 			// --from didn't match any local wallet (directly or via
 			// ENS/address-book resolution), so it must be a multisig
@@ -423,7 +462,7 @@ exact addresses start with 0x.`,
 		}
 
 		tipGas := config.TipGas
-		if txType == types.DynamicFeeTxType && tipGas == 0 {
+		if (txType == types.DynamicFeeTxType || txType == types.BlobTxType) && tipGas == 0 {
 			tipGas, err = reader.GetSuggestedGasTipCap()
 			if err != nil {
 				appUI.Error("Couldn't estimate recommended gas price: %s", err)
@@ -431,6 +470,18 @@ exact addresses start with 0x.`,
 			}
 		}
 
+		var (
+			blobFeeCap *big.Int
+			sidecar    *types.BlobTxSidecar
+		)
+		if txType == types.BlobTxType {
+			blobFeeCap, sidecar, err = prepareBlobs(reader, fromAcc, tokenAddrLocal)
+			if err != nil {
+				appUI.Error("Couldn't prepare the blobs: %s", err)
+				return
+			}
+		}
+
 		var amountWei *big.Int
 		gasLimit := config.GasLimit
 		if gasLimit == 0 {
@@ -439,6 +490,7 @@ exact addresses start with 0x.`,
 					gasLimit, err = reader.EstimateExactGas(fromAddr, toAddr, 0, big.NewInt(1), cmdutil.StringParamToBytes(data))
 					if err != nil {
 						appUI.Error("Getting estimated gas for the tx failed: %s", err)
+						cmdutil.ShowRevertReason(appUI, err, toAddr, nil)
 						return
 					}
 					extraGasLimit = 0 // exact gas for ALL; no extra needed
@@ -452,6 +504,12 @@ exact addresses start with 0x.`,
 						big.NewInt(int64(gasLimit)),
 						jarviscommon.FloatToBigInt(gasPrice+config.ExtraGasPrice, 9),
 					)
+					if sidecar != nil {
+						gasCost.Add(gasCost, new(big.Int).Mul(
+							big.NewInt(int64(len(sidecar.Blobs)*params.BlobTxBlobGasPerBlob)),
+							blobFeeCap,
+						))
+					}
 					if ethBalance.Cmp(gasCost) == -1 {
 						appUI.Error("Wallet doesn't have enough token to cover gas. Aborted.")
 						return
@@ -466,6 +524,7 @@ exact addresses start with 0x.`,
 					gasLimit, err = reader.EstimateExactGas(fromAddr, toAddr, 0, amountWei, cmdutil.StringParamToBytes(data))
 					if err != nil {
 						appUI.Error("Getting estimated gas for the tx failed: %s", err)
+						cmdutil.ShowRevertReason(appUI, err, toAddr, nil)
 						return
 					}
 				}
@@ -502,6 +561,7 @@ exact addresses start with 0x.`,
 				gasLimit, err = reader.EstimateGas(fromAddr, tokenAddrLocal, gasPrice+config.ExtraGasPrice, 0, innerData)
 				if err != nil {
 					appUI.Error("Couldn't estimate gas limit: %s", err)
+					cmdutil.ShowRevertReason(appUI, err, tokenAddrLocal, nil)
 					return
 				}
 			}
@@ -509,7 +569,7 @@ exact addresses start with 0x.`,
 
 		nonce := config.Nonce
 		if nonce == 0 {
-			nonce, err = reader.GetMinedNonce(fromAddr)
+			nonce, err = cmdutil.NextNonce(appUI, reader, fromAddr)
 			if err != nil {
 				appUI.Error("Couldn't get nonce: %s", err)
 				return
@@ -522,11 +582,49 @@ exact addresses start with 0x.`,
 			gasLimit: gasLimit + extraGasLimit,
 			gasPrice: gasPrice + config.ExtraGasPrice,
 			tipGas:   tipGas + config.ExtraTipGas,
+
+			blobFeeCap: blobFeeCap,
+			sidecar:    sidecar,
 		}
 		handleSend(sp, fromAcc, toAddr, amountWei, tokenAddrLocal, data, reader, analyzer, bc)
 	},
 }
 
+// prepareBlobs loads the --blob files into a sidecar with its KZG
+// commitments and proofs, and settles the max fee per blob gas. Blobs ride
+// on a native-token send only, and only keystore wallets can sign them: the
+// Ledger and Trezor apps don't know type-3 txs.
+func prepareBlobs(
+	reader utilreader.Reader,
+	from types2.AccDesc,
+	tokenAddr string,
+) (*big.Int, *types.BlobTxSidecar, error) {
+	if tokenAddr != util.ETH_ADDR {
+		return nil, nil, fmt.Errorf("blobs can't be attached to a token transfer")
+	}
+	if from.Kind != "keystore" {
+		return nil, nil, fmt.Errorf("%s wallets can't sign blob txs, use a keystore wallet", from.Kind)
+	}
+
+	blobs, err := jarviscommon.LoadBlobsFromFiles(config.BlobFiles)
+	if err != nil {
+		return nil, nil, err
+	}
+	sidecar, err := jarviscommon.BuildBlobSidecar(blobs, !config.LegacyBlobProofs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if config.BlobGasFeeCap > 0 {
+		return jarviscommon.GweiToWei(config.BlobGasFeeCap), sidecar, nil
+	}
+	blobFeeCap, err := reader.RecommendedBlobGasFeeCap()
+	if err != nil {
+		return nil, nil, fmt.Errorf("couldn't get the blob base fee: %w", err)
+	}
+	return blobFeeCap, sidecar, nil
+}
+
 // detectSafeForSend resolves keyword (an address, jarvis name, EIP-3770
 // short reference, or Safe-app URL) to a SafeContract, and returns true
 // only when the unified multisig detector reports the address as a Safe.
@@ -580,40 +678,11 @@ func sendFromSafe(
 	bc cmdutil.TxBroadcaster,
 	safeContract *safe.SafeContract,
 ) {
-	appUI.Section("Safe info")
-	appUI.Info("Safe address : %s", safeContract.Address)
-	if v, err := safeContract.Version(); err == nil {
-		appUI.Info("Safe version : %s", v)
-	}
-	if t, err := safeContract.Threshold(); err == nil {
-		appUI.Info("Threshold    : %d", t)
-	}
-
-	owners, err := safeContract.Owners()
-	if err != nil {
-		appUI.Error("getting safe owners failed: %s", err)
+	fromAcc, ok := safeSendOwner(safeContract)
+	if !ok {
 		return
 	}
 
-	var fromAcc types2.AccDesc
-	var matchingOwners int
-	for _, owner := range owners {
-		acc, err := accounts.GetAccount(owner)
-		if err == nil {
-			fromAcc = acc
-			matchingOwners++
-		}
-	}
-	if matchingOwners == 0 {
-		appUI.Error("You don't have any wallet that is an owner of this Safe. Please run `jarvis wallet add` first.")
-		return
-	}
-	if matchingOwners > 1 {
-		appUI.Error("You have multiple wallets that are owners of this Safe; please pass --from explicitly.")
-		return
-	}
-	fromAddr := fromAcc.Address
-
 	amountStr, currency, err := util.ValueToAmountAndCurrency(value)
 	if err != nil {
 		appUI.Error("Wrong format of the --value/-v param")
@@ -705,6 +774,68 @@ func sendFromSafe(
 		safeData = erc20Data
 	}
 
+	// Synthesise a TxContext just rich enough for showSafeTxToConfirm to
+	// resolve the destination ABI and decode the inner calldata. The
+	// fields we omit (FromAcc, Broadcaster, etc.) are not consulted by
+	// the display path.
+	tcView := cmdutil.TxContext{
+		Reader:   reader,
+		Analyzer: analyzer,
+		Resolver: resolver,
+	}
+	proposeSendSafeTx(&tcView, safeContract, fromAcc, safeTo, safeValue, safeData, safe.OpCall, nil)
+}
+
+// safeSendOwner shows the Safe and picks the only local wallet owning it.
+// false means the failure was already reported to the UI.
+func safeSendOwner(safeContract *safe.SafeContract) (types2.AccDesc, bool) {
+	appUI.Section("Safe info")
+	appUI.Info("Safe address : %s", safeContract.Address)
+	if v, err := safeContract.Version(); err == nil {
+		appUI.Info("Safe version : %s", v)
+	}
+	if t, err := safeContract.Threshold(); err == nil {
+		appUI.Info("Threshold    : %d", t)
+	}
+
+	owners, err := safeContract.Owners()
+	if err != nil {
+		appUI.Error("getting safe owners failed: %s", err)
+		return types2.AccDesc{}, false
+	}
+
+	var fromAcc types2.AccDesc
+	var matchingOwners int
+	for _, owner := range owners {
+		acc, err := accounts.GetAccount(owner)
+		if err == nil {
+			fromAcc = acc
+			matchingOwners++
+		}
+	}
+	if matchingOwners == 0 {
+		appUI.Error("You don't have any wallet that is an owner of this Safe. Please run `jarvis wallet add` first.")
+		return types2.AccDesc{}, false
+	}
+	if matchingOwners > 1 {
+		appUI.Error("You have multiple wallets that are owners of this Safe; please pass --from explicitly.")
+		return types2.AccDesc{}, false
+	}
+	return fromAcc, true
+}
+
+// prepareSafeTx builds the SafeTx (to, value, data, op) at the Safe's next
+// nonce and shows it for review. false means the failure was already
+// reported to the UI.
+func prepareSafeTx(
+	tcView *cmdutil.TxContext,
+	safeContract *safe.SafeContract,
+	safeTo ethcommon.Address,
+	safeValue *big.Int,
+	safeData []byte,
+	op safe.Operation,
+	abis map[string]*abi.ABI,
+) (collector *safe.TxServiceCollector, stx *safe.SafeTx, hash [32]byte, domainSep [32]byte, ok bool) {
 	collector, err := safe.NewTxServiceCollector(config.Network().GetChainID())
 	if err != nil {
 		appUI.Error("Couldn't init Safe Transaction Service client for chain %d: %s", config.Network().GetChainID(), err)
@@ -718,29 +849,44 @@ func sendFromSafe(
 	}
 	appUI.Info("SafeTx nonce: %d", safeNonce)
 
-	domainSep, err := safeContract.DomainSeparator()
+	domainSep, err = safeContract.DomainSeparator()
 	if err != nil {
 		appUI.Error("Couldn't read on-chain domainSeparator: %s", err)
 		return
 	}
 
-	stx := safe.NewSafeTx(safeTo, safeValue, safeData, safe.OpCall, safeNonce)
-	hash := stx.SafeTxHash(domainSep)
+	stx = safe.NewSafeTx(safeTo, safeValue, safeData, op, safeNonce)
+	hash = stx.SafeTxHash(domainSep)
 
-	// Synthesise a TxContext just rich enough for showSafeTxToConfirm to
-	// resolve the destination ABI and decode the inner calldata. The
-	// fields we omit (FromAcc, Broadcaster, etc.) are not consulted by
-	// the display path.
-	tcView := cmdutil.TxContext{
-		Reader:   reader,
-		Analyzer: analyzer,
-		Resolver: resolver,
+	showSafeTxToConfirmWithABIs(stx, hash, tcView, abis)
+	return collector, stx, hash, domainSep, true
+}
+
+// proposeSendSafeTx signs the SafeTx (to, value, data, op) at the Safe's next
+// nonce with fromAcc, an owner of the Safe, and submits it to the Safe
+// Transaction Service. The print-out matches `jarvis safe init` so the
+// follow-up commands (approve / execute) are immediately discoverable. It
+// returns the safeTxHash of the proposal, false when nothing was proposed.
+func proposeSendSafeTx(
+	tcView *cmdutil.TxContext,
+	safeContract *safe.SafeContract,
+	fromAcc types2.AccDesc,
+	safeTo ethcommon.Address,
+	safeValue *big.Int,
+	safeData []byte,
+	op safe.Operation,
+	abis map[string]*abi.ABI,
+) ([32]byte, bool) {
+	fromAddr := fromAcc.Address
+
+	collector, stx, hash, domainSep, ok := prepareSafeTx(tcView, safeContract, safeTo, safeValue, safeData, op, abis)
+	if !ok {
+		return hash, false
 	}
-	showSafeTxToConfirm(stx, hash, &tcView)
 
 	if !config.YesToAllPrompt && !appUI.Confirm("Sign and submit this Safe transaction?", true) {
 		appUI.Warn("Aborted by user.")
-		return
+		return hash, false
 	}
 
 	appUI.Info("Unlock %s and sign the EIP-712 safeTxHash now...", fromAddr)
@@ -748,16 +894,17 @@ func sendFromSafe(
 	if err != nil {
 		appUI.Error("Couldn't unlock wallet: %s", err)
 		if errors.Is(err, cmdutil.ErrWalletUnlock) {
+			cmdutil.ReleaseUnsettledNonces()
 			os.Exit(126)
 		}
-		return
+		return hash, false
 	}
 
 	structHash := stx.StructHash()
 	sig, err := account.SignSafeHash(domainSep, structHash)
 	if err != nil {
 		appUI.Error("Couldn't sign safeTxHash: %s", err)
-		return
+		return hash, false
 	}
 
 	if err := collector.Propose(
@@ -767,7 +914,7 @@ func sendFromSafe(
 		sig,
 	); err != nil {
 		appUI.Error("Submitting proposal to Safe Transaction Service failed: %s", err)
-		return
+		return hash, false
 	}
 
 	appUI.Success("Proposal submitted.")
@@ -777,6 +924,7 @@ func sendFromSafe(
 	appUI.Info("  jarvis msig approve %s 0x%s%s", safeContract.Address, ethcommon.Bytes2Hex(hash[:]), networkFlag())
 	appUI.Info("Once threshold is met, anyone can execute with:")
 	appUI.Info("  jarvis msig execute %s 0x%s%s", safeContract.Address, ethcommon.Bytes2Hex(hash[:]), networkFlag())
+	return hash, true
 }
 
 func init() {
@@ -784,8 +932,13 @@ func init() {
 	sendCmd.Flags().StringVarP(&to, "to", "t", "", "Account to send eth to. It can be ethereum address or a hint string to look it up in the address database. See jarvis addr for all of the known addresses")
 	sendCmd.Flags().StringVarP(&value, "amount", "v", "0", "Amount of eth to send. It is in eth/token value, not wei/twei. If a float number is passed, it will be interpreted as ETH, otherwise, it must be in the form of `float|ALL address` or `float|ALL name`. In the later case, `name` will be used to look for the token address. Eg. 0.01, 0.01 knc, 0.01 0xdd974d5c2e2928dea5f71b9825b8b646686bd200, ALL KNC are valid values.")
 	sendCmd.Flags().StringVarP(&data, "data", "D", "", "Data to send along with the transaction. It is in hex format.")
-	sendCmd.MarkFlagRequired("to")
-	sendCmd.MarkFlagRequired("amount")
+	sendCmd.Flags().StringArrayVar(&config.BlobFiles, "blob", []string{}, "File whose content is sent as an EIP-4844 blob, in hex (0x...) or binary. Repeat the flag to attach several blobs. A file of exactly 131072 bytes is taken as an already encoded blob.")
+	sendCmd.Flags().Float64Var(&config.BlobGasFeeCap, "blob-gasprice", 0, "Max fee per blob gas in gwei. If default value is used, we will use twice the blob base fee from the node")
+	sendCmd.Flags().BoolVar(&config.LegacyBlobProofs, "legacy-blob-proofs", false, "Attach one KZG proof per blob (the pre-Osaka format) instead of the per-cell proofs")
+	sendCmd.Flags().StringVar(&sendBatchFile, "batch", "", "CSV file of to,amount,token rows to pay in one go. Recipients and tokens are resolved like --to and --amount, an empty token is the native token. From a wallet every transfer is a tx with consecutive nonces (see --disperse), from a Safe they are packed into one MultiSendCallOnly SafeTx.")
+	sendCmd.Flags().BoolVar(&sendDisperse, "disperse", false, "With --batch from a wallet, pay each token in a single tx through the Disperse contract instead of a tx per transfer.")
+	sendCmd.Flags().StringVar(&disperseAddress, "disperse-address", jarviscommon.DisperseAddress, "Address of the Disperse contract used by --disperse.")
+	sendCmd.Flags().StringVar(&multiSendAddressOverride, "multisend-address", "", "Safe-only: MultiSendCallOnly contract to delegatecall for --batch. Default: probe the canonical Safe deployments for the chain.")
 
 	rootCmd.AddCommand(sendCmd)
 }
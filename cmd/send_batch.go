@@ -0,0 +1,564 @@
+package cmd
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/tranvictor/jarvis/accounts"
+	types2 "github.com/tranvictor/jarvis/accounts/types"
+	cmdutil "github.com/tranvictor/jarvis/cmd/util"
+	jarviscommon "github.com/tranvictor/jarvis/common"
+	"github.com/tranvictor/jarvis/config"
+	"github.com/tranvictor/jarvis/safe"
+	"github.com/tranvictor/jarvis/util"
+	jarvisaccount "github.com/tranvictor/jarvis/util/account"
+	utilreader "github.com/tranvictor/jarvis/util/reader"
+)
+
+var (
+	sendBatchFile   string
+	sendDisperse    bool
+	disperseAddress string
+)
+
+// batchPayout is one row of a --batch file resolved against the address
+// book and the token list.
+type batchPayout struct {
+	line   int
+	to     string
+	toName string
+	token  string // util.ETH_ADDR for the native token
+	amount *big.Int
+}
+
+// batchTokenTotal sums the payouts of one token. Groups keep the order in
+// which their token first shows up in the file.
+type batchTokenTotal struct {
+	token    string
+	symbol   string
+	decimals uint64
+	payouts  []batchPayout
+	total    *big.Int
+}
+
+// batchTx is one tx an EOA sends to run its part of the batch.
+type batchTx struct {
+	to    string
+	value *big.Int
+	data  []byte
+	abi   *abi.ABI
+	desc  string
+	// lines are the file lines the tx pays, reported when the batch stops
+	// before sending them.
+	lines []int
+	// waitMined makes the batch wait for the tx to be mined even with
+	// --no-wait: the next tx can't be estimated before.
+	waitMined bool
+	// afterApproval is set on a disperseToken call planned after an approve
+	// of the batch. Its gas can't be estimated before the approve is mined.
+	afterApproval bool
+}
+
+// handleBatchSend is `jarvis send --batch`: it resolves the transfers of the
+// file, shows them with per-token totals checked against the sender's
+// balances, and pays them from an EOA (one tx per transfer, or one Disperse
+// call per token with --disperse) or from a Safe (one MultiSendCallOnly
+// SafeTx).
+func handleBatchSend(tc cmdutil.TxContext, extraGasLimit uint64) {
+	if len(config.BlobFiles) > 0 || data != "" {
+		appUI.Error("--blob and --data can't be used with --batch.")
+		return
+	}
+	if cmdutil.ScheduleSet() {
+		appUI.Error("--at-block, --at-time and --when can't be used with --batch.")
+		return
+	}
+
+	f, err := os.Open(sendBatchFile)
+	if err != nil {
+		appUI.Error("Couldn't open the batch file: %s", err)
+		return
+	}
+	rows, err := jarviscommon.ParseTransferBatch(f)
+	f.Close()
+	if err != nil {
+		appUI.Error("Couldn't read the batch file %s: %s", sendBatchFile, err)
+		return
+	}
+
+	groups, err := resolveBatch(tc.Reader, tc.Resolver, rows)
+	if err != nil {
+		appUI.Error("%s", err)
+		return
+	}
+
+	acc, resolvedFrom, err := cmdutil.ResolveAccount(tc.Resolver, config.From)
+	if err != nil {
+		sc, ok := detectSafeForSend(tc.Reader, tc.Resolver, config.From)
+		if !ok {
+			appUI.Error("Couldn't find a wallet or Safe with keyword %s. Batches can't be sent from classic multisigs.", config.From)
+			return
+		}
+		sendBatchFromSafe(tc, sc, groups)
+		return
+	}
+	if resolvedFrom != "" && !strings.EqualFold(resolvedFrom, config.From) {
+		config.From = resolvedFrom
+	}
+	sendBatchFromEOA(tc, acc, groups, extraGasLimit)
+}
+
+// resolveBatch resolves the recipients, tokens and amounts of rows and groups
+// them by token.
+func resolveBatch(
+	reader utilreader.Reader,
+	resolver cmdutil.ABIResolver,
+	rows []jarviscommon.BatchTransfer,
+) ([]*batchTokenTotal, error) {
+	groups := []*batchTokenTotal{}
+	byToken := map[string]*batchTokenTotal{}
+
+	for _, row := range rows {
+		toAddr, toName, err := resolver.GetAddressFromString(row.To)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: couldn't find recipient %s: %w", row.Line, row.To, err)
+		}
+
+		tokenAddr, err := resolveBatchToken(resolver, row.Token)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", row.Line, err)
+		}
+		group := byToken[strings.ToLower(tokenAddr)]
+		if group == nil {
+			group, err = newBatchTokenTotal(reader, tokenAddr, row.Token)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", row.Line, err)
+			}
+			byToken[strings.ToLower(tokenAddr)] = group
+			groups = append(groups, group)
+		}
+
+		if strings.EqualFold(row.Amount, "ALL") {
+			return nil, fmt.Errorf("line %d: ALL can't be used in a batch", row.Line)
+		}
+		amount, err := jarviscommon.FloatStringToBig(row.Amount, group.decimals)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: couldn't read amount %s: %w", row.Line, row.Amount, err)
+		}
+		if amount.Sign() <= 0 {
+			return nil, fmt.Errorf("line %d: amount must be positive", row.Line)
+		}
+
+		group.payouts = append(group.payouts, batchPayout{
+			line:   row.Line,
+			to:     toAddr,
+			toName: toName,
+			token:  tokenAddr,
+			amount: amount,
+		})
+		group.total.Add(group.total, amount)
+	}
+	return groups, nil
+}
+
+// resolveBatchToken resolves the token column the same way send resolves the
+// currency of --amount. An empty column is the native token.
+func resolveBatchToken(resolver cmdutil.ABIResolver, keyword string) (string, error) {
+	if keyword == "" || strings.EqualFold(keyword, config.Network().GetNativeTokenSymbol()) {
+		return util.ETH_ADDR, nil
+	}
+	addr, _, err := resolver.GetMatchingAddress(keyword + " token")
+	if err == nil {
+		return addr, nil
+	}
+	if util.IsAddress(keyword) {
+		return keyword, nil
+	}
+	return "", fmt.Errorf("couldn't find token %s by name or address", keyword)
+}
+
+func newBatchTokenTotal(reader utilreader.Reader, tokenAddr string, keyword string) (*batchTokenTotal, error) {
+	group := &batchTokenTotal{token: tokenAddr, total: big.NewInt(0)}
+	if tokenAddr == util.ETH_ADDR {
+		group.symbol = config.Network().GetNativeTokenSymbol()
+		group.decimals = config.Network().GetNativeTokenDecimal()
+		return group, nil
+	}
+	decimals, err := reader.ERC20Decimal(tokenAddr)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get decimals of token %s: %w", tokenAddr, err)
+	}
+	group.decimals = decimals
+	group.symbol, err = reader.ERC20Symbol(tokenAddr)
+	if err != nil || group.symbol == "" {
+		group.symbol = keyword
+	}
+	return group, nil
+}
+
+// showBatch prints every transfer and the per-token totals, checking each
+// total against the balance of holder. It returns false when a balance is
+// short.
+func showBatch(reader utilreader.Reader, groups []*batchTokenTotal, holder string) bool {
+	transfers := [][]string{}
+	for _, g := range groups {
+		for _, p := range g.payouts {
+			transfers = append(transfers, []string{
+				fmt.Sprintf("%d", p.line),
+				fmt.Sprintf("%s (%s)", p.to, p.toName),
+				jarviscommon.BigToFloatString(p.amount, g.decimals),
+				g.symbol,
+			})
+		}
+	}
+	appUI.Section(fmt.Sprintf("Batch: %d transfer(s)", len(transfers)))
+	appUI.Table([]string{"Line", "To", "Amount", "Token"}, transfers)
+
+	ok := true
+	totals := [][]string{}
+	for _, g := range groups {
+		var (
+			balance *big.Int
+			err     error
+		)
+		if g.token == util.ETH_ADDR {
+			balance, err = reader.GetBalance(holder)
+		} else {
+			balance, err = reader.ERC20Balance(g.token, holder)
+		}
+		status := "ok"
+		balanceStr := "?"
+		if err != nil {
+			ok = false
+			status = fmt.Sprintf("couldn't read balance: %s", err)
+		} else {
+			balanceStr = jarviscommon.BigToFloatString(balance, g.decimals)
+			if balance.Cmp(g.total) < 0 {
+				ok = false
+				status = "short by " + jarviscommon.BigToFloatString(new(big.Int).Sub(g.total, balance), g.decimals)
+			}
+		}
+		totals = append(totals, []string{
+			g.symbol,
+			fmt.Sprintf("%d", len(g.payouts)),
+			jarviscommon.BigToFloatString(g.total, g.decimals),
+			balanceStr,
+			status,
+		})
+	}
+	appUI.Section("Totals of " + holder)
+	appUI.Table([]string{"Token", "Transfers", "Total", "Balance", "Check"}, totals)
+	return ok
+}
+
+func sendBatchFromSafe(tc cmdutil.TxContext, safeContract *safe.SafeContract, groups []*batchTokenTotal) {
+	fromAcc, ok := safeSendOwner(safeContract)
+	if !ok {
+		return
+	}
+	if !showBatch(tc.Reader, groups, safeContract.Address) {
+		appUI.Error("The Safe can't pay the whole batch. Aborted.")
+		return
+	}
+
+	calls := []jarviscommon.MultiSendCall{}
+	abis := map[string]*abi.ABI{}
+	for _, g := range groups {
+		for _, p := range g.payouts {
+			if g.token == util.ETH_ADDR {
+				calls = append(calls, jarviscommon.MultiSendCall{
+					To:    ethcommon.HexToAddress(p.to),
+					Value: p.amount,
+				})
+				continue
+			}
+			transfer, err := jarviscommon.PackERC20Data("transfer", jarviscommon.HexToAddress(p.to), p.amount)
+			if err != nil {
+				appUI.Error("Couldn't pack ERC20 transfer data: %s", err)
+				return
+			}
+			calls = append(calls, jarviscommon.MultiSendCall{
+				To:    ethcommon.HexToAddress(g.token),
+				Value: big.NewInt(0),
+				Data:  transfer,
+			})
+			abis[strings.ToLower(g.token)] = jarviscommon.GetERC20ABI()
+		}
+	}
+
+	tcView := cmdutil.TxContext{
+		Reader:   tc.Reader,
+		Analyzer: tc.Analyzer,
+		Resolver: tc.Resolver,
+		Safe:     safeContract,
+	}
+	// A single transfer is proposed as a plain CALL, like the tx builder
+	// batches.
+	if len(calls) == 1 {
+		c := calls[0]
+		proposeSendSafeTx(&tcView, safeContract, fromAcc, c.To, c.Value, c.Data, safe.OpCall, abis)
+		return
+	}
+
+	multiSend, label, err := safe.ResolveMultiSendCallOnly(safeContract, config.Network(), multiSendAddressOverride)
+	if err != nil {
+		appUI.Error("%s", err)
+		return
+	}
+	packed, err := jarviscommon.PackMultiSend(calls)
+	if err != nil {
+		appUI.Error("Couldn't pack the MultiSend batch: %s", err)
+		return
+	}
+	appUI.Info("MultiSend: %s (%s)", multiSend.Hex(), label)
+	// Value stays 0: MultiSend runs as a delegatecall in the Safe's own
+	// context, so each transfer spends the Safe's balance directly.
+	proposeSendSafeTx(&tcView, safeContract, fromAcc, multiSend, big.NewInt(0), packed, safe.OpDelegateCall, abis)
+}
+
+func sendBatchFromEOA(tc cmdutil.TxContext, fromAcc types2.AccDesc, groups []*batchTokenTotal, extraGasLimit uint64) {
+	reader := tc.Reader
+	fromAddr := fromAcc.Address
+	if config.UnsignedTxFile != "" {
+		appUI.Error("--unsigned-out writes a single tx, it can't be used with --batch.")
+		return
+	}
+
+	ok := showBatch(reader, groups, fromAddr)
+	appUI.Info("Gas fees are paid on top of the %s total.", config.Network().GetNativeTokenSymbol())
+	if !ok {
+		appUI.Error("%s can't pay the whole batch. Aborted.", fromAddr)
+		return
+	}
+
+	txs, err := planBatchTxs(reader, fromAddr, groups)
+	if err != nil {
+		appUI.Error("%s", err)
+		return
+	}
+
+	txType, err := cmdutil.ValidTxType(reader, config.Network())
+	if err != nil {
+		appUI.Error("Couldn't determine proper tx type: %s", err)
+		return
+	}
+	gasPrice := config.GasPrice
+	if gasPrice == 0 {
+		gasPrice, err = reader.RecommendedGasPrice()
+		if err != nil {
+			appUI.Error("Couldn't estimate recommended gas price: %s", err)
+			return
+		}
+	}
+	tipGas := config.TipGas
+	if txType == types.DynamicFeeTxType && tipGas == 0 {
+		tipGas, err = reader.GetSuggestedGasTipCap()
+		if err != nil {
+			appUI.Error("Couldn't estimate recommended gas price: %s", err)
+			return
+		}
+	}
+
+	appUI.Section(fmt.Sprintf("%d tx(s) to send from %s", len(txs), fromAddr))
+	for i, t := range txs {
+		appUI.Info("%d. %s", i+1, t.desc)
+	}
+	if !config.YesToAllPrompt && !appUI.Confirm("Sign and send the batch?", true) {
+		appUI.Warn("Aborted by user.")
+		return
+	}
+
+	// Unlock once for the whole batch rather than once per tx.
+	account, err := accounts.UnlockAccount(fromAcc)
+	if err != nil {
+		appUI.Error("Couldn't unlock wallet: %s", err)
+		cmdutil.ReleaseUnsettledNonces()
+		os.Exit(126)
+	}
+
+	for i, t := range txs {
+		if !sendBatchTx(tc, account, fromAddr, t, i, txType, gasPrice, tipGas, extraGasLimit) {
+			notSent := []string{}
+			for _, rest := range txs[i:] {
+				for _, line := range rest.lines {
+					notSent = append(notSent, fmt.Sprintf("%d", line))
+				}
+			}
+			appUI.Error("The batch stopped, the transfers of lines %s were not sent.", strings.Join(notSent, ", "))
+			return
+		}
+	}
+	appUI.Success("Sent %d tx(s).", len(txs))
+}
+
+// planBatchTxs lists the txs paying the batch from an EOA: one transfer per
+// payout, or with --disperse one Disperse call per token, preceded by an
+// approval of Disperse when the allowance doesn't cover the token's total.
+func planBatchTxs(reader utilreader.Reader, fromAddr string, groups []*batchTokenTotal) ([]batchTx, error) {
+	if sendDisperse {
+		if !util.IsAddress(disperseAddress) {
+			return nil, fmt.Errorf("--disperse-address %s is not an address", disperseAddress)
+		}
+		// a call to an address without code succeeds, so disperseEther would
+		// send the whole total to it
+		code, err := reader.GetCode(disperseAddress)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read the code of Disperse at %s: %w", disperseAddress, err)
+		}
+		if len(code) == 0 {
+			return nil, fmt.Errorf("Disperse isn't deployed at %s on %s, give its address with --disperse-address", disperseAddress, config.Network().GetName())
+		}
+	}
+
+	txs := []batchTx{}
+	for _, g := range groups {
+		if !sendDisperse {
+			for _, p := range g.payouts {
+				t := batchTx{
+					to:    p.to,
+					value: p.amount,
+					desc:  fmt.Sprintf("send %s %s to %s (%s)", jarviscommon.BigToFloatString(p.amount, g.decimals), g.symbol, p.to, p.toName),
+					lines: []int{p.line},
+				}
+				if g.token != util.ETH_ADDR {
+					transfer, err := jarviscommon.PackERC20Data("transfer", jarviscommon.HexToAddress(p.to), p.amount)
+					if err != nil {
+						return nil, fmt.Errorf("couldn't pack ERC20 transfer data: %w", err)
+					}
+					t.to, t.value, t.data, t.abi = g.token, big.NewInt(0), transfer, jarviscommon.GetERC20ABI()
+				}
+				txs = append(txs, t)
+			}
+			continue
+		}
+
+		recipients := make([]ethcommon.Address, 0, len(g.payouts))
+		values := make([]*big.Int, 0, len(g.payouts))
+		lines := make([]int, 0, len(g.payouts))
+		for _, p := range g.payouts {
+			recipients = append(recipients, ethcommon.HexToAddress(p.to))
+			values = append(values, p.amount)
+			lines = append(lines, p.line)
+		}
+		desc := fmt.Sprintf("disperse %s %s to %d recipients", jarviscommon.BigToFloatString(g.total, g.decimals), g.symbol, len(recipients))
+
+		if g.token == util.ETH_ADDR {
+			disperseData, err := jarviscommon.PackDisperseEther(recipients, values)
+			if err != nil {
+				return nil, fmt.Errorf("couldn't pack disperseEther data: %w", err)
+			}
+			txs = append(txs, batchTx{
+				to: disperseAddress, value: g.total, data: disperseData,
+				abi: jarviscommon.GetDisperseABI(), desc: desc, lines: lines,
+			})
+			continue
+		}
+
+		allowance, err := reader.ERC20Allowance(g.token, fromAddr, disperseAddress)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read the %s allowance of Disperse: %w", g.symbol, err)
+		}
+		approving := allowance.Cmp(g.total) < 0
+		if approving {
+			approveData, err := jarviscommon.PackERC20Data("approve", jarviscommon.HexToAddress(disperseAddress), g.total)
+			if err != nil {
+				return nil, fmt.Errorf("couldn't pack approve data: %w", err)
+			}
+			txs = append(txs, batchTx{
+				to: g.token, value: big.NewInt(0), data: approveData, abi: jarviscommon.GetERC20ABI(),
+				desc:      fmt.Sprintf("approve Disperse (%s) to spend %s %s", disperseAddress, jarviscommon.BigToFloatString(g.total, g.decimals), g.symbol),
+				waitMined: true,
+			})
+		}
+		disperseData, err := jarviscommon.PackDisperseToken(ethcommon.HexToAddress(g.token), recipients, values)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't pack disperseToken data: %w", err)
+		}
+		txs = append(txs, batchTx{
+			to: disperseAddress, value: big.NewInt(0), data: disperseData,
+			abi: jarviscommon.GetDisperseABI(), desc: desc, lines: lines,
+			afterApproval: approving,
+		})
+	}
+	return txs, nil
+}
+
+// sendBatchTx signs and broadcasts the i-th tx of the batch with the next
+// nonce of the account. It returns false when the batch must stop.
+func sendBatchTx(
+	tc cmdutil.TxContext,
+	account *jarvisaccount.Account,
+	fromAddr string,
+	t batchTx,
+	i int,
+	txType uint8,
+	gasPrice float64,
+	tipGas float64,
+	extraGasLimit uint64,
+) bool {
+	reader := tc.Reader
+	appUI.Info("%s", t.desc)
+
+	if config.DontBroadcast && t.afterApproval && config.GasLimit == 0 {
+		// the approve isn't sent with --dry, so disperseToken would revert
+		appUI.Warn("The gas of this tx can't be estimated with --dry, the approve before it has to be mined first. Skipped.")
+		return true
+	}
+
+	nonce := config.Nonce + uint64(i)
+	if config.Nonce == 0 {
+		var err error
+		nonce, err = cmdutil.NextNonce(appUI, reader, fromAddr)
+		if err != nil {
+			appUI.Error("Couldn't get nonce: %s", err)
+			return false
+		}
+	}
+
+	gasLimit := config.GasLimit
+	if gasLimit == 0 {
+		var err error
+		gasLimit, err = reader.EstimateExactGas(fromAddr, t.to, 0, t.value, t.data)
+		if err != nil {
+			appUI.Error("Couldn't estimate gas: %s", err)
+			cmdutil.ShowRevertReason(appUI, err, t.to, nil)
+			cmdutil.ReleaseNonce(reader, fromAddr, nonce)
+			return false
+		}
+	}
+
+	tx := jarviscommon.BuildExactTx(
+		txType,
+		nonce,
+		t.to,
+		t.value,
+		gasLimit+extraGasLimit,
+		gasPrice+config.ExtraGasPrice,
+		tipGas+config.ExtraTipGas,
+		t.data,
+		config.Network().GetChainID(),
+	)
+	signedTx, err := cmdutil.SignTxAs(account, fromAddr, tx)
+	if err != nil {
+		appUI.Error("%s", err)
+		cmdutil.ReleaseNonce(reader, fromAddr, nonce)
+		return false
+	}
+
+	if t.waitMined && config.DontWaitToBeMined {
+		config.DontWaitToBeMined = false
+		defer func() { config.DontWaitToBeMined = true }()
+	}
+	broadcasted, err := cmdutil.HandlePostSign(appUI, signedTx, reader, tc.Analyzer, t.abi, tc.Broadcaster)
+	if err != nil && !broadcasted {
+		appUI.Error("Failed to broadcast the tx: %s", err)
+		return false
+	}
+	return config.DontBroadcast || broadcasted
+}
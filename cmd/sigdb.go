@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tranvictor/jarvis/util/sigdb"
+)
+
+var sigdbUpdateURL string
+
+var sigdbCmd = &cobra.Command{
+	Use:   "sigdb",
+	Short: "Manage the local function and event signature database",
+	Long: `sigdb manages the signatures jarvis decodes calls and logs with when the
+contract has no ABI, e.g. it isn't verified on the block explorer.
+
+Signatures like transfer(address,uint256) are kept in
+~/.jarvis/sigdb/` + sigdb.FileName + `, on top of the ones bundled with jarvis.
+A selector or an event topic can match several signatures; jarvis uses the
+first one the data decodes cleanly with and marks what it decoded that way
+as guessed.`,
+}
+
+var sigdbUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Add the signatures of a public signature database",
+	Long: `Downloads the dump of a public signature database, ` + sigdb.DefaultUpdateURL + `
+by default, and adds the signatures the local database doesn't have yet.
+
+Network access is required.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		stop := appUI.Spinner(fmt.Sprintf("Fetching signatures from %s...", sigdbUpdateURL))
+		sigs, err := sigdb.Fetch(context.Background(), sigdbUpdateURL)
+		stop()
+		if err != nil {
+			appUI.Error("sigdb update failed: %s", err)
+			os.Exit(1)
+		}
+		addSignatures(sigs)
+	},
+}
+
+var sigdbImportCmd = &cobra.Command{
+	Use:   "import <file or url>...",
+	Short: "Add the signatures of ABIs or signature lists",
+	Long: `Adds the signatures of the files or urls given: ABIs, Foundry or Hardhat
+artifacts, or text with a signature per line (4byte-style dumps with the
+selector before the signature work too), gzipped or not.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		sigs := []string{}
+		for _, source := range args {
+			found, err := readSignatures(source)
+			if err != nil {
+				appUI.Error("Couldn't read signatures from %s: %s", source, err)
+				os.Exit(1)
+			}
+			appUI.Info("%s: %d signatures", source, len(found))
+			sigs = append(sigs, found...)
+		}
+		addSignatures(sigs)
+	},
+}
+
+var sigdbAddCmd = &cobra.Command{
+	Use:   "add <signature>...",
+	Short: "Add function or event signatures",
+	Example: `  jarvis sigdb add 'rebalance(uint256,address)'
+  jarvis sigdb add 'event Rebalanced(uint256 indexed epoch, address keeper)'`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, sig := range args {
+			if _, err := sigdb.Canonical(sig); err != nil {
+				appUI.Error("%s", err)
+				os.Exit(1)
+			}
+		}
+		addSignatures(args)
+	},
+}
+
+func readSignatures(source string) ([]string, error) {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		return sigdb.Fetch(context.Background(), source)
+	}
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return nil, err
+	}
+	return sigdb.Parse(data)
+}
+
+func addSignatures(sigs []string) {
+	db := sigdb.Shared()
+	added, err := db.Add(sigs)
+	if err != nil {
+		appUI.Error("Couldn't add the signatures: %s", err)
+		os.Exit(1)
+	}
+	total, _ := db.Len()
+	appUI.Success("Added %d new signatures to %s, %d known in total", added, db.Path(), total)
+}
+
+func init() {
+	sigdbUpdateCmd.Flags().StringVar(&sigdbUpdateURL, "url", sigdb.DefaultUpdateURL, "url of the signature dump to download")
+	sigdbCmd.AddCommand(sigdbUpdateCmd)
+	sigdbCmd.AddCommand(sigdbImportCmd)
+	sigdbCmd.AddCommand(sigdbAddCmd)
+	rootCmd.AddCommand(sigdbCmd)
+}
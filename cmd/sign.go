@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/spf13/cobra"
+
+	"github.com/tranvictor/jarvis/accounts"
+	jarviscommon "github.com/tranvictor/jarvis/common"
+	"github.com/tranvictor/jarvis/config"
+	"github.com/tranvictor/jarvis/networks"
+	"github.com/tranvictor/jarvis/util"
+)
+
+var signOutFile string
+
+var signCmd = &cobra.Command{
+	Use:   "sign <file>",
+	Short: "Sign an unsigned tx file, without any network access",
+	Long: `Sign a tx file written by --unsigned-out (e.g. jarvis send ... --unsigned-out tx.json)
+with the local keystore, Ledger or Trezor wallet that the file names as sender.
+
+This command never talks to a node, so it can run on an air-gapped machine.
+The signed tx is written to a new file (<file>-signed.json by default) which
+can be carried back to an online machine and pushed with jarvis broadcast.
+
+The decoded call shown before signing was produced by the machine that built
+the file; the tx fields (to, value, data, fees) are what actually gets signed.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		f, err := util.ReadTxFile(args[0])
+		if err != nil {
+			appUI.Error("%s", err)
+			return
+		}
+		if f.SignedTx != "" {
+			appUI.Warn("%s is already signed (tx %s). Signing it again.", args[0], f.TxHash)
+		}
+
+		tx, err := f.Transaction()
+		if err != nil {
+			appUI.Error("Couldn't build the tx from %s: %s", args[0], err)
+			return
+		}
+
+		showTxFileToSign(f, tx)
+
+		if !config.YesToAllPrompt && !appUI.Confirm("Sign this transaction?", true) {
+			appUI.Warn("Aborted by user.")
+			return
+		}
+
+		fromAcc, err := accounts.GetAccount(f.From)
+		if err != nil {
+			appUI.Error("You don't have the wallet %s on this machine. Please run `jarvis wallet add` first.", f.From)
+			return
+		}
+
+		appUI.Info("Unlock your wallet and sign now...")
+		account, err := accounts.UnlockAccount(fromAcc)
+		if err != nil {
+			appUI.Error("Couldn't unlock wallet: %s", err)
+			os.Exit(126)
+		}
+		signedAddr, signedTx, err := account.SignTx(tx, new(big.Int).SetUint64(f.ChainID))
+		if err != nil {
+			appUI.Error("Couldn't sign tx: %s", err)
+			return
+		}
+		if signedAddr.Cmp(jarviscommon.HexToAddress(f.From)) != 0 {
+			appUI.Error(
+				"Signed from wrong address. You could use wrong hw or passphrase. Expected wallet: %s, signed wallet: %s",
+				f.From,
+				signedAddr.Hex(),
+			)
+			return
+		}
+		if err := f.SetSigned(signedTx); err != nil {
+			appUI.Error("%s", err)
+			return
+		}
+
+		out := signOutFile
+		if out == "" {
+			out = signedTxFilePath(args[0])
+		}
+		if err := util.WriteTxFile(out, f); err != nil {
+			appUI.Error("%s", err)
+			return
+		}
+		appUI.Success("Signed tx %s written to %s", f.TxHash, out)
+		appUI.Info("Broadcast it from an online machine with:")
+		appUI.Info("  jarvis broadcast %s --network %s", out, f.Network)
+	},
+}
+
+// signedTxFilePath derives the default output of `jarvis sign` from its
+// input: tx.json -> tx-signed.json.
+func signedTxFilePath(path string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-signed" + ext
+}
+
+// showTxFileToSign prints the tx fields the offline signer is about to
+// commit to. Amounts are rendered with the network's native token when the
+// chain is known locally; no RPC call is made.
+func showTxFileToSign(f *util.TxFile, tx *types.Transaction) {
+	value := tx.Value().String() + " wei"
+	if n, err := networks.GetNetworkByID(f.ChainID); err == nil {
+		value = jarviscommon.BigToFloatString(tx.Value(), n.GetNativeTokenDecimal()) + " " + n.GetNativeTokenSymbol()
+	}
+	gwei := func(wei *big.Int) string {
+		return jarviscommon.BigToFloatString(wei, 9) + " gwei"
+	}
+
+	to := "(contract creation)"
+	if tx.To() != nil {
+		to = tx.To().Hex()
+	}
+	rows := [][2]string{
+		{"Network", fmt.Sprintf("%s (chain %d)", f.Network, f.ChainID)},
+		{"From", f.From},
+		{"To", to},
+		{"Value", value},
+		{"Nonce", fmt.Sprintf("%d", tx.Nonce())},
+		{"Gas limit", fmt.Sprintf("%d", tx.Gas())},
+	}
+	if tx.Type() == types.DynamicFeeTxType {
+		rows = append(rows,
+			[2]string{"Max fee", gwei(tx.GasFeeCap())},
+			[2]string{"Tip", gwei(tx.GasTipCap())},
+		)
+		if len(tx.AccessList()) > 0 {
+			addresses, keys := jarviscommon.AccessListSize(tx.AccessList())
+			rows = append(rows, [2]string{"Access list", fmt.Sprintf("%d addresses, %d storage keys", addresses, keys)})
+		}
+	} else {
+		rows = append(rows, [2]string{"Gas price", gwei(tx.GasPrice())})
+	}
+
+	appUI.Section("Confirm tx data before signing")
+	appUI.KeyValue(rows)
+	if f.Call != nil {
+		appUI.Warn("The call below was decoded by the machine that built this file.")
+		util.PrintFunctionCallDisplay(appUI, f.Call)
+	} else if len(tx.Data()) > 0 {
+		appUI.Warn("The file carries no decoded call for this calldata.")
+		appUI.Info("Data: 0x%x", tx.Data())
+	}
+}
+
+func init() {
+	signCmd.Flags().StringVarP(&signOutFile, "out", "O", "", "Where to write the signed tx file. Default: <file>-signed.json")
+	rootCmd.AddCommand(signCmd)
+}
@@ -0,0 +1,318 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/spf13/cobra"
+
+	"github.com/tranvictor/jarvis/accounts"
+	cmdutil "github.com/tranvictor/jarvis/cmd/util"
+	jarviscommon "github.com/tranvictor/jarvis/common"
+	"github.com/tranvictor/jarvis/config"
+	"github.com/tranvictor/jarvis/util"
+	utilreader "github.com/tranvictor/jarvis/util/reader"
+)
+
+// pendingTxCmd groups the commands that act on a tx which was broadcasted but
+// is stuck in the mempool.
+var pendingTxCmd = &cobra.Command{
+	Use:   "tx",
+	Short: "Speed up or cancel a pending transaction",
+	Long: `Replace a pending transaction sent from one of your wallets by signing
+another one with the same nonce and higher fees.
+
+  jarvis tx speedup <hash>  re-sends the same call with bumped fees
+  jarvis tx cancel <hash>   sends 0 to yourself with bumped fees, so the
+                            original call never executes
+
+Fees are bumped by at least 10% over the original (the minimum nodes accept
+for a replacement), or to the network's current suggestion if that is higher.
+Use --gasprice / --tipgas to ask for more.
+
+A blob tx can only be replaced by a blob tx carrying the same blobs, and
+nodes don't return them: give the files it was sent with again with --blob.
+Its fees, the blob fee included, are doubled as nodes demand. An EIP-7702 tx
+is sped up with the same authorizations, and cancelled by a plain tx that
+doesn't set the delegations either.`,
+	TraverseChildren: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// Honour a network-prefixed hash (e.g. "base:0x...") the same way
+		// the contract commands do, unless -k was given explicitly.
+		if len(args) > 0 && !cmd.Flags().Changed("network") {
+			if nwks, txs := cmdutil.ScanForTxs(args[0]); len(txs) > 0 && nwks[0] != "" {
+				config.NetworkString = nwks[0]
+			}
+		}
+		return cmdutil.CommonSendPreprocess(appUI, cmd, args)
+	},
+}
+
+var speedupTxCmd = &cobra.Command{
+	Use:   "speedup <hash>",
+	Short: "Re-send a pending tx with the same nonce and higher fees",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		handleReplaceTx(cmd, args[0], false)
+	},
+}
+
+var cancelTxCmd = &cobra.Command{
+	Use:   "cancel <hash>",
+	Short: "Cancel a pending tx by replacing it with a 0-value self-transfer",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		handleReplaceTx(cmd, args[0], true)
+	},
+}
+
+// handleReplaceTx drives both `tx speedup` and `tx cancel`: it looks up the
+// pending tx, rebuilds it with bumped fees, signs it with the wallet that
+// signed the original and then follows both hashes until one is mined.
+func handleReplaceTx(cmd *cobra.Command, arg string, cancel bool) {
+	tc, _ := cmdutil.TxContextFrom(cmd)
+
+	reader := tc.Reader
+	bc := tc.Broadcaster
+	if reader == nil || bc == nil {
+		appUI.Error("Couldn't establish connection to node.")
+		return
+	}
+
+	_, txs := cmdutil.ScanForTxs(arg)
+	if len(txs) == 0 {
+		appUI.Error("Couldn't find a tx hash in %q", arg)
+		return
+	}
+	hash := txs[0]
+
+	txinfo, err := reader.TxInfoFromHash(hash)
+	if errors.Is(err, utilreader.ErrTxNotFound) {
+		appUI.Error("None of the nodes knows about %s. It may have been dropped from the mempool; send it again with --nonce instead.", hash)
+		return
+	}
+	if err != nil {
+		appUI.Error("Couldn't get tx info from the blockchain: %s", err)
+		return
+	}
+	switch txinfo.Status {
+	case "done", "reverted":
+		appUI.Error("%s is already mined (%s). There is nothing to replace.", hash, txinfo.Status)
+		return
+	}
+	orig := txinfo.Tx.Transaction
+	chainID := config.Network().GetChainID()
+	if orig.Type() == types.BlobTxType && len(config.BlobFiles) > 0 {
+		orig, err = withBlobs(orig)
+		if err != nil {
+			appUI.Error("%s", err)
+			return
+		}
+	}
+
+	var from string
+	if txinfo.Tx.Extra.From != nil {
+		from = txinfo.Tx.Extra.From.Hex()
+	} else {
+		signer, err := jarviscommon.GetSignerAddressFromTx(orig, new(big.Int).SetUint64(chainID))
+		if err != nil {
+			appUI.Error("Couldn't derive the sender of %s: %s", hash, err)
+			return
+		}
+		from = signer.Hex()
+	}
+
+	// The replacement has to be signed by the same wallet, whatever kind it
+	// is (keystore, trezor, ledger...), so look it up by the sender address
+	// rather than by --from.
+	fromAcc, err := accounts.GetAccount(from)
+	if err != nil {
+		appUI.Error("You don't have the wallet that signed this tx (%s). Please run `jarvis wallet add` first.", from)
+		return
+	}
+
+	minedNonce, err := reader.GetMinedNonce(from)
+	if err != nil {
+		appUI.Error("Couldn't get the nonce of %s: %s", from, err)
+		return
+	}
+	if minedNonce > orig.Nonce() {
+		appUI.Error("Nonce %d of %s is already used by a mined tx. There is nothing to replace.", orig.Nonce(), from)
+		return
+	}
+
+	gasPrice := config.GasPrice
+	if gasPrice == 0 {
+		gasPrice, err = reader.RecommendedGasPrice()
+		if err != nil {
+			appUI.Error("Couldn't get recommended gas price: %s", err)
+			return
+		}
+	}
+	var tipGas float64
+	if orig.Type() != types.LegacyTxType && orig.Type() != types.AccessListTxType {
+		tipGas = config.TipGas
+		if tipGas == 0 {
+			tipGas, err = reader.GetSuggestedGasTipCap()
+			if err != nil {
+				appUI.Error("Couldn't get suggested tip: %s", err)
+				return
+			}
+		}
+	}
+
+	replacement, err := jarviscommon.BuildReplacementTx(
+		orig,
+		cancel,
+		jarviscommon.HexToAddress(from),
+		jarviscommon.GweiToWei(gasPrice),
+		jarviscommon.GweiToWei(tipGas),
+		chainID,
+	)
+	if err != nil {
+		appUI.Error("Couldn't build the replacement tx: %s", err)
+		if errors.Is(err, jarviscommon.ErrBlobsMissing) {
+			appUI.Info("Give the files the tx was sent with again with --blob.")
+		}
+		return
+	}
+	if replacement.Type() == types.BlobTxType && fromAcc.Kind != "keystore" {
+		appUI.Error("%s wallets can't sign blob txs, use a keystore wallet.", fromAcc.Kind)
+		return
+	}
+
+	action := "Speeding up"
+	if cancel {
+		action = "Cancelling"
+	}
+	appUI.Section(action + " tx")
+	appUI.KeyValue(replacementFeeRows(orig, replacement))
+
+	signedTx, err := cmdutil.PromptAndSignTx(appUI, fromAcc, replacement, nil, tc.Analyzer)
+	if err != nil {
+		if errors.Is(err, cmdutil.ErrWalletUnlock) {
+			cmdutil.ReleaseUnsettledNonces()
+			os.Exit(126)
+		}
+		appUI.Error("Failed to proceed after signing the tx: %s. Aborted.", err)
+		return
+	}
+
+	if config.DontBroadcast || config.DontWaitToBeMined {
+		if _, err := cmdutil.HandlePostSign(appUI, signedTx, reader, tc.Analyzer, nil, bc); err != nil {
+			appUI.Error("Failed to proceed after signing the tx: %s. Aborted.", err)
+		}
+		return
+	}
+
+	_, broadcasted, err := bc.BroadcastTx(signedTx)
+	util.DisplayBroadcastedTx(appUI, signedTx, broadcasted, err, config.Network())
+	if !broadcasted {
+		return
+	}
+
+	mo, err := util.EthTxMonitor(config.Network())
+	if err != nil {
+		appUI.Error("Couldn't monitor the tx: %s", err)
+		return
+	}
+	stop := appUI.Spinner("Waiting for one of the competing txs to be mined...")
+	result := mo.BlockingWaitForReplacement(hash, signedTx.Hash().Hex())
+	stop()
+
+	if result.Mined.Status == "lost" {
+		appUI.Warn("Neither %s nor %s was mined and both disappeared from the nodes.", hash, signedTx.Hash().Hex())
+		return
+	}
+	for _, replaced := range result.Replaced {
+		appUI.Info("%s was replaced.", replaced)
+	}
+	if result.MinedHash == hash {
+		appUI.Warn("The original tx got mined before the replacement.")
+	}
+	util.AnalyzeAndPrint(
+		appUI,
+		reader,
+		tc.Analyzer,
+		result.MinedHash,
+		config.Network(),
+		false,
+		"",
+		nil,
+		nil,
+		config.DegenMode,
+	)
+}
+
+// withBlobs attaches the blobs of --blob to orig, a blob tx read from a node,
+// which comes without them. They must be the blobs orig was sent with.
+func withBlobs(orig *types.Transaction) (*types.Transaction, error) {
+	blobs, err := jarviscommon.LoadBlobsFromFiles(config.BlobFiles)
+	if err != nil {
+		return nil, err
+	}
+	sidecar, err := jarviscommon.BuildBlobSidecar(blobs, !config.LegacyBlobProofs)
+	if err != nil {
+		return nil, err
+	}
+	hashes, origHashes := sidecar.BlobHashes(), orig.BlobHashes()
+	if len(hashes) != len(origHashes) {
+		return nil, fmt.Errorf("the tx carries %d blobs, --blob gives %d", len(origHashes), len(hashes))
+	}
+	for i := range hashes {
+		if hashes[i] != origHashes[i] {
+			return nil, fmt.Errorf("blob %d isn't the one the tx was sent with", i)
+		}
+	}
+	return orig.WithBlobTxSidecar(sidecar), nil
+}
+
+// replacementFeeRows renders the old and new fees side by side so the user
+// can see the bump before unlocking the wallet.
+func replacementFeeRows(orig, replacement *types.Transaction) [][2]string {
+	gwei := func(wei *big.Int) string {
+		return jarviscommon.BigToFloatString(wei, 9) + " gwei"
+	}
+	rows := [][2]string{
+		{"Nonce", new(big.Int).SetUint64(orig.Nonce()).String()},
+	}
+	if replacement.Type() != types.LegacyTxType && replacement.Type() != types.AccessListTxType {
+		rows = append(rows,
+			[2]string{"Max fee", gwei(orig.GasFeeCap()) + " -> " + gwei(replacement.GasFeeCap())},
+			[2]string{"Tip", gwei(orig.GasTipCap()) + " -> " + gwei(replacement.GasTipCap())},
+		)
+		if replacement.Type() == types.BlobTxType {
+			rows = append(rows,
+				[2]string{"Max blob fee", gwei(orig.BlobGasFeeCap()) + " -> " + gwei(replacement.BlobGasFeeCap())},
+			)
+		}
+	} else {
+		rows = append(rows,
+			[2]string{"Gas price", gwei(orig.GasPrice()) + " -> " + gwei(replacement.GasPrice())},
+		)
+	}
+	return rows
+}
+
+func init() {
+	pendingTxCmd.PersistentFlags().
+		Float64VarP(&config.GasPrice, "gasprice", "p", 0, "Gas price (max fee for dynamic fee txs) in gwei. It is only used when it is higher than the minimum replacement bump.")
+	pendingTxCmd.PersistentFlags().
+		Float64VarP(&config.TipGas, "tipgas", "s", 0, "Tip in gwei for dynamic fee txs. It is only used when it is higher than the minimum replacement bump.")
+	pendingTxCmd.PersistentFlags().
+		BoolVarP(&config.DontBroadcast, "dry", "d", false, "Will not broadcast the tx, only show signed tx.")
+	pendingTxCmd.PersistentFlags().
+		BoolVarP(&config.DontWaitToBeMined, "no-wait", "F", false, "Will not wait the tx to be mined.")
+
+	pendingTxCmd.PersistentFlags().
+		StringArrayVar(&config.BlobFiles, "blob", []string{}, "With a blob tx, the files its blobs were sent from, in the same order. Nodes don't return the blobs, the replacement has to carry them again.")
+	pendingTxCmd.PersistentFlags().
+		BoolVar(&config.LegacyBlobProofs, "legacy-blob-proofs", false, "Attach one KZG proof per blob (the pre-Osaka format) instead of the per-cell proofs")
+
+	pendingTxCmd.AddCommand(speedupTxCmd)
+	pendingTxCmd.AddCommand(cancelTxCmd)
+	rootCmd.AddCommand(pendingTxCmd)
+}
@@ -0,0 +1,81 @@
+package util
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/core/types"
+
+	jarviscommon "github.com/tranvictor/jarvis/common"
+	"github.com/tranvictor/jarvis/ui"
+	utilreader "github.com/tranvictor/jarvis/util/reader"
+)
+
+// accessListSavings remembers, by unsigned tx hash, how much gas the access
+// list attached by AttachAccessList saves, so the confirmation screen can
+// show it.
+var accessListSavings sync.Map
+
+// AttachAccessList asks the node for the EIP-2930 access list of tx with
+// eth_createAccessList and compares the gas estimated with and without it.
+// The list is attached, and the gas limit lowered by the gas it saves, only
+// when it actually saves gas; otherwise tx is returned as-is. Failing to
+// build the list is never fatal since the tx is valid without one.
+func AttachAccessList(u ui.UI, reader utilreader.Reader, from string, tx *types.Transaction) *types.Transaction {
+	if tx.To() == nil {
+		return tx
+	}
+	if tx.Type() != types.DynamicFeeTxType {
+		u.Warn("Access lists are only attached to dynamic fee txs, sending without one.")
+		return tx
+	}
+
+	to := tx.To().Hex()
+	al, _, err := reader.CreateAccessList(from, to, tx.Value(), tx.Data())
+	if err != nil {
+		u.Warn("Couldn't create the access list, sending without one: %s", err)
+		return tx
+	}
+	if len(al) == 0 {
+		u.Info("The tx doesn't need an access list.")
+		return tx
+	}
+
+	without, err := reader.EstimateGasWithAccessList(from, to, tx.Value(), tx.Data(), nil)
+	if err != nil {
+		u.Warn("Couldn't estimate gas without the access list, sending without one: %s", err)
+		return tx
+	}
+	with, err := reader.EstimateGasWithAccessList(from, to, tx.Value(), tx.Data(), al)
+	if err != nil {
+		u.Warn("Couldn't estimate gas with the access list, sending without one: %s", err)
+		return tx
+	}
+
+	addresses, keys := jarviscommon.AccessListSize(al)
+	if with >= without || without-with >= tx.Gas() {
+		u.Info(
+			"An access list of %d addresses and %d storage keys doesn't save gas (%d with it, %d without), sending without one.",
+			addresses, keys, with, without,
+		)
+		return tx
+	}
+
+	saved := without - with
+	withList, err := jarviscommon.WithAccessList(tx, al, tx.Gas()-saved)
+	if err != nil {
+		u.Warn("%s", err)
+		return tx
+	}
+	accessListSavings.Store(withList.Hash(), saved)
+	return withList
+}
+
+// accessListSaving returns the gas saved by the access list of tx, if it was
+// attached by AttachAccessList.
+func accessListSaving(tx *types.Transaction) (uint64, bool) {
+	saved, found := accessListSavings.Load(tx.Hash())
+	if !found {
+		return 0, false
+	}
+	return saved.(uint64), true
+}
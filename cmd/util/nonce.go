@@ -0,0 +1,112 @@
+package util
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/tranvictor/jarvis/config"
+	"github.com/tranvictor/jarvis/ui"
+	"github.com/tranvictor/jarvis/util/nonce"
+	utilreader "github.com/tranvictor/jarvis/util/reader"
+)
+
+type heldNonce struct {
+	from  string
+	nonce uint64
+}
+
+// unsettled are the nonces this process reserved and neither broadcasted nor
+// gave back yet, with the manager holding each.
+var unsettled = struct {
+	sync.Mutex
+	nonces map[heldNonce]*nonce.Manager
+}{nonces: map[heldNonce]*nonce.Manager{}}
+
+func forgetNonce(from string, n uint64) {
+	unsettled.Lock()
+	defer unsettled.Unlock()
+	delete(unsettled.nonces, heldNonce{strings.ToLower(from), n})
+}
+
+// ReleaseUnsettledNonces gives back the nonces this process reserved for txs
+// it didn't broadcast, e.g. because a command returned after the user
+// aborted a prompt. It is run when a command exits.
+func ReleaseUnsettledNonces() {
+	unsettled.Lock()
+	defer unsettled.Unlock()
+	for held, m := range unsettled.nonces {
+		_ = m.Release(held.from, held.nonce)
+	}
+	unsettled.nonces = map[heldNonce]*nonce.Manager{}
+}
+
+// NonceManager returns the nonce manager of the current network.
+func NonceManager(reader utilreader.Reader) *nonce.Manager {
+	return nonce.NewManager(nonce.DefaultDir(), config.Network().GetName(), reader)
+}
+
+// NextNonce reserves the next nonce of from with the local nonce manager, so
+// jarvis commands sending from the same wallet back to back, even from
+// several processes, never get the same one. The reservation is settled by
+// HandlePostSign once the tx is broadcasted, or given back with ReleaseNonce
+// when the command gives up on it. Whatever is left is given back by
+// ReleaseUnsettledNonces when the command exits.
+func NextNonce(u ui.UI, reader utilreader.Reader, from string) (uint64, error) {
+	m := NonceManager(reader)
+	n, status, err := m.Reserve(from)
+	if err != nil {
+		return 0, err
+	}
+	unsettled.Lock()
+	unsettled.nonces[heldNonce{strings.ToLower(from), n}] = m
+	unsettled.Unlock()
+	if len(status.Gaps) > 0 {
+		u.Warn(
+			"%s has %d nonce gap(s) from %d: txs sent with later nonces won't be mined until they are filled. This tx uses nonce %d, run jarvis nonce fill %s to fill the rest.",
+			from, len(status.Gaps), status.Gaps[0], n, from,
+		)
+	} else if n > status.Pending {
+		u.Info("Nonces %d to %d of %s are held by other jarvis commands, using %d.", status.Pending, n-1, from, n)
+	}
+	return n, nil
+}
+
+// ReleaseNonce gives back the nonce reserved by NextNonce for a tx that won't
+// be broadcasted. It does nothing when the nonce was given with --nonce.
+func ReleaseNonce(reader utilreader.Reader, from string, n uint64) {
+	if config.Nonce != 0 {
+		return
+	}
+	forgetNonce(from, n)
+	// a stale reservation only delays the nonce until it expires
+	_ = NonceManager(reader).Release(from, n)
+}
+
+// KeepNonce leaves the nonce reserved by NextNonce held after the command
+// exits, for a tx broadcasted by another command, e.g. one written with
+// --unsigned-out. The reservation expires after nonce.ReservationTTL like
+// any other. It does nothing when the nonce was given with --nonce.
+func KeepNonce(reader utilreader.Reader, from string, n uint64) {
+	if config.Nonce != 0 {
+		return
+	}
+	forgetNonce(from, n)
+	_ = NonceManager(reader).Hold(from, n)
+}
+
+// settleNonce records the outcome of broadcasting tx with the nonce manager.
+// Txs of another chain than the current network's, e.g. sent by a
+// cross-network batch, are left alone.
+func settleNonce(reader utilreader.Reader, from string, tx *types.Transaction, broadcasted bool) {
+	if tx.ChainId().Uint64() != config.Network().GetChainID() {
+		return
+	}
+	if broadcasted {
+		forgetNonce(from, tx.Nonce())
+		_ = NonceManager(reader).MarkBroadcasted(from, tx.Nonce(), tx.Hash().Hex())
+		return
+	}
+	ReleaseNonce(reader, from, tx.Nonce())
+}
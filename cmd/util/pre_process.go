@@ -19,8 +19,49 @@ import (
 	"github.com/tranvictor/jarvis/txanalyzer"
 	"github.com/tranvictor/jarvis/ui"
 	"github.com/tranvictor/jarvis/util"
+	utilreader "github.com/tranvictor/jarvis/util/reader"
 )
 
+// NewTxReader returns the reader of network, suggesting the fees of the
+// --speed tier.
+func NewTxReader(network networks.Network) (*utilreader.EthReader, error) {
+	speed, err := utilreader.ParseGasSpeed(config.GasSpeed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --speed: %w", err)
+	}
+	r, err := util.EthReader(network)
+	if err != nil {
+		return nil, err
+	}
+	r.Speed = speed
+	return r, nil
+}
+
+// NewTxBroadcaster returns the broadcaster of network: its private relays
+// when --private is set, its public nodes otherwise. The public nodes still
+// follow the blocks targeted by relayed txs.
+func NewTxBroadcaster(u ui.UI, network networks.Network) (TxBroadcaster, error) {
+	if !config.PrivateTx {
+		bc, err := util.EthBroadcaster(network)
+		if err != nil {
+			return nil, err
+		}
+		return bc, nil
+	}
+	r, err := util.EthReader(network)
+	if err != nil {
+		return nil, err
+	}
+	rb, err := util.EthRelayBroadcaster(network, r, config.RelayBlocks)
+	if err != nil {
+		return nil, err
+	}
+	rb.Wait = !config.DontWaitToBeMined
+	rb.Logf = u.Info
+	u.Info("Txs are sent to private relays only.")
+	return rb, nil
+}
+
 // showNodeErrorGuidance prints a structured diagnostic block when an RPC call
 // fails, suggesting how the user can inspect and fix their node configuration.
 func showNodeErrorGuidance(u ui.UI, network networks.Network) {
@@ -53,7 +94,7 @@ func CommonFunctionCallPreprocess(u ui.UI, cmd *cobra.Command, args []string) (e
 
 	tc := TxContext{}
 
-	r, err := util.EthReader(config.Network())
+	r, err := NewTxReader(config.Network())
 	if err != nil {
 		return fmt.Errorf("couldn't connect to blockchain: %w", err)
 	}
@@ -114,7 +155,7 @@ func CommonNetworkPreprocess(u ui.UI, cmd *cobra.Command, args []string) error {
 
 	tc := TxContext{}
 
-	r, err := util.EthReader(config.Network())
+	r, err := NewTxReader(config.Network())
 	if err != nil {
 		return fmt.Errorf("couldn't connect to blockchain: %w", err)
 	}
@@ -139,7 +180,7 @@ func CommonSendPreprocess(u ui.UI, cmd *cobra.Command, args []string) error {
 
 	tc := TxContext{}
 
-	r, err := util.EthReader(config.Network())
+	r, err := NewTxReader(config.Network())
 	if err != nil {
 		return fmt.Errorf("couldn't connect to blockchain: %w", err)
 	}
@@ -147,7 +188,7 @@ func CommonSendPreprocess(u ui.UI, cmd *cobra.Command, args []string) error {
 	tc.Analyzer = txanalyzer.NewGenericAnalyzer(r, config.Network())
 	tc.Resolver = DefaultABIResolver{}
 
-	bc, err := util.EthBroadcaster(config.Network())
+	bc, err := NewTxBroadcaster(u, config.Network())
 	if err != nil {
 		return fmt.Errorf("couldn't connect to broadcaster: %w", err)
 	}
@@ -235,7 +276,7 @@ func CommonTxPreprocess(u ui.UI, cmd *cobra.Command, args []string) (err error)
 	}
 
 	if config.Nonce == 0 {
-		tc.Nonce, err = reader.GetMinedNonce(tc.From)
+		tc.Nonce, err = NextNonce(u, reader, tc.From)
 		if err != nil {
 			showNodeErrorGuidance(u, config.Network())
 			return fmt.Errorf("getting nonce failed: %w", err)
@@ -266,7 +307,7 @@ func CommonTxPreprocess(u ui.UI, cmd *cobra.Command, args []string) (err error)
 		}
 	}
 
-	bc, err := util.EthBroadcaster(config.Network())
+	bc, err := NewTxBroadcaster(u, config.Network())
 	if err != nil {
 		showNodeErrorGuidance(u, config.Network())
 		return fmt.Errorf("couldn't connect to broadcaster: %w", err)
@@ -492,7 +533,7 @@ func CommonSafeTxPreprocess(u ui.UI, cmd *cobra.Command, args []string) error {
 	}
 
 	if config.Nonce == 0 {
-		tc.Nonce, err = r.GetMinedNonce(tc.From)
+		tc.Nonce, err = NextNonce(u, r, tc.From)
 		if err != nil {
 			showNodeErrorGuidance(u, config.Network())
 			return fmt.Errorf("getting nonce failed: %w", err)
@@ -521,7 +562,7 @@ func CommonSafeTxPreprocess(u ui.UI, cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	bc, err := util.EthBroadcaster(config.Network())
+	bc, err := NewTxBroadcaster(u, config.Network())
 	if err != nil {
 		showNodeErrorGuidance(u, config.Network())
 		return fmt.Errorf("couldn't connect to broadcaster: %w", err)
@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 
@@ -198,6 +199,9 @@ func PromptTxConfirmation(
 		u.Error("%s", err)
 		return err
 	}
+	if tx.To() != nil && len(tx.Data()) > 0 {
+		util.PreviewAssetChanges(u, from.Address, tx.To().Hex(), tx.Value(), tx.Data(), nil, util.NewEnrichedResolver(network), network)
+	}
 	if !config.YesToAllPrompt && !u.Confirm("Confirm?", true) {
 		return fmt.Errorf("user aborted")
 	}
@@ -373,6 +377,49 @@ func PromptFunctionCallData(
 	return method, params, nil
 }
 
+// showAuthorizationsToConfirm lists the EIP-7702 authorizations of a type-4
+// tx: who hands the control of their account to which contract.
+func showAuthorizationsToConfirm(u ui.UI, auths []types.SetCodeAuthorization, network jarvisnetworks.Network) {
+	for i, auth := range auths {
+		authorityText := "(unsigned)"
+		if authority, err := auth.Authority(); err == nil {
+			authorityText = u.Style(util.StyledAddress(util.GetJarvisAddress(authority.Hex(), network)))
+		}
+		if auth.Address == (common.Address{}) {
+			u.Critical("Auth %d: %s revokes its delegation (nonce %d, chain %s)",
+				i, authorityText, auth.Nonce, auth.ChainID.Dec())
+			continue
+		}
+		delegate := util.StyledAddress(util.GetJarvisAddress(auth.Address.Hex(), network))
+		u.Critical("Auth %d: %s delegates to %s (nonce %d, chain %s)",
+			i, authorityText, u.Style(delegate), auth.Nonce, auth.ChainID.Dec())
+	}
+}
+
+// withABI returns abis with key mapped to a, copying abis so the caller's
+// map is never modified.
+func withABI(abis map[string]*abi.ABI, key string, a *abi.ABI) map[string]*abi.ABI {
+	result := make(map[string]*abi.ABI, len(abis)+1)
+	for k, v := range abis {
+		result[k] = v
+	}
+	result[key] = a
+	return result
+}
+
+// ShowTxInfo writes the review of tx shown before signing without asking
+// for a confirmation, for previews such as the dry run of jarvis run.
+func ShowTxInfo(
+	u ui.UI,
+	analyzer util.TxAnalyzer,
+	from jarviscommon.Address,
+	tx *types.Transaction,
+	customABIs map[string]*abi.ABI,
+	network jarvisnetworks.Network,
+) error {
+	return showTxInfoToConfirm(u, analyzer, from, tx, customABIs, network)
+}
+
 // showTxInfoToConfirm writes the transaction summary (from, to, value, gas,
 // decoded function call) to the UI for the user to review before signing.
 func showTxInfoToConfirm(
@@ -425,6 +472,41 @@ func showTxInfoToConfirm(
 			jarviscommon.BigToFloat(tx.GasTipCap(), 9),
 			tx.Gas(), gasCost, network.GetNativeTokenSymbol(),
 		)
+		if len(tx.AccessList()) > 0 {
+			addresses, keys := jarviscommon.AccessListSize(tx.AccessList())
+			if saved, found := accessListSaving(tx); found {
+				u.Critical("Access list: %d addresses, %d storage keys (saves %d gas)", addresses, keys, saved)
+			} else {
+				u.Critical("Access list: %d addresses, %d storage keys", addresses, keys)
+			}
+		}
+	case types.BlobTxType:
+		u.Critical("Nonce : %d", tx.Nonce())
+		u.Critical("Gas   : Max %.4f gwei, Tip %.4f gwei (%d gas = %.8f %s)",
+			jarviscommon.BigToFloat(tx.GasFeeCap(), 9),
+			jarviscommon.BigToFloat(tx.GasTipCap(), 9),
+			tx.Gas(), gasCost, network.GetNativeTokenSymbol(),
+		)
+		blobCost := jarviscommon.BigToFloat(
+			big.NewInt(0).Mul(new(big.Int).SetUint64(tx.BlobGas()), tx.BlobGasFeeCap()),
+			18,
+		)
+		u.Critical("Blobs : %d, Max %.4f gwei per blob gas (%d blob gas = %.8f %s)",
+			len(tx.BlobHashes()),
+			jarviscommon.BigToFloat(tx.BlobGasFeeCap(), 9),
+			tx.BlobGas(), blobCost, network.GetNativeTokenSymbol(),
+		)
+		for i, h := range tx.BlobHashes() {
+			u.Info("  blob %d: %s", i, h.Hex())
+		}
+	case types.SetCodeTxType:
+		u.Critical("Nonce : %d", tx.Nonce())
+		u.Critical("Gas   : Max %.4f gwei, Tip %.4f gwei (%d gas = %.8f %s)",
+			jarviscommon.BigToFloat(tx.GasFeeCap(), 9),
+			jarviscommon.BigToFloat(tx.GasTipCap(), 9),
+			tx.Gas(), gasCost, network.GetNativeTokenSymbol(),
+		)
+		showAuthorizationsToConfirm(u, tx.SetCodeAuthorizations(), network)
 	}
 
 	if tx.To() == nil {
@@ -439,8 +521,33 @@ func showTxInfoToConfirm(
 		return nil
 	}
 
+	// the destination is an EOA running its delegate's code: decode the
+	// call with the delegate's ABI.
+	if delegate, delegated, _ := util.GetDelegate(tx.To().Hex(), network); delegated {
+		delegateStyled := util.StyledAddress(util.GetJarvisAddress(delegate, network))
+		u.Warn("To is an EOA delegated to %s (EIP-7702)", u.Style(delegateStyled))
+		key := strings.ToLower(tx.To().Hex())
+		if _, found := customABIs[key]; !found {
+			if a, err := util.GetABI(delegate, network); err == nil {
+				customABIs = withABI(customABIs, key, a)
+			}
+		}
+	}
+
+	// eth_call the tx at the pending block so a revert shows up, decoded,
+	// before signing instead of as an opaque error once mined.
+	reverted, reason, err := util.SimulateTx(from.Address, tx.To().Hex(), tx.Value(), tx.Data(), network, customABIs)
+	switch {
+	case err != nil:
+		u.Warn("Simulation: couldn't run the tx: %s", err)
+	case reverted:
+		u.Error("Simulation: the tx REVERTS: %s", reason)
+	default:
+		u.Success("Simulation: the tx succeeds")
+	}
+
 	fc := analyzer.AnalyzeFunctionCallRecursively(
-		util.GetABI,
+		util.ResolveABI,
 		tx.Value(),
 		tx.To().Hex(),
 		tx.Data(),
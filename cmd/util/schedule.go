@@ -0,0 +1,209 @@
+package util
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/tranvictor/jarvis/accounts"
+	jtypes "github.com/tranvictor/jarvis/accounts/types"
+	jarviscommon "github.com/tranvictor/jarvis/common"
+	"github.com/tranvictor/jarvis/config"
+	jarvisnetworks "github.com/tranvictor/jarvis/networks"
+	"github.com/tranvictor/jarvis/ui"
+	"github.com/tranvictor/jarvis/util"
+	"github.com/tranvictor/jarvis/util/nonce"
+	utilreader "github.com/tranvictor/jarvis/util/reader"
+	"github.com/tranvictor/jarvis/util/schedule"
+)
+
+// ScheduleTrigger builds the trigger of --at-block, --at-time and --when. It
+// returns nil when the tx isn't scheduled. The --when condition is resolved
+// and read once here so a mistake in it shows up before anything is signed.
+func ScheduleTrigger(u ui.UI, resolver ABIResolver, network jarvisnetworks.Network) (*schedule.Trigger, error) {
+	trigger := &schedule.Trigger{
+		AtBlock: config.ScheduleAtBlock,
+		Logf:    u.Info,
+	}
+	if config.ScheduleAtTime != "" {
+		t, err := schedule.ParseTime(config.ScheduleAtTime)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --at-time: %w", err)
+		}
+		trigger.AtTime = t
+	}
+	if config.ScheduleWhen != "" {
+		when, err := conditionReader(u, resolver, config.ScheduleWhen, network)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --when: %w", err)
+		}
+		trigger.When = when
+	}
+	if !trigger.IsSet() {
+		return nil, nil
+	}
+	return trigger, nil
+}
+
+// ScheduleSet tells whether --at-block, --at-time or --when is set. Commands
+// that don't broadcast through SignAndBroadcast reject them with it.
+func ScheduleSet() bool {
+	return config.ScheduleAtBlock != 0 || config.ScheduleAtTime != "" || config.ScheduleWhen != ""
+}
+
+// conditionReader resolves the contract, method and params of a --when
+// expression and returns the function reading it on chain.
+func conditionReader(u ui.UI, resolver ABIResolver, expr string, network jarvisnetworks.Network) (func() (bool, error), error) {
+	cond, err := schedule.ParseCondition(expr)
+	if err != nil {
+		return nil, err
+	}
+	to, _, err := resolver.GetAddressFromString(cond.Contract)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't find address %s: %w", cond.Contract, err)
+	}
+	a, err := resolver.ConfigToABI(to, false, "", network)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get abi for %s: %w", to, err)
+	}
+
+	var method *abi.Method
+	for name := range a.Methods {
+		m := a.Methods[name]
+		if m.Name == cond.Method || m.Sig == cond.Method {
+			method = &m
+			break
+		}
+	}
+	if method == nil {
+		return nil, fmt.Errorf("%s has no method %s", to, cond.Method)
+	}
+	if len(method.Outputs) == 0 {
+		return nil, fmt.Errorf("%s returns nothing to compare", method.Sig)
+	}
+	if len(cond.Params) != len(method.Inputs) {
+		return nil, fmt.Errorf("%s takes %d param(s), %d given", method.Sig, len(method.Inputs), len(cond.Params))
+	}
+	params := []interface{}{}
+	for i, input := range method.Inputs {
+		if cond.Params[i] == "" {
+			return nil, fmt.Errorf("param %d (%s) is empty", i+1, input.Name)
+		}
+		param, err := PromptParam(u, false, input, cond.Params[i], network)
+		if err != nil {
+			return nil, fmt.Errorf("param %d (%s) is not valid: %w", i+1, input.Name, err)
+		}
+		params = append(params, param)
+	}
+
+	r, err := util.EthReader(network)
+	if err != nil {
+		return nil, err
+	}
+	read := func() (bool, error) {
+		data, err := r.ReadContractToBytes(-1, utilreader.DEFAULT_ADDRESS, to, a, method.Name, params...)
+		if err != nil {
+			return false, fmt.Errorf("couldn't read %s: %w", method.Sig, err)
+		}
+		values, err := method.Outputs.UnpackValues(data)
+		if err != nil {
+			return false, fmt.Errorf("couldn't decode %s: %w", method.Sig, err)
+		}
+		return cond.Holds(values[0])
+	}
+
+	holds, err := read()
+	if err != nil {
+		return nil, err
+	}
+	u.Info("Condition: %s on %s, currently %v", cond, jarviscommon.VerboseAddress(util.GetJarvisAddress(to, network)), holds)
+	return read, nil
+}
+
+// signAndBroadcastScheduled is SignAndBroadcast for a tx held back by
+// trigger. A keystore tx is signed right away so nobody has to type the
+// passphrase when it fires; a hw wallet tx is confirmed now and signed when
+// the trigger fires, which needs the device at hand then. The tx is dropped
+// if another tx uses its nonce while it waits.
+func signAndBroadcastScheduled(
+	u ui.UI,
+	fromAcc jtypes.AccDesc,
+	tx *types.Transaction,
+	customABIs map[string]*abi.ABI,
+	reader utilreader.Reader,
+	analyzer util.TxAnalyzer,
+	a *abi.ABI,
+	bc TxBroadcaster,
+	trigger *schedule.Trigger,
+) (bool, error) {
+	chain, err := util.EthReader(config.Network())
+	if err != nil {
+		ReleaseNonce(reader, fromAcc.Address, tx.Nonce())
+		return false, err
+	}
+
+	var signedTx *types.Transaction
+	if fromAcc.Kind == "keystore" {
+		signedTx, err = PromptAndSignTx(u, fromAcc, tx, customABIs, analyzer)
+	} else {
+		err = PromptTxConfirmation(u, analyzer, util.GetJarvisAddress(fromAcc.Address, config.Network()), tx, customABIs, config.Network())
+		if err != nil {
+			u.Error("Aborted!")
+		}
+	}
+	if err != nil {
+		ReleaseNonce(reader, fromAcc.Address, tx.Nonce())
+		return false, err
+	}
+
+	held := time.Now()
+	nonceUnused := func() error {
+		mined, err := chain.GetMinedNonce(fromAcc.Address)
+		if err != nil {
+			// the node is retried on the next poll
+			return nil
+		}
+		if mined > tx.Nonce() {
+			return fmt.Errorf("nonce %d of %s got used by another tx, the scheduled tx is dropped", tx.Nonce(), fromAcc.Address)
+		}
+		if config.Nonce == 0 && time.Since(held) > nonce.ReservationTTL/3 {
+			_ = NonceManager(reader).Hold(fromAcc.Address, tx.Nonce())
+			held = time.Now()
+		}
+		return nil
+	}
+	trigger.Check = nonceUnused
+
+	u.Info("The tx (nonce %d) is held back until %s. Keep jarvis running.", tx.Nonce(), trigger)
+	if err := trigger.Wait(chain); err != nil {
+		u.Error("%s", err)
+		ReleaseNonce(reader, fromAcc.Address, tx.Nonce())
+		return false, err
+	}
+	u.Success("The schedule is reached.")
+
+	if signedTx == nil {
+		u.Info("Unlock your wallet and sign now...")
+		account, err := accounts.UnlockAccount(fromAcc)
+		if err != nil {
+			ReleaseNonce(reader, fromAcc.Address, tx.Nonce())
+			return false, fmt.Errorf("%w: %s", ErrWalletUnlock, err)
+		}
+		signedTx, err = SignTxAs(account, fromAcc.Address, tx)
+		if err != nil {
+			ReleaseNonce(reader, fromAcc.Address, tx.Nonce())
+			return false, err
+		}
+	}
+
+	// the nonce could have been used while the wallet was being unlocked
+	if mined, err := chain.GetMinedNonce(fromAcc.Address); err == nil && mined > tx.Nonce() {
+		err = fmt.Errorf("nonce %d of %s got used by another tx, the scheduled tx is dropped", tx.Nonce(), fromAcc.Address)
+		u.Error("%s", err)
+		ReleaseNonce(reader, fromAcc.Address, tx.Nonce())
+		return false, err
+	}
+	return HandlePostSign(u, signedTx, reader, analyzer, a, bc)
+}
@@ -76,6 +76,10 @@ func TxContextFrom(cmd *cobra.Command) (TxContext, bool) {
 // ValidTxType returns the appropriate transaction type for the current network,
 // respecting config.ForceLegacy.
 func ValidTxType(r reader.Reader, network jarvisnetworks.Network) (uint8, error) {
+	if len(config.BlobFiles) > 0 {
+		return validBlobTxType(r, network)
+	}
+
 	if config.ForceLegacy {
 		return types.LegacyTxType, nil
 	}
@@ -91,3 +95,22 @@ func ValidTxType(r reader.Reader, network jarvisnetworks.Network) (uint8, error)
 
 	return types.DynamicFeeTxType, nil
 }
+
+// validBlobTxType is ValidTxType for when blobs are attached: there is no
+// fallback, either the chain takes type-3 txs or the command must fail.
+func validBlobTxType(r reader.Reader, network jarvisnetworks.Network) (uint8, error) {
+	if config.ForceLegacy {
+		return 0, fmt.Errorf("blob txs can't be sent as legacy txs")
+	}
+
+	isBlobAvailable, err := r.CheckBlobTxAvailable()
+	if err != nil {
+		return 0, fmt.Errorf("couldn't check if the chain support blob txs: %w", err)
+	}
+
+	if !isBlobAvailable {
+		return 0, fmt.Errorf("%s doesn't support blob txs", network.GetName())
+	}
+
+	return types.BlobTxType, nil
+}
@@ -26,6 +26,7 @@ import (
 	jarvisnetworks "github.com/tranvictor/jarvis/networks"
 	"github.com/tranvictor/jarvis/ui"
 	"github.com/tranvictor/jarvis/util"
+	jarvisaccount "github.com/tranvictor/jarvis/util/account"
 	utilreader "github.com/tranvictor/jarvis/util/reader"
 )
 
@@ -143,10 +144,10 @@ func AnalyzeAndShowMsigTxInfo(
 	return
 }
 
-// classicMsigABI returns the ABI for packing Gnosis Classic multisig calls.
+// ClassicMsigABI returns the ABI for packing Gnosis Classic multisig calls.
 // It prefers the verified explorer ABI when available and falls back to the
 // built-in classic ABI when the contract is unverified — same as bapprove.
-func classicMsigABI(resolver ABIResolver, addr string, network jarvisnetworks.Network) *abi.ABI {
+func ClassicMsigABI(resolver ABIResolver, addr string, network jarvisnetworks.Network) *abi.ABI {
 	a, err := resolver.GetABI(addr, network)
 	if err == nil {
 		return a
@@ -154,6 +155,24 @@ func classicMsigABI(resolver ABIResolver, addr string, network jarvisnetworks.Ne
 	return util.GetGnosisMsigABI()
 }
 
+// msigSubmissionTopic is the topic of the Submission(uint256) event a Gnosis
+// Classic multisig emits when a tx is submitted to it.
+const msigSubmissionTopic = "0xc0ba8fe4b176c1714197d43b9cc6bcf797a4a7461c5fe8d0ef6e184ae7601e51"
+
+// MsigTxIDFromReceipt returns the id of the multisig tx the init tx of
+// receipt submitted to the Gnosis Classic multisig msig, nil when the
+// receipt has no such submission.
+func MsigTxIDFromReceipt(receipt *types.Receipt, msig string) *big.Int {
+	for _, l := range receipt.Logs {
+		if strings.EqualFold(l.Address.Hex(), msig) &&
+			len(l.Topics) > 1 &&
+			l.Topics[0].Hex() == msigSubmissionTopic {
+			return l.Topics[1].Big()
+		}
+	}
+	return nil
+}
+
 // PostProcessFunc is a callback called with the decoded function call after
 // displaying a multisig transaction. Return an error to abort the flow.
 type PostProcessFunc func(fc *jarviscommon.FunctionCall) error
@@ -233,13 +252,7 @@ func HandleApproveOrRevokeOrExecuteMsig(
 			u.Error("Can't get receipt of the init tx. That tx might still be pending.")
 			return
 		}
-		for _, l := range txInfo.Receipt.Logs {
-			if strings.EqualFold(l.Address.Hex(), tc.To) &&
-				l.Topics[0].Hex() == "0xc0ba8fe4b176c1714197d43b9cc6bcf797a4a7461c5fe8d0ef6e184ae7601e51" {
-				txid = l.Topics[1].Big()
-				break
-			}
-		}
+		txid = MsigTxIDFromReceipt(txInfo.Receipt, tc.To)
 		if txid == nil {
 			u.Error("The provided tx hash is not a gnosis multisig init tx or with a different multisig.")
 			return
@@ -263,7 +276,7 @@ func HandleApproveOrRevokeOrExecuteMsig(
 		return
 	}
 
-	a := classicMsigABI(tc.Resolver, tc.To, config.Network())
+	a := ClassicMsigABI(tc.Resolver, tc.To, config.Network())
 
 	data, err := a.Pack(method, txid)
 	if err != nil {
@@ -276,6 +289,7 @@ func HandleApproveOrRevokeOrExecuteMsig(
 		gasLimit, err = reader.EstimateExactGas(tc.From, tc.To, 0, tc.Value, data)
 		if err != nil {
 			u.Error("Couldn't estimate gas limit: %s", err)
+			ShowRevertReason(u, err, tc.To, map[string]*abi.ABI{strings.ToLower(tc.To): a})
 			return
 		}
 	}
@@ -303,6 +317,15 @@ func HandleApproveOrRevokeOrExecuteMsig(
 	}
 }
 
+// ShowRevertReason tells why a call to to reverts when err, the error of an
+// eth_estimateGas or eth_call, is a revert. Nodes only report an opaque
+// "execution reverted" otherwise.
+func ShowRevertReason(u ui.UI, err error, to string, customABIs map[string]*abi.ABI) {
+	if reason, ok := util.RevertReason(err, to, config.Network(), customABIs); ok {
+		u.Error("The tx reverts: %s", reason)
+	}
+}
+
 // ErrWalletUnlock is returned by SignAndBroadcast when the wallet cannot be
 // unlocked. Callers that need a specific exit code (e.g. 126) can test with
 // errors.Is.
@@ -310,6 +333,8 @@ var ErrWalletUnlock = errors.New("wallet unlock failed")
 
 // SignAndBroadcast prompts the user for confirmation, unlocks the wallet,
 // signs the transaction, verifies the signer, and hands off to HandlePostSign.
+// With --at-block, --at-time or --when the broadcast is held back until the
+// schedule is reached.
 func SignAndBroadcast(
 	u ui.UI,
 	fromAcc jtypes.AccDesc,
@@ -320,30 +345,116 @@ func SignAndBroadcast(
 	a *abi.ABI,
 	bc TxBroadcaster,
 ) (bool, error) {
+	if config.AccessList {
+		tx = AttachAccessList(u, reader, fromAcc.Address, tx)
+	}
+
+	if config.UnsignedTxFile != "" {
+		if err := WriteUnsignedTx(u, config.UnsignedTxFile, fromAcc, tx, customABIs, analyzer); err != nil {
+			ReleaseNonce(reader, fromAcc.Address, tx.Nonce())
+			return false, err
+		}
+		// the tx is broadcasted later by jarvis broadcast
+		KeepNonce(reader, fromAcc.Address, tx.Nonce())
+		return false, nil
+	}
+
+	trigger, err := ScheduleTrigger(u, DefaultABIResolver{}, config.Network())
+	if err != nil {
+		ReleaseNonce(reader, fromAcc.Address, tx.Nonce())
+		return false, err
+	}
+	if trigger != nil {
+		return signAndBroadcastScheduled(u, fromAcc, tx, customABIs, reader, analyzer, a, bc, trigger)
+	}
+
+	signedTx, err := PromptAndSignTx(u, fromAcc, tx, customABIs, analyzer)
+	if err != nil {
+		ReleaseNonce(reader, fromAcc.Address, tx.Nonce())
+		return false, err
+	}
+
+	return HandlePostSign(u, signedTx, reader, analyzer, a, bc)
+}
+
+// PromptAndSignTx is the first half of SignAndBroadcast: it prompts the user
+// for confirmation, unlocks the wallet, signs the transaction and verifies
+// the signer. Commands that need to broadcast or monitor the signed tx in
+// their own way (e.g. `jarvis tx speedup`) call it directly.
+func PromptAndSignTx(
+	u ui.UI,
+	fromAcc jtypes.AccDesc,
+	tx *types.Transaction,
+	customABIs map[string]*abi.ABI,
+	analyzer util.TxAnalyzer,
+) (*types.Transaction, error) {
 	if err := PromptTxConfirmation(u, analyzer, util.GetJarvisAddress(fromAcc.Address, config.Network()), tx, customABIs, config.Network()); err != nil {
 		u.Error("Aborted!")
-		return false, err
+		return nil, err
 	}
 
 	u.Info("Unlock your wallet and sign now...")
 	account, err := accounts.UnlockAccount(fromAcc)
 	if err != nil {
-		return false, fmt.Errorf("%w: %s", ErrWalletUnlock, err)
+		return nil, fmt.Errorf("%w: %s", ErrWalletUnlock, err)
 	}
+	return SignTxAs(account, fromAcc.Address, tx)
+}
 
+// SignTxAs signs tx on the current network with an already unlocked account
+// and makes sure the signer is fromAddr, which catches a wrong hw wallet or
+// passphrase.
+func SignTxAs(account *jarvisaccount.Account, fromAddr string, tx *types.Transaction) (*types.Transaction, error) {
 	signedAddr, signedTx, err := account.SignTx(tx, big.NewInt(int64(config.Network().GetChainID())))
 	if err != nil {
-		return false, fmt.Errorf("couldn't sign tx: %w", err)
+		return nil, fmt.Errorf("couldn't sign tx: %w", err)
 	}
-	if signedAddr.Cmp(jarviscommon.HexToAddress(fromAcc.Address)) != 0 {
-		return false, fmt.Errorf(
+	if signedAddr.Cmp(jarviscommon.HexToAddress(fromAddr)) != 0 {
+		return nil, fmt.Errorf(
 			"signed from wrong address. You could use wrong hw or passphrase. Expected wallet: %s, signed wallet: %s",
-			fromAcc.Address,
+			fromAddr,
 			signedAddr.Hex(),
 		)
 	}
+	return signedTx, nil
+}
 
-	return HandlePostSign(u, signedTx, reader, analyzer, a, bc)
+// WriteUnsignedTx is the --unsigned-out branch of SignAndBroadcast. Instead of
+// unlocking a wallet it decodes the calldata with the online analyzer and
+// writes everything the offline signer needs to see to path.
+func WriteUnsignedTx(
+	u ui.UI,
+	path string,
+	fromAcc jtypes.AccDesc,
+	tx *types.Transaction,
+	customABIs map[string]*abi.ABI,
+	analyzer util.TxAnalyzer,
+) error {
+	var call *util.FunctionCallDisplay
+	if tx.To() != nil && len(tx.Data()) > 0 && analyzer != nil {
+		fc := analyzer.AnalyzeFunctionCallRecursively(
+			util.ResolveABI, tx.Value(), tx.To().Hex(), tx.Data(), customABIs)
+		call = util.DisplayFunctionCall(u, fc)
+	}
+
+	f, err := util.NewTxFile(
+		config.Network().GetName(),
+		config.Network().GetChainID(),
+		fromAcc.Address,
+		tx,
+		call,
+	)
+	if err != nil {
+		return err
+	}
+	if err := util.WriteTxFile(path, f); err != nil {
+		return err
+	}
+
+	u.Success("Unsigned tx (nonce %d) written to %s", tx.Nonce(), path)
+	u.Info("Sign it on the offline machine with:")
+	u.Info("  jarvis sign %s", path)
+	return nil
 }
 
 type signedTxResultJSON struct {
@@ -395,13 +506,17 @@ func HandlePostSign(
 		defer resultJSON.Write(u, config.JSONOutputFile)
 	}
 
+	defer func() {
+		settleNonce(reader, signerHex.Hex(), signedTx, broadcasted)
+	}()
+
 	if config.DontBroadcast {
 		u.Critical("Signed tx: %s", signedHex)
 		return false, nil
 	}
 
 	if !config.RetryBroadcast {
-		_, broadcasted, err := broadcaster.BroadcastTx(signedTx)
+		_, broadcasted, err = broadcaster.BroadcastTx(signedTx)
 		if config.DontWaitToBeMined {
 			util.DisplayBroadcastedTx(u, signedTx, broadcasted, err, config.Network())
 			return broadcasted, err
@@ -0,0 +1,31 @@
+package common
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// WithAccessList rebuilds a dynamic-fee tx with al attached and its gas limit
+// set to gasLimit. The tx must not be signed yet.
+func WithAccessList(tx *types.Transaction, al types.AccessList, gasLimit uint64) (*types.Transaction, error) {
+	if tx.Type() != types.DynamicFeeTxType {
+		return nil, fmt.Errorf("access lists are only attached to dynamic fee txs, got tx type %d", tx.Type())
+	}
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:    tx.ChainId(),
+		Nonce:      tx.Nonce(),
+		GasTipCap:  tx.GasTipCap(),
+		GasFeeCap:  tx.GasFeeCap(),
+		Gas:        gasLimit,
+		To:         tx.To(),
+		Value:      tx.Value(),
+		Data:       tx.Data(),
+		AccessList: al,
+	}), nil
+}
+
+// AccessListSize returns how many addresses and storage keys al holds.
+func AccessListSize(al types.AccessList) (addresses int, storageKeys int) {
+	return len(al), al.StorageKeys()
+}
@@ -0,0 +1,39 @@
+package common
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestWithAccessList(t *testing.T) {
+	to := common.HexToAddress("0x1d9937e170Fc2174408581265bA0B87afDA4947F")
+	al := types.AccessList{
+		{Address: to, StorageKeys: []common.Hash{{0x01}, {0x02}}},
+		{Address: common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")},
+	}
+	tx := BuildExactTx(types.DynamicFeeTxType, 5, to.Hex(), big.NewInt(7), 100000, 20, 1, []byte{0xa9, 0x05, 0x9c, 0xbb}, 1)
+
+	withList, err := WithAccessList(tx, al, 95000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if withList.Gas() != 95000 || len(withList.AccessList()) != 2 {
+		t.Fatalf("gas = %d, access list = %v", withList.Gas(), withList.AccessList())
+	}
+	if withList.Nonce() != tx.Nonce() || withList.GasFeeCap().Cmp(tx.GasFeeCap()) != 0 ||
+		withList.GasTipCap().Cmp(tx.GasTipCap()) != 0 || withList.Value().Cmp(tx.Value()) != 0 ||
+		*withList.To() != to || withList.ChainId().Cmp(tx.ChainId()) != 0 {
+		t.Fatalf("tx fields changed")
+	}
+	if addrs, keys := AccessListSize(withList.AccessList()); addrs != 2 || keys != 2 {
+		t.Fatalf("size = %d addresses, %d keys", addrs, keys)
+	}
+
+	legacy := BuildExactTx(types.LegacyTxType, 5, to.Hex(), big.NewInt(7), 100000, 20, 0, nil, 1)
+	if _, err := WithAccessList(legacy, al, 95000); err == nil {
+		t.Fatalf("expected an error for a legacy tx")
+	}
+}
@@ -0,0 +1,162 @@
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// BlobSize is the size in bytes of one EIP-4844 blob.
+const BlobSize = params.BlobTxFieldElementsPerBlob * 32
+
+// BlobDataCapacity is how many bytes of arbitrary data EncodeBlob can pack in
+// one blob: 31 bytes per 32-byte field element, with the top byte of every
+// element left at zero so it always stays below the BLS12-381 modulus.
+const BlobDataCapacity = params.BlobTxFieldElementsPerBlob * 31
+
+// EncodeBlob packs data into a blob, 31 bytes per field element.
+func EncodeBlob(data []byte) (*kzg4844.Blob, error) {
+	if len(data) > BlobDataCapacity {
+		return nil, fmt.Errorf("%d bytes don't fit in a blob, the limit is %d", len(data), BlobDataCapacity)
+	}
+	var blob kzg4844.Blob
+	for i := 0; i*31 < len(data); i++ {
+		end := (i + 1) * 31
+		if end > len(data) {
+			end = len(data)
+		}
+		copy(blob[i*32+1:], data[i*31:end])
+	}
+	return &blob, nil
+}
+
+// ReadBlobFile reads the content of one blob file. A file holding 0x-prefixed
+// hex text is decoded, anything else is taken as raw bytes.
+func ReadBlobFile(path string) ([]byte, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	trimmed := strings.TrimSpace(string(content))
+	if strings.HasPrefix(trimmed, "0x") {
+		if decoded, err := hexutil.Decode(trimmed); err == nil {
+			return decoded, nil
+		}
+	}
+	return content, nil
+}
+
+// LoadBlobsFromFiles builds one blob per file. A file of exactly BlobSize
+// bytes is taken as an already-encoded blob (e.g. produced by a rollup batch
+// poster); any other file is packed with EncodeBlob.
+func LoadBlobsFromFiles(paths []string) ([]kzg4844.Blob, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no blob files given")
+	}
+	if len(paths) > params.BlobTxMaxBlobs {
+		return nil, fmt.Errorf("a blob tx carries at most %d blobs, got %d files", params.BlobTxMaxBlobs, len(paths))
+	}
+	blobs := make([]kzg4844.Blob, 0, len(paths))
+	for _, path := range paths {
+		data, err := ReadBlobFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading blob file %s failed: %w", path, err)
+		}
+		if len(data) == BlobSize {
+			var blob kzg4844.Blob
+			copy(blob[:], data)
+			blobs = append(blobs, blob)
+			continue
+		}
+		blob, err := EncodeBlob(data)
+		if err != nil {
+			return nil, fmt.Errorf("blob file %s: %w", path, err)
+		}
+		blobs = append(blobs, *blob)
+	}
+	return blobs, nil
+}
+
+// BuildBlobSidecar computes the KZG commitment of every blob and attaches the
+// proofs nodes need to accept the tx. With cellProofs the sidecar carries the
+// per-cell proofs required since Osaka (sidecar version 1); without it, the
+// single per-blob proof of the original EIP-4844 wrapper (version 0).
+func BuildBlobSidecar(blobs []kzg4844.Blob, cellProofs bool) (*types.BlobTxSidecar, error) {
+	commitments := make([]kzg4844.Commitment, 0, len(blobs))
+	proofs := []kzg4844.Proof{}
+	for i := range blobs {
+		commitment, err := kzg4844.BlobToCommitment(&blobs[i])
+		if err != nil {
+			return nil, fmt.Errorf("computing commitment of blob %d failed: %w", i, err)
+		}
+		commitments = append(commitments, commitment)
+
+		if cellProofs {
+			cells, err := kzg4844.ComputeCellProofs(&blobs[i])
+			if err != nil {
+				return nil, fmt.Errorf("computing cell proofs of blob %d failed: %w", i, err)
+			}
+			proofs = append(proofs, cells...)
+		} else {
+			proof, err := kzg4844.ComputeBlobProof(&blobs[i], commitment)
+			if err != nil {
+				return nil, fmt.Errorf("computing proof of blob %d failed: %w", i, err)
+			}
+			proofs = append(proofs, proof)
+		}
+	}
+	version := types.BlobSidecarVersion0
+	if cellProofs {
+		version = types.BlobSidecarVersion1
+	}
+	return types.NewBlobTxSidecar(version, blobs, commitments, proofs), nil
+}
+
+// BuildExactBlobTx is the type-3 counterpart of BuildExactTx. The returned tx
+// carries sidecar, so once signed its binary encoding is the network wrapper
+// form that eth_sendRawTransaction expects for blob txs.
+func BuildExactBlobTx(
+	nonce uint64,
+	to string,
+	ethAmount *big.Int,
+	gasLimit uint64,
+	priceGwei float64,
+	tipCapGwei float64,
+	blobFeeCap *big.Int,
+	data []byte,
+	sidecar *types.BlobTxSidecar,
+	chainID uint64,
+) *types.Transaction {
+	return types.NewTx(&types.BlobTx{
+		ChainID:    uint256.NewInt(chainID),
+		Nonce:      nonce,
+		GasTipCap:  uint256.MustFromBig(GweiToWei(tipCapGwei)),
+		GasFeeCap:  uint256.MustFromBig(GweiToWei(priceGwei)),
+		Gas:        gasLimit,
+		To:         common.HexToAddress(to),
+		Value:      uint256.MustFromBig(ethAmount),
+		Data:       data,
+		BlobFeeCap: uint256.MustFromBig(blobFeeCap),
+		BlobHashes: sidecar.BlobHashes(),
+		Sidecar:    sidecar,
+	})
+}
+
+// DecodeBlob is the inverse of EncodeBlob. Trailing zero bytes are dropped,
+// since the packing can't tell them apart from padding.
+func DecodeBlob(blob *kzg4844.Blob) []byte {
+	data := make([]byte, 0, BlobDataCapacity)
+	for i := 0; i < params.BlobTxFieldElementsPerBlob; i++ {
+		data = append(data, blob[i*32+1:(i+1)*32]...)
+	}
+	return bytes.TrimRight(data, "\x00")
+}
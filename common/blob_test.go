@@ -0,0 +1,104 @@
+package common
+
+import (
+	"bytes"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+)
+
+func TestEncodeDecodeBlob(t *testing.T) {
+	data := bytes.Repeat([]byte{0xff, 0x01, 0x7a}, 1000)
+	blob, err := EncodeBlob(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < len(blob); i += 32 {
+		if blob[i] != 0 {
+			t.Fatalf("top byte of field element %d is %x, want 0", i/32, blob[i])
+		}
+	}
+	if got := DecodeBlob(blob); !bytes.Equal(got, data) {
+		t.Fatalf("decoded %d bytes, want %d", len(got), len(data))
+	}
+	if _, err := EncodeBlob(make([]byte, BlobDataCapacity+1)); err == nil {
+		t.Fatalf("expected an error for data over the blob capacity")
+	}
+}
+
+func TestLoadBlobsFromFiles(t *testing.T) {
+	dir := t.TempDir()
+	hexFile := filepath.Join(dir, "calldata.hex")
+	if err := os.WriteFile(hexFile, []byte("0xdeadbeef\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rawFile := filepath.Join(dir, "blob.bin")
+	raw := make([]byte, BlobSize)
+	raw[33] = 0x42
+	if err := os.WriteFile(rawFile, raw, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	blobs, err := LoadBlobsFromFiles([]string{hexFile, rawFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blobs) != 2 {
+		t.Fatalf("got %d blobs, want 2", len(blobs))
+	}
+	if got := DecodeBlob(&blobs[0]); !bytes.Equal(got, []byte{0xde, 0xad, 0xbe, 0xef}) {
+		t.Fatalf("hex file decoded to %x", got)
+	}
+	if !bytes.Equal(blobs[1][:], raw) {
+		t.Fatalf("a BlobSize file must be used as-is")
+	}
+}
+
+func TestBuildExactBlobTx(t *testing.T) {
+	blob, err := EncodeBlob([]byte("jarvis"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cellProofs := range []bool{false, true} {
+		sidecar, err := BuildBlobSidecar([]kzg4844.Blob{*blob}, cellProofs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sidecar.ValidateBlobCommitmentHashes(sidecar.BlobHashes()); err != nil {
+			t.Fatal(err)
+		}
+
+		tx := BuildExactBlobTx(
+			3, "0x1d9937e170Fc2174408581265bA0B87afDA4947F", big.NewInt(0),
+			21000, 2, 1, big.NewInt(1e9), nil, sidecar, 1,
+		)
+		if tx.Type() != types.BlobTxType || len(tx.BlobHashes()) != 1 {
+			t.Fatalf("unexpected tx: type %d, %d blob hashes", tx.Type(), len(tx.BlobHashes()))
+		}
+
+		key, _ := crypto.GenerateKey()
+		signed, err := types.SignTx(tx, types.LatestSignerForChainID(big.NewInt(1)), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw, err := signed.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded := new(types.Transaction)
+		if err := decoded.UnmarshalBinary(raw); err != nil {
+			t.Fatal(err)
+		}
+		if decoded.BlobTxSidecar() == nil {
+			t.Fatalf("raw tx must be in the network wrapper form")
+		}
+		if decoded.Hash() != signed.Hash() {
+			t.Fatalf("hash changed across encoding")
+		}
+	}
+}
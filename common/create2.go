@@ -0,0 +1,57 @@
+package common
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// DeterministicDeployer is the CREATE2 factory of
+// github.com/Arachnid/deterministic-deployment-proxy. It is deployed at the
+// same address on most chains with a keyless tx, so a contract it deploys
+// with the same salt and init code gets the same address on all of them.
+// Its calldata is the 32 byte salt followed by the init code.
+var DeterministicDeployer = common.HexToAddress("0x4e59b44847b379578588920cA78FbF26c0B4956C")
+
+// Create2Address is the address deployer creates a contract at with
+// CREATE2, salt and initCode (EIP-1014).
+func Create2Address(deployer common.Address, salt [32]byte, initCode []byte) common.Address {
+	return crypto.CreateAddress2(deployer, salt, crypto.Keccak256(initCode))
+}
+
+// DeterministicDeployData is the calldata of a tx to DeterministicDeployer
+// deploying initCode with salt.
+func DeterministicDeployData(salt [32]byte, initCode []byte) []byte {
+	return append(salt[:], initCode...)
+}
+
+// ParseSalt reads a CREATE2 salt given as hex of up to 32 bytes, left padded
+// with zeros, or as a decimal number.
+func ParseSalt(s string) (salt [32]byte, err error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		b, err := hexToBytes(s[2:])
+		if err != nil || len(b) > 32 {
+			return salt, fmt.Errorf("salt %s is not hex of up to 32 bytes", s)
+		}
+		copy(salt[32-len(b):], b)
+		return salt, nil
+	}
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok || n.Sign() < 0 || n.BitLen() > 256 {
+		return salt, fmt.Errorf("salt %s is neither hex nor a 256 bit number", s)
+	}
+	n.FillBytes(salt[:])
+	return salt, nil
+}
+
+func hexToBytes(s string) ([]byte, error) {
+	if len(s)%2 == 1 {
+		s = "0" + s
+	}
+	return hex.DecodeString(s)
+}
@@ -0,0 +1,49 @@
+package common
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestCreate2Address(t *testing.T) {
+	// example 5 of EIP-1014
+	salt, err := ParseSalt("0x00000000000000000000000000000000000000000000000000000000cafebabe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := Create2Address(common.HexToAddress("0x00000000000000000000000000000000deadbeef"), salt, common.FromHex("0xdeadbeef"))
+	if want := common.HexToAddress("0x60f3f640a8508fC6a86d45DF051962668E1e8AC7"); got != want {
+		t.Fatalf("got %s, want %s", got.Hex(), want.Hex())
+	}
+	data := DeterministicDeployData(salt, []byte{0xde, 0xad})
+	if len(data) != 34 || data[31] != 0xbe || data[33] != 0xad {
+		t.Fatalf("deploy data %x", data)
+	}
+}
+
+func TestParseSalt(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		last byte
+	}{
+		{"0x1", 1},
+		{"0xcafe", 0xfe},
+		{"258", 2},
+		{"0", 0},
+	} {
+		salt, err := ParseSalt(tc.in)
+		if err != nil {
+			t.Fatalf("%s: %s", tc.in, err)
+		}
+		if salt[31] != tc.last {
+			t.Fatalf("%s parsed to %x", tc.in, salt)
+		}
+	}
+	for _, bad := range []string{"0xzz", "-1", "salt", "0x" + strings.Repeat("00", 33)} {
+		if _, err := ParseSalt(bad); err == nil {
+			t.Fatalf("%q must not parse", bad)
+		}
+	}
+}
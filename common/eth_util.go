@@ -36,6 +36,11 @@ func GetMultiCallABI() *abi.ABI {
 	return &result
 }
 
+func GetMultiCall3ABI() *abi.ABI {
+	result, _ := abi.JSON(strings.NewReader(multicall3abi))
+	return &result
+}
+
 func GetERC20ABI() *abi.ABI {
 	result, _ := abi.JSON(strings.NewReader(erc20abi))
 	return &result
@@ -46,11 +51,21 @@ func GetMultiSendABI() *abi.ABI {
 	return &result
 }
 
+func GetDisperseABI() *abi.ABI {
+	result, _ := abi.JSON(strings.NewReader(disperseabi))
+	return &result
+}
+
 func GetEIP1967BeaconABI() *abi.ABI {
 	result, _ := abi.JSON(strings.NewReader(eip1967beacon))
 	return &result
 }
 
+func GetDiamondLoupeABI() *abi.ABI {
+	result, _ := abi.JSON(strings.NewReader(diamondloupeabi))
+	return &result
+}
+
 func PackERC20Data(function string, params ...interface{}) ([]byte, error) {
 	return GetERC20ABI().Pack(function, params...)
 }
@@ -56,6 +56,9 @@ type FunctionCall struct {
 	Data                 []byte
 	DecodedFunctionCalls []*FunctionCall
 	Error                string
+	// Guessed is set when the method was decoded with a signature from the
+	// local signature database because the destination has no ABI for it.
+	Guessed bool
 }
 
 // ParamResult is the general struct that aims to be able to store all of the information of a parameter
@@ -112,6 +115,19 @@ type LogResult struct {
 	Name   string
 	Topics []TopicResult
 	Data   []ParamResult
+	// Guessed is set when the event was decoded with a signature from the
+	// local signature database.
+	Guessed bool
+}
+
+// AuthorizationResult is one EIP-7702 authorization carried by a type-4 tx.
+// A zero Delegate address means the authority revokes its delegation.
+type AuthorizationResult struct {
+	Authority Address
+	Delegate  Address
+	Nonce     string
+	ChainID   string
+	Error     string `json:",omitempty"`
 }
 
 type TxResults map[string]*TxResult
@@ -136,6 +152,17 @@ type TxResult struct {
 	Timestamp string
 	TxType    string
 
+	// Blob tx (type 3) details, empty for other txs.
+	BlobHashes   []string `json:",omitempty"`
+	BlobGasUsed  string   `json:",omitempty"`
+	BlobGasPrice string   `json:",omitempty"`
+
+	// EIP-7702: the delegates From and To currently run the code of, and
+	// the authorizations of a type-4 tx.
+	FromDelegate   *Address              `json:",omitempty"`
+	ToDelegate     *Address              `json:",omitempty"`
+	Authorizations []AuthorizationResult `json:",omitempty"`
+
 	FunctionCall *FunctionCall
 	Logs         []LogResult
 
@@ -0,0 +1,81 @@
+package common
+
+import (
+	"bytes"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// minimalProxy is the runtime code of a minimal proxy around the PUSHn of
+// its implementation: code before the push, and code after it where a
+// zero byte stands for the jump destination, which depends on n.
+type minimalProxy struct {
+	prefix []byte
+	suffix []byte
+}
+
+var minimalProxies = []minimalProxy{
+	// EIP-1167, with the vanity variants pushing shorter addresses
+	{
+		prefix: hexutil.MustDecode("0x363d3d373d3d3d363d"),
+		suffix: hexutil.MustDecode("0x5af43d82803e903d916000" + "57fd5bf3"),
+	},
+	// ERC-7511, EIP-1167 with PUSH0
+	{
+		prefix: hexutil.MustDecode("0x365f5f375f5f365f"),
+		suffix: hexutil.MustDecode("0x5af43d5f5f3e6000" + "573d5ffd5b3d5ff3"),
+	},
+}
+
+// safeProxyMasterCopy is the PUSH32 of the masterCopy() selector the Safe
+// proxies answer from slot 0 themselves.
+var safeProxyMasterCopy = append(
+	hexutil.MustDecode("0x7fa619486e"), make([]byte, 28)...,
+)
+
+// legacySafeProxy is how the Safe 1.0 proxy, without masterCopy(), loads
+// slot 0 and forwards the calldata to it.
+var legacySafeProxy = hexutil.MustDecode("0x73ffffffffffffffffffffffffffffffffffffffff600054163660008037")
+
+// MinimalProxyImplementation tells whether code is the runtime code of an
+// EIP-1167 minimal proxy (a clone), including its vanity and PUSH0
+// variants, and returns the implementation it delegates to if so.
+func MinimalProxyImplementation(code []byte) (common.Address, bool) {
+	for _, p := range minimalProxies {
+		if !bytes.HasPrefix(code, p.prefix) || len(code) <= len(p.prefix) {
+			continue
+		}
+		op := code[len(p.prefix)]
+		// PUSH1..PUSH20
+		if op < 0x60 || op > 0x73 {
+			continue
+		}
+		n := int(op-0x60) + 1
+		rest := code[len(p.prefix)+1:]
+		if len(rest) != n+len(p.suffix) {
+			continue
+		}
+		if matchMasked(rest[n:], p.suffix) {
+			return common.BytesToAddress(rest[:n]), true
+		}
+	}
+	return common.Address{}, false
+}
+
+// matchMasked compares code to pattern, where zero bytes of pattern match
+// anything.
+func matchMasked(code, pattern []byte) bool {
+	for i := range pattern {
+		if pattern[i] != 0 && code[i] != pattern[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// IsSafeProxyCode tells whether code is the runtime code of a Safe proxy,
+// which delegates every call to the singleton (masterCopy) at its slot 0.
+func IsSafeProxyCode(code []byte) bool {
+	return bytes.Contains(code, safeProxyMasterCopy) || bytes.Contains(code, legacySafeProxy)
+}
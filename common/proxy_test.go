@@ -0,0 +1,49 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func TestMinimalProxyImplementation(t *testing.T) {
+	for _, tc := range []struct {
+		code string
+		impl string
+	}{
+		// EIP-1167
+		{"0x363d3d373d3d3d363d73bebebebebebebebebebebebebebebebebebebebe5af43d82803e903d91602b57fd5bf3", "0xbebebebebebebebebebebebebebebebebebebebe"},
+		// EIP-1167 vanity, the implementation starts with 4 zero bytes
+		{"0x363d3d373d3d3d363d6fbebebebebebebebebebebebebebebebe5af43d82803e903d91602757fd5bf3", "0x00000000bebebebebebebebebebebebebebebebe"},
+		// ERC-7511
+		{"0x365f5f375f5f365f73bebebebebebebebebebebebebebebebebebebebe5af43d5f5f3e6029573d5ffd5b3d5ff3", "0xbebebebebebebebebebebebebebebebebebebebe"},
+	} {
+		impl, ok := MinimalProxyImplementation(hexutil.MustDecode(tc.code))
+		if !ok || impl != common.HexToAddress(tc.impl) {
+			t.Fatalf("%s: got %s, %t, want %s", tc.code, impl.Hex(), ok, tc.impl)
+		}
+	}
+
+	for _, code := range []string{
+		"0x",
+		"0x363d3d373d3d3d363d73bebebebebebebebebebebebebebebebebebebebe5af43d82803e903d91602b57fd5bf300",
+		"0x363d3d373d3d3d363d73bebebebebebebebebebebebebebebebebebebebe5af43d82803e903d91602b57fd5b",
+		"0x6080604052348015600f57600080fd5b50",
+	} {
+		if _, ok := MinimalProxyImplementation(hexutil.MustDecode(code)); ok {
+			t.Fatalf("%s taken for a minimal proxy", code)
+		}
+	}
+}
+
+func TestIsSafeProxyCode(t *testing.T) {
+	// runtime code of SafeProxy 1.3.0
+	safeProxy := hexutil.MustDecode("0x608060405273ffffffffffffffffffffffffffffffffffffffff600054167fa619486e0000000000000000000000000000000000000000000000000000000060003514156050578060005260206000f35b3660008037600080366000845af43d6000803e60008114156070573d6000fd5b3d6000f3fea2646970667358221220d1429297349653a4918076d650332de1a1068c5f3e07c5c82360c277770b955264736f6c63430007060033")
+	if !IsSafeProxyCode(safeProxy) {
+		t.Fatal("SafeProxy not detected")
+	}
+	if IsSafeProxyCode(hexutil.MustDecode("0x6080604052348015600f57600080fd5b50")) {
+		t.Fatal("plain code taken for a Safe proxy")
+	}
+}
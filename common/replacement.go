@@ -0,0 +1,181 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/holiman/uint256"
+)
+
+// ReplacementBumpPercent is the minimum fee increase, in percent, that geth's
+// txpool (and every client that copied its policy) demands before it accepts
+// a tx with the same sender and nonce as one it already holds. Anything
+// below this is rejected with "replacement transaction underpriced".
+const ReplacementBumpPercent = 10
+
+// CancelGasLimit is the gas limit of the 0-value self-transfer used to cancel
+// a pending tx.
+const CancelGasLimit uint64 = 21000
+
+// BlobReplacementBumpPercent is the fee increase geth's blob pool demands
+// before it accepts a replacement of a blob tx, on each of its fees.
+const BlobReplacementBumpPercent = 100
+
+// ErrBlobsMissing is returned when a blob tx is replaced without its blobs.
+// A blob tx can only be replaced by another blob tx, and nodes don't return
+// the blobs of the txs they hold.
+var ErrBlobsMissing = errors.New("the replacement of a blob tx needs its blobs, which nodes don't return")
+
+// BumpFee returns the smallest fee that satisfies the replacement rule for
+// old, or suggested when the network currently asks for more than that.
+// The division rounds up so a bumped fee is never a single wei short.
+func BumpFee(old *big.Int, suggested *big.Int) *big.Int {
+	return bumpFee(old, suggested, ReplacementBumpPercent)
+}
+
+func bumpFee(old *big.Int, suggested *big.Int, percent int64) *big.Int {
+	if old == nil {
+		old = big.NewInt(0)
+	}
+	bumped := new(big.Int).Mul(old, big.NewInt(100+percent))
+	bumped.Add(bumped, big.NewInt(99))
+	bumped.Div(bumped, big.NewInt(100))
+	if suggested != nil && suggested.Cmp(bumped) > 0 {
+		return new(big.Int).Set(suggested)
+	}
+	return bumped
+}
+
+// bumpDynamicFees bumps the tip and the fee cap of orig by percent, keeping
+// the fee cap at least the tip.
+func bumpDynamicFees(orig *types.Transaction, suggestedPrice, suggestedTip *big.Int, percent int64) (tip, feeCap *big.Int) {
+	tip = bumpFee(orig.GasTipCap(), suggestedTip, percent)
+	feeCap = bumpFee(orig.GasFeeCap(), suggestedPrice, percent)
+	if feeCap.Cmp(tip) < 0 {
+		feeCap = new(big.Int).Set(tip)
+	}
+	return tip, feeCap
+}
+
+// BuildReplacementTx rebuilds orig with the same nonce and fees bumped past
+// the replacement threshold. When cancel is true the replacement is a 0-value
+// self-transfer to from instead of a copy of the original call.
+//
+// For dynamic-fee txs both the tip and the fee cap are bumped (nodes check
+// each of them); suggestedPrice is used as a floor for the fee cap and
+// suggestedTip as a floor for the tip. Legacy and access-list txs only carry
+// one gas price, which is bumped against suggestedPrice.
+//
+// A blob tx is replaced by a blob tx carrying the same blobs, cancelled or
+// not, with its three fees bumped by BlobReplacementBumpPercent. orig must
+// carry its sidecar, ErrBlobsMissing is returned otherwise. A set-code tx is
+// sped up with the same authorizations and cancelled by a dynamic-fee tx, so
+// the delegations aren't set either.
+func BuildReplacementTx(
+	orig *types.Transaction,
+	cancel bool,
+	from common.Address,
+	suggestedPrice *big.Int,
+	suggestedTip *big.Int,
+	chainID uint64,
+) (*types.Transaction, error) {
+	to := orig.To()
+	value := orig.Value()
+	data := orig.Data()
+	gas := orig.Gas()
+	var accessList types.AccessList
+	if cancel {
+		to = &from
+		value = big.NewInt(0)
+		data = []byte{}
+		gas = CancelGasLimit
+	} else if to == nil {
+		return nil, fmt.Errorf("can't speed up a contract creation tx, cancel it and deploy again instead")
+	} else {
+		accessList = orig.AccessList()
+	}
+
+	dynamicFeeTx := func() *types.Transaction {
+		tip, feeCap := bumpDynamicFees(orig, suggestedPrice, suggestedTip, ReplacementBumpPercent)
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID:    new(big.Int).SetUint64(chainID),
+			Nonce:      orig.Nonce(),
+			GasTipCap:  tip,
+			GasFeeCap:  feeCap,
+			Gas:        gas,
+			To:         to,
+			Value:      value,
+			Data:       data,
+			AccessList: accessList,
+		})
+	}
+
+	switch orig.Type() {
+	case types.BlobTxType:
+		sidecar := orig.BlobTxSidecar()
+		if sidecar == nil {
+			return nil, ErrBlobsMissing
+		}
+		tip, feeCap := bumpDynamicFees(orig, suggestedPrice, suggestedTip, BlobReplacementBumpPercent)
+		blobFeeCap := bumpFee(orig.BlobGasFeeCap(), nil, BlobReplacementBumpPercent)
+		return types.NewTx(&types.BlobTx{
+			ChainID:    uint256.NewInt(chainID),
+			Nonce:      orig.Nonce(),
+			GasTipCap:  uint256.MustFromBig(tip),
+			GasFeeCap:  uint256.MustFromBig(feeCap),
+			Gas:        gas,
+			To:         *to,
+			Value:      uint256.MustFromBig(value),
+			Data:       data,
+			AccessList: accessList,
+			BlobFeeCap: uint256.MustFromBig(blobFeeCap),
+			BlobHashes: orig.BlobHashes(),
+			Sidecar:    sidecar,
+		}), nil
+	case types.SetCodeTxType:
+		if cancel {
+			return dynamicFeeTx(), nil
+		}
+		tip, feeCap := bumpDynamicFees(orig, suggestedPrice, suggestedTip, ReplacementBumpPercent)
+		return types.NewTx(&types.SetCodeTx{
+			ChainID:    uint256.NewInt(chainID),
+			Nonce:      orig.Nonce(),
+			GasTipCap:  uint256.MustFromBig(tip),
+			GasFeeCap:  uint256.MustFromBig(feeCap),
+			Gas:        gas,
+			To:         *to,
+			Value:      uint256.MustFromBig(value),
+			Data:       data,
+			AccessList: accessList,
+			AuthList:   orig.SetCodeAuthorizations(),
+		}), nil
+	case types.DynamicFeeTxType:
+		return dynamicFeeTx(), nil
+	case types.AccessListTxType:
+		return types.NewTx(&types.AccessListTx{
+			ChainID:    new(big.Int).SetUint64(chainID),
+			Nonce:      orig.Nonce(),
+			GasPrice:   BumpFee(orig.GasPrice(), suggestedPrice),
+			Gas:        gas,
+			To:         to,
+			Value:      value,
+			Data:       data,
+			AccessList: accessList,
+		}), nil
+	case types.LegacyTxType:
+		return types.NewTx(&types.LegacyTx{
+			Nonce:    orig.Nonce(),
+			GasPrice: BumpFee(orig.GasPrice(), suggestedPrice),
+			Gas:      gas,
+			To:       to,
+			Value:    value,
+			Data:     data,
+		}), nil
+	default:
+		return nil, fmt.Errorf("can't replace tx of type %d", orig.Type())
+	}
+
+}
@@ -0,0 +1,191 @@
+package common
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+	"github.com/holiman/uint256"
+)
+
+func TestBumpFeeRoundsUp(t *testing.T) {
+	cases := []struct {
+		old, suggested, want int64
+	}{
+		{100, 0, 110},
+		// 10% of 101 is 10.1; rounding down would give 111, one wei short.
+		{101, 0, 112},
+		{0, 0, 0},
+		// The network asking for more than the bump wins.
+		{100, 500, 500},
+		{100, 105, 110},
+	}
+	for _, c := range cases {
+		got := BumpFee(big.NewInt(c.old), big.NewInt(c.suggested))
+		if got.Cmp(big.NewInt(c.want)) != 0 {
+			t.Errorf("BumpFee(%d, %d) = %s, want %d", c.old, c.suggested, got, c.want)
+		}
+	}
+}
+
+func TestBuildReplacementTxDynamicFee(t *testing.T) {
+	to := common.HexToAddress("0x1d9937e170Fc2174408581265bA0B87afDA4947F")
+	from := common.HexToAddress("0xBeEEE605DC6a531AeB4bc3C809Cf6Dd86674F001")
+	orig := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   big.NewInt(1),
+		Nonce:     7,
+		GasTipCap: big.NewInt(1_000_000_000),
+		GasFeeCap: big.NewInt(30_000_000_000),
+		Gas:       100000,
+		To:        &to,
+		Value:     big.NewInt(5),
+		Data:      []byte{0xa9, 0x05, 0x9c, 0xbb},
+	})
+
+	speedup, err := BuildReplacementTx(orig, false, from, big.NewInt(20_000_000_000), big.NewInt(2_000_000_000), 1)
+	if err != nil {
+		t.Fatalf("speedup: %s", err)
+	}
+	if speedup.Nonce() != 7 || *speedup.To() != to || speedup.Value().Int64() != 5 || speedup.Gas() != 100000 {
+		t.Fatalf("speedup must keep nonce, to, value and gas: %+v", speedup)
+	}
+	if speedup.GasTipCap().Int64() != 2_000_000_000 {
+		t.Fatalf("tip = %s, want the suggested 2 gwei", speedup.GasTipCap())
+	}
+	if speedup.GasFeeCap().Int64() != 33_000_000_000 {
+		t.Fatalf("fee cap = %s, want 33 gwei", speedup.GasFeeCap())
+	}
+
+	cancel, err := BuildReplacementTx(orig, true, from, nil, nil, 1)
+	if err != nil {
+		t.Fatalf("cancel: %s", err)
+	}
+	if *cancel.To() != from || cancel.Value().Sign() != 0 || len(cancel.Data()) != 0 || cancel.Gas() != CancelGasLimit {
+		t.Fatalf("cancel must be a 0-value self-transfer: %+v", cancel)
+	}
+	if cancel.Nonce() != 7 {
+		t.Fatalf("cancel nonce = %d, want 7", cancel.Nonce())
+	}
+}
+
+func TestBuildReplacementTxLegacy(t *testing.T) {
+	to := common.HexToAddress("0x1d9937e170Fc2174408581265bA0B87afDA4947F")
+	orig := types.NewTx(&types.LegacyTx{
+		Nonce:    3,
+		GasPrice: big.NewInt(10_000_000_000),
+		Gas:      21000,
+		To:       &to,
+		Value:    big.NewInt(1),
+	})
+	tx, err := BuildReplacementTx(orig, false, common.Address{}, big.NewInt(1), nil, 56)
+	if err != nil {
+		t.Fatalf("BuildReplacementTx: %s", err)
+	}
+	if tx.Type() != types.LegacyTxType {
+		t.Fatalf("type = %d, want legacy", tx.Type())
+	}
+	if tx.GasPrice().Int64() != 11_000_000_000 {
+		t.Fatalf("gas price = %s, want 11 gwei", tx.GasPrice())
+	}
+}
+
+func TestBuildReplacementTxRejectsContractCreationSpeedup(t *testing.T) {
+	orig := types.NewTx(&types.LegacyTx{
+		Nonce:    0,
+		GasPrice: big.NewInt(1),
+		Gas:      100000,
+		Data:     []byte{0x60, 0x80},
+	})
+	if _, err := BuildReplacementTx(orig, false, common.Address{}, nil, nil, 1); err == nil {
+		t.Fatalf("expected an error when speeding up a contract creation")
+	}
+	if _, err := BuildReplacementTx(orig, true, common.Address{}, nil, nil, 1); err != nil {
+		t.Fatalf("cancelling a contract creation should work: %s", err)
+	}
+}
+
+func TestBuildReplacementTxBlob(t *testing.T) {
+	to := common.HexToAddress("0x1d9937e170Fc2174408581265bA0B87afDA4947F")
+	from := common.HexToAddress("0xBeEEE605DC6a531AeB4bc3C809Cf6Dd86674F001")
+	sidecar := types.NewBlobTxSidecar(
+		types.BlobSidecarVersion0,
+		[]kzg4844.Blob{{}},
+		[]kzg4844.Commitment{{}},
+		[]kzg4844.Proof{{}},
+	)
+	orig := types.NewTx(&types.BlobTx{
+		ChainID:    uint256.NewInt(1),
+		Nonce:      4,
+		GasTipCap:  uint256.NewInt(1_000_000_000),
+		GasFeeCap:  uint256.NewInt(30_000_000_000),
+		Gas:        50000,
+		To:         to,
+		Data:       []byte{0x01},
+		BlobFeeCap: uint256.NewInt(5),
+		BlobHashes: sidecar.BlobHashes(),
+	})
+
+	// the node returns blob txs without their blobs
+	if _, err := BuildReplacementTx(orig, false, from, nil, nil, 1); !errors.Is(err, ErrBlobsMissing) {
+		t.Fatalf("got %v, want ErrBlobsMissing", err)
+	}
+
+	orig = orig.WithBlobTxSidecar(sidecar)
+	for _, cancel := range []bool{false, true} {
+		tx, err := BuildReplacementTx(orig, cancel, from, nil, nil, 1)
+		if err != nil {
+			t.Fatalf("cancel %t: %s", cancel, err)
+		}
+		if tx.Type() != types.BlobTxType || tx.BlobTxSidecar() == nil || len(tx.BlobHashes()) != 1 ||
+			tx.BlobHashes()[0] != sidecar.BlobHashes()[0] {
+			t.Fatalf("cancel %t: the replacement must be a blob tx with the same blobs", cancel)
+		}
+		if tx.GasTipCap().Int64() != 2_000_000_000 || tx.GasFeeCap().Int64() != 60_000_000_000 || tx.BlobGasFeeCap().Int64() != 10 {
+			t.Fatalf("cancel %t: fees %s, %s, %s aren't doubled", cancel, tx.GasTipCap(), tx.GasFeeCap(), tx.BlobGasFeeCap())
+		}
+		if cancel && (*tx.To() != from || len(tx.Data()) != 0) {
+			t.Fatalf("cancel must be a self-transfer: %+v", tx)
+		}
+	}
+}
+
+func TestBuildReplacementTxSetCode(t *testing.T) {
+	authority := common.HexToAddress("0x1d9937e170Fc2174408581265bA0B87afDA4947F")
+	auths := []types.SetCodeAuthorization{{
+		ChainID: *uint256.NewInt(1),
+		Address: common.HexToAddress("0x63c0c19a282a1B52b07dD5a65b58948A07DAE32B"),
+		Nonce:   9,
+	}}
+	orig := types.NewTx(&types.SetCodeTx{
+		ChainID:   uint256.NewInt(1),
+		Nonce:     8,
+		GasTipCap: uint256.NewInt(1_000_000_000),
+		GasFeeCap: uint256.NewInt(30_000_000_000),
+		Gas:       80000,
+		To:        authority,
+		AuthList:  auths,
+	})
+
+	speedup, err := BuildReplacementTx(orig, false, authority, nil, nil, 1)
+	if err != nil {
+		t.Fatalf("speedup: %s", err)
+	}
+	if speedup.Type() != types.SetCodeTxType || len(speedup.SetCodeAuthorizations()) != 1 ||
+		speedup.SetCodeAuthorizations()[0] != auths[0] {
+		t.Fatalf("speedup must keep the authorizations: %+v", speedup.SetCodeAuthorizations())
+	}
+	if speedup.GasFeeCap().Int64() != 33_000_000_000 {
+		t.Fatalf("fee cap = %s, want 33 gwei", speedup.GasFeeCap())
+	}
+
+	cancel, err := BuildReplacementTx(orig, true, authority, nil, nil, 1)
+	if err != nil {
+		t.Fatalf("cancel: %s", err)
+	}
+	if cancel.Type() != types.DynamicFeeTxType || cancel.Gas() != CancelGasLimit {
+		t.Fatalf("cancel must be a plain self-transfer, got type %d", cancel.Type())
+	}
+}
@@ -0,0 +1,116 @@
+package common
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	errorSelector = crypto.Keccak256([]byte("Error(string)"))[:4]
+	panicSelector = crypto.Keccak256([]byte("Panic(uint256)"))[:4]
+)
+
+// IsRevert tells whether err, returned by an eth_call or eth_estimateGas,
+// is the call reverting rather than the node failing.
+func IsRevert(err error) bool {
+	if err == nil {
+		return false
+	}
+	if _, ok := RevertData(err); ok {
+		return true
+	}
+	return strings.Contains(err.Error(), "execution reverted")
+}
+
+// RevertData extracts the revert data nodes return along with the error of
+// a reverting eth_call or eth_estimateGas. err can wrap or join the errors
+// of several nodes.
+func RevertData(err error) ([]byte, bool) {
+	var dataErr rpc.DataError
+	if !errors.As(err, &dataErr) {
+		return nil, false
+	}
+	hexData, ok := dataErr.ErrorData().(string)
+	if !ok {
+		return nil, false
+	}
+	data, decodeErr := hexutil.Decode(hexData)
+	if decodeErr != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// DecodeRevert renders revert data: the message of Error(string), the
+// reason of Panic(uint256), or a custom error of one of abis with its
+// params. Unknown errors are shown as their selector and raw data.
+func DecodeRevert(data []byte, abis ...*abi.ABI) string {
+	if len(data) == 0 {
+		return "reverted without a reason"
+	}
+	if len(data) < 4 {
+		return fmt.Sprintf("reverted with malformed data %s", hexutil.Encode(data))
+	}
+	switch {
+	case bytes.Equal(data[:4], errorSelector):
+		if reason, err := abi.UnpackRevert(data); err == nil {
+			return fmt.Sprintf("Error(%q)", reason)
+		}
+	case bytes.Equal(data[:4], panicSelector):
+		if reason, err := abi.UnpackRevert(data); err == nil {
+			return fmt.Sprintf("Panic: %s", reason)
+		}
+	}
+	for _, a := range abis {
+		if a == nil {
+			continue
+		}
+		for _, e := range a.Errors {
+			if !bytes.Equal(e.ID[:4], data[:4]) {
+				continue
+			}
+			values, err := e.Inputs.Unpack(data[4:])
+			if err != nil {
+				continue
+			}
+			params := make([]string, 0, len(values))
+			for i, v := range values {
+				param := formatRevertParam(v)
+				if e.Inputs[i].Name != "" {
+					param = e.Inputs[i].Name + ": " + param
+				}
+				params = append(params, param)
+			}
+			return fmt.Sprintf("%s(%s)", e.Name, strings.Join(params, ", "))
+		}
+	}
+	if len(data) == 4 {
+		return fmt.Sprintf("unknown custom error %s", hexutil.Encode(data))
+	}
+	return fmt.Sprintf("unknown custom error %s with data %s", hexutil.Encode(data[:4]), hexutil.Encode(data[4:]))
+}
+
+func formatRevertParam(v interface{}) string {
+	switch value := v.(type) {
+	case common.Address:
+		return value.Hex()
+	case *big.Int:
+		return value.String()
+	case []byte:
+		return hexutil.Encode(value)
+	case [32]byte:
+		return hexutil.Encode(value[:])
+	case string:
+		return fmt.Sprintf("%q", value)
+	}
+	return fmt.Sprintf("%v", v)
+}
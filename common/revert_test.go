@@ -0,0 +1,89 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+type testDataError struct {
+	data interface{}
+}
+
+func (e testDataError) Error() string          { return "execution reverted" }
+func (e testDataError) ErrorCode() int         { return 3 }
+func (e testDataError) ErrorData() interface{} { return e.data }
+
+const revertTestABI = `[
+	{"type":"error","name":"InsufficientBalance","inputs":[{"name":"account","type":"address"},{"name":"needed","type":"uint256"}]},
+	{"type":"error","name":"Paused","inputs":[]}
+]`
+
+func TestDecodeRevert(t *testing.T) {
+	a, err := abi.JSON(strings.NewReader(revertTestABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	account := common.HexToAddress("0x1d9937e170Fc2174408581265bA0B87afDA4947F")
+	custom, err := a.Errors["InsufficientBalance"].Inputs.Pack(account, big.NewInt(42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	custom = append(a.Errors["InsufficientBalance"].ID.Bytes()[:4:4], custom...)
+
+	errorString, _ := abi.Arguments{{Type: mustType(t, "string")}}.Pack("not owner")
+	panicCode, _ := abi.Arguments{{Type: mustType(t, "uint256")}}.Pack(big.NewInt(0x11))
+
+	for _, tc := range []struct {
+		data []byte
+		want string
+	}{
+		{nil, "reverted without a reason"},
+		{append(append([]byte{}, errorSelector...), errorString...), `Error("not owner")`},
+		{append(append([]byte{}, panicSelector...), panicCode...), "Panic: arithmetic underflow or overflow"},
+		{custom, fmt.Sprintf("InsufficientBalance(account: %s, needed: 42)", account.Hex())},
+		{a.Errors["Paused"].ID.Bytes()[:4], "Paused()"},
+		{[]byte{0xde, 0xad, 0xbe, 0xef}, "unknown custom error 0xdeadbeef"},
+	} {
+		if got := DecodeRevert(tc.data, nil, &a); got != tc.want {
+			t.Fatalf("DecodeRevert(%x) = %q, want %q", tc.data, got, tc.want)
+		}
+	}
+	if got := DecodeRevert(custom); !strings.HasPrefix(got, "unknown custom error") {
+		t.Fatalf("without the abi got %q", got)
+	}
+}
+
+func TestRevertData(t *testing.T) {
+	err := fmt.Errorf("couldn't read from any nodes: %w", errors.Join(
+		errors.New("node1: timeout"),
+		fmt.Errorf("node2: %w", testDataError{data: "0xdeadbeef"}),
+	))
+	data, ok := RevertData(err)
+	if !ok || hexutil.Encode(data) != "0xdeadbeef" {
+		t.Fatalf("got %x %v", data, ok)
+	}
+	if !IsRevert(err) {
+		t.Fatalf("a joined revert is a revert")
+	}
+	if _, ok := RevertData(errors.New("connection refused")); ok {
+		t.Fatalf("no data in a plain error")
+	}
+	if IsRevert(errors.New("connection refused")) || !IsRevert(errors.New("execution reverted")) {
+		t.Fatalf("IsRevert misclassified a plain error")
+	}
+}
+
+func mustType(t *testing.T, name string) abi.Type {
+	typ, err := abi.NewType(name, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return typ
+}
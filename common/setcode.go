@@ -0,0 +1,48 @@
+package common
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// AuthorizationGas is the intrinsic gas every EIP-7702 authorization adds
+// to a tx. Nodes estimate gas without the authorization list, so it has to
+// be added on top of their estimate.
+const AuthorizationGas = params.CallNewAccountGas
+
+// BuildExactSetCodeTx is the type-4 counterpart of BuildExactTx. auths must
+// hold at least one signed authorization; the protocol rejects type-4 txs
+// with an empty list.
+func BuildExactSetCodeTx(
+	nonce uint64,
+	to string,
+	ethAmount *big.Int,
+	gasLimit uint64,
+	priceGwei float64,
+	tipCapGwei float64,
+	data []byte,
+	auths []types.SetCodeAuthorization,
+	chainID uint64,
+) *types.Transaction {
+	return types.NewTx(&types.SetCodeTx{
+		ChainID:   uint256.NewInt(chainID),
+		Nonce:     nonce,
+		GasTipCap: uint256.MustFromBig(GweiToWei(tipCapGwei)),
+		GasFeeCap: uint256.MustFromBig(GweiToWei(priceGwei)),
+		Gas:       gasLimit,
+		To:        common.HexToAddress(to),
+		Value:     uint256.MustFromBig(ethAmount),
+		Data:      data,
+		AuthList:  auths,
+	})
+}
+
+// DelegateOf tells whether code is an EIP-7702 delegation designator
+// (0xef0100 || address) and returns the delegate address if so.
+func DelegateOf(code []byte) (common.Address, bool) {
+	return types.ParseDelegation(code)
+}
@@ -0,0 +1,66 @@
+package common
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+)
+
+func TestBuildExactSetCodeTx(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	authority := crypto.PubkeyToAddress(key.PublicKey)
+	delegate := common.HexToAddress("0x63c0c19a282a1B52b07dD5a65b58948A07DAE32B")
+
+	auth, err := types.SignSetCode(key, types.SetCodeAuthorization{
+		ChainID: *uint256.NewInt(1),
+		Address: delegate,
+		Nonce:   8,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := BuildExactSetCodeTx(
+		7, authority.Hex(), big.NewInt(0), 50000, 3, 1, nil,
+		[]types.SetCodeAuthorization{auth}, 1,
+	)
+	if tx.Type() != types.SetCodeTxType || tx.To() == nil || *tx.To() != authority {
+		t.Fatalf("unexpected tx: type %d, to %v", tx.Type(), tx.To())
+	}
+
+	signed, err := types.SignTx(tx, types.LatestSignerForChainID(big.NewInt(1)), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := new(types.Transaction)
+	if err := decoded.UnmarshalBinary(raw); err != nil {
+		t.Fatal(err)
+	}
+	auths := decoded.SetCodeAuthorizations()
+	if len(auths) != 1 || auths[0].Address != delegate || auths[0].Nonce != 8 {
+		t.Fatalf("unexpected authorizations: %+v", auths)
+	}
+	if got, err := auths[0].Authority(); err != nil || got != authority {
+		t.Fatalf("authority = %s (%v), want %s", got, err, authority)
+	}
+}
+
+func TestDelegateOf(t *testing.T) {
+	delegate := common.HexToAddress("0x63c0c19a282a1B52b07dD5a65b58948A07DAE32B")
+	if got, ok := DelegateOf(types.AddressToDelegation(delegate)); !ok || got != delegate {
+		t.Fatalf("DelegateOf = %s, %t", got, ok)
+	}
+	if _, ok := DelegateOf(common.FromHex("0x6080604052")); ok {
+		t.Fatalf("contract code must not be taken as a delegation")
+	}
+	if _, ok := DelegateOf(nil); ok {
+		t.Fatalf("an EOA without code must not be taken as a delegation")
+	}
+}
@@ -0,0 +1,47 @@
+package common
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// solcMetadataMarker is the "solc" key of the CBOR metadata solc appends to
+// the runtime code of a contract, followed by the 3 byte compiler version
+// and the 2 byte length of the whole metadata.
+var solcMetadataMarker = []byte{0x64, 's', 'o', 'l', 'c', 0x43}
+
+// lastSolcMetadata returns where the last solc metadata of code starts.
+func lastSolcMetadata(code []byte) (int, bool) {
+	i := bytes.LastIndex(code, solcMetadataMarker)
+	if i < 0 || i+len(solcMetadataMarker)+3+2 > len(code) {
+		return 0, false
+	}
+	return i, true
+}
+
+// SolcVersion reads the version of solc that compiled code, e.g. 0.8.24,
+// from its metadata.
+func SolcVersion(code []byte) (string, bool) {
+	i, ok := lastSolcMetadata(code)
+	if !ok {
+		return "", false
+	}
+	v := code[i+len(solcMetadataMarker):]
+	return fmt.Sprintf("%d.%d.%d", v[0], v[1], v[2]), true
+}
+
+// ConstructorArgsOf splits the abi encoded constructor params off the init
+// code of a contract compiled by solc: they are what follows the metadata
+// closing its runtime code. It can't tell params apart from code without
+// metadata, e.g. compiled with bytecodeHash none.
+func ConstructorArgsOf(initCode []byte) ([]byte, error) {
+	i, ok := lastSolcMetadata(initCode)
+	if !ok {
+		return nil, fmt.Errorf("the init code has no solc metadata to find the constructor params after")
+	}
+	end := i + len(solcMetadataMarker) + 3 + 2
+	if (len(initCode)-end)%32 != 0 {
+		return nil, fmt.Errorf("%d bytes after the solc metadata aren't abi encoded params", len(initCode)-end)
+	}
+	return initCode[end:], nil
+}
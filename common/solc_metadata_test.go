@@ -0,0 +1,38 @@
+package common
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestConstructorArgsOf(t *testing.T) {
+	// runtime code ending with ipfs + solc 0.8.24 metadata, then one param
+	code := common.FromHex("0x6080604052348015600e575f80fd5b50" +
+		"a2646970667358221220" + "1111111111111111111111111111111111111111111111111111111111111111" +
+		"64736f6c63430008180033")
+	param := common.LeftPadBytes([]byte{0x2a}, 32)
+	initCode := append(append([]byte{}, code...), param...)
+
+	args, err := ConstructorArgsOf(initCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(args, param) {
+		t.Fatalf("args %x", args)
+	}
+	if v, ok := SolcVersion(initCode); !ok || v != "0.8.24" {
+		t.Fatalf("version %s %v", v, ok)
+	}
+
+	if args, err := ConstructorArgsOf(code); err != nil || len(args) != 0 {
+		t.Fatalf("no params, got %x %v", args, err)
+	}
+	if _, err := ConstructorArgsOf(append(code, 0x01)); err == nil {
+		t.Fatalf("a stray byte isn't an abi encoded param")
+	}
+	if _, err := ConstructorArgsOf([]byte{0x60, 0x80}); err == nil {
+		t.Fatalf("no metadata, no params")
+	}
+}
@@ -0,0 +1,84 @@
+package common
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DisperseAddress is where the Disperse contract (disperse.app) is deployed
+// on most EVM chains.
+const DisperseAddress = "0xD152f549545093347A162Dce210e7293f1452150"
+
+// BatchTransfer is one row of a batch transfer file, as written by the user:
+// the recipient and the token are keywords still to be resolved. An empty
+// Token means the network's native token.
+type BatchTransfer struct {
+	Line   int
+	To     string
+	Amount string
+	Token  string
+}
+
+// ParseTransferBatch reads rows of to,amount[,token] in CSV. Blank lines,
+// lines starting with # and a leading header row (whose first column is
+// "to") are skipped.
+func ParseTransferBatch(r io.Reader) ([]BatchTransfer, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+
+	result := []BatchTransfer{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		for i := range record {
+			record[i] = strings.TrimSpace(record[i])
+		}
+		if len(record) == 1 && record[0] == "" {
+			continue
+		}
+		if len(result) == 0 && strings.EqualFold(record[0], "to") {
+			continue
+		}
+		if len(record) < 2 || len(record) > 3 {
+			return nil, fmt.Errorf("line %d: expected to,amount[,token], got %d columns", line, len(record))
+		}
+		t := BatchTransfer{Line: line, To: record[0], Amount: record[1]}
+		if len(record) == 3 {
+			t.Token = record[2]
+		}
+		if t.To == "" || t.Amount == "" {
+			return nil, fmt.Errorf("line %d: recipient and amount can't be empty", line)
+		}
+		result = append(result, t)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no transfer in the batch")
+	}
+	return result, nil
+}
+
+// PackDisperseEther packs a disperseEther call paying values[i] of the
+// native token to recipients[i]. The tx must carry the sum of values.
+func PackDisperseEther(recipients []common.Address, values []*big.Int) ([]byte, error) {
+	return GetDisperseABI().Pack("disperseEther", recipients, values)
+}
+
+// PackDisperseToken packs a disperseToken call paying values[i] of token to
+// recipients[i]. The sender must have approved Disperse for the sum first.
+func PackDisperseToken(token common.Address, recipients []common.Address, values []*big.Int) ([]byte, error) {
+	return GetDisperseABI().Pack("disperseToken", token, recipients, values)
+}
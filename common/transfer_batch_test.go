@@ -0,0 +1,80 @@
+package common
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestParseTransferBatch(t *testing.T) {
+	input := `to,amount,token
+# payroll of October
+alice, 1.5, usdc
+
+0x1d9937e170Fc2174408581265bA0B87afDA4947F,0.01
+bob,2,`
+	rows, err := ParseTransferBatch(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []BatchTransfer{
+		{Line: 3, To: "alice", Amount: "1.5", Token: "usdc"},
+		{Line: 5, To: "0x1d9937e170Fc2174408581265bA0B87afDA4947F", Amount: "0.01"},
+		{Line: 6, To: "bob", Amount: "2"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("got %d rows, want %d: %+v", len(rows), len(want), rows)
+	}
+	for i := range want {
+		if rows[i] != want[i] {
+			t.Fatalf("row %d = %+v, want %+v", i, rows[i], want[i])
+		}
+	}
+}
+
+func TestParseTransferBatchErrors(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"to,amount,token\n",
+		"alice\n",
+		"alice,1,usdc,extra\n",
+		"alice,,usdc\n",
+	} {
+		if _, err := ParseTransferBatch(strings.NewReader(input)); err == nil {
+			t.Fatalf("expected an error for %q", input)
+		}
+	}
+}
+
+func TestPackDisperse(t *testing.T) {
+	recipients := []common.Address{
+		common.HexToAddress("0x1d9937e170Fc2174408581265bA0B87afDA4947F"),
+		common.HexToAddress("0x0000000000000000000000000000000000000001"),
+	}
+	values := []*big.Int{big.NewInt(1), big.NewInt(2)}
+
+	data, err := PackDisperseEther(recipients, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	method, err := GetDisperseABI().MethodById(data[:4])
+	if err != nil || method.Name != "disperseEther" {
+		t.Fatalf("unexpected method %v: %v", method, err)
+	}
+
+	token := common.HexToAddress(DisperseAddress)
+	data, err = PackDisperseToken(token, recipients, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args, err := GetDisperseABI().Methods["disperseToken"].Inputs.Unpack(data[4:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args[0].(common.Address) != token || !bytes.Equal(args[1].([]common.Address)[0].Bytes(), recipients[0].Bytes()) {
+		t.Fatalf("unexpected args %v", args)
+	}
+}
@@ -2,6 +2,7 @@ package common
 
 import (
 	"errors"
+	"os"
 	"sync"
 )
 
@@ -36,3 +37,17 @@ func RunParallel(funcs ...func() error) (error, int) {
 	// If there are no errors, errors.Join returns nil
 	return errors.Join(allErrs...), len(allErrs)
 }
+
+// WriteFileAtomic writes data to path through a temporary file renamed over
+// path, so readers and a crash never see a truncated file.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
@@ -45,6 +45,9 @@ var (
 	ExtraGasPrice float64
 	TipGas        float64
 	ExtraTipGas   float64
+	// GasSpeed is the fee tier (slow, normal or fast) suggested when
+	// GasPrice or TipGas isn't set.
+	GasSpeed      string
 	GasLimit      uint64
 	ExtraGasLimit uint64
 	Nonce         uint64
@@ -68,9 +71,39 @@ var (
 	RetryBroadcast    bool
 	YesToAllPrompt    bool
 	ForceLegacy       bool
+	// AccessList makes transactional commands attach the EIP-2930 access
+	// list from eth_createAccessList when it saves gas.
+	AccessList bool
+	// PrivateTx makes transactional commands send the tx only to the
+	// private relays of the network, RelayBlocks is how many blocks they
+	// have to get it mined.
+	PrivateTx   bool
+	RelayBlocks uint64
+	// ScheduleAtBlock, ScheduleAtTime and ScheduleWhen hold the signed tx
+	// back until the block, the time and the contract read condition given
+	// with --at-block, --at-time and --when are all reached.
+	ScheduleAtBlock uint64
+	ScheduleAtTime  string
+	ScheduleWhen    string
+
+	// BlobFiles makes send build an EIP-4844 blob tx carrying one blob per
+	// file. BlobGasFeeCap is the max fee per blob gas in gwei, 0 means
+	// taking it from the node. LegacyBlobProofs attaches the pre-Osaka
+	// per-blob KZG proof instead of the per-cell proofs.
+	BlobFiles        []string
+	BlobGasFeeCap    float64
+	LegacyBlobProofs bool
 
 	CustomABI      string
 	JSONOutputFile string
+	// UnsignedTxFile, when set, makes transactional commands write the
+	// unsigned tx to this path for offline signing instead of signing it.
+	UnsignedTxFile string
+
+	// Create2 makes contract deploy go through the deterministic deployer
+	// with the CREATE2 salt Create2Salt.
+	Create2     bool
+	Create2Salt string
 
 	Simulate bool
 )
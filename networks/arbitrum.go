@@ -25,7 +25,7 @@ func NewArbitrumMainnet() *arbitrumMainnet {
 			},
 			BlockExplorerAPIKeyVariableName: "ETHERSCAN_API_KEY",
 			BlockExplorerAPIURL:             "https://api.etherscan.io/v2",
-			MultiCallContractAddress:        common.HexToAddress("0x80C7DD17B01855a6D2347444a0FCC36136a314de"),
+			MultiCallContractAddress:        common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11"),
 		}),
 	}
 }
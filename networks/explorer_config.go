@@ -0,0 +1,79 @@
+package networks
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/tranvictor/jarvis/util/explorers"
+)
+
+// Types of block explorers a network can look contracts up on.
+const (
+	EtherscanExplorer  = "etherscan"
+	BlockscoutExplorer = "blockscout"
+	SourcifyExplorer   = "sourcify"
+)
+
+// ExplorerTypes are the types of block explorers jarvis supports.
+var ExplorerTypes = []string{EtherscanExplorer, BlockscoutExplorer, SourcifyExplorer}
+
+// ExplorerConfig is a block explorer of a network.
+type ExplorerConfig struct {
+	Type string `json:"type"`
+	// APIURL is the api of an Etherscan-like explorer, the domain of a
+	// Blockscout instance or the Sourcify server, the public one when left
+	// empty.
+	APIURL             string `json:"api_url,omitempty"`
+	APIKeyVariableName string `json:"api_key_variable_name,omitempty"`
+}
+
+func (c ExplorerConfig) Validate() error {
+	switch c.Type {
+	case EtherscanExplorer, BlockscoutExplorer:
+		if c.APIURL == "" {
+			return fmt.Errorf("the %s explorer needs an api url", c.Type)
+		}
+	case SourcifyExplorer:
+		if c.APIURL == "" {
+			return nil
+		}
+	default:
+		return fmt.Errorf("explorer type %q isn't one of %s", c.Type, strings.Join(ExplorerTypes, ", "))
+	}
+	if _, err := url.ParseRequestURI(c.APIURL); err != nil {
+		return fmt.Errorf("explorer api url %s is not a valid url", c.APIURL)
+	}
+	return nil
+}
+
+// URL is the url of the explorer.
+func (c ExplorerConfig) URL() string {
+	if c.Type == SourcifyExplorer && c.APIURL == "" {
+		return explorers.SourcifyServerURL
+	}
+	return c.APIURL
+}
+
+// Explorer returns the explorer of the chain.
+func (c ExplorerConfig) Explorer(chainID uint64) (explorers.BlockExplorer, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	switch c.Type {
+	case BlockscoutExplorer:
+		return explorers.NewBlockscoutExplorer(c.APIURL), nil
+	case SourcifyExplorer:
+		return explorers.NewSourcifyExplorer(c.APIURL, chainID), nil
+	}
+	return explorers.NewEtherscanLikeExplorer(c.APIURL, explorerAPIKey(c.APIKeyVariableName), chainID), nil
+}
+
+func explorerAPIKey(variableName string) string {
+	apiKey := strings.Trim(os.Getenv(variableName), " ")
+	if apiKey == "" {
+		apiKey = defaultAPIKey
+	}
+	return apiKey
+}
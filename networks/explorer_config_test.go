@@ -0,0 +1,98 @@
+package networks
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExplorersFromJSON(t *testing.T) {
+	blockscout := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/smart-contracts/0x01" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `{"name":"Vault","is_verified":true,"abi":[]}`)
+	}))
+	defer blockscout.Close()
+	sourcify := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/contract/4663/0x02" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, `{"match":"match","abi":[{"type":"fallback"}]}`)
+	}))
+	defer sourcify.Close()
+
+	n, err := NewNetworkFromJSON([]byte(fmt.Sprintf(`{
+		"name": "custom",
+		"chain_id": 4663,
+		"explorers": [
+			{"type": "blockscout", "api_url": %q},
+			{"type": "sourcify", "api_url": %q}
+		]
+	}`, blockscout.URL, sourcify.URL)))
+	if err != nil {
+		t.Fatalf("NewNetworkFromJSON failed: %s", err)
+	}
+	if got := n.GetBlockExplorerAPIURL(); got != blockscout.URL {
+		t.Errorf("GetBlockExplorerAPIURL() = %q, want the first explorer's", got)
+	}
+	if abi, err := n.GetABIString("0x01"); err != nil || abi != "[]" {
+		t.Errorf("GetABIString(0x01) = %s, %v, want blockscout's abi", abi, err)
+	}
+	// not verified on blockscout, so it falls through to sourcify
+	if abi, err := n.GetABIString("0x02"); err != nil || abi != `[{"type":"fallback"}]` {
+		t.Errorf("GetABIString(0x02) = %s, %v, want sourcify's abi", abi, err)
+	}
+	if info, err := n.GetContractInfo("0x01"); err != nil || info.Name != "Vault" {
+		t.Errorf("GetContractInfo(0x01) = %+v, %v", info, err)
+	}
+	if _, err := n.GetABIString("0x03"); err == nil {
+		t.Error("GetABIString(0x03) found an abi no explorer has")
+	}
+
+	// the explorers survive a round trip through the network's json
+	raw, err := n.MarshalJSON()
+	if err != nil {
+		t.Fatalf("MarshalJSON failed: %s", err)
+	}
+	again := &GenericEtherscanNetwork{}
+	if err := again.UnmarshalJSON(raw); err != nil {
+		t.Fatalf("UnmarshalJSON failed: %s", err)
+	}
+	if abi, err := again.GetABIString("0x02"); err != nil || abi != `[{"type":"fallback"}]` {
+		t.Errorf("after a round trip, GetABIString(0x02) = %s, %v", abi, err)
+	}
+}
+
+func TestInvalidExplorerIsRejected(t *testing.T) {
+	for _, explorers := range []string{
+		`[{"type": "routescan", "api_url": "https://api.routescan.io"}]`,
+		`[{"type": "blockscout"}]`,
+		`[{"type": "etherscan", "api_url": "not a url"}]`,
+	} {
+		_, err := NewNetworkFromJSON([]byte(`{"name": "custom", "chain_id": 4663, "explorers": ` + explorers + `}`))
+		if err == nil {
+			t.Errorf("explorers %s were accepted", explorers)
+		}
+	}
+}
+
+func TestSourceVerifierOf(t *testing.T) {
+	n, err := NewNetworkFromJSON([]byte(`{
+		"name": "custom",
+		"chain_id": 4663,
+		"explorers": [{"type": "blockscout", "api_url": "https://explorer.example"}]
+	}`))
+	if err != nil {
+		t.Fatalf("NewNetworkFromJSON failed: %s", err)
+	}
+	if _, ok := SourceVerifierOf(n); ok {
+		t.Error("a network without an etherscan api url verifies sources")
+	}
+	if _, ok := SourceVerifierOf(EthereumMainnet); !ok {
+		t.Error("mainnet doesn't verify sources")
+	}
+}
@@ -2,7 +2,6 @@ package networks
 
 import (
 	"encoding/json"
-	"os"
 	"strings"
 	"time"
 
@@ -31,26 +30,61 @@ type GenericEtherscanNetworkConfig struct {
 	// a self-hosted Safe Transaction Service here. omitempty keeps it out
 	// of the JSON of the bundled networks, which all leave it unset.
 	SafeTxServiceURL string `json:"safe_tx_service_url,omitempty"`
+	// Explorers are more block explorers contracts are looked up on, in
+	// order, when they aren't verified on the Etherscan-like explorer at
+	// BlockExplorerAPIURL. With BlockExplorerAPIURL left empty they are
+	// the only ones, for chains without an Etherscan instance.
+	Explorers []ExplorerConfig `json:"explorers,omitempty"`
 }
 
 // GenericEtherscanNetwork is a generic implementation of a network that uses Etherscan as their official explorer
 type GenericEtherscanNetwork struct {
 	*explorers.EtherscanLikeExplorer
 	Config GenericEtherscanNetworkConfig
+
+	// explorer is the Etherscan-like explorer followed by the ones of
+	// Config.Explorers
+	explorer explorers.BlockExplorer
 }
 
 func NewGenericEtherscanNetwork(config GenericEtherscanNetworkConfig) *GenericEtherscanNetwork {
-	apiKey := strings.Trim(os.Getenv(config.BlockExplorerAPIKeyVariableName), " ")
-	if apiKey == "" {
-		apiKey = defaultAPIKey
-	}
 	result := &GenericEtherscanNetwork{
-		EtherscanLikeExplorer: explorers.NewEtherscanLikeExplorer(config.BlockExplorerAPIURL, apiKey, config.ChainID),
-		Config:                config,
+		EtherscanLikeExplorer: explorers.NewEtherscanLikeExplorer(
+			config.BlockExplorerAPIURL,
+			explorerAPIKey(config.BlockExplorerAPIKeyVariableName),
+			config.ChainID,
+		),
+		Config: config,
+	}
+	all := explorers.Explorers{}
+	if config.BlockExplorerAPIURL != "" {
+		all = append(all, result.EtherscanLikeExplorer)
+	}
+	for _, c := range config.Explorers {
+		// invalid configs are rejected when the network is added
+		if e, err := c.Explorer(config.ChainID); err == nil {
+			all = append(all, e)
+		}
+	}
+	result.explorer = all
+	if len(all) == 1 {
+		result.explorer = all[0]
 	}
 	return result
 }
 
+func (gn *GenericEtherscanNetwork) RecommendedGasPrice() (float64, error) {
+	return gn.explorer.RecommendedGasPrice()
+}
+
+func (gn *GenericEtherscanNetwork) GetABIString(address string) (string, error) {
+	return gn.explorer.GetABIString(address)
+}
+
+func (gn *GenericEtherscanNetwork) GetContractInfo(address string) (explorers.ContractInfo, error) {
+	return gn.explorer.GetContractInfo(address)
+}
+
 func (gn *GenericEtherscanNetwork) GetName() string {
 	return gn.Config.Name
 }
@@ -87,10 +121,24 @@ func (gn *GenericEtherscanNetwork) GetBlockExplorerAPIKeyVariableName() string {
 	return gn.Config.BlockExplorerAPIKeyVariableName
 }
 
+// GetBlockExplorerAPIURL is the url of the first explorer of the network.
 func (gn *GenericEtherscanNetwork) GetBlockExplorerAPIURL() string {
+	if gn.Config.BlockExplorerAPIURL == "" && len(gn.Config.Explorers) > 0 {
+		return gn.Config.Explorers[0].URL()
+	}
 	return gn.Config.BlockExplorerAPIURL
 }
 
+// SourceVerifier returns the Etherscan instance of the network, which
+// verifies contract sources. Networks configured with other explorers only
+// have none.
+func (gn *GenericEtherscanNetwork) SourceVerifier() (explorers.SourceVerifier, bool) {
+	if gn.Config.BlockExplorerAPIURL == "" {
+		return nil, false
+	}
+	return gn.EtherscanLikeExplorer, true
+}
+
 // GetSafeTxServiceURL normalises the configured URL the same way
 // txservice does for the env overrides: trimmed, with no trailing slash,
 // so callers can concatenate paths onto it unconditionally.
@@ -107,7 +155,12 @@ func (gn *GenericEtherscanNetwork) MarshalJSON() ([]byte, error) {
 }
 
 func (gn *GenericEtherscanNetwork) UnmarshalJSON(data []byte) error {
-	return json.Unmarshal(data, &gn.Config)
+	config := GenericEtherscanNetworkConfig{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return err
+	}
+	*gn = *NewGenericEtherscanNetwork(config)
+	return nil
 }
 
 func (gn *GenericEtherscanNetwork) IsSyncTxSupported() bool {
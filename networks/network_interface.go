@@ -33,11 +33,24 @@ type Network interface {
 	// an env var being exported in every shell.
 	GetSafeTxServiceURL() string
 
-	// this interface can return "" in case
-	// there is no multicall contract on the network
+	// MultiCallContract is the Multicall3 of the network. It can return ""
+	// or the zero address when the network doesn't configure one, and the
+	// usual Multicall3 deployment is used then
 	MultiCallContract() string
 
 	// since network is a persistent object, we need to implement MarshalJSON and UnmarshalJSON
 	MarshalJSON() ([]byte, error)
 	UnmarshalJSON([]byte) error
 }
+
+// SourceVerifierOf returns the explorer of network that verifies contract
+// sources, if it has one.
+func SourceVerifierOf(network Network) (explorers.SourceVerifier, bool) {
+	if n, ok := network.(interface {
+		SourceVerifier() (explorers.SourceVerifier, bool)
+	}); ok {
+		return n.SourceVerifier()
+	}
+	v, ok := network.(explorers.SourceVerifier)
+	return v, ok
+}
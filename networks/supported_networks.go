@@ -171,6 +171,11 @@ func NewNetworkFromJSON(content []byte) (Network, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal network config: %w", err)
 	}
+	for _, e := range networkConfig.Explorers {
+		if err := e.Validate(); err != nil {
+			return nil, err
+		}
+	}
 
 	return NewGenericEtherscanNetwork(networkConfig), nil
 }
@@ -0,0 +1,257 @@
+// Package playbook reads the YAML runbooks `jarvis run` executes: an ordered
+// list of steps (contract reads, contract txs, sends, multisig proposals and
+// approvals) sharing variables, where later steps can use the outputs of
+// earlier ones.
+//
+//	name: rotate-oracle
+//	network: mainnet
+//	from: ops-hot
+//	vars:
+//	  feed: 0x...
+//	steps:
+//	  - id: current
+//	    read: {contract: oracle-registry, method: getOracle, params: ["${vars.feed}"]}
+//	  - id: propose
+//	    msig_init:
+//	      msig: treasury-safe
+//	      contract: oracle-registry
+//	      method: setOracle
+//	      params: ["${vars.feed}", "${steps.current.0}"]
+//
+// The execution itself lives in the cmd package, this package only parses,
+// validates, interpolates and keeps the resumable state of a run.
+package playbook
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Playbook is a parsed playbook file. Network and From are the defaults of
+// the steps that don't set their own.
+type Playbook struct {
+	Name    string            `yaml:"name"`
+	Network string            `yaml:"network"`
+	From    string            `yaml:"from"`
+	Vars    map[string]string `yaml:"vars"`
+	Steps   []Step            `yaml:"steps"`
+
+	// Hash is the sha256 of the file content, recorded in the state so a
+	// run is never resumed against an edited playbook.
+	Hash string `yaml:"-"`
+}
+
+// Step is one step of a playbook. Exactly one of the action fields is set.
+type Step struct {
+	ID      string `yaml:"id"`
+	Name    string `yaml:"name"`
+	Network string `yaml:"network"`
+	From    string `yaml:"from"`
+
+	Read        *Call        `yaml:"read"`
+	Tx          *Call        `yaml:"tx"`
+	Send        *Send        `yaml:"send"`
+	MsigInit    *MsigInit    `yaml:"msig_init"`
+	MsigApprove *MsigApprove `yaml:"msig_approve"`
+}
+
+// Call is a contract function call. Method is a name, or a full signature
+// such as transfer(address,uint256) for overloaded functions. Params follow
+// the same rules as the params of jarvis contract tx; a list param is
+// written as a YAML list. Value is in the native token, for payable calls.
+type Call struct {
+	Contract string  `yaml:"contract"`
+	Method   string  `yaml:"method"`
+	Params   []Param `yaml:"params"`
+	Value    string  `yaml:"value"`
+	ABI      string  `yaml:"abi"`
+}
+
+// Send is a native or ERC20 transfer. Amount takes the same form as the
+// --amount of jarvis send, e.g. "0.5" or "100 usdc".
+type Send struct {
+	To     string `yaml:"to"`
+	Amount string `yaml:"amount"`
+}
+
+// MsigInit proposes a call through a Gnosis Classic multisig or a Safe.
+type MsigInit struct {
+	Msig string `yaml:"msig"`
+	Call `yaml:",inline"`
+}
+
+// MsigApprove approves a pending multisig tx: a Classic txid (or the hash of
+// its init tx) or a Safe safeTxHash.
+type MsigApprove struct {
+	Msig string `yaml:"msig"`
+	Tx   string `yaml:"tx"`
+}
+
+// Param is a call param: a scalar, or a list for array params.
+type Param struct {
+	Scalar string
+	List   []Param
+	IsList bool
+}
+
+// UnmarshalYAML accepts both scalars and lists.
+func (p *Param) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var list []Param
+	if err := unmarshal(&list); err == nil {
+		p.List, p.IsList = list, true
+		return nil
+	}
+	return unmarshal(&p.Scalar)
+}
+
+// String renders the param the way the jarvis param parser reads it: lists
+// are wrapped in [ ] with their elements separated by commas.
+func (p Param) String() string {
+	if !p.IsList {
+		return p.Scalar
+	}
+	elems := make([]string, 0, len(p.List))
+	for _, e := range p.List {
+		elems = append(elems, e.String())
+	}
+	return "[" + strings.Join(elems, ", ") + "]"
+}
+
+// Kind names the action of the step.
+func (s *Step) Kind() string {
+	switch {
+	case s.Read != nil:
+		return "read"
+	case s.Tx != nil:
+		return "tx"
+	case s.Send != nil:
+		return "send"
+	case s.MsigInit != nil:
+		return "msig_init"
+	case s.MsigApprove != nil:
+		return "msig_approve"
+	}
+	return ""
+}
+
+// SendsTx tells whether running the step signs something.
+func (s *Step) SendsTx() bool {
+	return s.Read == nil
+}
+
+var idRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// Load reads and validates the playbook at path.
+func Load(path string) (*Playbook, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(content)
+}
+
+// Parse parses and validates a playbook.
+func Parse(content []byte) (*Playbook, error) {
+	pb := &Playbook{}
+	if err := yaml.UnmarshalStrict(content, pb); err != nil {
+		return nil, fmt.Errorf("couldn't parse playbook: %w", err)
+	}
+	sum := sha256.Sum256(content)
+	pb.Hash = hex.EncodeToString(sum[:])
+	if err := pb.validate(); err != nil {
+		return nil, err
+	}
+	return pb, nil
+}
+
+func (pb *Playbook) validate() error {
+	if len(pb.Steps) == 0 {
+		return fmt.Errorf("the playbook has no steps")
+	}
+	seen := map[string]bool{}
+	for i := range pb.Steps {
+		s := &pb.Steps[i]
+		if s.ID == "" {
+			s.ID = fmt.Sprintf("step%d", i+1)
+		}
+		if !idRe.MatchString(s.ID) {
+			return fmt.Errorf("step %d: invalid id %q", i+1, s.ID)
+		}
+		if seen[s.ID] {
+			return fmt.Errorf("step %d: duplicated id %q", i+1, s.ID)
+		}
+		seen[s.ID] = true
+
+		actions := 0
+		for _, set := range []bool{s.Read != nil, s.Tx != nil, s.Send != nil, s.MsigInit != nil, s.MsigApprove != nil} {
+			if set {
+				actions++
+			}
+		}
+		if actions != 1 {
+			return fmt.Errorf("step %s: exactly one of read, tx, send, msig_init and msig_approve must be set", s.ID)
+		}
+		if err := s.validate(); err != nil {
+			return fmt.Errorf("step %s: %w", s.ID, err)
+		}
+		if s.Network == "" && pb.Network == "" {
+			return fmt.Errorf("step %s: no network, set it on the step or the playbook", s.ID)
+		}
+		if s.SendsTx() && s.From == "" && pb.From == "" {
+			return fmt.Errorf("step %s: no from account, set it on the step or the playbook", s.ID)
+		}
+	}
+	return nil
+}
+
+func (s *Step) validate() error {
+	switch {
+	case s.Read != nil:
+		return s.Read.validate()
+	case s.Tx != nil:
+		return s.Tx.validate()
+	case s.Send != nil:
+		if s.Send.To == "" || s.Send.Amount == "" {
+			return fmt.Errorf("send needs to and amount")
+		}
+	case s.MsigInit != nil:
+		if s.MsigInit.Msig == "" {
+			return fmt.Errorf("msig_init needs msig")
+		}
+		return s.MsigInit.Call.validate()
+	case s.MsigApprove != nil:
+		if s.MsigApprove.Msig == "" || s.MsigApprove.Tx == "" {
+			return fmt.Errorf("msig_approve needs msig and tx")
+		}
+	}
+	return nil
+}
+
+func (c *Call) validate() error {
+	if c.Contract == "" || c.Method == "" {
+		return fmt.Errorf("a call needs contract and method")
+	}
+	return nil
+}
+
+// NetworkOf returns the network step s runs on.
+func (pb *Playbook) NetworkOf(s *Step) string {
+	if s.Network != "" {
+		return s.Network
+	}
+	return pb.Network
+}
+
+// FromOf returns the account step s signs with.
+func (pb *Playbook) FromOf(s *Step) string {
+	if s.From != "" {
+		return s.From
+	}
+	return pb.From
+}
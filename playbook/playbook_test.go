@@ -0,0 +1,187 @@
+package playbook
+
+import (
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const testPlaybook = `
+name: rotate-oracle
+network: mainnet
+from: ops-hot
+vars:
+  feed: "0x1d9937e170Fc2174408581265bA0B87afDA4947F"
+  amount: 1000
+steps:
+  - id: current
+    read:
+      contract: oracle-registry
+      method: getOracle
+      params: ["${vars.feed}"]
+  - id: set
+    network: arbitrum
+    tx:
+      contract: oracle-registry
+      method: setOracles
+      params:
+        - [a, b]
+        - ${steps.current.0}
+  - send:
+      to: alice
+      amount: ${vars.amount} usdc
+  - id: approve
+    from: ops-cold
+    msig_approve:
+      msig: treasury
+      tx: ${steps.set.tx_hash}
+`
+
+func TestParse(t *testing.T) {
+	pb, err := Parse([]byte(testPlaybook))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pb.Steps) != 4 || pb.Hash == "" {
+		t.Fatalf("unexpected playbook %+v", pb)
+	}
+	if pb.Vars["amount"] != "1000" {
+		t.Fatalf("numeric var = %q", pb.Vars["amount"])
+	}
+	if pb.Steps[2].ID != "step3" || pb.Steps[2].Kind() != "send" {
+		t.Fatalf("unnamed step got id %q kind %q", pb.Steps[2].ID, pb.Steps[2].Kind())
+	}
+	set := &pb.Steps[1]
+	if pb.NetworkOf(set) != "arbitrum" || pb.FromOf(set) != "ops-hot" {
+		t.Fatalf("step defaults: network %s, from %s", pb.NetworkOf(set), pb.FromOf(set))
+	}
+	if got := set.Tx.Params[0].String(); got != "[a, b]" {
+		t.Fatalf("list param renders as %q", got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for name, content := range map[string]string{
+		"no steps":     "network: mainnet\n",
+		"two actions":  "network: mainnet\nfrom: a\nsteps:\n  - send: {to: a, amount: '1'}\n    tx: {contract: c, method: m}\n",
+		"no action":    "network: mainnet\nsteps:\n  - id: x\n",
+		"duplicate id": "network: mainnet\nsteps:\n  - {id: x, read: {contract: c, method: m}}\n  - {id: x, read: {contract: c, method: m}}\n",
+		"no network":   "steps:\n  - read: {contract: c, method: m}\n",
+		"no from":      "network: mainnet\nsteps:\n  - send: {to: a, amount: '1'}\n",
+		"unknown key":  "network: mainnet\nsteps:\n  - read: {contract: c, method: m, gas: 1}\n",
+	} {
+		if _, err := Parse([]byte(content)); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	sc := NewScope(map[string]string{"feed": "0xfeed"}, func(name string) string {
+		return map[string]string{"OPS_USER": "alice"}[name]
+	})
+	sc.Outputs["current"] = map[string]string{"0": "42", "oracle": "0xabc"}
+	sc.Unavailable["set"] = true
+
+	got, err := sc.Interpolate("${vars.feed}/${ steps.current.oracle }/${steps.current.0}/${env.OPS_USER}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "0xfeed/0xabc/42/alice" {
+		t.Fatalf("got %q", got)
+	}
+
+	if _, err := sc.Interpolate("${steps.set.tx_hash}"); !errors.Is(err, ErrNotAvailable) {
+		t.Fatalf("expected ErrNotAvailable, got %v", err)
+	}
+	for _, bad := range []string{"${vars.nope}", "${steps.later.x}", "${steps.current.nope}", "${feed}"} {
+		if _, err := sc.Interpolate(bad); err == nil {
+			t.Fatalf("expected an error for %s", bad)
+		}
+	}
+
+	p, err := sc.InterpolateParam(Param{IsList: true, List: []Param{{Scalar: "${vars.feed}"}, {Scalar: "b"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.String() != "[0xfeed, b]" {
+		t.Fatalf("got %q", p.String())
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	addr := common.HexToAddress("0x1d9937e170Fc2174408581265bA0B87afDA4947F")
+	for _, tc := range []struct {
+		in   interface{}
+		want string
+	}{
+		{big.NewInt(1000), "1000"},
+		{addr, addr.Hex()},
+		{[]byte{0xde, 0xad}, "0xdead"},
+		{true, "true"},
+		{[]common.Address{addr, addr}, "[" + addr.Hex() + ", " + addr.Hex() + "]"},
+		{uint8(7), "7"},
+	} {
+		if got := FormatValue(tc.in); got != tc.want {
+			t.Fatalf("FormatValue(%v) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestState(t *testing.T) {
+	dir := t.TempDir()
+	path := DefaultStatePath(filepath.Join(dir, "rotate.yaml"))
+	if filepath.Base(path) != "rotate.state.json" {
+		t.Fatalf("state path %s", path)
+	}
+
+	pb, err := Parse([]byte(testPlaybook))
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, err := LoadState(path, pb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Done("current") {
+		t.Fatalf("a fresh state has nothing done")
+	}
+	if err := st.Record("current", StatusDone, map[string]string{"0": "42"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Record("set", StatusFailed, nil, errors.New("reverted")); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.RecordBroadcast("approve", "0xabc", "0x02f8"); err != nil {
+		t.Fatal(err)
+	}
+
+	st, err = LoadState(path, pb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !st.Done("current") || st.Done("set") || st.Steps["current"].Outputs["0"] != "42" {
+		t.Fatalf("unexpected state %+v", st.Steps)
+	}
+	if s, ok := st.Broadcasted("approve"); !ok || st.Done("approve") || s.TxHash != "0xabc" || s.RawTx != "0x02f8" {
+		t.Fatalf("the broadcasted step wasn't kept: %+v", st.Steps["approve"])
+	}
+	if _, ok := st.Broadcasted("set"); ok {
+		t.Fatalf("a failed step is taken as broadcasted")
+	}
+
+	edited, err := Parse([]byte(testPlaybook + "\n# edited\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadState(path, edited); err == nil {
+		t.Fatalf("resuming an edited playbook must fail")
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("temporary state file left behind")
+	}
+}
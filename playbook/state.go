@@ -0,0 +1,125 @@
+package playbook
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	jarviscommon "github.com/tranvictor/jarvis/common"
+)
+
+// Step statuses recorded in the state file.
+const (
+	StatusDone   = "done"
+	StatusFailed = "failed"
+	// StatusBroadcasted is a step whose tx is signed and maybe broadcasted
+	// but not known to be mined yet. A resumed run follows its tx rather
+	// than sending the step again.
+	StatusBroadcasted = "broadcasted"
+)
+
+// State is what a run remembers so it can continue where it stopped. It is
+// saved after every step.
+type State struct {
+	PlaybookHash string                `json:"playbook_hash"`
+	Steps        map[string]*StepState `json:"steps"`
+
+	path string
+}
+
+// StepState is the outcome of one step.
+type StepState struct {
+	Status  string            `json:"status"`
+	Outputs map[string]string `json:"outputs,omitempty"`
+	Error   string            `json:"error,omitempty"`
+	At      time.Time         `json:"at"`
+	// TxHash and RawTx are the signed tx of a broadcasted step, RawTx is
+	// broadcasted again when the nodes no longer know TxHash.
+	TxHash string `json:"tx_hash,omitempty"`
+	RawTx  string `json:"raw_tx,omitempty"`
+}
+
+// DefaultStatePath is the state file of the playbook at path:
+// playbook.yaml keeps its state in playbook.state.json.
+func DefaultStatePath(path string) string {
+	for _, ext := range []string{".yaml", ".yml"} {
+		if strings.HasSuffix(path, ext) {
+			return strings.TrimSuffix(path, ext) + ".state.json"
+		}
+	}
+	return path + ".state.json"
+}
+
+// LoadState reads the state at path for pb. A missing file is a fresh
+// state. A state left by another version of the playbook is an error:
+// resuming it could skip steps that changed.
+func LoadState(path string, pb *Playbook) (*State, error) {
+	st := &State{PlaybookHash: pb.Hash, Steps: map[string]*StepState{}, path: path}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read state %s: %w", path, err)
+	}
+	if err := json.Unmarshal(content, st); err != nil {
+		return nil, fmt.Errorf("couldn't parse state %s: %w", path, err)
+	}
+	if st.PlaybookHash != pb.Hash {
+		return nil, fmt.Errorf("state %s was left by a different version of the playbook, use --restart to run it from the start", path)
+	}
+	if st.Steps == nil {
+		st.Steps = map[string]*StepState{}
+	}
+	return st, nil
+}
+
+// Done tells whether step id completed in an earlier run.
+func (st *State) Done(id string) bool {
+	s, ok := st.Steps[id]
+	return ok && s.Status == StatusDone
+}
+
+// Broadcasted tells whether the tx of step id was signed in an earlier run
+// without it being known to be mined, and returns the state of the step.
+func (st *State) Broadcasted(id string) (*StepState, bool) {
+	if st == nil {
+		return nil, false
+	}
+	s, ok := st.Steps[id]
+	if !ok || s.Status != StatusBroadcasted {
+		return nil, false
+	}
+	return s, true
+}
+
+// RecordBroadcast saves the signed tx of step id before it is broadcasted,
+// so a run stopped before the tx is mined can't send the step twice.
+func (st *State) RecordBroadcast(id string, txHash string, rawTx string) error {
+	st.Steps[id] = &StepState{Status: StatusBroadcasted, TxHash: txHash, RawTx: rawTx, At: time.Now()}
+	return st.save()
+}
+
+// Record saves the outcome of step id.
+func (st *State) Record(id string, status string, outputs map[string]string, stepErr error) error {
+	s := &StepState{Status: status, Outputs: outputs, At: time.Now()}
+	if stepErr != nil {
+		s.Error = stepErr.Error()
+	}
+	st.Steps[id] = s
+	return st.save()
+}
+
+func (st *State) save() error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	// a run killed while saving keeps the state of the previous step
+	if err := jarviscommon.WriteFileAtomic(st.path, data, 0o644); err != nil {
+		return fmt.Errorf("couldn't write state %s: %w", st.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package playbook
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// ErrNotAvailable is returned when a reference points at the output of a
+// step that didn't produce it, e.g. the tx hash of a step skipped by a dry
+// run.
+var ErrNotAvailable = errors.New("output not available")
+
+// Scope holds what ${...} references resolve to: ${vars.<name>},
+// ${steps.<id>.<output>} and ${env.<NAME>}.
+type Scope struct {
+	Vars map[string]string
+	// Outputs are the outputs of the steps run so far, by step id.
+	Outputs map[string]map[string]string
+	// Unavailable are the steps whose outputs can't be known, by step id.
+	Unavailable map[string]bool
+	Env         func(string) string
+}
+
+// NewScope returns a scope with the playbook's variables.
+func NewScope(vars map[string]string, env func(string) string) *Scope {
+	if vars == nil {
+		vars = map[string]string{}
+	}
+	return &Scope{
+		Vars:        vars,
+		Outputs:     map[string]map[string]string{},
+		Unavailable: map[string]bool{},
+		Env:         env,
+	}
+}
+
+var refRe = regexp.MustCompile(`\$\{\s*([^}]*?)\s*\}`)
+
+// Interpolate replaces every ${...} reference in s.
+func (sc *Scope) Interpolate(s string) (string, error) {
+	var firstErr error
+	result := refRe.ReplaceAllStringFunc(s, func(match string) string {
+		ref := refRe.FindStringSubmatch(match)[1]
+		value, err := sc.lookup(ref)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		return value
+	})
+	if firstErr != nil {
+		return "", firstErr
+	}
+	return result, nil
+}
+
+// InterpolateParam interpolates every element of p.
+func (sc *Scope) InterpolateParam(p Param) (Param, error) {
+	if !p.IsList {
+		v, err := sc.Interpolate(p.Scalar)
+		return Param{Scalar: v}, err
+	}
+	list := make([]Param, 0, len(p.List))
+	for _, e := range p.List {
+		v, err := sc.InterpolateParam(e)
+		if err != nil {
+			return Param{}, err
+		}
+		list = append(list, v)
+	}
+	return Param{List: list, IsList: true}, nil
+}
+
+func (sc *Scope) lookup(ref string) (string, error) {
+	parts := strings.SplitN(ref, ".", 3)
+	switch {
+	case len(parts) == 2 && parts[0] == "vars":
+		v, ok := sc.Vars[parts[1]]
+		if !ok {
+			return "", fmt.Errorf("undefined variable %s", parts[1])
+		}
+		return v, nil
+	case len(parts) == 2 && parts[0] == "env" && sc.Env != nil:
+		return sc.Env(parts[1]), nil
+	case len(parts) == 3 && parts[0] == "steps":
+		if sc.Unavailable[parts[1]] {
+			return "", fmt.Errorf("%w: ${%s}", ErrNotAvailable, ref)
+		}
+		outputs, ok := sc.Outputs[parts[1]]
+		if !ok {
+			return "", fmt.Errorf("step %s hasn't run before this one", parts[1])
+		}
+		v, ok := outputs[parts[2]]
+		if !ok {
+			return "", fmt.Errorf("step %s has no output %s", parts[1], parts[2])
+		}
+		return v, nil
+	}
+	return "", fmt.Errorf("invalid reference ${%s}, use vars.<name>, steps.<id>.<output> or env.<NAME>", ref)
+}
+
+// FormatValue renders a value returned by a contract call so it can be fed
+// back as the param of a later step.
+func FormatValue(v interface{}) string {
+	switch value := v.(type) {
+	case *big.Int:
+		return value.String()
+	case common.Address:
+		return value.Hex()
+	case common.Hash:
+		return value.Hex()
+	case []byte:
+		return hexutil.Encode(value)
+	case [32]byte:
+		return hexutil.Encode(value[:])
+	case string:
+		return value
+	case []common.Address:
+		elems := make([]string, 0, len(value))
+		for _, e := range value {
+			elems = append(elems, e.Hex())
+		}
+		return "[" + strings.Join(elems, ", ") + "]"
+	case []*big.Int:
+		elems := make([]string, 0, len(value))
+		for _, e := range value {
+			elems = append(elems, e.String())
+		}
+		return "[" + strings.Join(elems, ", ") + "]"
+	}
+	return fmt.Sprintf("%v", v)
+}
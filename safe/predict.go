@@ -0,0 +1,121 @@
+package safe
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Deployment is the set of canonical contracts a Safe release deploys new
+// Safes with. Like MultiSendCallOnly they are deployed deterministically, so
+// the addresses hold on almost every chain.
+type Deployment struct {
+	Version         string
+	ProxyFactory    common.Address
+	Singleton       common.Address
+	SingletonL2     common.Address
+	FallbackHandler common.Address
+}
+
+// Deployments are the canonical deployments of the releases Safe{Wallet}
+// creates Safes with.
+var Deployments = map[string]Deployment{
+	"1.4.1": {
+		Version:         "1.4.1",
+		ProxyFactory:    common.HexToAddress("0x4e1DCf7AD4e460CfD30791CCC4F9c8a4f820ec67"),
+		Singleton:       common.HexToAddress("0x41675C099F32341bf84BFc5382aF534df5C7461a"),
+		SingletonL2:     common.HexToAddress("0x29fcB43b46531BcA003ddC8FCB67FFE91900C762"),
+		FallbackHandler: common.HexToAddress("0xfd0732Dc9E303f09fCEf3a7388Ad10A83459Ec99"),
+	},
+	"1.3.0": {
+		Version:         "1.3.0",
+		ProxyFactory:    common.HexToAddress("0xa6B71E26C5e0845f74c812102Ca7114b6a896AB2"),
+		Singleton:       common.HexToAddress("0xd9Db270c1B5E3Bd161E8c8503c55cEABeE709552"),
+		SingletonL2:     common.HexToAddress("0x3E5c63644E683549055b9Be8653de26E0B4CD36E"),
+		FallbackHandler: common.HexToAddress("0xf48f2B2d2a534e402487b3ee7C18c33Aec0Fe5e4"),
+	},
+}
+
+// SAFE_SETUP_ABI holds Safe.setup, the initializer a proxy is created with,
+// and SafeProxyFactory.proxyCreationCode, the proxy bytecode the factory
+// deploys.
+const SAFE_SETUP_ABI string = `[
+  {
+    "inputs": [
+      {"internalType": "address[]", "name": "_owners", "type": "address[]"},
+      {"internalType": "uint256", "name": "_threshold", "type": "uint256"},
+      {"internalType": "address", "name": "to", "type": "address"},
+      {"internalType": "bytes", "name": "data", "type": "bytes"},
+      {"internalType": "address", "name": "fallbackHandler", "type": "address"},
+      {"internalType": "address", "name": "paymentToken", "type": "address"},
+      {"internalType": "uint256", "name": "payment", "type": "uint256"},
+      {"internalType": "address payable", "name": "paymentReceiver", "type": "address"}
+    ],
+    "name": "setup",
+    "outputs": [],
+    "stateMutability": "nonpayable",
+    "type": "function"
+  },
+  {
+    "inputs": [],
+    "name": "proxyCreationCode",
+    "outputs": [{"internalType": "bytes", "name": "", "type": "bytes"}],
+    "stateMutability": "pure",
+    "type": "function"
+  }
+]`
+
+func GetSafeSetupABI() *abi.ABI {
+	a, err := abi.JSON(strings.NewReader(SAFE_SETUP_ABI))
+	if err != nil {
+		panic(err)
+	}
+	return &a
+}
+
+// SetupData is the initializer Safe{Wallet} creates a Safe with: owners,
+// threshold and fallback handler, no module setup and no refund.
+func SetupData(owners []common.Address, threshold uint64, fallbackHandler common.Address) ([]byte, error) {
+	if len(owners) == 0 {
+		return nil, fmt.Errorf("a Safe needs at least one owner")
+	}
+	if threshold == 0 || threshold > uint64(len(owners)) {
+		return nil, fmt.Errorf("threshold %d is out of [1, %d]", threshold, len(owners))
+	}
+	return GetSafeSetupABI().Pack(
+		"setup",
+		owners,
+		new(big.Int).SetUint64(threshold),
+		common.Address{},
+		[]byte{},
+		fallbackHandler,
+		common.Address{},
+		big.NewInt(0),
+		common.Address{},
+	)
+}
+
+// PredictAddress is the address SafeProxyFactory.createProxyWithNonce
+// deploys a proxy of singleton at for initializer and saltNonce.
+// proxyCreationCode is the factory's proxyCreationCode(), which differs
+// between releases.
+func PredictAddress(
+	factory, singleton common.Address,
+	proxyCreationCode []byte,
+	initializer []byte,
+	saltNonce *big.Int,
+) common.Address {
+	salt := crypto.Keccak256Hash(
+		crypto.Keccak256(initializer),
+		common.LeftPadBytes(saltNonce.Bytes(), 32),
+	)
+	initCode := append(
+		append([]byte{}, proxyCreationCode...),
+		common.LeftPadBytes(singleton.Bytes(), 32)...,
+	)
+	return crypto.CreateAddress2(factory, salt, crypto.Keccak256(initCode))
+}
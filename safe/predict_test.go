@@ -0,0 +1,48 @@
+package safe
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestSetupData(t *testing.T) {
+	owners := []common.Address{
+		common.HexToAddress("0x1d9937e170Fc2174408581265bA0B87afDA4947F"),
+		common.HexToAddress("0xBeEEE605DC6a531AeB4bc3C809Cf6Dd86674F001"),
+	}
+	d := Deployments["1.4.1"]
+	data, err := SetupData(owners, 2, d.FallbackHandler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data[:4], common.FromHex("0xb63e800d")) {
+		t.Fatalf("selector %x, want setup's b63e800d", data[:4])
+	}
+	for _, threshold := range []uint64{0, 3} {
+		if _, err := SetupData(owners, threshold, d.FallbackHandler); err == nil {
+			t.Fatalf("threshold %d of 2 owners must be refused", threshold)
+		}
+	}
+}
+
+func TestPredictAddress(t *testing.T) {
+	d := Deployments["1.3.0"]
+	code := common.FromHex("0x608060405234801561001057600080fd5b50")
+	init := []byte{0xb6, 0x3e, 0x80, 0x0d}
+
+	got := PredictAddress(d.ProxyFactory, d.Singleton, code, init, big.NewInt(7))
+
+	var salt [32]byte
+	copy(salt[:], crypto.Keccak256(crypto.Keccak256(init), common.LeftPadBytes([]byte{7}, 32)))
+	deployment := append(append([]byte{}, code...), common.LeftPadBytes(d.Singleton.Bytes(), 32)...)
+	if want := crypto.CreateAddress2(d.ProxyFactory, salt, crypto.Keccak256(deployment)); got != want {
+		t.Fatalf("got %s, want %s", got.Hex(), want.Hex())
+	}
+	if got == PredictAddress(d.ProxyFactory, d.Singleton, code, init, big.NewInt(8)) {
+		t.Fatalf("the salt nonce must change the address")
+	}
+}
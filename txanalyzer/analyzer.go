@@ -41,6 +41,48 @@ func (self *TxAnalyzer) setBasicTxInfo(txinfo TxInfo, result *TxResult) {
 	result.GasLimit = fmt.Sprintf("%d", txinfo.Tx.Gas())
 	result.GasUsed = fmt.Sprintf("%d", txinfo.Receipt.GasUsed)
 	result.GasCost = fmt.Sprintf("%.8f", BigToFloat(txinfo.GasCost(), self.ctx.Network.GetNativeTokenDecimal()))
+
+	for _, h := range txinfo.Tx.BlobHashes() {
+		result.BlobHashes = append(result.BlobHashes, h.Hex())
+	}
+	if txinfo.Receipt.BlobGasUsed > 0 {
+		result.BlobGasUsed = fmt.Sprintf("%d", txinfo.Receipt.BlobGasUsed)
+	}
+	if txinfo.Receipt.BlobGasPrice != nil {
+		result.BlobGasPrice = fmt.Sprintf("%.4f", BigToFloat(txinfo.Receipt.BlobGasPrice, 9))
+	}
+
+	for _, auth := range txinfo.Tx.SetCodeAuthorizations() {
+		result.Authorizations = append(result.Authorizations, self.authorizationResult(auth))
+	}
+}
+
+// authorizationResult decodes an EIP-7702 authorization. An authorization
+// whose signer can't be recovered is skipped by the protocol, so it is
+// reported with an error rather than dropped.
+func (self *TxAnalyzer) authorizationResult(auth types.SetCodeAuthorization) AuthorizationResult {
+	r := AuthorizationResult{
+		Delegate: self.ctx.GetJarvisAddress(auth.Address.Hex()),
+		Nonce:    fmt.Sprintf("%d", auth.Nonce),
+		ChainID:  auth.ChainID.Dec(),
+	}
+	authority, err := auth.Authority()
+	if err != nil {
+		r.Error = fmt.Sprintf("invalid signature: %s", err)
+		return r
+	}
+	r.Authority = self.ctx.GetJarvisAddress(authority.Hex())
+	return r
+}
+
+// setDelegates flags the sender and the destination when they are EOAs that
+// currently delegate to a contract with EIP-7702. This is the state at the
+// time of the analysis, not at the time the tx was mined.
+func (self *TxAnalyzer) setDelegates(txinfo TxInfo, result *TxResult) {
+	result.FromDelegate = self.ctx.DelegateOf(txinfo.Tx.Extra.From.Hex())
+	if txinfo.Tx.To() != nil {
+		result.ToDelegate = self.ctx.DelegateOf(txinfo.Tx.To().Hex())
+	}
 }
 
 // nonArrayParamAsJarvisValue converts a scalar ABI value to a jarvis Value,
@@ -284,7 +326,7 @@ func (self *TxAnalyzer) analyzeFunctionCallRecursively(
 		fc.Destination.Decimal = int64(hint.Decimal)
 	}
 
-	fc.Method, fc.Params, err = self.analyzeMethodCall(a, data, hint)
+	fc.Method, fc.Params, fc.Guessed, err = self.analyzeMethodCall(a, data, hint)
 	if err != nil {
 		// Keep the underlying reason: "no method with id: 0x..." tells the
 		// operator the ABI is missing/wrong, which a generic message doesn't.
@@ -417,25 +459,33 @@ func unpackMultiSendPayload(data []byte) ([]byte, error) {
 }
 
 // analyzeMethodCall is the internal variant that accepts a token hint so that
-// ERC20 integer params can be annotated with decimal context.
+// ERC20 integer params can be annotated with decimal context. A selector
+// neither a nor the ERC20 ABI has is looked up in the signature database;
+// guessed tells the method was decoded with the first signature there that
+// the calldata decodes cleanly with.
 func (self *TxAnalyzer) analyzeMethodCall(
 	a *abi.ABI,
 	data []byte,
 	hint *ERC20Info,
-) (method string, params []ParamResult, err error) {
+) (method string, params []ParamResult, guessed bool, err error) {
 	m, err := a.MethodById(data)
 	if err != nil {
 		// Unknown selector — fall back to the standard ERC20 ABI.
 		a = GetERC20ABI()
 		m, err = a.MethodById(data)
 	}
+	if err != nil && self.ctx.Signatures != nil {
+		if candidates := self.ctx.Signatures.Methods(data); len(candidates) > 0 {
+			m, err, guessed = &candidates[0], nil, true
+		}
+	}
 	if err != nil {
-		return "", []ParamResult{}, err
+		return "", []ParamResult{}, false, err
 	}
 	method = m.Name
 	ps, err := m.Inputs.UnpackValues(data[4:])
 	if err != nil {
-		return method, []ParamResult{}, err
+		return method, []ParamResult{}, guessed, err
 	}
 
 	params = []ParamResult{}
@@ -443,7 +493,7 @@ func (self *TxAnalyzer) analyzeMethodCall(
 		params = append(params, self.paramAsJarvisParamResult(input.Name, input.Type, ps[i], hint))
 	}
 
-	return method, params, nil
+	return method, params, guessed, nil
 }
 
 // AnalyzeMethodCall is the public interface method; it delegates to the
@@ -452,7 +502,8 @@ func (self *TxAnalyzer) AnalyzeMethodCall(
 	a *abi.ABI,
 	data []byte,
 ) (method string, params []ParamResult, err error) {
-	return self.analyzeMethodCall(a, data, nil)
+	method, params, _, err = self.analyzeMethodCall(a, data, nil)
+	return method, params, err
 }
 
 // isValueType reports whether an ABI type is stored by value in an event topic.
@@ -468,9 +519,10 @@ func isValueType(t abi.Type) bool {
 }
 
 // AnalyzeLog decodes one event log. lookupABI resolves the emitting contract's
-// ABI when customABIs has no entry for it; pass nil to use the plain util.GetABI
-// lookup. Callers that hold a --abi fallback should pass the same ABIDatabase
-// they used for the calldata so an unverified contract's events decode too.
+// ABI when customABIs has no entry for it; pass nil to use util.ResolveABI,
+// which follows proxies to where their events are declared. Callers that hold
+// a --abi fallback should pass the same ABIDatabase they used for the calldata
+// so an unverified contract's events decode too.
 func (self *TxAnalyzer) AnalyzeLog(
 	lookupABI ABIDatabase,
 	customABIs map[string]*abi.ABI,
@@ -491,14 +543,28 @@ func (self *TxAnalyzer) AnalyzeLog(
 	a := customABIs[strings.ToLower(l.Address.Hex())]
 	if a == nil {
 		if lookupABI == nil {
-			lookupABI = util.GetABI
+			lookupABI = util.ResolveABI
 		}
 		a, err = lookupABI(l.Address.Hex(), self.ctx.Network)
 		if err != nil {
-			return logResult, fmt.Errorf("getting abi for %s failed: %s", l.Address.Hex(), err)
+			err = fmt.Errorf("getting abi for %s failed: %s", l.Address.Hex(), err)
+		}
+	}
+	var event *abi.Event
+	switch {
+	case err != nil:
+	case a == nil:
+		err = fmt.Errorf("no abi for %s", l.Address.Hex())
+	default:
+		event, err = findEventById(a, l.Topics[0].Bytes())
+	}
+	if err != nil && self.ctx.Signatures != nil {
+		// without an ABI for the event, the first signature of the database
+		// the log decodes cleanly with is the best guess
+		if candidates := self.ctx.Signatures.Events(l.Topics, l.Data); len(candidates) > 0 {
+			event, err, logResult.Guessed = &candidates[0], nil, true
 		}
 	}
-	event, err := findEventById(a, l.Topics[0].Bytes())
 	if err != nil {
 		return logResult, err
 	}
@@ -583,6 +649,7 @@ func (self *TxAnalyzer) AnalyzeOffline(
 	result.Status = txinfo.Status
 	if txinfo.Status == "done" || txinfo.Status == "reverted" {
 		self.setBasicTxInfo(*txinfo, result)
+		self.setDelegates(*txinfo, result)
 		if !isContract {
 			result.TxType = "normal"
 		} else {
@@ -7,8 +7,9 @@ import (
 	jarviscommon "github.com/tranvictor/jarvis/common"
 	. "github.com/tranvictor/jarvis/networks"
 	"github.com/tranvictor/jarvis/util"
-	"github.com/tranvictor/jarvis/util/reader"
 	"github.com/tranvictor/jarvis/util/addrbook"
+	"github.com/tranvictor/jarvis/util/reader"
+	"github.com/tranvictor/jarvis/util/sigdb"
 )
 
 // ERC20Info holds the token metadata discovered for a contract address.
@@ -35,13 +36,17 @@ type cachedERC20 struct {
 type AnalysisContext struct {
 	Network  Network
 	Resolver addrbook.AddressResolver
+	// Signatures decodes the calls and logs no ABI describes, by guessing
+	// their signature.
+	Signatures *sigdb.DB
 
 	// reader is stored for future enrichment queries that need direct RPC
 	// access (e.g. slot reads, multicall batching).
 	reader reader.Reader
 
-	mu    sync.RWMutex
-	erc20 map[string]cachedERC20 // keyed by lower-case address
+	mu        sync.RWMutex
+	erc20     map[string]cachedERC20           // keyed by lower-case address
+	delegates map[string]*jarviscommon.Address // keyed by lower-case address, nil means not delegated
 }
 
 // NewAnalysisContext creates a fresh AnalysisContext using the default
@@ -64,10 +69,12 @@ func NewAnalysisContext(r reader.Reader, network Network) *AnalysisContext {
 // NewAnalysisContext does that for you by default.
 func NewAnalysisContextWithResolver(r reader.Reader, network Network, res addrbook.AddressResolver) *AnalysisContext {
 	return &AnalysisContext{
-		Network:  network,
-		Resolver: res,
-		reader:   r,
-		erc20:    make(map[string]cachedERC20),
+		Network:    network,
+		Resolver:   res,
+		Signatures: sigdb.Shared(),
+		reader:     r,
+		erc20:      make(map[string]cachedERC20),
+		delegates:  make(map[string]*jarviscommon.Address),
 	}
 }
 
@@ -78,6 +85,37 @@ func (ctx *AnalysisContext) GetJarvisAddress(addr string) jarviscommon.Address {
 	return ctx.Resolver.Resolve(addr)
 }
 
+// DelegateOf returns the address book view of the delegate addr runs the
+// code of via EIP-7702, or nil when addr isn't delegated or the code can't
+// be read. Lookups are cached for the session only: unlike token metadata,
+// a delegation can change with any tx of addr.
+func (ctx *AnalysisContext) DelegateOf(addr string) *jarviscommon.Address {
+	if ctx.reader == nil {
+		return nil
+	}
+	key := strings.ToLower(addr)
+
+	ctx.mu.RLock()
+	delegate, found := ctx.delegates[key]
+	ctx.mu.RUnlock()
+	if found {
+		return delegate
+	}
+
+	code, err := ctx.reader.GetCode(addr)
+	if err != nil {
+		return nil
+	}
+	if d, ok := jarviscommon.DelegateOf(code); ok {
+		a := ctx.GetJarvisAddress(d.Hex())
+		delegate = &a
+	}
+	ctx.mu.Lock()
+	ctx.delegates[key] = delegate
+	ctx.mu.Unlock()
+	return delegate
+}
+
 // ERC20InfoFor returns token metadata for addr if the address is a known ERC20
 // token, or nil otherwise. Results are cached in memory for the session
 // lifetime; the underlying util functions also persist to disk across runs.
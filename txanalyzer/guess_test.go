@@ -0,0 +1,59 @@
+package txanalyzer
+
+import (
+	"math/big"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// TestGuessMethodWithoutABI covers a call to a contract without an ABI whose
+// selector the signature database knows: it decodes, marked as guessed.
+func TestGuessMethodWithoutABI(t *testing.T) {
+	ta := pureAnalyzer()
+	if _, err := ta.ctx.Signatures.Add([]string{"rebalance(uint256,address)"}); err != nil {
+		t.Fatal(err)
+	}
+	data := crypto.Keccak256([]byte("rebalance(uint256,address)"))[:4]
+	data = append(data, ethcommon.BigToHash(big.NewInt(3)).Bytes()...)
+	data = append(data, ethcommon.BytesToHash(ethcommon.HexToAddress("0xaa").Bytes()).Bytes()...)
+
+	fc := ta.AnalyzeFunctionCallRecursively(
+		noABIFound, big.NewInt(0), "0x1bD5af8e731D0969E8eBf7ea87f06d9Dc096d155", data, nil,
+	)
+	if fc.Error != "" || fc.Method != "rebalance" || !fc.Guessed {
+		t.Fatalf("got %q (guessed %t, error %q), want a guessed rebalance", fc.Method, fc.Guessed, fc.Error)
+	}
+	if len(fc.Params) != 2 || fc.Params[0].Values[0].Raw != "3" {
+		t.Fatalf("unexpected params %+v", fc.Params)
+	}
+
+	// a selector nobody knows stays undecoded
+	fc = ta.AnalyzeFunctionCallRecursively(
+		noABIFound, big.NewInt(0), "0x1bD5af8e731D0969E8eBf7ea87f06d9Dc096d155", []byte{1, 2, 3, 4}, nil,
+	)
+	if fc.Method != "" || fc.Guessed || fc.Error == "" {
+		t.Fatalf("got %q (guessed %t), want an error", fc.Method, fc.Guessed)
+	}
+}
+
+func TestGuessEventWithoutABI(t *testing.T) {
+	l := &types.Log{
+		Address: ethcommon.HexToAddress("0x1bD5af8e731D0969E8eBf7ea87f06d9Dc096d155"),
+		Topics: []ethcommon.Hash{
+			crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)")),
+			ethcommon.BytesToHash(ethcommon.HexToAddress("0xaa").Bytes()),
+			ethcommon.BytesToHash(ethcommon.HexToAddress("0xbb").Bytes()),
+		},
+		Data: ethcommon.BigToHash(big.NewInt(5)).Bytes(),
+	}
+	result, err := pureAnalyzer().AnalyzeLog(noABIFound, nil, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Name != "Transfer" || !result.Guessed || len(result.Topics) != 2 || len(result.Data) != 1 {
+		t.Fatalf("got %+v, want a guessed Transfer", result)
+	}
+}
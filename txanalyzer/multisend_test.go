@@ -12,6 +12,7 @@ import (
 	jarviscommon "github.com/tranvictor/jarvis/common"
 	jarvisnetworks "github.com/tranvictor/jarvis/networks"
 	"github.com/tranvictor/jarvis/util/addrbook"
+	"github.com/tranvictor/jarvis/util/sigdb"
 )
 
 // noABIFound stands in for the block-explorer lookup: MultiSendCallOnly and the
@@ -23,6 +24,8 @@ func noABIFound(address string, network jarvisnetworks.Network) (*abi.ABI, error
 
 func pureAnalyzer() *TxAnalyzer {
 	ctx := NewAnalysisContextWithResolver(nil, jarvisnetworks.BSCMainnet, addrbook.Map{})
+	// only the bundled signatures, whatever the user's database holds
+	ctx.Signatures = sigdb.New("")
 	return NewGenericAnalyzerWithContext(ctx)
 }
 
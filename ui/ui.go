@@ -37,6 +37,13 @@ func (s StyledText) MarshalJSON() ([]byte, error) {
 	return json.Marshal(s.Text)
 }
 
+// UnmarshalJSON reads back what MarshalJSON wrote. The Severity is not part
+// of the serialized form, so it comes back as SeverityInfo.
+func (s *StyledText) UnmarshalJSON(data []byte) error {
+	s.Severity = SeverityInfo
+	return json.Unmarshal(data, &s.Text)
+}
+
 // UI provides all terminal interaction for Jarvis commands.
 //
 // It abstracts output, user prompts, and indentation so that:
@@ -0,0 +1,144 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tranvictor/jarvis/networks"
+	"github.com/tranvictor/jarvis/util/abistore"
+	"github.com/tranvictor/jarvis/util/cache"
+)
+
+var abiStore = abistore.New(abistore.DefaultDir())
+
+// ABIStore is the store jarvis keeps the ABIs of contracts in.
+func ABIStore() *abistore.Store {
+	return abiStore
+}
+
+// StoredABI returns the stored ABI of addr on network, if there is one.
+func StoredABI(addr string, network networks.Network) (*abistore.Entry, bool) {
+	return abiStore.Get(network.GetName(), addr)
+}
+
+// abiJSON returns the ABI in str, which is either an ABI or a Foundry or
+// Hardhat artifact.
+func abiJSON(str string) (json.RawMessage, error) {
+	trimmed := strings.TrimSpace(str)
+	if strings.HasPrefix(trimmed, "[") {
+		if !json.Valid([]byte(trimmed)) {
+			return nil, fmt.Errorf("the abi isn't valid json")
+		}
+		return json.RawMessage(trimmed), nil
+	}
+	artifact := struct {
+		ABI json.RawMessage `json:"abi"`
+	}{}
+	if err := json.Unmarshal([]byte(trimmed), &artifact); err != nil {
+		return nil, fmt.Errorf("neither an abi nor an artifact: %w", err)
+	}
+	if !strings.HasPrefix(strings.TrimSpace(string(artifact.ABI)), "[") {
+		return nil, fmt.Errorf("the artifact has no abi")
+	}
+	return artifact.ABI, nil
+}
+
+func storeABI(addr string, network networks.Network, origin, source, str string, pinned bool) (*abistore.Entry, error) {
+	raw, err := abiJSON(str)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := GetABIFromBytes(raw); err != nil {
+		return nil, err
+	}
+	e := &abistore.Entry{
+		Address:   strings.ToLower(addr),
+		Origin:    origin,
+		Source:    source,
+		FetchedAt: time.Now(),
+		Pinned:    pinned,
+		ABI:       raw,
+	}
+	return e, abiStore.Put(network.GetName(), e)
+}
+
+// legacyCachedABI returns the ABI jarvis cached for addr before it had the
+// store. The cache didn't tell the networks apart.
+func legacyCachedABI(addr string) (string, bool) {
+	return cache.GetCache(fmt.Sprintf("%s_abi", strings.ToLower(addr)))
+}
+
+// fetchABI gets the ABI of addr from the block explorer of network and
+// stores it. An ABI the explorer returns broken is returned as it is for
+// the callers to fail on, but isn't stored, so the entry is nil.
+func fetchABI(addr string, network networks.Network) (string, *abistore.Entry, error) {
+	reader, err := EthReader(network)
+	if err != nil {
+		return "", nil, err
+	}
+	abiStr, err := reader.GetABIString(addr)
+	if err != nil {
+		return "", nil, err
+	}
+	e, err := storeABI(addr, network, abistore.FromExplorer, network.GetBlockExplorerAPIURL(), abiStr, false)
+	if err != nil {
+		return abiStr, nil, nil
+	}
+	return abiStr, e, nil
+}
+
+func fetchABIEntry(addr string, network networks.Network) (*abistore.Entry, error) {
+	_, e, err := fetchABI(addr, network)
+	if err == nil && e == nil {
+		err = fmt.Errorf("the abi of %s from %s isn't valid", addr, network.GetBlockExplorerAPIURL())
+	}
+	return e, err
+}
+
+// SetABI makes the ABI read from pathOrAddress (see ReadCustomABIString)
+// the ABI of addr on network, over whatever its block explorer has, until
+// it is removed.
+func SetABI(addr string, pathOrAddress string, network networks.Network) (*abistore.Entry, error) {
+	str, err := ReadCustomABIString(addr, pathOrAddress, network)
+	if err != nil {
+		return nil, err
+	}
+	return storeABI(addr, network, abistore.FromUser, customABISource(pathOrAddress), str, true)
+}
+
+// PinABI pins or unpins the stored ABI of addr on network. A missing ABI is
+// fetched first.
+func PinABI(addr string, network networks.Network, pinned bool) (*abistore.Entry, error) {
+	e, found := StoredABI(addr, network)
+	if !found {
+		if !pinned {
+			return nil, fmt.Errorf("no abi of %s is stored on %s", addr, network.GetName())
+		}
+		var err error
+		if e, err = fetchABIEntry(addr, network); err != nil {
+			return nil, err
+		}
+	}
+	e.Pinned = pinned
+	return e, abiStore.Put(network.GetName(), e)
+}
+
+// RefreshABI fetches the ABI of addr again from the block explorer of
+// network. Pinned ABIs are only replaced when force is set.
+func RefreshABI(addr string, network networks.Network, force bool) (*abistore.Entry, error) {
+	if e, found := StoredABI(addr, network); found && e.Pinned && !force {
+		return nil, fmt.Errorf("the abi of %s is pinned (%s, from %s)", addr, e.Origin, e.Source)
+	}
+	return fetchABIEntry(addr, network)
+}
+
+// customABISource is what a custom ABI is recorded to come from.
+func customABISource(pathOrAddress string) string {
+	trimmed := strings.TrimSpace(pathOrAddress)
+	if strings.HasPrefix(trimmed, "[") || strings.HasPrefix(trimmed, "{") {
+		return "inline json"
+	}
+	return pathOrAddress
+}
@@ -0,0 +1,158 @@
+// Package abistore keeps the ABIs jarvis decodes contracts with, one file
+// per contract under ~/.jarvis/abis/<network>/<address>.json, together with
+// where each ABI came from and when.
+//
+// ABIs fetched from block explorers are stored as they are fetched and
+// replaced when they are fetched again. Pinned entries are never replaced
+// by a fetch; ABIs set by the user are pinned and override whatever the
+// explorer has for the address.
+package abistore
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	jarviscommon "github.com/tranvictor/jarvis/common"
+)
+
+// Origins of an ABI.
+const (
+	// FromExplorer is an ABI fetched from the block explorer of the network.
+	FromExplorer = "explorer"
+	// FromCustom is an ABI given to a command with --abi.
+	FromCustom = "custom"
+	// FromUser is an ABI set with jarvis abi set, overriding the others.
+	FromUser = "user"
+	// FromCache is an ABI carried over from the jarvis cache, which didn't
+	// tell the networks apart.
+	FromCache = "cache"
+)
+
+// Entry is the ABI of a contract on a network.
+type Entry struct {
+	Address string `json:"address"`
+	// Origin is one of FromExplorer, FromCustom, FromUser and FromCache.
+	Origin string `json:"origin"`
+	// Source is where the ABI was read from: the explorer API, a file, an
+	// url or the address it was copied from.
+	Source    string          `json:"source"`
+	FetchedAt time.Time       `json:"fetched_at"`
+	Pinned    bool            `json:"pinned"`
+	ABI       json.RawMessage `json:"abi"`
+}
+
+// Overrides tells whether the entry was set by the user, and so is used as
+// it is, even for proxies.
+func (e *Entry) Overrides() bool {
+	return e.Origin == FromUser
+}
+
+// Store is the ABIs stored under a directory.
+type Store struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// DefaultDir is ~/.jarvis/abis.
+func DefaultDir() string {
+	u, err := user.Current()
+	if err != nil {
+		return filepath.Join(".jarvis", "abis")
+	}
+	return filepath.Join(u.HomeDir, ".jarvis", "abis")
+}
+
+// New returns the store under dir.
+func New(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+func (s *Store) networkDir(network string) string {
+	return filepath.Join(s.dir, strings.ToLower(network))
+}
+
+// Path is the file the ABI of address on network is stored in.
+func (s *Store) Path(network, address string) string {
+	return filepath.Join(s.networkDir(network), strings.ToLower(address)+".json")
+}
+
+// Get returns the entry of address on network, if there is one.
+func (s *Store) Get(network, address string) (*Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, err := readEntry(s.Path(network, address))
+	if err != nil {
+		return nil, false
+	}
+	return e, true
+}
+
+func readEntry(path string) (*Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	e := &Entry{}
+	if err := json.Unmarshal(data, e); err != nil {
+		return nil, fmt.Errorf("couldn't decode %s: %w", path, err)
+	}
+	return e, nil
+}
+
+// Put stores e as the entry of its address on network, replacing the one
+// there was.
+func (s *Store) Put(network string, e *Entry) error {
+	if !json.Valid(e.ABI) {
+		return fmt.Errorf("the abi of %s isn't valid json", e.Address)
+	}
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(s.networkDir(network), 0o755); err != nil {
+		return err
+	}
+	return jarviscommon.WriteFileAtomic(s.Path(network, e.Address), data, 0o644)
+}
+
+// Remove removes the entry of address on network.
+func (s *Store) Remove(network, address string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := os.Remove(s.Path(network, address))
+	if os.IsNotExist(err) {
+		return fmt.Errorf("no abi of %s is stored on %s", address, network)
+	}
+	return err
+}
+
+// List returns the entries of network, by address.
+func (s *Store) List(network string) ([]*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	files, err := filepath.Glob(filepath.Join(s.networkDir(network), "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	entries := []*Entry{}
+	for _, f := range files {
+		e, err := readEntry(f)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return strings.ToLower(entries[i].Address) < strings.ToLower(entries[j].Address)
+	})
+	return entries, nil
+}
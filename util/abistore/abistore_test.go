@@ -0,0 +1,55 @@
+package abistore
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	s := New(t.TempDir())
+	if _, found := s.Get("mainnet", "0xAA"); found {
+		t.Fatal("found an entry in an empty store")
+	}
+
+	fetchedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, e := range []*Entry{
+		{Address: "0xBB", Origin: FromExplorer, Source: "https://api.etherscan.io/v2/api", FetchedAt: fetchedAt, ABI: json.RawMessage(`[]`)},
+		{Address: "0xAA", Origin: FromUser, Source: "out/Vault.sol/Vault.json", FetchedAt: fetchedAt, Pinned: true, ABI: json.RawMessage(`[{"type":"fallback"}]`)},
+	} {
+		if err := s.Put("mainnet", e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Put("mainnet", &Entry{Address: "0xCC", ABI: json.RawMessage(`not json`)}); err == nil {
+		t.Fatal("stored an invalid abi")
+	}
+
+	e, found := s.Get("mainnet", "0xaa")
+	if !found || !e.Overrides() || !e.Pinned || !e.FetchedAt.Equal(fetchedAt) {
+		t.Fatalf("got %+v", e)
+	}
+	stored := bytes.Buffer{}
+	if err := json.Compact(&stored, e.ABI); err != nil || stored.String() != `[{"type":"fallback"}]` {
+		t.Fatalf("stored abi %s, %v", e.ABI, err)
+	}
+	if _, found := s.Get("bsc", "0xAA"); found {
+		t.Fatal("entries leak across networks")
+	}
+
+	entries, err := s.List("mainnet")
+	if err != nil || len(entries) != 2 || entries[0].Address != "0xAA" {
+		t.Fatalf("listed %v, %v", entries, err)
+	}
+
+	if err := s.Remove("mainnet", "0xaa"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Remove("mainnet", "0xaa"); err == nil {
+		t.Fatal("removed a missing entry")
+	}
+	if entries, _ := s.List("mainnet"); len(entries) != 1 {
+		t.Fatalf("listed %v after removing", entries)
+	}
+}
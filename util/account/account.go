@@ -7,6 +7,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
 
 	"github.com/tranvictor/jarvis/util/account/ledgereum"
 	"github.com/tranvictor/jarvis/util/account/trezoreum"
@@ -122,3 +123,23 @@ func (self *Account) SignTypedDataV4(typedData *TypedDataV4) ([]byte, error) {
 	}
 	return sig, nil
 }
+
+// SignSetCodeAuthorization signs an EIP-7702 authorization that lets
+// delegate's code run in the context of this account. nonce must be the
+// account nonce at the time the authorization is processed, which is one
+// more than the tx nonce when the account also sends the tx itself.
+func (self *Account) SignSetCodeAuthorization(
+	chainID uint64,
+	delegate common.Address,
+	nonce uint64,
+) (types.SetCodeAuthorization, error) {
+	auth, err := self.signer.SignSetCodeAuthorization(types.SetCodeAuthorization{
+		ChainID: *uint256.NewInt(chainID),
+		Address: delegate,
+		Nonce:   nonce,
+	})
+	if err != nil {
+		return auth, fmt.Errorf("couldn't sign authorization: %w", err)
+	}
+	return auth, nil
+}
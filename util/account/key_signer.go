@@ -71,6 +71,12 @@ func (self *KeySigner) SignPersonalMessage(message []byte) ([]byte, error) {
 	return sig, nil
 }
 
+// SignSetCodeAuthorization signs the EIP-7702 authorization with the
+// wrapped private key.
+func (self *KeySigner) SignSetCodeAuthorization(auth types.SetCodeAuthorization) (types.SetCodeAuthorization, error) {
+	return types.SignSetCode(self.key, auth)
+}
+
 func NewKeySigner(key *ecdsa.PrivateKey) *KeySigner {
 	return &KeySigner{key}
 }
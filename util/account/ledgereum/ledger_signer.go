@@ -263,6 +263,15 @@ func (self *LedgerSigner) SignPersonalMessage(message []byte) ([]byte, error) {
 	return sig, nil
 }
 
+// SignSetCodeAuthorization always fails: the Ledger Ethereum app only signs
+// authorizations for a whitelist of delegate contracts through an opcode
+// jarvis doesn't drive, and there is no safe raw-hash fallback.
+func (self *LedgerSigner) SignSetCodeAuthorization(
+	auth types.SetCodeAuthorization,
+) (types.SetCodeAuthorization, error) {
+	return auth, fmt.Errorf("ledger doesn't support signing EIP-7702 authorizations")
+}
+
 // shouldFallBackToPersonalSign returns true for failures that look like
 // "device refused / unsupported instruction" rather than user rejection.
 func shouldFallBackToPersonalSign(err error) bool {
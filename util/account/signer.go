@@ -49,4 +49,11 @@ type Signer interface {
 	// want to expose — a hostile dApp could trick a user into signing
 	// an unprefixed 32-byte value that is also a valid tx hash.
 	SignPersonalMessage(message []byte) ([]byte, error)
+
+	// SignSetCodeAuthorization signs an EIP-7702 authorization tuple
+	// (chainId, address, nonce) and returns it with V, R and S filled in.
+	// Backends that can't sign authorizations return an error rather than
+	// falling back to any other signing scheme: a 7702 authorization hands
+	// full control of the account to the delegate.
+	SignSetCodeAuthorization(auth types.SetCodeAuthorization) (types.SetCodeAuthorization, error)
 }
@@ -182,6 +182,15 @@ func (self *TrezorSigner) SignPersonalMessage(message []byte) ([]byte, error) {
 	return self.trezor.SignPersonalMessage(self.path, message)
 }
 
+// SignSetCodeAuthorization always fails: Trezor firmware has no message
+// for EIP-7702 authorizations, and signing the authorization digest as a
+// raw hash is exactly what the firmware is designed to refuse.
+func (self *TrezorSigner) SignSetCodeAuthorization(
+	auth types.SetCodeAuthorization,
+) (types.SetCodeAuthorization, error) {
+	return auth, fmt.Errorf("trezor doesn't support signing EIP-7702 authorizations")
+}
+
 // shouldFallBackToPersonalSign returns true when the failure indicates the
 // firmware does not implement EthereumSignTypedHash, rather than a user
 // rejection or a transport error.
@@ -4,6 +4,7 @@ import (
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/core/types"
 
 	jarviscommon "github.com/tranvictor/jarvis/common"
 )
@@ -13,4 +14,5 @@ type TxAnalyzer interface {
 	AnalyzeMethodCall(a *abi.ABI, data []byte) (method string, params []jarviscommon.ParamResult, err error)
 	AnalyzeOffline(txinfo *jarviscommon.TxInfo, lookupABI jarviscommon.ABIDatabase, customABIs map[string]*abi.ABI, isContract bool) *jarviscommon.TxResult
 	ParamAsJarvisParamResult(name string, t abi.Type, value interface{}) jarviscommon.ParamResult
+	AnalyzeLog(lookupABI jarviscommon.ABIDatabase, customABIs map[string]*abi.ABI, l *types.Log) (jarviscommon.LogResult, error)
 }
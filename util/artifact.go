@@ -0,0 +1,108 @@
+package util
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// ContractArtifact is what a deployment needs from a compiled contract:
+// its creation bytecode and, when the artifact has one, its ABI.
+type ContractArtifact struct {
+	Name     string
+	ABI      *abi.ABI
+	Bytecode []byte
+}
+
+// unlinkedLibrary matches the placeholders solc leaves in the bytecode of a
+// contract using external libraries that aren't linked yet.
+var unlinkedLibrary = regexp.MustCompile(`__[^_]+__`)
+
+// ReadContractArtifact reads a contract to deploy from a file path or an
+// http(s) URL. See ParseContractArtifact for the formats.
+func ReadContractArtifact(source string) (*ContractArtifact, error) {
+	var data []byte
+	var err error
+	if isHttpURL(source) {
+		var str string
+		str, err = GetABIStringFromURL(source)
+		data = []byte(str)
+	} else {
+		data, err = os.ReadFile(source)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read %s: %w", source, err)
+	}
+	return ParseContractArtifact(data)
+}
+
+// ParseContractArtifact reads the creation bytecode and ABI of a Foundry
+// (out/<File>.sol/<Contract>.json) or Hardhat (artifacts/.../<Contract>.json)
+// artifact, or a bare hex bytecode, which has no ABI.
+func ParseContractArtifact(data []byte) (*ContractArtifact, error) {
+	trimmed := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(trimmed, "{") {
+		code, err := parseBytecode(trimmed)
+		if err != nil {
+			return nil, err
+		}
+		return &ContractArtifact{Bytecode: code}, nil
+	}
+
+	raw := struct {
+		ContractName string          `json:"contractName"`
+		ABI          json.RawMessage `json:"abi"`
+		Bytecode     json.RawMessage `json:"bytecode"`
+	}{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("couldn't decode the artifact: %w", err)
+	}
+	if len(raw.Bytecode) == 0 {
+		return nil, fmt.Errorf("the artifact has no bytecode")
+	}
+
+	var bytecode string
+	if err := json.Unmarshal(raw.Bytecode, &bytecode); err != nil {
+		// foundry: {"object": "0x...", "sourceMap": ..., "linkReferences": ...}
+		object := struct {
+			Object string `json:"object"`
+		}{}
+		if err := json.Unmarshal(raw.Bytecode, &object); err != nil {
+			return nil, fmt.Errorf("couldn't decode the bytecode of the artifact: %w", err)
+		}
+		bytecode = object.Object
+	}
+	code, err := parseBytecode(bytecode)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ContractArtifact{Name: raw.ContractName, Bytecode: code}
+	if len(raw.ABI) > 0 && string(raw.ABI) != "null" {
+		result.ABI, err = GetABIFromBytes(raw.ABI)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't decode the abi of the artifact: %w", err)
+		}
+	}
+	return result, nil
+}
+
+func parseBytecode(s string) ([]byte, error) {
+	s = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(s), "0x"), "0X")
+	if s == "" {
+		return nil, fmt.Errorf("the bytecode is empty, abstract contracts and interfaces can't be deployed")
+	}
+	if lib := unlinkedLibrary.FindString(s); lib != "" {
+		return nil, fmt.Errorf("the bytecode has unlinked libraries (%s), link them before deploying", lib)
+	}
+	code, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("the bytecode isn't hex: %w", err)
+	}
+	return code, nil
+}
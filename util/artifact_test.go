@@ -0,0 +1,49 @@
+package util_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tranvictor/jarvis/util"
+)
+
+const artifactABI = `[{"type":"constructor","inputs":[{"name":"owner","type":"address"}],"stateMutability":"nonpayable"}]`
+
+func TestParseContractArtifact(t *testing.T) {
+	for name, data := range map[string]string{
+		"foundry": `{"abi":` + artifactABI + `,"bytecode":{"object":"0x6080604052","sourceMap":"","linkReferences":{}}}`,
+		"hardhat": `{"_format":"hh-sol-artifact-1","contractName":"Vault","abi":` + artifactABI + `,"bytecode":"0x6080604052","linkReferences":{}}`,
+	} {
+		art, err := util.ParseContractArtifact([]byte(data))
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if len(art.Bytecode) != 5 || art.Bytecode[0] != 0x60 {
+			t.Fatalf("%s: bytecode %x", name, art.Bytecode)
+		}
+		if art.ABI == nil || len(art.ABI.Constructor.Inputs) != 1 {
+			t.Fatalf("%s: constructor not read", name)
+		}
+	}
+
+	art, err := util.ParseContractArtifact([]byte("0x6080604052\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if art.ABI != nil || len(art.Bytecode) != 5 {
+		t.Fatalf("bare bytecode read as %+v", art)
+	}
+}
+
+func TestParseContractArtifactRejects(t *testing.T) {
+	for want, data := range map[string]string{
+		"empty":    `{"abi":[],"bytecode":"0x"}`,
+		"unlinked": `{"abi":[],"bytecode":"0x6080__$1234567890abcdef1234567890abcdef12$__6080"}`,
+		"hex":      `0x60zz`,
+	} {
+		_, err := util.ParseContractArtifact([]byte(data))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("got %v, want an error about %s", err, want)
+		}
+	}
+}
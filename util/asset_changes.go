@@ -0,0 +1,375 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	jarviscommon "github.com/tranvictor/jarvis/common"
+	"github.com/tranvictor/jarvis/networks"
+	"github.com/tranvictor/jarvis/ui"
+	"github.com/tranvictor/jarvis/util/addrbook"
+	"github.com/tranvictor/jarvis/util/reader"
+)
+
+// Token standards of asset changes. Native is the native token of the
+// network, moved by call values.
+const (
+	AssetNative  = "native"
+	AssetERC20   = "erc20"
+	AssetERC721  = "erc721"
+	AssetERC1155 = "erc1155"
+)
+
+var (
+	transferTopic       = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+	approvalTopic       = crypto.Keccak256Hash([]byte("Approval(address,address,uint256)"))
+	approvalForAllTopic = crypto.Keccak256Hash([]byte("ApprovalForAll(address,address,bool)"))
+	transferSingleTopic = crypto.Keccak256Hash([]byte("TransferSingle(address,address,address,uint256,uint256)"))
+	transferBatchTopic  = crypto.Keccak256Hash([]byte("TransferBatch(address,address,address,uint256[],uint256[])"))
+
+	// amounts from 2^255 up are shown as unlimited: nobody approves that
+	// much on purpose, it is max uint256 or close to it.
+	unlimitedAllowance = new(big.Int).Lsh(big.NewInt(1), 255)
+)
+
+// AssetChange is the net change of the balance of Holder in one asset. For
+// ERC721 Delta is the number of tokens gained or lost and TokenIDs the
+// tokens moved; for ERC1155 TokenID is the id the balance is of.
+type AssetChange struct {
+	Holder   common.Address
+	Standard string
+	Token    common.Address
+	TokenID  *big.Int
+	TokenIDs []*big.Int
+	Delta    *big.Int
+}
+
+// AssetApproval is an allowance set during a call. All is set for
+// ApprovalForAll, then Approved tells whether it is granted or revoked. For
+// ERC721 Approval, TokenID is the token the spender may move.
+type AssetApproval struct {
+	Owner    common.Address
+	Standard string
+	Token    common.Address
+	Spender  common.Address
+	Amount   *big.Int
+	TokenID  *big.Int
+	All      bool
+	Approved bool
+}
+
+// Unlimited tells whether the approval lets the spender move every token
+// of the owner, now and later.
+func (a AssetApproval) Unlimited() bool {
+	if a.All {
+		return a.Approved
+	}
+	return a.Amount != nil && a.Amount.Cmp(unlimitedAllowance) >= 0
+}
+
+// AssetChanges are the balance changes and approvals a call makes.
+type AssetChanges struct {
+	Changes   []AssetChange
+	Approvals []AssetApproval
+	// Reverted is set when the whole call reverts: nothing changes.
+	Reverted    bool
+	RevertError string
+}
+
+type assetKey struct {
+	holder  common.Address
+	token   common.Address
+	tokenID string
+}
+
+type assetChangesBuilder struct {
+	changes   map[assetKey]*AssetChange
+	order     []assetKey
+	approvals []AssetApproval
+}
+
+func (b *assetChangesBuilder) add(holder common.Address, standard string, token common.Address, tokenID *big.Int, delta *big.Int) {
+	key := assetKey{holder: holder, token: token}
+	if standard == AssetERC1155 {
+		key.tokenID = tokenID.String()
+	}
+	c, found := b.changes[key]
+	if !found {
+		c = &AssetChange{Holder: holder, Standard: standard, Token: token, Delta: big.NewInt(0)}
+		if standard == AssetERC1155 {
+			c.TokenID = tokenID
+		}
+		b.changes[key] = c
+		b.order = append(b.order, key)
+	}
+	c.Delta.Add(c.Delta, delta)
+	if standard == AssetERC721 {
+		c.TokenIDs = append(c.TokenIDs, tokenID)
+	}
+}
+
+func (b *assetChangesBuilder) move(from, to common.Address, standard string, token common.Address, tokenID *big.Int, amount *big.Int) {
+	if from == to || amount.Sign() == 0 {
+		return
+	}
+	if from != (common.Address{}) {
+		b.add(from, standard, token, tokenID, new(big.Int).Neg(amount))
+	}
+	if to != (common.Address{}) {
+		b.add(to, standard, token, tokenID, amount)
+	}
+}
+
+// AssetChangesFromTrace derives the asset changes of a call from its
+// callTracer trace: native balances from call values, tokens from the
+// Transfer, TransferSingle and TransferBatch logs and approvals from the
+// Approval and ApprovalForAll logs. Reverted sub-calls are left out, their
+// effects are undone.
+func AssetChangesFromTrace(frame *reader.CallFrame) AssetChanges {
+	if frame.Error != "" {
+		return AssetChanges{Reverted: true, RevertError: frame.Error}
+	}
+	b := &assetChangesBuilder{changes: map[assetKey]*AssetChange{}}
+	b.walk(frame)
+
+	result := AssetChanges{Approvals: b.approvals}
+	for _, key := range b.order {
+		c := b.changes[key]
+		// what came in and went out again, e.g. through a router, nets out
+		if c.Delta.Sign() == 0 {
+			continue
+		}
+		result.Changes = append(result.Changes, *c)
+	}
+	return result
+}
+
+func (b *assetChangesBuilder) walk(frame *reader.CallFrame) {
+	if frame.Error != "" {
+		return
+	}
+	switch strings.ToUpper(frame.Type) {
+	case "CALL", "CREATE", "CREATE2", "SELFDESTRUCT":
+		if frame.Value != nil {
+			b.move(frame.From, frame.To, AssetNative, common.Address{}, nil, frame.Value.ToInt())
+		}
+	}
+	for _, log := range frame.Logs {
+		b.log(log)
+	}
+	for i := range frame.Calls {
+		b.walk(&frame.Calls[i])
+	}
+}
+
+var transferBatchArgs = func() abi.Arguments {
+	uints, _ := abi.NewType("uint256[]", "", nil)
+	return abi.Arguments{{Type: uints}, {Type: uints}}
+}()
+
+func (b *assetChangesBuilder) log(log reader.CallLog) {
+	if len(log.Topics) == 0 {
+		return
+	}
+	topicAddr := func(i int) common.Address {
+		return common.BytesToAddress(log.Topics[i].Bytes())
+	}
+	switch log.Topics[0] {
+	case transferTopic:
+		switch {
+		case len(log.Topics) == 3 && len(log.Data) >= 32:
+			b.move(topicAddr(1), topicAddr(2), AssetERC20, log.Address, nil, new(big.Int).SetBytes(log.Data[:32]))
+		case len(log.Topics) == 4:
+			b.move(topicAddr(1), topicAddr(2), AssetERC721, log.Address, log.Topics[3].Big(), big.NewInt(1))
+		}
+	case transferSingleTopic:
+		if len(log.Topics) == 4 && len(log.Data) >= 64 {
+			id := new(big.Int).SetBytes(log.Data[:32])
+			b.move(topicAddr(2), topicAddr(3), AssetERC1155, log.Address, id, new(big.Int).SetBytes(log.Data[32:64]))
+		}
+	case transferBatchTopic:
+		if len(log.Topics) != 4 {
+			return
+		}
+		values, err := transferBatchArgs.Unpack(log.Data)
+		if err != nil {
+			return
+		}
+		ids, amounts := values[0].([]*big.Int), values[1].([]*big.Int)
+		for i := 0; i < len(ids) && i < len(amounts); i++ {
+			b.move(topicAddr(2), topicAddr(3), AssetERC1155, log.Address, ids[i], amounts[i])
+		}
+	case approvalTopic:
+		switch {
+		case len(log.Topics) == 3 && len(log.Data) >= 32:
+			b.approvals = append(b.approvals, AssetApproval{
+				Owner: topicAddr(1), Spender: topicAddr(2), Standard: AssetERC20, Token: log.Address,
+				Amount: new(big.Int).SetBytes(log.Data[:32]), Approved: true,
+			})
+		case len(log.Topics) == 4:
+			spender := topicAddr(2)
+			b.approvals = append(b.approvals, AssetApproval{
+				Owner: topicAddr(1), Spender: spender, Standard: AssetERC721, Token: log.Address,
+				TokenID: log.Topics[3].Big(), Approved: spender != (common.Address{}),
+			})
+		}
+	case approvalForAllTopic:
+		if len(log.Topics) == 3 && len(log.Data) >= 32 {
+			b.approvals = append(b.approvals, AssetApproval{
+				Owner: topicAddr(1), Spender: topicAddr(2), Token: log.Address,
+				All: true, Approved: new(big.Int).SetBytes(log.Data[:32]).Sign() != 0,
+			})
+		}
+	}
+}
+
+// PreviewAssetChanges traces a call with debug_traceCall and shows what it
+// would change: the balances it moves and the approvals it sets, every
+// address labelled through resolver. you is the account the preview is
+// for, its changes come first. Nodes without tracing get a warning, never
+// an error: the preview only adds to the review.
+func PreviewAssetChanges(
+	u ui.UI,
+	you, to string,
+	value *big.Int,
+	data []byte,
+	codeOverrides map[string][]byte,
+	resolver addrbook.AddressResolver,
+	network networks.Network,
+) {
+	r, err := EthReader(network)
+	if err != nil {
+		u.Warn("Asset changes: couldn't connect to the nodes: %s", err)
+		return
+	}
+	frame, err := r.TraceCall(you, to, value, data, codeOverrides)
+	if err != nil {
+		if errors.Is(err, reader.ErrTracingNotSupported) {
+			u.Warn("Asset changes: not available, none of the %s nodes supports debug_traceCall. Add a node with tracing (jarvis node add) to see them.", network.GetName())
+		} else {
+			u.Warn("Asset changes: couldn't trace the tx: %s", err)
+		}
+		return
+	}
+	DisplayAssetChanges(u, AssetChangesFromTrace(frame), common.HexToAddress(you), resolver, network)
+}
+
+// DisplayAssetChanges shows changes grouped by holder, you first.
+func DisplayAssetChanges(u ui.UI, changes AssetChanges, you common.Address, resolver addrbook.AddressResolver, network networks.Network) {
+	label := func(addr common.Address) string {
+		text := jarviscommon.PlainAddress(resolver.Resolve(addr.Hex()))
+		if addr == you {
+			text += " [you]"
+		}
+		return text
+	}
+
+	u.Info("Asset changes (simulated):")
+	if changes.Reverted {
+		u.Error("  the tx reverts (%s), nothing would change", changes.RevertError)
+		return
+	}
+	if len(changes.Changes) == 0 && len(changes.Approvals) == 0 {
+		u.Info("  no balance or approval changes")
+		return
+	}
+
+	holders := []common.Address{}
+	byHolder := map[common.Address][]AssetChange{}
+	for _, c := range changes.Changes {
+		if _, found := byHolder[c.Holder]; !found {
+			holders = append(holders, c.Holder)
+		}
+		byHolder[c.Holder] = append(byHolder[c.Holder], c)
+	}
+	sort.SliceStable(holders, func(i, j int) bool { return holders[i] == you && holders[j] != you })
+
+	for _, holder := range holders {
+		u.Critical("  %s", label(holder))
+		for _, c := range byHolder[holder] {
+			line := "    " + formatAssetDelta(c, resolver, network)
+			if c.Delta.Sign() < 0 {
+				u.Warn("%s", line)
+			} else {
+				u.Success("%s", line)
+			}
+		}
+	}
+
+	if len(changes.Approvals) > 0 {
+		u.Info("Approvals:")
+	}
+	for _, a := range changes.Approvals {
+		token := assetName(a.Token, a.Standard, resolver, network)
+		var line string
+		switch {
+		case a.All && a.Approved:
+			line = fmt.Sprintf("%s lets %s move ALL its %s", label(a.Owner), label(a.Spender), token)
+		case a.All:
+			line = fmt.Sprintf("%s revokes %s from moving its %s", label(a.Owner), label(a.Spender), token)
+		case a.Standard == AssetERC721 && !a.Approved:
+			line = fmt.Sprintf("%s clears the approval of %s #%s", label(a.Owner), token, a.TokenID)
+		case a.Standard == AssetERC721:
+			line = fmt.Sprintf("%s lets %s move %s #%s", label(a.Owner), label(a.Spender), token, a.TokenID)
+		case a.Unlimited():
+			line = fmt.Sprintf("%s grants UNLIMITED %s allowance to %s", label(a.Owner), token, label(a.Spender))
+		case a.Amount.Sign() == 0:
+			line = fmt.Sprintf("%s revokes the %s allowance of %s", label(a.Owner), token, label(a.Spender))
+		default:
+			line = fmt.Sprintf("%s grants %s allowance to %s", label(a.Owner), formatTokenAmount(a.Amount, a.Token, resolver, network), label(a.Spender))
+		}
+		if a.Unlimited() {
+			u.Error("  %s", line)
+		} else {
+			u.Critical("  %s", line)
+		}
+	}
+}
+
+func formatAssetDelta(c AssetChange, resolver addrbook.AddressResolver, network networks.Network) string {
+	sign := "+"
+	if c.Delta.Sign() < 0 {
+		sign = "-"
+	}
+	amount := new(big.Int).Abs(c.Delta)
+	switch c.Standard {
+	case AssetNative:
+		return fmt.Sprintf("%s%s %s", sign, jarviscommon.BigToFloatString(amount, network.GetNativeTokenDecimal()), network.GetNativeTokenSymbol())
+	case AssetERC721:
+		ids := make([]string, 0, len(c.TokenIDs))
+		for _, id := range c.TokenIDs {
+			ids = append(ids, "#"+id.String())
+		}
+		return fmt.Sprintf("%s%s %s (moved %s)", sign, amount, assetName(c.Token, c.Standard, resolver, network), strings.Join(ids, ", "))
+	case AssetERC1155:
+		return fmt.Sprintf("%s%s of id %s of %s", sign, amount, c.TokenID, assetName(c.Token, c.Standard, resolver, network))
+	}
+	return sign + formatTokenAmount(amount, c.Token, resolver, network)
+}
+
+// formatTokenAmount renders an ERC20 amount with the token decimals, or raw
+// when they can't be read.
+func formatTokenAmount(amount *big.Int, token common.Address, resolver addrbook.AddressResolver, network networks.Network) string {
+	name := assetName(token, AssetERC20, resolver, network)
+	decimals, err := GetERC20Decimal(token.Hex(), network)
+	if err != nil {
+		return fmt.Sprintf("%s (raw) %s", amount, name)
+	}
+	return fmt.Sprintf("%s %s", jarviscommon.BigToFloatString(amount, decimals), name)
+}
+
+// assetName names a token by its symbol when it has one, by its address
+// book label otherwise.
+func assetName(token common.Address, standard string, resolver addrbook.AddressResolver, network networks.Network) string {
+	if symbol, err := GetERC20Symbol(token.Hex(), network); err == nil && symbol != "" {
+		return fmt.Sprintf("%s (%s)", symbol, token.Hex())
+	}
+	return jarviscommon.PlainAddress(resolver.Resolve(token.Hex()))
+}
@@ -0,0 +1,160 @@
+package util_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+
+	"github.com/tranvictor/jarvis/util"
+	"github.com/tranvictor/jarvis/util/reader"
+)
+
+// a swap of 10 ETH for 31000 USDC through a router, with an unlimited USDC
+// approval and a reverted sub-call whose transfer must not count.
+const swapTrace = `{
+	"type": "CALL",
+	"from": "0x1111111111111111111111111111111111111111",
+	"to": "0x2222222222222222222222222222222222222222",
+	"value": "0x8ac7230489e80000",
+	"input": "0x",
+	"logs": [{
+		"address": "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+		"topics": [
+			"0x8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925",
+			"0x0000000000000000000000001111111111111111111111111111111111111111",
+			"0x0000000000000000000000002222222222222222222222222222222222222222"
+		],
+		"data": "0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"
+	}],
+	"calls": [{
+		"type": "CALL",
+		"from": "0x2222222222222222222222222222222222222222",
+		"to": "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+		"value": "0x0",
+		"input": "0x",
+		"logs": [{
+			"address": "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+			"topics": [
+				"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+				"0x0000000000000000000000002222222222222222222222222222222222222222",
+				"0x0000000000000000000000001111111111111111111111111111111111111111"
+			],
+			"data": "0x0000000000000000000000000000000000000000000000000000000737be7600"
+		}]
+	}, {
+		"type": "CALL",
+		"from": "0x2222222222222222222222222222222222222222",
+		"to": "0x3333333333333333333333333333333333333333",
+		"value": "0x1",
+		"input": "0x",
+		"error": "execution reverted"
+	}, {
+		"type": "CALL",
+		"from": "0x2222222222222222222222222222222222222222",
+		"to": "0xbc4ca0eda7647a8ab7c2061c2e118a18a936f13d",
+		"input": "0x",
+		"logs": [{
+			"address": "0xbc4ca0eda7647a8ab7c2061c2e118a18a936f13d",
+			"topics": [
+				"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+				"0x0000000000000000000000002222222222222222222222222222222222222222",
+				"0x0000000000000000000000001111111111111111111111111111111111111111",
+				"0x0000000000000000000000000000000000000000000000000000000000000007"
+			],
+			"data": "0x"
+		}]
+	}]
+}`
+
+func TestAssetChangesFromTrace(t *testing.T) {
+	frame := &reader.CallFrame{}
+	if err := json.Unmarshal([]byte(swapTrace), frame); err != nil {
+		t.Fatal(err)
+	}
+	changes := util.AssetChangesFromTrace(frame)
+	if changes.Reverted {
+		t.Fatalf("the call doesn't revert")
+	}
+
+	you := ethcommon.HexToAddress("0x1111111111111111111111111111111111111111")
+	router := ethcommon.HexToAddress("0x2222222222222222222222222222222222222222")
+	usdc := ethcommon.HexToAddress("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48")
+	ten := new(big.Int).Mul(big.NewInt(10), big.NewInt(1e18))
+
+	want := map[string]*big.Int{
+		you.Hex() + "/native":    new(big.Int).Neg(ten),
+		router.Hex() + "/native": ten,
+		you.Hex() + "/erc20":     big.NewInt(31_000_000_000),
+		router.Hex() + "/erc20":  big.NewInt(-31_000_000_000),
+		you.Hex() + "/erc721":    big.NewInt(1),
+		router.Hex() + "/erc721": big.NewInt(-1),
+	}
+	if len(changes.Changes) != len(want) {
+		t.Fatalf("got %d changes, want %d: %+v", len(changes.Changes), len(want), changes.Changes)
+	}
+	for _, c := range changes.Changes {
+		key := c.Holder.Hex() + "/" + c.Standard
+		if want[key] == nil || want[key].Cmp(c.Delta) != 0 {
+			t.Fatalf("%s changed by %s, want %s", key, c.Delta, want[key])
+		}
+		if c.Standard == util.AssetERC20 && c.Token != usdc {
+			t.Fatalf("erc20 change of token %s", c.Token.Hex())
+		}
+		if c.Standard == util.AssetERC721 && (len(c.TokenIDs) != 1 || c.TokenIDs[0].Int64() != 7) {
+			t.Fatalf("erc721 token ids %v", c.TokenIDs)
+		}
+	}
+
+	if len(changes.Approvals) != 1 {
+		t.Fatalf("approvals %+v", changes.Approvals)
+	}
+	a := changes.Approvals[0]
+	if a.Owner != you || a.Spender != router || a.Token != usdc || !a.Unlimited() {
+		t.Fatalf("approval %+v", a)
+	}
+}
+
+func TestAssetChangesFromRevertedTrace(t *testing.T) {
+	changes := util.AssetChangesFromTrace(&reader.CallFrame{Type: "CALL", Error: "execution reverted"})
+	if !changes.Reverted || len(changes.Changes) != 0 {
+		t.Fatalf("a reverted call changes nothing, got %+v", changes)
+	}
+}
+
+func TestAssetChangesERC1155Batch(t *testing.T) {
+	// TransferBatch(operator, from, to, [1, 2], [5, 6]) minted to holder
+	data := ethcommon.FromHex("0x" +
+		"0000000000000000000000000000000000000000000000000000000000000040" +
+		"00000000000000000000000000000000000000000000000000000000000000a0" +
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+		"0000000000000000000000000000000000000000000000000000000000000001" +
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+		"0000000000000000000000000000000000000000000000000000000000000005" +
+		"0000000000000000000000000000000000000000000000000000000000000006")
+	holder := ethcommon.HexToAddress("0x4444444444444444444444444444444444444444")
+	frame := &reader.CallFrame{
+		Type: "CALL",
+		Logs: []reader.CallLog{{
+			Address: ethcommon.HexToAddress("0x5555555555555555555555555555555555555555"),
+			Topics: []ethcommon.Hash{
+				ethcommon.HexToHash("0x4a39dc06d4c0dbc64b70af90fd698a233a518aa5d07e595d983b8c0526c8f7fb"),
+				ethcommon.BytesToHash(holder.Bytes()),
+				{},
+				ethcommon.BytesToHash(holder.Bytes()),
+			},
+			Data: data,
+		}},
+	}
+	changes := util.AssetChangesFromTrace(frame)
+	if len(changes.Changes) != 2 {
+		t.Fatalf("changes %+v", changes.Changes)
+	}
+	for i, c := range changes.Changes {
+		if c.Holder != holder || c.Standard != util.AssetERC1155 || c.TokenID.Int64() != int64(i+1) || c.Delta.Int64() != int64(i+5) {
+			t.Fatalf("change %d: %+v", i, c)
+		}
+	}
+}
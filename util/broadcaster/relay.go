@@ -0,0 +1,298 @@
+package broadcaster
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/tranvictor/jarvis/common"
+)
+
+const (
+	// RelayMethodPrivate relays take the tx with eth_sendPrivateTransaction
+	// and keep it until it is mined or maxBlockNumber passes.
+	RelayMethodPrivate = "private"
+	// RelayMethodBundle relays take the tx as a one tx bundle with
+	// eth_sendBundle. A bundle targets a single block so it is resubmitted
+	// for every block until the tx is mined.
+	RelayMethodBundle = "bundle"
+)
+
+// DefaultRelayBlocks is how many blocks a tx sent to relays has to be mined
+// in when the caller doesn't say.
+const DefaultRelayBlocks = 25
+
+// Relay is a private tx submission endpoint.
+type Relay struct {
+	Name   string
+	URL    string
+	Method string
+	// Headers are sent with every request, e.g. an API key.
+	Headers map[string]string
+	// AuthKey, when set, signs every request body into the
+	// X-Flashbots-Signature header. It identifies the sender to the relay
+	// and holds no funds.
+	AuthKey *ecdsa.PrivateKey
+}
+
+// ChainReader is what the relay broadcaster needs from the public nodes to
+// follow the blocks the tx targets.
+type ChainReader interface {
+	CurrentBlock() (uint64, error)
+	TxInfoFromHash(tx string) (common.TxInfo, error)
+}
+
+// RelayBroadcaster sends signed txs to private relays only, so they never
+// reach the public mempool. Unlike Broadcaster it returns once the tx is
+// mined or its target blocks have passed: a tx a relay dropped is never
+// seen by the public nodes, so waiting for it there would hang.
+type RelayBroadcaster struct {
+	relays  []Relay
+	clients map[string]*rpc.Client
+	chain   ChainReader
+
+	// Blocks is how many blocks after the current one the tx may be mined
+	// in.
+	Blocks uint64
+	// PollInterval is how often the chain is checked while waiting.
+	PollInterval time.Duration
+	// Wait makes BroadcastTx wait for the tx to be mined even when only
+	// private relays are used. Bundle relays always wait since each bundle
+	// is only good for one block.
+	Wait bool
+	// Logf, when set, reports the progress of the submission.
+	Logf func(format string, args ...interface{})
+}
+
+// NewRelayBroadcaster dials relays. chain follows the blocks, usually a
+// reader of the public nodes of the same network.
+func NewRelayBroadcaster(relays []Relay, chain ChainReader, blocks uint64) (*RelayBroadcaster, error) {
+	if len(relays) == 0 {
+		return nil, fmt.Errorf("no private relay is configured")
+	}
+	if blocks == 0 {
+		blocks = DefaultRelayBlocks
+	}
+	clients := map[string]*rpc.Client{}
+	for _, r := range relays {
+		if r.Method != RelayMethodPrivate && r.Method != RelayMethodBundle {
+			return nil, fmt.Errorf("relay %q: unknown method %q, use %s or %s", r.Name, r.Method, RelayMethodPrivate, RelayMethodBundle)
+		}
+		opts := []rpc.ClientOption{}
+		for k, v := range r.Headers {
+			opts = append(opts, rpc.WithHeader(k, v))
+		}
+		if r.AuthKey != nil {
+			opts = append(opts, rpc.WithHTTPClient(&http.Client{
+				Transport: &flashbotsSigner{key: r.AuthKey, base: http.DefaultTransport},
+			}))
+		}
+		client, err := rpc.DialOptions(context.Background(), r.URL, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("relay %q at %s: %w", r.Name, r.URL, err)
+		}
+		clients[r.Name] = client
+	}
+	return &RelayBroadcaster{
+		relays:       relays,
+		clients:      clients,
+		chain:        chain,
+		Blocks:       blocks,
+		PollInterval: 2 * time.Second,
+		Wait:         true,
+	}, nil
+}
+
+func (rb *RelayBroadcaster) logf(format string, args ...interface{}) {
+	if rb.Logf != nil {
+		rb.Logf(format, args...)
+	}
+}
+
+func (rb *RelayBroadcaster) relaysOf(method string) []Relay {
+	result := []Relay{}
+	for _, r := range rb.relays {
+		if r.Method == method {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+// send calls method on every relay of rs in parallel and succeeds when at
+// least one of them accepts.
+func (rb *RelayBroadcaster) send(rs []Relay, method string, param interface{}) error {
+	timeout, cancel := context.WithTimeout(context.Background(), 4*time.Second)
+	defer cancel()
+	tasks := []func() error{}
+	for _, r := range rs {
+		relay := r
+		tasks = append(tasks, func() error {
+			var result json.RawMessage
+			if err := rb.clients[relay.Name].CallContext(timeout, &result, method, param); err != nil {
+				return fmt.Errorf("relay %q at %s: %w", relay.Name, relay.URL, err)
+			}
+			return nil
+		})
+	}
+	err, numErrs := common.RunParallel(tasks...)
+	if numErrs == len(rs) {
+		return err
+	}
+	return nil
+}
+
+type privateTxParam struct {
+	Tx             string         `json:"tx"`
+	MaxBlockNumber hexutil.Uint64 `json:"maxBlockNumber"`
+}
+
+type bundleParam struct {
+	Txs         []string       `json:"txs"`
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+}
+
+func (rb *RelayBroadcaster) BroadcastTx(tx *types.Transaction) (string, bool, error) {
+	data, err := tx.MarshalBinary()
+	if err != nil {
+		return "", false, fmt.Errorf("tx is not valid, couldn't use rlp to encode it: %w", err)
+	}
+	return rb.Broadcast(hexutil.Encode(data))
+}
+
+// Broadcast submits data, the hex encoded signed tx, to the relays. It
+// returns true when the tx is mined, or when a private relay accepted it
+// and Wait is off.
+func (rb *RelayBroadcaster) Broadcast(data string) (string, bool, error) {
+	hash := common.RawTxToHash(data)
+	head, err := rb.chain.CurrentBlock()
+	if err != nil {
+		return hash, false, fmt.Errorf("couldn't get the current block: %w", err)
+	}
+	last := head + rb.Blocks
+
+	private, bundle := rb.relaysOf(RelayMethodPrivate), rb.relaysOf(RelayMethodBundle)
+	accepted := false
+	errs := []error{}
+	if len(private) > 0 {
+		err := rb.send(private, "eth_sendPrivateTransaction", privateTxParam{Tx: data, MaxBlockNumber: hexutil.Uint64(last)})
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			accepted = true
+			rb.logf("Private relays accepted the tx, it can be mined until block %d.", last)
+		}
+	}
+	if len(bundle) == 0 {
+		if !accepted {
+			return hash, false, errors.Join(errs...)
+		}
+		if !rb.Wait {
+			return hash, true, nil
+		}
+	}
+
+	for target := head + 1; target <= last; target++ {
+		if len(bundle) > 0 {
+			err := rb.send(bundle, "eth_sendBundle", bundleParam{Txs: []string{data}, BlockNumber: hexutil.Uint64(target)})
+			if err != nil {
+				errs = append(errs, fmt.Errorf("block %d: %w", target, err))
+			} else {
+				accepted = true
+				rb.logf("Bundle submitted for block %d (last %d).", target, last)
+			}
+		}
+		if !accepted {
+			return hash, false, errors.Join(errs...)
+		}
+		mined, err := rb.waitPast(hash, target)
+		if err != nil {
+			return hash, false, err
+		}
+		if mined {
+			return hash, true, nil
+		}
+	}
+	return hash, false, fmt.Errorf("the relays didn't include the tx in blocks %d to %d", head+1, last)
+}
+
+// waitPast polls until the tx is mined or block target is mined without
+// it.
+func (rb *RelayBroadcaster) waitPast(hash string, target uint64) (bool, error) {
+	for {
+		info, err := rb.chain.TxInfoFromHash(hash)
+		if err == nil && (info.Status == "done" || info.Status == "reverted") {
+			return true, nil
+		}
+		current, err := rb.chain.CurrentBlock()
+		if err != nil {
+			return false, fmt.Errorf("couldn't get the current block: %w", err)
+		}
+		if current >= target {
+			// the block may have been mined between the two calls
+			info, err := rb.chain.TxInfoFromHash(hash)
+			return err == nil && (info.Status == "done" || info.Status == "reverted"), nil
+		}
+		time.Sleep(rb.PollInterval)
+	}
+}
+
+// flashbotsSigner signs request bodies the way Flashbots style relays
+// authenticate searchers: an EIP-191 signature of the hex keccak of the
+// body, sent as address:signature.
+type flashbotsSigner struct {
+	key  *ecdsa.PrivateKey
+	base http.RoundTripper
+
+	once sync.Once
+	addr string
+}
+
+func (s *flashbotsSigner) RoundTrip(req *http.Request) (*http.Response, error) {
+	s.once.Do(func() {
+		s.addr = crypto.PubkeyToAddress(s.key.PublicKey).Hex()
+	})
+	body := []byte{}
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	sig, err := FlashbotsSignature(s.key, body)
+	if err != nil {
+		return nil, err
+	}
+	signed := req.Clone(req.Context())
+	signed.Body = io.NopCloser(bytes.NewReader(body))
+	signed.ContentLength = int64(len(body))
+	signed.Header.Set("X-Flashbots-Signature", s.addr+":"+sig)
+	return s.base.RoundTrip(signed)
+}
+
+// FlashbotsSignature returns the hex signature of body expected in the
+// X-Flashbots-Signature header.
+func FlashbotsSignature(key *ecdsa.PrivateKey, body []byte) (string, error) {
+	digest := hexutil.Encode(crypto.Keccak256(body))
+	sig, err := crypto.Sign(accounts.TextHash([]byte(digest)), key)
+	if err != nil {
+		return "", err
+	}
+	sig[64] += 27
+	return hexutil.Encode(sig), nil
+}
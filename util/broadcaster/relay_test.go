@@ -0,0 +1,221 @@
+package broadcaster
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/tranvictor/jarvis/common"
+)
+
+// relayStandIn is a local JSON-RPC relay recording what it is sent.
+type relayStandIn struct {
+	mu       sync.Mutex
+	methods  []string
+	params   []json.RawMessage
+	headers  []http.Header
+	bodies   [][]byte
+	failWith string
+}
+
+func (rs *relayStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	var req struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	json.Unmarshal(body, &req)
+	rs.mu.Lock()
+	rs.methods = append(rs.methods, req.Method)
+	rs.params = append(rs.params, req.Params[0])
+	rs.headers = append(rs.headers, r.Header.Clone())
+	rs.bodies = append(rs.bodies, body)
+	failWith := rs.failWith
+	rs.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if failWith != "" {
+		w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(req.ID) + `,"error":{"code":-32000,"message":"` + failWith + `"}}`))
+		return
+	}
+	w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(req.ID) + `,"result":{"bundleHash":"0x01"}}`))
+}
+
+// fakeChain advances one block per CurrentBlock call and mines the tx at
+// minedAt.
+type fakeChain struct {
+	mu      sync.Mutex
+	block   uint64
+	minedAt uint64
+}
+
+func (c *fakeChain) CurrentBlock() (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.block++
+	return c.block, nil
+}
+
+func (c *fakeChain) TxInfoFromHash(tx string) (common.TxInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.minedAt != 0 && c.block >= c.minedAt {
+		return common.TxInfo{Status: "done"}, nil
+	}
+	return common.TxInfo{Status: "notfound"}, nil
+}
+
+func newTestRelayBroadcaster(t *testing.T, chain ChainReader, relays ...Relay) *RelayBroadcaster {
+	t.Helper()
+	rb, err := NewRelayBroadcaster(relays, chain, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rb.PollInterval = time.Millisecond
+	return rb
+}
+
+const testRawTx = "0x02f86b0180843b9aca00850c92a69c0082520894000000000000000000000000000000000000dead8080c080a0"
+
+func TestRelayBundleResubmittedUntilMined(t *testing.T) {
+	stand := &relayStandIn{}
+	srv := httptest.NewServer(stand)
+	defer srv.Close()
+
+	// head is 1, the bundle targets 2, 3 then is mined in 4 (<= 1+3)
+	chain := &fakeChain{minedAt: 4}
+	rb := newTestRelayBroadcaster(t, chain, Relay{Name: "builder", URL: srv.URL, Method: RelayMethodBundle})
+	hash, ok, err := rb.Broadcast(testRawTx)
+	if err != nil || !ok {
+		t.Fatalf("expected inclusion, got %v %v", ok, err)
+	}
+	if hash != common.RawTxToHash(testRawTx) {
+		t.Fatalf("hash %s", hash)
+	}
+	targets := []uint64{}
+	for i, m := range stand.methods {
+		if m != "eth_sendBundle" {
+			t.Fatalf("unexpected method %s", m)
+		}
+		var p bundleParam
+		if err := json.Unmarshal(stand.params[i], &p); err != nil {
+			t.Fatal(err)
+		}
+		if len(p.Txs) != 1 || p.Txs[0] != testRawTx {
+			t.Fatalf("bundle txs %v", p.Txs)
+		}
+		targets = append(targets, uint64(p.BlockNumber))
+	}
+	if len(targets) < 2 || targets[0] != 2 || targets[1] != 3 {
+		t.Fatalf("bundle targets %v", targets)
+	}
+}
+
+func TestRelayBundleExpires(t *testing.T) {
+	stand := &relayStandIn{}
+	srv := httptest.NewServer(stand)
+	defer srv.Close()
+
+	rb := newTestRelayBroadcaster(t, &fakeChain{}, Relay{Name: "builder", URL: srv.URL, Method: RelayMethodBundle})
+	_, ok, err := rb.Broadcast(testRawTx)
+	if ok || err == nil || !strings.Contains(err.Error(), "blocks 2 to 4") {
+		t.Fatalf("expected expiry, got %v %v", ok, err)
+	}
+	if len(stand.methods) != 3 {
+		t.Fatalf("expected one bundle per target block, got %d", len(stand.methods))
+	}
+}
+
+func TestRelayPrivateTx(t *testing.T) {
+	stand := &relayStandIn{}
+	srv := httptest.NewServer(stand)
+	defer srv.Close()
+
+	rb := newTestRelayBroadcaster(t, &fakeChain{}, Relay{
+		Name: "protect", URL: srv.URL, Method: RelayMethodPrivate,
+		Headers: map[string]string{"X-Api-Key": "secret"},
+	})
+	rb.Wait = false
+	_, ok, err := rb.Broadcast(testRawTx)
+	if err != nil || !ok {
+		t.Fatalf("expected acceptance, got %v %v", ok, err)
+	}
+	if len(stand.methods) != 1 || stand.methods[0] != "eth_sendPrivateTransaction" {
+		t.Fatalf("methods %v", stand.methods)
+	}
+	var p privateTxParam
+	if err := json.Unmarshal(stand.params[0], &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Tx != testRawTx || p.MaxBlockNumber != 4 {
+		t.Fatalf("param %+v", p)
+	}
+	if stand.headers[0].Get("X-Api-Key") != "secret" {
+		t.Fatalf("custom header not sent")
+	}
+}
+
+func TestRelayRejected(t *testing.T) {
+	stand := &relayStandIn{failWith: "nonce too low"}
+	srv := httptest.NewServer(stand)
+	defer srv.Close()
+
+	for _, method := range []string{RelayMethodPrivate, RelayMethodBundle} {
+		rb := newTestRelayBroadcaster(t, &fakeChain{}, Relay{Name: "r", URL: srv.URL, Method: method})
+		_, ok, err := rb.Broadcast(testRawTx)
+		if ok || err == nil || !strings.Contains(err.Error(), "nonce too low") {
+			t.Fatalf("%s: expected the relay error, got %v %v", method, ok, err)
+		}
+	}
+}
+
+func TestRelayFlashbotsSignature(t *testing.T) {
+	stand := &relayStandIn{}
+	srv := httptest.NewServer(stand)
+	defer srv.Close()
+
+	key, _ := crypto.GenerateKey()
+	rb := newTestRelayBroadcaster(t, &fakeChain{}, Relay{Name: "fb", URL: srv.URL, Method: RelayMethodPrivate, AuthKey: key})
+	rb.Wait = false
+	if _, ok, err := rb.Broadcast(testRawTx); !ok {
+		t.Fatal(err)
+	}
+
+	parts := strings.Split(stand.headers[0].Get("X-Flashbots-Signature"), ":")
+	if len(parts) != 2 {
+		t.Fatalf("header %q", stand.headers[0].Get("X-Flashbots-Signature"))
+	}
+	sig, err := hexutil.Decode(parts[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig[64] -= 27
+	digest := hexutil.Encode(crypto.Keccak256(stand.bodies[0]))
+	pub, err := crypto.SigToPub(accounts.TextHash([]byte(digest)), sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := crypto.PubkeyToAddress(*pub).Hex()
+	if signer != parts[0] || signer != crypto.PubkeyToAddress(key.PublicKey).Hex() {
+		t.Fatalf("signature recovers %s, header says %s", signer, parts[0])
+	}
+}
+
+func TestNewRelayBroadcasterErrors(t *testing.T) {
+	if _, err := NewRelayBroadcaster(nil, &fakeChain{}, 0); err == nil {
+		t.Fatalf("expected an error without relays")
+	}
+	if _, err := NewRelayBroadcaster([]Relay{{Name: "r", URL: "http://localhost", Method: "public"}}, &fakeChain{}, 0); err == nil {
+		t.Fatalf("expected an error for an unknown method")
+	}
+}
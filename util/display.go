@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 
 	jarviscommon "github.com/tranvictor/jarvis/common"
@@ -68,6 +69,7 @@ func buildFunctionCallDisplay(fc *jarviscommon.FunctionCall, nested bool) *Funct
 		Destination: StyledAddress(fc.Destination),
 		Error:       fc.Error,
 		Method:      fc.Method,
+		Guessed:     fc.Guessed,
 	}
 	if nested && fc.Value != nil {
 		d.Value = fmt.Sprintf("%f ETH", jarviscommon.BigToFloat(fc.Value, 18))
@@ -87,7 +89,7 @@ func buildFunctionCallDisplay(fc *jarviscommon.FunctionCall, nested bool) *Funct
 }
 
 func buildLogDisplay(log jarviscommon.LogResult) LogDisplay {
-	d := LogDisplay{Name: log.Name}
+	d := LogDisplay{Name: log.Name, Guessed: log.Guessed}
 	for _, topic := range log.Topics {
 		d.Topics = append(d.Topics, TopicDisplay{
 			Name:    topic.Name,
@@ -100,6 +102,20 @@ func buildLogDisplay(log jarviscommon.LogResult) LogDisplay {
 	return d
 }
 
+func buildAuthorizationDisplay(a jarviscommon.AuthorizationResult) AuthorizationDisplay {
+	d := AuthorizationDisplay{
+		Authority: StyledAddress(a.Authority),
+		Delegate:  StyledAddress(a.Delegate),
+		Nonce:     a.Nonce,
+		ChainID:   a.ChainID,
+		Error:     a.Error,
+	}
+	if jarviscommon.HexToAddress(a.Delegate.Address) == (common.Address{}) {
+		d.Delegate = ui.StyledText{Text: "revoke", Severity: ui.SeverityCritical}
+	}
+	return d
+}
+
 func buildTxDisplay(result *jarviscommon.TxResult, fullDetail bool) *TxDisplay {
 	d := &TxDisplay{
 		Status: result.Status,
@@ -108,6 +124,21 @@ func buildTxDisplay(result *jarviscommon.TxResult, fullDetail bool) *TxDisplay {
 		Value:  result.Value,
 		TxType: result.TxType,
 		Error:  result.Error,
+
+		BlobHashes:   result.BlobHashes,
+		BlobGasUsed:  result.BlobGasUsed,
+		BlobGasPrice: result.BlobGasPrice,
+	}
+	if result.FromDelegate != nil {
+		st := StyledAddress(*result.FromDelegate)
+		d.FromDelegate = &st
+	}
+	if result.ToDelegate != nil {
+		st := StyledAddress(*result.ToDelegate)
+		d.ToDelegate = &st
+	}
+	for _, a := range result.Authorizations {
+		d.Authorizations = append(d.Authorizations, buildAuthorizationDisplay(a))
 	}
 	if fullDetail {
 		d.Nonce = result.Nonce
@@ -301,7 +332,7 @@ func printFunctionCallDisplay(u ui.UI, d *FunctionCallDisplay, nested bool) {
 
 	if nested {
 		// Inner calls are visually subordinate — a simple arrow label, no Section.
-		u.Info("↳ %s  [%s]", d.Method, u.Style(d.Destination))
+		u.Info("↳ %s  [%s]", guessedLabel(d.Method, d.Guessed), u.Style(d.Destination))
 		if d.Error != "" {
 			u.Indent().Error("%s", d.Error)
 		}
@@ -312,13 +343,16 @@ func printFunctionCallDisplay(u ui.UI, d *FunctionCallDisplay, nested bool) {
 		return
 	}
 
-	u.Section(fmt.Sprintf("Function call: %s", d.Method))
+	u.Section(fmt.Sprintf("Function call: %s", guessedLabel(d.Method, d.Guessed)))
 
 	// Build a single TableWithGroups: contract metadata (group 0) + params (group 1).
 	metaGroup := [][]ui.TableCell{{ui.TC("Contract"), tableCell(d.Destination)}}
 	if d.Value != "" {
 		metaGroup = append(metaGroup, []ui.TableCell{ui.TC("Value"), ui.TC(d.Value)})
 	}
+	if d.Guessed {
+		metaGroup = append(metaGroup, []ui.TableCell{ui.TC("Decoded with"), ui.TCS(guessedNote, ui.SeverityWarn)})
+	}
 	// A method can resolve while its arguments fail to unpack; say so instead of
 	// showing a name over an empty parameter list.
 	if d.Error != "" {
@@ -344,6 +378,18 @@ func printFunctionCallDisplay(u ui.UI, d *FunctionCallDisplay, nested bool) {
 	}
 }
 
+// guessedNote tells where the signature of a guessed method came from.
+const guessedNote = "a signature guessed from the local signature database, the contract has no ABI for it"
+
+// guessedLabel marks the name of a method or event decoded with a guessed
+// signature, which may not be the one the contract was compiled with.
+func guessedLabel(name string, guessed bool) string {
+	if guessed {
+		return name + " (guessed)"
+	}
+	return name
+}
+
 // logSimpleRows returns all simple [param, value] rows for a single log,
 // used when building the combined all-logs table.
 func logSimpleRows(d LogDisplay) [][]ui.TableCell {
@@ -368,7 +414,7 @@ func printAllLogs(u ui.UI, logs []LogDisplay) {
 
 	groups := make([][][]ui.TableCell, len(logs))
 	for i, d := range logs {
-		eventLabel := fmt.Sprintf("%d. %s", i+1, d.Name)
+		eventLabel := fmt.Sprintf("%d. %s", i+1, guessedLabel(d.Name, d.Guessed))
 		paramRows := logSimpleRows(d)
 		if len(paramRows) == 0 {
 			groups[i] = [][]ui.TableCell{{ui.TC(eventLabel), ui.TC(""), ui.TC("")}}
@@ -393,7 +439,7 @@ func printAllLogs(u ui.UI, logs []LogDisplay) {
 // printLogDisplay renders a single log entry. Used by the standalone DisplayLog
 // public API when a caller needs to print one log outside of a full tx context.
 func printLogDisplay(u ui.UI, idx int, d LogDisplay) {
-	u.Section(fmt.Sprintf("Log %d: %s", idx+1, d.Name))
+	u.Section(fmt.Sprintf("Log %d: %s", idx+1, guessedLabel(d.Name, d.Guessed)))
 
 	var rows [][]ui.TableCell
 	for _, topic := range d.Topics {
@@ -418,24 +464,67 @@ func printTxDisplay(u ui.UI, d *TxDisplay, network networks.Network) {
 	}
 	txGroup := [][]ui.TableCell{
 		{ui.TC("Status"), ui.TC(statusVal)},
-		{ui.TC("From"), tableCell(d.From)},
-		{ui.TC("Value"), ui.TC(d.Value + " " + network.GetNativeTokenSymbol())},
-		{ui.TC("To"), tableCell(d.To)},
+	}
+	if d.RevertReason != "" {
+		txGroup = append(txGroup, []ui.TableCell{ui.TC("Revert reason"), ui.TCS(d.RevertReason, ui.SeverityError)})
+	}
+	txGroup = append(txGroup, []ui.TableCell{ui.TC("From"), tableCell(d.From)})
+	if d.FromDelegate != nil {
+		txGroup = append(txGroup, []ui.TableCell{ui.TC("From delegated to"), tableCell(*d.FromDelegate)})
+	}
+	txGroup = append(txGroup,
+		[]ui.TableCell{ui.TC("Value"), ui.TC(d.Value + " " + network.GetNativeTokenSymbol())},
+		[]ui.TableCell{ui.TC("To"), tableCell(d.To)},
+	)
+	if d.ToDelegate != nil {
+		txGroup = append(txGroup, []ui.TableCell{ui.TC("To delegated to"), tableCell(*d.ToDelegate)})
 	}
 	if d.Hash != "" {
 		txGroup = append([][]ui.TableCell{{ui.TC("Hash"), ui.TC(d.Hash)}}, txGroup...)
 	}
 
+	groups := [][][]ui.TableCell{txGroup}
 	if d.Nonce != "" {
 		// Degen mode: gas details in the same card, separated by a divider.
-		gasGroup := [][]ui.TableCell{
+		groups = append(groups, [][]ui.TableCell{
 			{ui.TC("Nonce"), ui.TC(d.Nonce)},
 			{ui.TC("Gas price"), ui.TC(d.GasPrice + " gwei")},
 			{ui.TC("Gas limit"), ui.TC(d.GasLimit)},
 			{ui.TC("Gas used"), ui.TC(d.GasUsed)},
 			{ui.TC("Gas cost"), ui.TC(d.GasCost)},
+		})
+	}
+	if len(d.BlobHashes) > 0 {
+		blobGroup := [][]ui.TableCell{}
+		for i, h := range d.BlobHashes {
+			blobGroup = append(blobGroup, []ui.TableCell{ui.TC(fmt.Sprintf("Blob hash %d", i)), ui.TC(h)})
+		}
+		if d.BlobGasUsed != "" {
+			blobGroup = append(blobGroup, []ui.TableCell{ui.TC("Blob gas used"), ui.TC(d.BlobGasUsed)})
+		}
+		if d.BlobGasPrice != "" {
+			blobGroup = append(blobGroup, []ui.TableCell{ui.TC("Blob gas price"), ui.TC(d.BlobGasPrice + " gwei")})
 		}
-		u.PrintTable(&ui.Table{Groups: [][][]ui.TableCell{txGroup, gasGroup}})
+		groups = append(groups, blobGroup)
+	}
+	if len(d.Authorizations) > 0 {
+		authGroup := [][]ui.TableCell{}
+		for i, a := range d.Authorizations {
+			label := ui.TC(fmt.Sprintf("Authorization %d", i))
+			if a.Error != "" {
+				authGroup = append(authGroup, []ui.TableCell{label, ui.TCS(a.Error, ui.SeverityError)})
+				continue
+			}
+			authGroup = append(authGroup,
+				[]ui.TableCell{label, tableCell(a.Authority)},
+				[]ui.TableCell{ui.TC("  delegates to"), tableCell(a.Delegate)},
+				[]ui.TableCell{ui.TC("  nonce / chain"), ui.TC(a.Nonce + " / " + a.ChainID)},
+			)
+		}
+		groups = append(groups, authGroup)
+	}
+	if len(groups) > 1 {
+		u.PrintTable(&ui.Table{Groups: groups})
 	} else {
 		u.PrintTable(&ui.Table{Rows: txGroup})
 	}
@@ -484,6 +573,12 @@ func DisplayFunctionCall(u ui.UI, fc *jarviscommon.FunctionCall) *FunctionCallDi
 	return d
 }
 
+// PrintFunctionCallDisplay writes an already-built function call view-model
+// to u, e.g. one read back from a tx file produced on another machine.
+func PrintFunctionCallDisplay(u ui.UI, d *FunctionCallDisplay) {
+	printFunctionCallDisplay(u, d, false)
+}
+
 // DisplayLog builds the human-readable view-model for a single event log entry
 // and writes it to u.
 func DisplayLog(u ui.UI, idx int, log jarviscommon.LogResult) LogDisplay {
@@ -33,6 +33,9 @@ type LogDisplay struct {
 	Name   string         `json:"name"`
 	Topics []TopicDisplay `json:"topics"`
 	Data   []ParamDisplay `json:"data"`
+	// Guessed marks an event decoded with a signature guessed from the
+	// signature database rather than the contract's ABI.
+	Guessed bool `json:"guessed,omitempty"`
 }
 
 // FunctionCallDisplay is the human-readable view-model for a decoded function
@@ -49,6 +52,20 @@ type FunctionCallDisplay struct {
 	Data       string                 `json:"data,omitempty"`
 	InnerCalls []*FunctionCallDisplay `json:"inner_calls,omitempty"`
 	Error      string                 `json:"error,omitempty"`
+	// Guessed marks a method decoded with a signature guessed from the
+	// signature database rather than the contract's ABI.
+	Guessed bool `json:"guessed,omitempty"`
+}
+
+// AuthorizationDisplay is the human-readable view-model for one EIP-7702
+// authorization. Delegate reads "revoke" when the authority clears its
+// delegation.
+type AuthorizationDisplay struct {
+	Authority ui.StyledText `json:"authority"` // serializes as string
+	Delegate  ui.StyledText `json:"delegate"`  // serializes as string
+	Nonce     string        `json:"nonce"`
+	ChainID   string        `json:"chain_id"`
+	Error     string        `json:"error,omitempty"`
 }
 
 // TxDisplay is the complete human-readable view-model for a single analyzed
@@ -61,6 +78,9 @@ type TxDisplay struct {
 	To     ui.StyledText `json:"to"`   // serializes as string
 	Value  string        `json:"value"`
 
+	// RevertReason is why a reverted tx reverted, from replaying it.
+	RevertReason string `json:"revert_reason,omitempty"`
+
 	// Gas/nonce detail — populated only when fullDetail (degen) mode is on.
 	Nonce    string `json:"nonce,omitempty"`
 	GasPrice string `json:"gas_price,omitempty"`
@@ -68,6 +88,17 @@ type TxDisplay struct {
 	GasUsed  string `json:"gas_used,omitempty"`
 	GasCost  string `json:"gas_cost,omitempty"`
 
+	// Blob tx (type 3) detail.
+	BlobHashes   []string `json:"blob_hashes,omitempty"`
+	BlobGasUsed  string   `json:"blob_gas_used,omitempty"`
+	BlobGasPrice string   `json:"blob_gas_price,omitempty"`
+
+	// EIP-7702 detail: current delegates of From/To and the authorizations
+	// carried by a type-4 tx.
+	FromDelegate   *ui.StyledText         `json:"from_delegate,omitempty"` // serializes as string
+	ToDelegate     *ui.StyledText         `json:"to_delegate,omitempty"`   // serializes as string
+	Authorizations []AuthorizationDisplay `json:"authorizations,omitempty"`
+
 	TxType       string               `json:"tx_type"`
 	FunctionCall *FunctionCallDisplay `json:"function_call,omitempty"`
 	Logs         []LogDisplay         `json:"logs,omitempty"`
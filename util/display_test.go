@@ -14,6 +14,7 @@ import (
 	"github.com/tranvictor/jarvis/ui"
 	"github.com/tranvictor/jarvis/util"
 	"github.com/tranvictor/jarvis/util/addrbook"
+	"github.com/tranvictor/jarvis/util/reader"
 )
 
 const nestedLayerABI = `[{
@@ -332,3 +333,27 @@ func TestUndecodedCallShowsContractAndData(t *testing.T) {
 		}
 	}
 }
+
+// unknownTxReader is a node that knows no tx.
+type unknownTxReader struct {
+	reader.Reader
+}
+
+func (unknownTxReader) TxInfoFromHash(tx string) (jarviscommon.TxInfo, error) {
+	return jarviscommon.TxInfo{Status: "notfound"}, reader.ErrTxNotFound
+}
+
+func TestAnalyzeAndPrintUnknownTx(t *testing.T) {
+	rec := ui.NewRecordingUI()
+	d := util.AnalyzeAndPrint(
+		rec, unknownTxReader{}, nil,
+		"0x5a2f0c6e4a1b7c8d9e0f1a2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c4d5e6f",
+		networks.EthereumMainnet, false, "", nil, nil, false,
+	)
+	if d != nil {
+		t.Fatalf("an unknown tx must not be displayed: %+v", d)
+	}
+	if !rec.HasMessage("none of the nodes knows the tx") {
+		t.Fatalf("the unknown tx isn't reported: %+v", rec.Entries())
+	}
+}
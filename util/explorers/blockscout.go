@@ -0,0 +1,155 @@
+package explorers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BlockscoutExplorer reads a Blockscout instance through its v2 REST API.
+type BlockscoutExplorer struct {
+	gpmu              sync.Mutex
+	latestGasPrice    float64
+	gasPriceTimestamp int64
+
+	Domain string
+}
+
+// NewBlockscoutExplorer returns the explorer of the Blockscout instance at
+// domain, e.g. https://eth.blockscout.com. The url of its api is taken too.
+func NewBlockscoutExplorer(domain string) *BlockscoutExplorer {
+	domain = strings.TrimRight(domain, "/")
+	domain = strings.TrimSuffix(domain, "/api/v2")
+	domain = strings.TrimSuffix(domain, "/api")
+	return &BlockscoutExplorer{
+		gpmu:   sync.Mutex{},
+		Domain: domain,
+	}
+}
+
+// get reads the v2 api at path. found is false when Blockscout has nothing
+// there.
+func (be *BlockscoutExplorer) get(path string, result interface{}) (found bool, err error) {
+	url := fmt.Sprintf("%s/api/v2/%s", be.Domain, path)
+	resp, err := http.Get(url)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, fmt.Errorf("error reading body from %s: %w", url, err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("%s answered %d: %s", url, resp.StatusCode, string(body))
+	}
+	if err := json.Unmarshal(body, result); err != nil {
+		return false, fmt.Errorf("error unmarshalling body from %s: %w", url, err)
+	}
+	return true, nil
+}
+
+// blockscoutGasPrice is a gas price in gwei of /stats, a number in older
+// Blockscout versions and {"price": ...} in newer ones.
+type blockscoutGasPrice float64
+
+func (p *blockscoutGasPrice) UnmarshalJSON(data []byte) error {
+	var price float64
+	if err := json.Unmarshal(data, &price); err == nil {
+		*p = blockscoutGasPrice(price)
+		return nil
+	}
+	tier := struct {
+		Price float64 `json:"price"`
+	}{}
+	if err := json.Unmarshal(data, &tier); err != nil {
+		return err
+	}
+	*p = blockscoutGasPrice(tier.Price)
+	return nil
+}
+
+func (be *BlockscoutExplorer) RecommendedGasPrice() (float64, error) {
+	be.gpmu.Lock()
+	defer be.gpmu.Unlock()
+
+	if be.latestGasPrice == 0 || time.Now().Unix()-be.gasPriceTimestamp > CACHE_TIME_OUT {
+		stats := struct {
+			GasPrices *struct {
+				Fast blockscoutGasPrice `json:"fast"`
+			} `json:"gas_prices"`
+		}{}
+		found, err := be.get("stats", &stats)
+		if err != nil {
+			return 0, fmt.Errorf("blockscout gas price lookup failed: %w", err)
+		}
+		if !found || stats.GasPrices == nil || stats.GasPrices.Fast == 0 {
+			return 0, fmt.Errorf("%s has no gas price oracle", be.Domain)
+		}
+		be.latestGasPrice = float64(stats.GasPrices.Fast)
+		be.gasPriceTimestamp = time.Now().Unix()
+	}
+	return be.latestGasPrice, nil
+}
+
+// blockscoutContract is the part of /smart-contracts/<address> jarvis
+// reads. An implementation is {"address": ...} in older Blockscout versions
+// and {"address_hash": ...} in newer ones.
+type blockscoutContract struct {
+	Name            string          `json:"name"`
+	ABI             json.RawMessage `json:"abi"`
+	IsVerified      *bool           `json:"is_verified"`
+	Implementations []struct {
+		Address     string `json:"address"`
+		AddressHash string `json:"address_hash"`
+	} `json:"implementations"`
+}
+
+func (c *blockscoutContract) verified() bool {
+	if c.IsVerified != nil {
+		return *c.IsVerified
+	}
+	return strings.HasPrefix(strings.TrimSpace(string(c.ABI)), "[")
+}
+
+func (be *BlockscoutExplorer) GetABIString(address string) (string, error) {
+	c := blockscoutContract{}
+	found, err := be.get("smart-contracts/"+address, &c)
+	if err != nil {
+		return "", err
+	}
+	if !found || !c.verified() {
+		return "", fmt.Errorf("%s isn't verified on %s", address, be.Domain)
+	}
+	if !strings.HasPrefix(strings.TrimSpace(string(c.ABI)), "[") {
+		return "", fmt.Errorf("%s has no abi of %s", be.Domain, address)
+	}
+	return string(c.ABI), nil
+}
+
+func (be *BlockscoutExplorer) GetContractInfo(address string) (ContractInfo, error) {
+	c := blockscoutContract{}
+	found, err := be.get("smart-contracts/"+address, &c)
+	if err != nil || !found || !c.verified() {
+		return ContractInfo{}, err
+	}
+	info := ContractInfo{
+		Name:       c.Name,
+		IsProxy:    len(c.Implementations) > 0,
+		IsVerified: true,
+	}
+	if len(c.Implementations) > 0 {
+		info.Implementation = c.Implementations[0].AddressHash
+		if info.Implementation == "" {
+			info.Implementation = c.Implementations[0].Address
+		}
+	}
+	return info, nil
+}
@@ -0,0 +1,56 @@
+package explorers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBlockscoutExplorer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2/smart-contracts/0x01":
+			fmt.Fprint(w, `{"name":"Proxy","is_verified":true,"abi":[{"type":"fallback"}],"proxy_type":"eip1967",
+				"implementations":[{"address_hash":"0x02","name":"Impl"}]}`)
+		case "/api/v2/smart-contracts/0x03":
+			fmt.Fprint(w, `{"is_verified":false,"creation_bytecode":"0x00"}`)
+		case "/api/v2/stats":
+			fmt.Fprint(w, `{"gas_prices":{"slow":{"price":1.1},"average":{"price":1.5},"fast":{"price":2.25}}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	// the api url is taken as well as the domain
+	be := NewBlockscoutExplorer(srv.URL + "/api/")
+
+	if abi, err := be.GetABIString("0x01"); err != nil || abi != `[{"type":"fallback"}]` {
+		t.Fatalf("got %s, %v", abi, err)
+	}
+	info, err := be.GetContractInfo("0x01")
+	if err != nil || info != (ContractInfo{Name: "Proxy", Implementation: "0x02", IsProxy: true, IsVerified: true}) {
+		t.Fatalf("got %+v, %v", info, err)
+	}
+	for _, address := range []string{"0x03", "0x04"} {
+		if _, err := be.GetABIString(address); err == nil {
+			t.Fatalf("got an abi of %s", address)
+		}
+		if info, err := be.GetContractInfo(address); err != nil || info.IsVerified {
+			t.Fatalf("%s: got %+v, %v", address, info, err)
+		}
+	}
+	if price, err := be.RecommendedGasPrice(); err != nil || price != 2.25 {
+		t.Fatalf("got gas price %f, %v", price, err)
+	}
+}
+
+func TestBlockscoutGasPriceOfOlderVersions(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"gas_prices":{"slow":1,"average":2,"fast":3.5}}`)
+	}))
+	defer srv.Close()
+	if price, err := NewBlockscoutExplorer(srv.URL).RecommendedGasPrice(); err != nil || price != 3.5 {
+		t.Fatalf("got gas price %f, %v", price, err)
+	}
+}
@@ -0,0 +1,243 @@
+package explorers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// VerifyRequest is a standard-JSON source verification of one contract.
+type VerifyRequest struct {
+	Address string
+	// StandardJSONInput is the solc standard-JSON input the contract was
+	// compiled from.
+	StandardJSONInput string
+	// ContractName is the fully qualified name, e.g.
+	// src/Vault.sol:Vault.
+	ContractName string
+	// CompilerVersion is the long solc version, e.g.
+	// v0.8.24+commit.e11b9ed9.
+	CompilerVersion string
+	// ConstructorArgs is the hex abi encoded constructor params, without
+	// 0x.
+	ConstructorArgs string
+}
+
+// VerifyStatus is the state of a submitted verification. Done is false while
+// it is queued, OK tells whether it passed once it is done.
+type VerifyStatus struct {
+	Done    bool
+	OK      bool
+	Message string
+}
+
+// ContractCreation is who created a contract and in which tx.
+type ContractCreation struct {
+	Creator string
+	TxHash  string
+}
+
+// SourceVerifier is implemented by the explorers jarvis can verify contract
+// sources on.
+type SourceVerifier interface {
+	VerifySourceCode(req VerifyRequest) (guid string, err error)
+	// VerifyProxy asks the explorer to detect the implementation of the
+	// proxy at address and show its ABI on the proxy. An empty
+	// implementation lets the explorer find it.
+	VerifyProxy(address, implementation string) (guid string, err error)
+	CheckVerifyStatus(guid string, proxy bool) (VerifyStatus, error)
+	GetContractCreation(address string) (ContractCreation, error)
+}
+
+func (ee *EtherscanLikeExplorer) apiURL() string {
+	return fmt.Sprintf("%s/api?chainid=%d", ee.Domain, ee.ChainID)
+}
+
+func (ee *EtherscanLikeExplorer) post(form url.Values) (abiresponse, error) {
+	form.Set("apikey", ee.APIKey)
+	resp, err := http.PostForm(ee.apiURL(), form)
+	if err != nil {
+		return abiresponse{}, err
+	}
+	defer resp.Body.Close()
+	return readAPIResponse(resp)
+}
+
+func (ee *EtherscanLikeExplorer) get(params url.Values) (abiresponse, error) {
+	params.Set("apikey", ee.APIKey)
+	resp, err := http.Get(ee.apiURL() + "&" + params.Encode())
+	if err != nil {
+		return abiresponse{}, err
+	}
+	defer resp.Body.Close()
+	return readAPIResponse(resp)
+}
+
+func readAPIResponse(resp *http.Response) (abiresponse, error) {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return abiresponse{}, fmt.Errorf("error reading body: %w", err)
+	}
+	result := abiresponse{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return abiresponse{}, fmt.Errorf("couldn't unmarshal %s: %w", string(body), err)
+	}
+	return result, nil
+}
+
+func (ee *EtherscanLikeExplorer) VerifySourceCode(req VerifyRequest) (string, error) {
+	form := url.Values{}
+	form.Set("module", "contract")
+	form.Set("action", "verifysourcecode")
+	form.Set("contractaddress", req.Address)
+	form.Set("sourceCode", req.StandardJSONInput)
+	form.Set("codeformat", "solidity-standard-json-input")
+	form.Set("contractname", req.ContractName)
+	form.Set("compilerversion", req.CompilerVersion)
+	// sic, that's how the API spells it
+	form.Set("constructorArguements", strings.TrimPrefix(req.ConstructorArgs, "0x"))
+	resp, err := ee.post(form)
+	if err != nil {
+		return "", err
+	}
+	if !resp.IsOK() {
+		return "", fmt.Errorf("the explorer refused the verification: %s", resp.Result)
+	}
+	return resp.Result, nil
+}
+
+func (ee *EtherscanLikeExplorer) VerifyProxy(address, implementation string) (string, error) {
+	form := url.Values{}
+	form.Set("module", "contract")
+	form.Set("action", "verifyproxycontract")
+	form.Set("address", address)
+	if implementation != "" {
+		form.Set("expectedimplementation", implementation)
+	}
+	resp, err := ee.post(form)
+	if err != nil {
+		return "", err
+	}
+	if !resp.IsOK() {
+		return "", fmt.Errorf("the explorer refused the proxy verification: %s", resp.Result)
+	}
+	return resp.Result, nil
+}
+
+func (ee *EtherscanLikeExplorer) CheckVerifyStatus(guid string, proxy bool) (VerifyStatus, error) {
+	params := url.Values{}
+	params.Set("module", "contract")
+	params.Set("action", "checkverifystatus")
+	if proxy {
+		params.Set("action", "checkproxyverification")
+	}
+	params.Set("guid", guid)
+	resp, err := ee.get(params)
+	if err != nil {
+		return VerifyStatus{}, err
+	}
+	return verifyStatusOf(resp), nil
+}
+
+// verifyStatusOf reads a check(proxy)verif(y|ication) response. The API
+// answers status 0 both while the verification is queued and when it failed,
+// only the message tells them apart.
+func verifyStatusOf(resp abiresponse) VerifyStatus {
+	msg := resp.Result
+	lower := strings.ToLower(msg)
+	switch {
+	case strings.Contains(lower, "pending") || strings.Contains(lower, "in progress"):
+		return VerifyStatus{Message: msg}
+	case resp.IsOK() || strings.Contains(lower, "already verified"):
+		return VerifyStatus{Done: true, OK: true, Message: msg}
+	}
+	return VerifyStatus{Done: true, Message: msg}
+}
+
+type contractCreationResponse struct {
+	Status  string          `json:"status"`
+	Message string          `json:"message"`
+	Result  json.RawMessage `json:"result"`
+}
+
+func (ee *EtherscanLikeExplorer) GetContractCreation(address string) (ContractCreation, error) {
+	u := fmt.Sprintf(
+		"%s&module=contract&action=getcontractcreation&contractaddresses=%s&apikey=%s",
+		ee.apiURL(), address, ee.APIKey,
+	)
+	resp, err := http.Get(u)
+	if err != nil {
+		return ContractCreation{}, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return ContractCreation{}, fmt.Errorf("error reading body: %w", err)
+	}
+	cr := contractCreationResponse{}
+	if err := json.Unmarshal(body, &cr); err != nil {
+		return ContractCreation{}, fmt.Errorf("couldn't unmarshal %s: %w", string(body), err)
+	}
+	results := []struct {
+		ContractCreator string `json:"contractCreator"`
+		TxHash          string `json:"txHash"`
+	}{}
+	if cr.Status != "1" || json.Unmarshal(cr.Result, &results) != nil || len(results) == 0 {
+		return ContractCreation{}, fmt.Errorf("the explorer doesn't know the creation of %s: %s", address, string(cr.Result))
+	}
+	return ContractCreation{Creator: results[0].ContractCreator, TxHash: results[0].TxHash}, nil
+}
+
+// WaitVerified polls the status of the verification guid until it is done
+// or timeout passes.
+func WaitVerified(v SourceVerifier, guid string, proxy bool, interval, timeout time.Duration) (VerifyStatus, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		status, err := v.CheckVerifyStatus(guid, proxy)
+		if err == nil && status.Done {
+			return status, nil
+		}
+		if time.Now().Add(interval).After(deadline) {
+			if err != nil {
+				return VerifyStatus{}, err
+			}
+			return status, fmt.Errorf("the verification is still pending after %s: %s", timeout, status.Message)
+		}
+		time.Sleep(interval)
+	}
+}
+
+type solcListResponse struct {
+	Releases map[string]string `json:"releases"`
+}
+
+// SolcListURL lists the solc releases with their commits.
+var SolcListURL = "https://binaries.soliditylang.org/bin/list.json"
+
+// SolcLongVersion turns a solc release like 0.8.24 into the version the
+// explorers take, v0.8.24+commit.e11b9ed9.
+func SolcLongVersion(version string) (string, error) {
+	resp, err := http.Get(SolcListURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	list := solcListResponse{}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return "", fmt.Errorf("couldn't decode the solc release list: %w", err)
+	}
+	file, found := list.Releases[strings.TrimPrefix(version, "v")]
+	if !found {
+		return "", fmt.Errorf("solc %s isn't a release", version)
+	}
+	// soljson-v0.8.24+commit.e11b9ed9.js
+	return strings.TrimSuffix(strings.TrimPrefix(file, "soljson-"), ".js"), nil
+}
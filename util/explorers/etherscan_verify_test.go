@@ -0,0 +1,76 @@
+package explorers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestVerifySourceCode(t *testing.T) {
+	polls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("chainid") != "8453" {
+			t.Errorf("chainid %s", r.URL.Query().Get("chainid"))
+		}
+		switch r.Method {
+		case http.MethodPost:
+			if err := r.ParseForm(); err != nil {
+				t.Fatal(err)
+			}
+			if r.PostForm.Get("action") != "verifysourcecode" ||
+				r.PostForm.Get("codeformat") != "solidity-standard-json-input" ||
+				r.PostForm.Get("contractname") != "src/Vault.sol:Vault" ||
+				r.PostForm.Get("constructorArguements") != "2a" ||
+				r.PostForm.Get("apikey") != "key" {
+				t.Errorf("form %v", r.PostForm)
+			}
+			fmt.Fprint(w, `{"status":"1","message":"OK","result":"guid-1"}`)
+		default:
+			if r.URL.Query().Get("action") != "checkverifystatus" || r.URL.Query().Get("guid") != "guid-1" {
+				t.Errorf("query %v", r.URL.Query())
+			}
+			polls++
+			if polls < 2 {
+				fmt.Fprint(w, `{"status":"0","message":"NOTOK","result":"Pending in queue"}`)
+				return
+			}
+			fmt.Fprint(w, `{"status":"1","message":"OK","result":"Pass - Verified"}`)
+		}
+	}))
+	defer srv.Close()
+
+	ee := NewEtherscanLikeExplorer(srv.URL, "key", 8453)
+	guid, err := ee.VerifySourceCode(VerifyRequest{
+		Address:           "0x1d9937e170Fc2174408581265bA0B87afDA4947F",
+		StandardJSONInput: `{"language":"Solidity"}`,
+		ContractName:      "src/Vault.sol:Vault",
+		CompilerVersion:   "v0.8.24+commit.e11b9ed9",
+		ConstructorArgs:   "0x2a",
+	})
+	if err != nil || guid != "guid-1" {
+		t.Fatalf("got %s %v", guid, err)
+	}
+	status, err := WaitVerified(ee, guid, false, time.Millisecond, time.Second)
+	if err != nil || !status.OK || polls != 2 {
+		t.Fatalf("got %+v %v after %d polls", status, err, polls)
+	}
+}
+
+func TestVerifyStatusOf(t *testing.T) {
+	for _, tc := range []struct {
+		resp     abiresponse
+		done, ok bool
+	}{
+		{abiresponse{Status: "0", Result: "Pending in queue"}, false, false},
+		{abiresponse{Status: "1", Result: "Pass - Verified"}, true, true},
+		{abiresponse{Status: "0", Result: "Already Verified"}, true, true},
+		{abiresponse{Status: "0", Result: "Fail - Unable to verify"}, true, false},
+	} {
+		got := verifyStatusOf(tc.resp)
+		if got.Done != tc.done || got.OK != tc.ok {
+			t.Fatalf("%s: got %+v", tc.resp.Result, got)
+		}
+	}
+}
@@ -1,5 +1,10 @@
 package explorers
 
+import (
+	"errors"
+	"fmt"
+)
+
 // ContractInfo is the subset of Etherscan-style getsourcecode response the
 // rest of jarvis cares about. Implementation is the proxy's underlying
 // singleton when applicable, empty otherwise.
@@ -22,3 +27,60 @@ type BlockExplorer interface {
 	// rather than as a hard failure.
 	GetContractInfo(address string) (ContractInfo, error)
 }
+
+// Explorers is a list of explorers used as one: every lookup goes through
+// them in order until one of them answers, so a chain can fall back to
+// e.g. Sourcify for the contracts its main explorer didn't verify.
+type Explorers []BlockExplorer
+
+func (es Explorers) RecommendedGasPrice() (float64, error) {
+	errs := []error{}
+	for _, e := range es {
+		price, err := e.RecommendedGasPrice()
+		if err == nil {
+			return price, nil
+		}
+		errs = append(errs, err)
+	}
+	return 0, es.failed(errs)
+}
+
+func (es Explorers) GetABIString(address string) (string, error) {
+	errs := []error{}
+	for _, e := range es {
+		abi, err := e.GetABIString(address)
+		if err == nil {
+			return abi, nil
+		}
+		errs = append(errs, err)
+	}
+	return "", es.failed(errs)
+}
+
+// GetContractInfo returns the info of the first explorer the contract is
+// verified on. A contract no explorer verified isn't an error, as long as
+// one of them answered.
+func (es Explorers) GetContractInfo(address string) (ContractInfo, error) {
+	errs := []error{}
+	for _, e := range es {
+		info, err := e.GetContractInfo(address)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if info.IsVerified {
+			return info, nil
+		}
+	}
+	if len(errs) < len(es) {
+		return ContractInfo{}, nil
+	}
+	return ContractInfo{}, es.failed(errs)
+}
+
+func (es Explorers) failed(errs []error) error {
+	if len(es) == 0 {
+		return fmt.Errorf("no block explorer is configured")
+	}
+	return errors.Join(errs...)
+}
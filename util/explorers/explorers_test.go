@@ -0,0 +1,63 @@
+package explorers
+
+import (
+	"fmt"
+	"testing"
+)
+
+type fakeExplorer struct {
+	abis  map[string]string
+	names map[string]string
+	down  bool
+}
+
+func (f fakeExplorer) RecommendedGasPrice() (float64, error) {
+	return 0, fmt.Errorf("no gas price oracle")
+}
+
+func (f fakeExplorer) GetABIString(address string) (string, error) {
+	if f.down {
+		return "", fmt.Errorf("down")
+	}
+	if abi, found := f.abis[address]; found {
+		return abi, nil
+	}
+	return "", fmt.Errorf("%s isn't verified", address)
+}
+
+func (f fakeExplorer) GetContractInfo(address string) (ContractInfo, error) {
+	if f.down {
+		return ContractInfo{}, fmt.Errorf("down")
+	}
+	name, found := f.names[address]
+	return ContractInfo{Name: name, IsVerified: found}, nil
+}
+
+func TestExplorersFallThrough(t *testing.T) {
+	es := Explorers{
+		fakeExplorer{down: true},
+		fakeExplorer{abis: map[string]string{"0x01": "[1]"}, names: map[string]string{"0x01": "One"}},
+		fakeExplorer{abis: map[string]string{"0x01": "[]", "0x02": "[2]"}, names: map[string]string{"0x02": "Two"}},
+	}
+	for address, want := range map[string]string{"0x01": "[1]", "0x02": "[2]"} {
+		if abi, err := es.GetABIString(address); err != nil || abi != want {
+			t.Fatalf("%s: got %s, %v, want %s", address, abi, err, want)
+		}
+		if info, err := es.GetContractInfo(address); err != nil || !info.IsVerified {
+			t.Fatalf("%s: got %+v, %v", address, info, err)
+		}
+	}
+	if _, err := es.GetABIString("0x03"); err == nil {
+		t.Fatal("got an abi no explorer has")
+	}
+	// unverified everywhere isn't an error, as long as an explorer answered
+	if info, err := es.GetContractInfo("0x03"); err != nil || info.IsVerified {
+		t.Fatalf("got %+v, %v", info, err)
+	}
+	if _, err := (Explorers{fakeExplorer{down: true}}).GetContractInfo("0x01"); err == nil {
+		t.Fatal("no error when every explorer is down")
+	}
+	if _, err := es.RecommendedGasPrice(); err == nil {
+		t.Fatal("got a gas price no explorer has")
+	}
+}
@@ -0,0 +1,163 @@
+package explorers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// SourcifyServerURL is the Sourcify API jarvis reads verified contracts
+// from.
+var SourcifyServerURL = "https://sourcify.dev/server"
+
+// sourcifyContract returns the fields of the verified contract at address
+// Sourcify has. found is false when Sourcify didn't verify it.
+func sourcifyContract(serverURL string, chainID uint64, address string, fields string) (body []byte, found bool, err error) {
+	resp, err := http.Get(fmt.Sprintf(
+		"%s/v2/contract/%d/%s?fields=%s",
+		strings.TrimRight(serverURL, "/"), chainID, address, fields,
+	))
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+	body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, fmt.Errorf("error reading body: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("sourcify answered %d: %s", resp.StatusCode, string(body))
+	}
+	return body, true, nil
+}
+
+// SourcifyStorageLayout returns the storage layout Sourcify compiled the
+// verified contract at address with, as {"storageLayout": {...}}. Unlike the
+// Etherscan-like explorers Sourcify recompiles what it verifies, so it has
+// the layout of any contract it verified.
+func SourcifyStorageLayout(chainID uint64, address string) ([]byte, error) {
+	body, found, err := sourcifyContract(SourcifyServerURL, chainID, address, "storageLayout")
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("%s isn't verified on sourcify", address)
+	}
+	result := struct {
+		StorageLayout json.RawMessage `json:"storageLayout"`
+	}{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("couldn't unmarshal %s: %w", string(body), err)
+	}
+	if len(result.StorageLayout) == 0 || string(result.StorageLayout) == "null" {
+		return nil, fmt.Errorf("sourcify has no storage layout of %s", address)
+	}
+	return body, nil
+}
+
+// SourcifyExplorer reads the contracts verified on a Sourcify server. Both
+// full matches, whose metadata hash matches too, and partial matches are
+// taken since their ABIs are the same. Sourcify has no gas price oracle.
+type SourcifyExplorer struct {
+	ServerURL string
+	ChainID   uint64
+}
+
+// NewSourcifyExplorer returns the explorer of chainID on the Sourcify
+// server at serverURL, SourcifyServerURL when it is empty.
+func NewSourcifyExplorer(serverURL string, chainID uint64) *SourcifyExplorer {
+	if serverURL == "" {
+		serverURL = SourcifyServerURL
+	}
+	return &SourcifyExplorer{
+		ServerURL: serverURL,
+		ChainID:   chainID,
+	}
+}
+
+// sourcifyVerified is the part of a Sourcify v2 contract jarvis reads.
+// Match is "exact_match" for full matches and "match" for partial ones.
+type sourcifyVerified struct {
+	Match    string          `json:"match"`
+	ABI      json.RawMessage `json:"abi"`
+	Metadata struct {
+		Output struct {
+			ABI json.RawMessage `json:"abi"`
+		} `json:"output"`
+		Settings struct {
+			CompilationTarget map[string]string `json:"compilationTarget"`
+		} `json:"settings"`
+	} `json:"metadata"`
+	Compilation struct {
+		Name string `json:"name"`
+	} `json:"compilation"`
+	ProxyResolution struct {
+		IsProxy         bool `json:"isProxy"`
+		Implementations []struct {
+			Address string `json:"address"`
+		} `json:"implementations"`
+	} `json:"proxyResolution"`
+}
+
+func (se *SourcifyExplorer) verified(address string) (*sourcifyVerified, error) {
+	body, found, err := sourcifyContract(se.ServerURL, se.ChainID, address, "abi,metadata,compilation,proxyResolution")
+	if err != nil || !found {
+		return nil, err
+	}
+	result := &sourcifyVerified{}
+	if err := json.Unmarshal(body, result); err != nil {
+		return nil, fmt.Errorf("couldn't unmarshal %s: %w", string(body), err)
+	}
+	if result.Match == "" {
+		return nil, nil
+	}
+	return result, nil
+}
+
+func (se *SourcifyExplorer) RecommendedGasPrice() (float64, error) {
+	return 0, fmt.Errorf("sourcify has no gas price oracle")
+}
+
+// GetABIString returns the ABI Sourcify has of the contract, read from its
+// metadata when Sourcify doesn't give it on its own.
+func (se *SourcifyExplorer) GetABIString(address string) (string, error) {
+	v, err := se.verified(address)
+	if err != nil {
+		return "", err
+	}
+	if v == nil {
+		return "", fmt.Errorf("%s isn't verified on sourcify", address)
+	}
+	for _, a := range []json.RawMessage{v.ABI, v.Metadata.Output.ABI} {
+		if strings.HasPrefix(strings.TrimSpace(string(a)), "[") {
+			return string(a), nil
+		}
+	}
+	return "", fmt.Errorf("sourcify has no abi of %s", address)
+}
+
+func (se *SourcifyExplorer) GetContractInfo(address string) (ContractInfo, error) {
+	v, err := se.verified(address)
+	if err != nil || v == nil {
+		return ContractInfo{}, err
+	}
+	info := ContractInfo{
+		Name:       v.Compilation.Name,
+		IsProxy:    v.ProxyResolution.IsProxy,
+		IsVerified: true,
+	}
+	if info.Name == "" {
+		for _, name := range v.Metadata.Settings.CompilationTarget {
+			info.Name = name
+		}
+	}
+	if impls := v.ProxyResolution.Implementations; len(impls) > 0 {
+		info.Implementation = impls[0].Address
+	}
+	return info, nil
+}
@@ -0,0 +1,77 @@
+package explorers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSourcifyStorageLayout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/contract/1/0x01":
+			if r.URL.Query().Get("fields") != "storageLayout" {
+				t.Errorf("query %v", r.URL.Query())
+			}
+			fmt.Fprint(w, `{"storageLayout":{"storage":[],"types":null},"matchId":"1"}`)
+		case "/v2/contract/1/0x02":
+			fmt.Fprint(w, `{"storageLayout":null}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"customCode":"not_found"}`)
+		}
+	}))
+	defer srv.Close()
+	defer func(url string) { SourcifyServerURL = url }(SourcifyServerURL)
+	SourcifyServerURL = srv.URL
+
+	if _, err := SourcifyStorageLayout(1, "0x01"); err != nil {
+		t.Fatal(err)
+	}
+	for _, address := range []string{"0x02", "0x03"} {
+		if _, err := SourcifyStorageLayout(1, address); err == nil {
+			t.Fatalf("got a layout of %s", address)
+		}
+	}
+}
+
+func TestSourcifyExplorer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/contract/10/0x01":
+			fmt.Fprint(w, `{"match":"exact_match","abi":[{"type":"fallback"}],"compilation":{"name":"Vault"},
+				"proxyResolution":{"isProxy":true,"implementations":[{"address":"0x02","name":"VaultImpl"}]}}`)
+		case "/v2/contract/10/0x03":
+			// a partial match from before sourcify served abis on their own
+			fmt.Fprint(w, `{"match":"match","abi":null,"metadata":{"output":{"abi":[{"type":"receive","stateMutability":"payable"}]},
+				"settings":{"compilationTarget":{"src/Box.sol":"Box"}}},"proxyResolution":null}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	se := NewSourcifyExplorer(srv.URL, 10)
+
+	if abi, err := se.GetABIString("0x01"); err != nil || abi != `[{"type":"fallback"}]` {
+		t.Fatalf("got %s, %v", abi, err)
+	}
+	info, err := se.GetContractInfo("0x01")
+	if err != nil || info != (ContractInfo{Name: "Vault", Implementation: "0x02", IsProxy: true, IsVerified: true}) {
+		t.Fatalf("got %+v, %v", info, err)
+	}
+
+	if abi, err := se.GetABIString("0x03"); err != nil || abi != `[{"type":"receive","stateMutability":"payable"}]` {
+		t.Fatalf("partial match: got %s, %v", abi, err)
+	}
+	if info, err := se.GetContractInfo("0x03"); err != nil || info.Name != "Box" || info.IsProxy {
+		t.Fatalf("partial match: got %+v, %v", info, err)
+	}
+
+	if _, err := se.GetABIString("0x04"); err == nil {
+		t.Fatal("got an abi of an unverified contract")
+	}
+	if info, err := se.GetContractInfo("0x04"); err != nil || info.IsVerified {
+		t.Fatalf("unverified: got %+v, %v", info, err)
+	}
+}
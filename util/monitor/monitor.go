@@ -1,6 +1,7 @@
 package monitor
 
 import (
+	"strings"
 	"sync"
 	"time"
 
@@ -134,3 +135,57 @@ func (tm TxMonitor) BlockingWaitForMultipleTxs(txs ...string) map[string]common.
 	})
 	return result
 }
+
+// ReplacementResult is the outcome of following a set of txs that compete for
+// the same sender and nonce: at most one of them can be mined.
+type ReplacementResult struct {
+	// Mined is the tx that made it on chain. Its Status is "done" or
+	// "reverted", or "lost" when none of the competing txs was ever mined.
+	Mined common.TxInfo
+	// MinedHash is the hash of Mined, empty when Status is "lost".
+	MinedHash string
+	// Replaced lists the hashes that were dropped in favour of Mined.
+	Replaced []string
+}
+
+func (tm TxMonitor) followReplacement(interval time.Duration, txs ...string) ReplacementResult {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	lastSeen := time.Now()
+
+	for {
+		t := <-ticker.C
+		for _, tx := range txs {
+			txinfo, _ := tm.reader.TxInfoFromHash(tx)
+			switch txinfo.Status {
+			case "done", "reverted":
+				result := ReplacementResult{
+					Mined:     txinfo,
+					MinedHash: tx,
+				}
+				for _, other := range txs {
+					if !strings.EqualFold(other, tx) {
+						result.Replaced = append(result.Replaced, other)
+					}
+				}
+				return result
+			case "pending", "error":
+				// "error" means the nodes couldn't answer, not that the tx is
+				// gone, so it doesn't count towards giving up either.
+				lastSeen = t
+			}
+		}
+		if t.Sub(lastSeen) > 3*time.Minute {
+			return ReplacementResult{
+				Mined: common.TxInfo{Status: "lost"},
+			}
+		}
+	}
+}
+
+// BlockingWaitForReplacement follows txs that share one nonce (an original and
+// its speed-up or cancel replacements) until one of them is mined, and reports
+// the others as replaced.
+func (tm TxMonitor) BlockingWaitForReplacement(txs ...string) ReplacementResult {
+	return tm.followReplacement(5*time.Second, txs...)
+}
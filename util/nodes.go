@@ -1,8 +1,10 @@
 package util
 
 import (
+	"crypto/ecdsa"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
 	"os/user"
 	"path/filepath"
@@ -10,6 +12,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum/crypto"
+
 	jarvisnetworks "github.com/tranvictor/jarvis/networks"
 	"github.com/tranvictor/jarvis/util/reader"
 )
@@ -23,6 +27,21 @@ type NodeConfig struct {
 	// included alongside the user's custom nodes. It defaults to true so that
 	// adding a custom node doesn't silently remove the built-in fallbacks.
 	UseDefaults bool `json:"use_defaults"`
+	// Relays are the private tx submission endpoints of the network:
+	// name → relay. Txs are only routed through them with --private.
+	Relays map[string]RelayConfig `json:"relays,omitempty"`
+}
+
+// RelayConfig is a private relay endpoint. Method is "private" for relays
+// taking eth_sendPrivateTransaction and "bundle" for relays taking
+// eth_sendBundle. FlashbotsAuth signs every request with the relay
+// reputation key (see RelayAuthKey); Headers are sent as is, e.g. an API
+// key.
+type RelayConfig struct {
+	URL           string            `json:"url"`
+	Method        string            `json:"method"`
+	FlashbotsAuth bool              `json:"flashbots_auth,omitempty"`
+	Headers       map[string]string `json:"headers,omitempty"`
 }
 
 // NodeConfigDir returns ~/.jarvis/nodes/, creating it when necessary.
@@ -139,6 +158,65 @@ func GetNodes(network jarvisnetworks.Network) (map[string]string, error) {
 	return nodes, nil
 }
 
+// GetRelays returns the private relays configured for a network, or an
+// empty map when there are none.
+func GetRelays(network jarvisnetworks.Network) map[string]RelayConfig {
+	cfg, err := LoadNodeConfig(network.GetName())
+	if err != nil || cfg.Relays == nil {
+		return map[string]RelayConfig{}
+	}
+	return cfg.Relays
+}
+
+// RelayAuthKey returns the key signing the requests of relays with
+// flashbots_auth, stored in ~/.jarvis/relay_auth.key and generated on first
+// use. It only builds a reputation with the relays and must never hold
+// funds.
+func RelayAuthKey() (*ecdsa.PrivateKey, error) {
+	usr, err := user.Current()
+	if err != nil {
+		return nil, fmt.Errorf("couldn't get current user: %w", err)
+	}
+	p := filepath.Join(usr.HomeDir, ".jarvis", "relay_auth.key")
+	key, err := crypto.LoadECDSA(p)
+	if err == nil {
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		// the relays know jarvis by this key, it isn't replaced silently
+		return nil, fmt.Errorf("couldn't load the relay auth key %s: %w", p, err)
+	}
+	key, err = crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return nil, err
+	}
+	if err := crypto.SaveECDSA(p, key); err != nil {
+		return nil, fmt.Errorf("couldn't save the relay auth key: %w", err)
+	}
+	return key, nil
+}
+
+// ValidateNodeURL checks that nodeURL is a node jarvis can dial: http(s), or
+// ws(s) which can also push new logs.
+func ValidateNodeURL(nodeURL string) error {
+	u, err := url.Parse(strings.TrimSpace(nodeURL))
+	if err != nil {
+		return fmt.Errorf("%s is not a valid url: %w", nodeURL, err)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "ws", "wss":
+	default:
+		return fmt.Errorf("%s must be an http://, https://, ws:// or wss:// url", nodeURL)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("%s has no host", nodeURL)
+	}
+	return nil
+}
+
 // TestNode dials a single RPC node and measures its round-trip latency using
 // a lightweight eth_getBalance call. Returns the latency and any error.
 func TestNode(name, url string, network jarvisnetworks.Network) (time.Duration, error) {
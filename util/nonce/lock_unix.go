@@ -0,0 +1,37 @@
+//go:build !windows
+
+package nonce
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+type fileLock struct {
+	f *os.File
+}
+
+// lockFile blocks until it holds an exclusive lock on path.
+func lockFile(path string) (*fileLock, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		err = unix.Flock(int(f.Fd()), unix.LOCK_EX)
+		if err != unix.EINTR {
+			break
+		}
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &fileLock{f}, nil
+}
+
+func (l *fileLock) unlock() {
+	unix.Flock(int(l.f.Fd()), unix.LOCK_UN)
+	l.f.Close()
+}
@@ -0,0 +1,32 @@
+//go:build windows
+
+package nonce
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+type fileLock struct {
+	f *os.File
+}
+
+// lockFile blocks until it holds an exclusive lock on path.
+func lockFile(path string) (*fileLock, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	ol := new(windows.Overlapped)
+	if err := windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0, ol); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &fileLock{f}, nil
+}
+
+func (l *fileLock) unlock() {
+	windows.UnlockFileEx(windows.Handle(l.f.Fd()), 0, 1, 0, new(windows.Overlapped))
+	l.f.Close()
+}
@@ -0,0 +1,310 @@
+// Package nonce hands out account nonces to jarvis commands that send txs
+// from the same wallet back to back, possibly from several processes at once.
+//
+// Every (network, address) pair has a state file under ~/.jarvis/nonces that
+// records the nonces handed out and not mined yet. The file is only read and
+// written under an exclusive OS file lock, so concurrent jarvis processes
+// never get the same nonce. Each time a nonce is requested the state is
+// reconciled with the mined and pending nonces of the node: mined nonces are
+// forgotten, and so are reservations whose tx was never broadcasted within
+// ReservationTTL (the process crashed or was killed) and broadcasted ones the
+// node no longer knows while it hasn't reached their nonce (the tx was
+// dropped from the mempool).
+//
+// A gap is a nonce nobody holds below a nonce that was broadcasted: the node
+// won't mine the later txs until a tx with the gap nonce is sent. Reserve
+// always hands out the lowest free nonce, so the next tx fills the gap.
+package nonce
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	jarviscommon "github.com/tranvictor/jarvis/common"
+	"github.com/tranvictor/jarvis/util/reader"
+)
+
+// ReservationTTL is how long a nonce handed out to a command is held for it
+// while its tx isn't broadcasted. It leaves room for the user to review the
+// tx and unlock a hardware wallet.
+const ReservationTTL = 15 * time.Minute
+
+// Reader is what the manager needs from the node.
+type Reader interface {
+	GetMinedNonce(address string) (uint64, error)
+	GetPendingNonce(address string) (uint64, error)
+	TxInfoFromHash(tx string) (jarviscommon.TxInfo, error)
+}
+
+// Reservation is a nonce handed out and not mined yet. TxHash is set once its
+// tx is broadcasted.
+type Reservation struct {
+	Nonce      uint64    `json:"nonce"`
+	ReservedAt time.Time `json:"reserved_at"`
+	TxHash     string    `json:"tx_hash,omitempty"`
+}
+
+type state struct {
+	Network      string        `json:"network"`
+	Address      string        `json:"address"`
+	Reservations []Reservation `json:"reservations"`
+}
+
+// Status is the reconciled view of an account's nonces.
+type Status struct {
+	Mined        uint64
+	Pending      uint64
+	Reservations []Reservation
+	// Gaps are the free nonces below the highest broadcasted reservation.
+	Gaps []uint64
+}
+
+// Manager tracks the nonces of the accounts of one network.
+type Manager struct {
+	dir     string
+	network string
+	reader  Reader
+	now     func() time.Time
+}
+
+// DefaultDir is ~/.jarvis/nonces.
+func DefaultDir() string {
+	u, err := user.Current()
+	if err != nil {
+		return filepath.Join(".jarvis", "nonces")
+	}
+	return filepath.Join(u.HomeDir, ".jarvis", "nonces")
+}
+
+// NewManager returns a manager keeping its state under dir.
+func NewManager(dir string, network string, reader Reader) *Manager {
+	return &Manager{
+		dir:     dir,
+		network: network,
+		reader:  reader,
+		now:     time.Now,
+	}
+}
+
+// Reserve hands out the lowest nonce of address that is neither mined,
+// pending on the node nor held by another command.
+func (m *Manager) Reserve(address string) (nonce uint64, status Status, err error) {
+	err = m.withState(address, func(s *state) error {
+		status, err = m.reconcile(s)
+		if err != nil {
+			return err
+		}
+		nonce = lowestFree(status)
+		s.Reservations = append(s.Reservations, Reservation{Nonce: nonce, ReservedAt: m.now()})
+		sortReservations(s.Reservations)
+		return nil
+	})
+	return nonce, status, err
+}
+
+// Release gives back a nonce whose tx won't be broadcasted. Releasing a nonce
+// that isn't reserved, e.g. one passed with --nonce, does nothing.
+func (m *Manager) Release(address string, nonce uint64) error {
+	return m.withState(address, func(s *state) error {
+		kept := s.Reservations[:0]
+		for _, r := range s.Reservations {
+			if r.Nonce != nonce || r.TxHash != "" {
+				kept = append(kept, r)
+			}
+		}
+		s.Reservations = kept
+		return nil
+	})
+}
+
+// MarkBroadcasted records that the tx using nonce was broadcasted, so its
+// reservation is held until the nonce is mined rather than expiring. A nonce
+// used without a reservation is recorded too: it was broadcasted all the same.
+func (m *Manager) MarkBroadcasted(address string, nonce uint64, txHash string) error {
+	return m.withState(address, func(s *state) error {
+		for i := range s.Reservations {
+			if s.Reservations[i].Nonce == nonce {
+				s.Reservations[i].TxHash = txHash
+				return nil
+			}
+		}
+		s.Reservations = append(s.Reservations, Reservation{Nonce: nonce, ReservedAt: m.now(), TxHash: txHash})
+		sortReservations(s.Reservations)
+		return nil
+	})
+}
+
+// Hold renews the reservation of nonce so it doesn't expire while its tx
+// waits for a schedule to be broadcasted. A reservation that expired is taken
+// back.
+func (m *Manager) Hold(address string, nonce uint64) error {
+	return m.withState(address, func(s *state) error {
+		for i := range s.Reservations {
+			if s.Reservations[i].Nonce == nonce {
+				if s.Reservations[i].TxHash == "" {
+					s.Reservations[i].ReservedAt = m.now()
+				}
+				return nil
+			}
+		}
+		s.Reservations = append(s.Reservations, Reservation{Nonce: nonce, ReservedAt: m.now()})
+		sortReservations(s.Reservations)
+		return nil
+	})
+}
+
+// Status reconciles the state of address with the node and reports it.
+func (m *Manager) Status(address string) (status Status, err error) {
+	err = m.withState(address, func(s *state) error {
+		status, err = m.reconcile(s)
+		return err
+	})
+	return status, err
+}
+
+// Reset forgets every reservation of address.
+func (m *Manager) Reset(address string) error {
+	return m.withState(address, func(s *state) error {
+		s.Reservations = nil
+		return nil
+	})
+}
+
+// reconcile drops the reservations that are mined, expired or whose tx was
+// dropped, and computes the gaps. It must be called with the state locked.
+func (m *Manager) reconcile(s *state) (Status, error) {
+	mined, err := m.reader.GetMinedNonce(s.Address)
+	if err != nil {
+		return Status{}, fmt.Errorf("couldn't get mined nonce of %s: %w", s.Address, err)
+	}
+	pending, err := m.reader.GetPendingNonce(s.Address)
+	if err != nil {
+		return Status{}, fmt.Errorf("couldn't get pending nonce of %s: %w", s.Address, err)
+	}
+	if pending < mined {
+		// the node answering for the pending nonce lags behind
+		pending = mined
+	}
+
+	kept := s.Reservations[:0]
+	for _, r := range s.Reservations {
+		if r.Nonce < mined {
+			continue
+		}
+		if r.TxHash == "" && m.now().Sub(r.ReservedAt) > ReservationTTL {
+			continue
+		}
+		if r.TxHash != "" && r.Nonce >= pending && m.dropped(r.TxHash) {
+			continue
+		}
+		kept = append(kept, r)
+	}
+	s.Reservations = kept
+
+	status := Status{
+		Mined:        mined,
+		Pending:      pending,
+		Reservations: append([]Reservation{}, kept...),
+	}
+	status.Gaps = findGaps(pending, kept)
+	return status, nil
+}
+
+// dropped tells whether no node knows the tx of txHash anymore. A tx that
+// couldn't be looked up is taken as known, so its nonce is never handed out
+// twice.
+func (m *Manager) dropped(txHash string) bool {
+	_, err := m.reader.TxInfoFromHash(txHash)
+	return errors.Is(err, reader.ErrTxNotFound)
+}
+
+// findGaps returns the nonces from pending up to the highest broadcasted
+// reservation that nobody holds.
+func findGaps(pending uint64, reservations []Reservation) []uint64 {
+	held := map[uint64]bool{}
+	var highestBroadcasted uint64
+	broadcasted := false
+	for _, r := range reservations {
+		held[r.Nonce] = true
+		if r.TxHash != "" && (!broadcasted || r.Nonce > highestBroadcasted) {
+			highestBroadcasted = r.Nonce
+			broadcasted = true
+		}
+	}
+	gaps := []uint64{}
+	if !broadcasted {
+		return gaps
+	}
+	for n := pending; n < highestBroadcasted; n++ {
+		if !held[n] {
+			gaps = append(gaps, n)
+		}
+	}
+	return gaps
+}
+
+// lowestFree returns the lowest nonce from the pending one nobody holds,
+// which is the first gap if there is any.
+func lowestFree(status Status) uint64 {
+	held := map[uint64]bool{}
+	for _, r := range status.Reservations {
+		held[r.Nonce] = true
+	}
+	n := status.Pending
+	for held[n] {
+		n++
+	}
+	return n
+}
+
+func sortReservations(rs []Reservation) {
+	sort.Slice(rs, func(i, j int) bool { return rs[i].Nonce < rs[j].Nonce })
+}
+
+// withState runs f on the state of address with the state file locked, and
+// persists the state f leaves.
+func (m *Manager) withState(address string, f func(s *state) error) error {
+	address = strings.ToLower(address)
+	dir := filepath.Join(m.dir, m.network)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("couldn't create nonce dir %s: %w", dir, err)
+	}
+
+	lock, err := lockFile(filepath.Join(dir, address+".lock"))
+	if err != nil {
+		return fmt.Errorf("couldn't lock the nonces of %s: %w", address, err)
+	}
+	defer lock.unlock()
+
+	path := filepath.Join(dir, address+".json")
+	s := &state{Network: m.network, Address: address}
+	content, err := os.ReadFile(path)
+	if err == nil {
+		if err := json.Unmarshal(content, s); err != nil {
+			return fmt.Errorf("couldn't parse nonce state %s: %w", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("couldn't read nonce state %s: %w", path, err)
+	}
+
+	if err := f(s); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	// the next process taking the lock must never read half a state
+	if err := jarviscommon.WriteFileAtomic(path, data, 0o644); err != nil {
+		return fmt.Errorf("couldn't write nonce state %s: %w", path, err)
+	}
+	return nil
+}
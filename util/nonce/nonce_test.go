@@ -0,0 +1,228 @@
+package nonce
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	jarviscommon "github.com/tranvictor/jarvis/common"
+	"github.com/tranvictor/jarvis/util/reader"
+)
+
+const testAddr = "0x1d9937e170Fc2174408581265bA0B87afDA4947F"
+
+type fakeReader struct {
+	mu      sync.Mutex
+	mined   uint64
+	pending uint64
+	// dropped are the hashes of the txs the node doesn't know
+	dropped map[string]bool
+}
+
+func (r *fakeReader) GetMinedNonce(string) (uint64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.mined, nil
+}
+
+func (r *fakeReader) GetPendingNonce(string) (uint64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.pending, nil
+}
+
+func (r *fakeReader) TxInfoFromHash(tx string) (jarviscommon.TxInfo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.dropped[tx] {
+		return jarviscommon.TxInfo{Status: "notfound"}, reader.ErrTxNotFound
+	}
+	return jarviscommon.TxInfo{Status: "pending"}, nil
+}
+
+func reserve(t *testing.T, m *Manager) uint64 {
+	t.Helper()
+	n, _, err := m.Reserve(testAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestReserveIsSequentialAndReleasable(t *testing.T) {
+	r := &fakeReader{mined: 10, pending: 10}
+	m := NewManager(t.TempDir(), "mainnet", r)
+
+	if n := reserve(t, m); n != 10 {
+		t.Fatalf("first nonce = %d, want 10", n)
+	}
+	if n := reserve(t, m); n != 11 {
+		t.Fatalf("second nonce = %d, want 11", n)
+	}
+	if err := m.Release(testAddr, 11); err != nil {
+		t.Fatal(err)
+	}
+	if n := reserve(t, m); n != 11 {
+		t.Fatalf("released nonce must be handed out again, got %d", n)
+	}
+}
+
+func TestReserveFollowsTheNode(t *testing.T) {
+	r := &fakeReader{mined: 3, pending: 5}
+	m := NewManager(t.TempDir(), "mainnet", r)
+
+	if n := reserve(t, m); n != 5 {
+		t.Fatalf("nonce = %d, want the pending nonce 5", n)
+	}
+	if err := m.MarkBroadcasted(testAddr, 5, "0xaa"); err != nil {
+		t.Fatal(err)
+	}
+
+	// everything got mined, possibly by txs sent outside of jarvis
+	r.mined, r.pending = 9, 9
+	status, err := m.Status(testAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status.Reservations) != 0 {
+		t.Fatalf("mined reservations must be dropped: %+v", status.Reservations)
+	}
+	if n := reserve(t, m); n != 9 {
+		t.Fatalf("nonce = %d, want 9", n)
+	}
+}
+
+func TestExpiredReservationLeavesAGap(t *testing.T) {
+	r := &fakeReader{mined: 0, pending: 0}
+	m := NewManager(t.TempDir(), "mainnet", r)
+	now := time.Now()
+	m.now = func() time.Time { return now }
+
+	reserve(t, m) // 0: the process dies before broadcasting
+	n1 := reserve(t, m)
+	if err := m.MarkBroadcasted(testAddr, n1, "0xbb"); err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := m.Status(testAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status.Gaps) != 0 {
+		t.Fatalf("a live reservation is not a gap: %v", status.Gaps)
+	}
+
+	now = now.Add(ReservationTTL + time.Minute)
+	status, err = m.Status(testAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status.Gaps) != 1 || status.Gaps[0] != 0 {
+		t.Fatalf("gaps = %v, want [0]", status.Gaps)
+	}
+	if n := reserve(t, m); n != 0 {
+		t.Fatalf("the gap must be handed out first, got %d", n)
+	}
+	if n := reserve(t, m); n != 2 {
+		t.Fatalf("nonce = %d, want 2", n)
+	}
+}
+
+func TestDroppedTxLeavesAGap(t *testing.T) {
+	r := &fakeReader{mined: 0, pending: 0, dropped: map[string]bool{}}
+	m := NewManager(t.TempDir(), "mainnet", r)
+
+	for n, hash := range []string{"0xaa", "0xbb", "0xcc"} {
+		reserve(t, m)
+		if err := m.MarkBroadcasted(testAddr, uint64(n), hash); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 0 is pending, 1 fell out of the mempool and 2 waits for it
+	r.pending = 1
+	r.dropped["0xbb"] = true
+	status, err := m.Status(testAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status.Gaps) != 1 || status.Gaps[0] != 1 {
+		t.Fatalf("gaps = %v, want [1]", status.Gaps)
+	}
+	if n := reserve(t, m); n != 1 {
+		t.Fatalf("the dropped nonce must be handed out first, got %d", n)
+	}
+
+	// a dropped tx below the pending nonce was replaced, it is kept until mined
+	r.pending = 3
+	r.dropped["0xaa"] = true
+	status, err = m.Status(testAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status.Reservations) != 3 {
+		t.Fatalf("reservations = %+v, want 0 to 2", status.Reservations)
+	}
+}
+
+func TestConcurrentReserve(t *testing.T) {
+	dir := t.TempDir()
+	r := &fakeReader{mined: 100, pending: 100}
+
+	const workers = 8
+	var wg sync.WaitGroup
+	got := make(chan uint64, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// a manager per worker, as separate processes would have
+			n, _, err := NewManager(dir, "mainnet", r).Reserve(testAddr)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			got <- n
+		}()
+	}
+	wg.Wait()
+	close(got)
+
+	seen := map[uint64]bool{}
+	for n := range got {
+		if seen[n] {
+			t.Fatalf("nonce %d handed out twice", n)
+		}
+		seen[n] = true
+	}
+	for n := uint64(100); n < 100+workers; n++ {
+		if !seen[n] {
+			t.Fatalf("nonce %d was skipped", n)
+		}
+	}
+}
+
+func TestHoldKeepsTheReservation(t *testing.T) {
+	r := &fakeReader{mined: 0, pending: 0}
+	m := NewManager(t.TempDir(), "mainnet", r)
+	now := time.Now()
+	m.now = func() time.Time { return now }
+
+	n := reserve(t, m)
+	now = now.Add(ReservationTTL - time.Minute)
+	if err := m.Hold(testAddr, n); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(ReservationTTL - time.Minute)
+	if got := reserve(t, m); got != n+1 {
+		t.Fatalf("a held nonce must not expire, got %d", got)
+	}
+
+	// taken back after it expired
+	now = now.Add(2 * ReservationTTL)
+	if err := m.Hold(testAddr, n); err != nil {
+		t.Fatal(err)
+	}
+	if got := reserve(t, m); got != n+1 {
+		t.Fatalf("nonce = %d, want %d", got, n+1)
+	}
+}
@@ -0,0 +1,102 @@
+// Package portfolio reads what a set of wallets holds across networks: the
+// native token and the known tokens of every network, batched in one
+// multicall per network, and their USD values where CoinGecko prices them.
+package portfolio
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	jarviscommon "github.com/tranvictor/jarvis/common"
+	jarvisnetworks "github.com/tranvictor/jarvis/networks"
+	"github.com/tranvictor/jarvis/util"
+)
+
+// Holding is the balance of a wallet in a token on a network.
+type Holding struct {
+	Network string
+	ChainID uint64
+	Block   int64
+	Wallet  string
+	Token   Token
+	// Balance is nil when it couldn't be read.
+	Balance *big.Int
+	// USDPrice is nil when there is no price of the token.
+	USDPrice *float64
+}
+
+// Amount is the balance in units of the token.
+func (h Holding) Amount() float64 {
+	if h.Balance == nil {
+		return 0
+	}
+	return jarviscommon.BigToFloat(h.Balance, h.Token.Decimals)
+}
+
+// USDValue is the value of the balance in USD, ok is false when the
+// balance or the price of the token is unknown.
+func (h Holding) USDValue() (value float64, ok bool) {
+	if h.Balance == nil || h.USDPrice == nil {
+		return 0, false
+	}
+	return h.Amount() * *h.USDPrice, true
+}
+
+// Read reads the balances of every wallet in tokens on network at atBlock,
+// -1 for the latest block, in one multicall. The holdings are grouped by
+// token, in the order of tokens, then in the order of wallets. Balances that
+// couldn't be read are left nil and why is in err, the holdings are still
+// returned unless the network couldn't be read at all.
+func Read(network jarvisnetworks.Network, atBlock int64, wallets []string, tokens []Token) ([]Holding, error) {
+	addresses := []string{}
+	for _, t := range tokens {
+		addresses = append(addresses, t.Address)
+	}
+	balances, block, err := util.GetHistoryBalances(atBlock, wallets, addresses, network)
+	if block == 0 {
+		if err == nil {
+			err = fmt.Errorf("the multicall didn't tell the block it read at")
+		}
+		return nil, fmt.Errorf("couldn't read the balances: %w", err)
+	}
+
+	holdings := []Holding{}
+	for i, t := range tokens {
+		for _, wallet := range wallets {
+			holdings = append(holdings, Holding{
+				Network: network.GetName(),
+				ChainID: network.GetChainID(),
+				Block:   block,
+				Wallet:  wallet,
+				Token:   t,
+				Balance: balances[jarviscommon.HexToAddress(wallet)][i],
+			})
+		}
+	}
+	return holdings, err
+}
+
+// Lookup returns the token at address on network, reading its symbol and
+// decimals. It errors when there is no ERC20 at address on network.
+func Lookup(network jarvisnetworks.Network, address string) (Token, error) {
+	for _, t := range KnownTokens(network) {
+		if strings.EqualFold(t.Address, address) {
+			return t, nil
+		}
+	}
+	decimals, err := util.GetERC20Decimal(address, network)
+	if err != nil {
+		return Token{}, fmt.Errorf("couldn't read the decimals of %s on %s: %w", address, network.GetName(), err)
+	}
+	symbol, err := util.GetERC20Symbol(address, network)
+	if err != nil {
+		// tokens like MKR have a bytes32 symbol
+		symbol = address
+	}
+	return Token{
+		Address:  address,
+		Symbol:   symbol,
+		Decimals: decimals,
+	}, nil
+}
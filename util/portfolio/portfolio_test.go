@@ -0,0 +1,118 @@
+package portfolio
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestKnownTokens(t *testing.T) {
+	for chainID, tokens := range knownTokens {
+		if _, found := coinGeckoChains[chainID]; !found {
+			t.Fatalf("chain %d has known tokens but coingecko doesn't know it", chainID)
+		}
+		seen := map[string]bool{}
+		for _, token := range tokens {
+			if !common.IsHexAddress(token.Address) || common.HexToAddress(token.Address).Hex() != token.Address {
+				t.Fatalf("%s of chain %d isn't a checksummed address", token.Address, chainID)
+			}
+			if token.Symbol == "" || token.Decimals == 0 || token.CoinGeckoID == "" {
+				t.Fatalf("%+v of chain %d is missing its symbol, decimals or coin", token, chainID)
+			}
+			if seen[token.Address] {
+				t.Fatalf("%s is known twice on chain %d", token.Address, chainID)
+			}
+			seen[token.Address] = true
+		}
+	}
+}
+
+func coinGecko(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var result interface{}
+		switch {
+		case r.URL.Path == "/simple/price":
+			result = map[string]map[string]float64{
+				"ethereum": {"usd": 2000},
+				"usd-coin": {"usd": 1},
+			}
+		case r.URL.Path == "/coins/ethereum/history":
+			if r.URL.Query().Get("date") != "01-02-2024" {
+				t.Errorf("got the history of %s", r.URL.Query().Get("date"))
+			}
+			result = map[string]interface{}{"market_data": map[string]interface{}{
+				"current_price": map[string]float64{"usd": 2300},
+			}}
+		case strings.HasPrefix(r.URL.Path, "/coins/"):
+			result = map[string]interface{}{}
+		case r.URL.Path == "/simple/token_price/arbitrum-one":
+			result = map[string]map[string]float64{
+				"0x00000000000000000000000000000000000000aa": {"usd": 3},
+			}
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(result)
+	}))
+	old := CoinGeckoAPIURL
+	CoinGeckoAPIURL = server.URL
+	t.Cleanup(func() {
+		CoinGeckoAPIURL = old
+		server.Close()
+	})
+	return server
+}
+
+func TestPriceHoldings(t *testing.T) {
+	coinGecko(t)
+	eth := Token{Address: NativeToken, Symbol: "ETH", Decimals: 18, CoinGeckoID: "ethereum"}
+	usdc := Token{Address: "0xaf88d065e77c8cC2239327C5EDb3A432268e5831", Symbol: "USDC", Decimals: 6, CoinGeckoID: "usd-coin"}
+	other := Token{Address: "0x00000000000000000000000000000000000000AA", Symbol: "OTHER", Decimals: 18}
+	unlisted := Token{Address: "0x00000000000000000000000000000000000000bb", Symbol: "UNLISTED", Decimals: 18}
+	holdings := func() []Holding {
+		return []Holding{
+			{ChainID: 42161, Token: eth, Balance: big.NewInt(5e17)},
+			{ChainID: 42161, Token: usdc, Balance: big.NewInt(7e6)},
+			{ChainID: 42161, Token: other, Balance: big.NewInt(1e18)},
+			{ChainID: 42161, Token: unlisted, Balance: big.NewInt(1e18)},
+			{ChainID: 42161, Token: usdc},
+			{ChainID: 999999, Token: other, Balance: big.NewInt(1e18)},
+		}
+	}
+
+	latest := holdings()
+	if err := PriceHoldings(latest, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		value float64
+		ok    bool
+	}{{1000, true}, {7, true}, {3, true}, {0, false}, {0, false}, {0, false}}
+	for i, w := range want {
+		value, ok := latest[i].USDValue()
+		if ok != w.ok || value != w.value {
+			t.Fatalf("holding %d is worth %f, %t, want %f, %t", i, value, ok, w.value, w.ok)
+		}
+	}
+
+	// tokens without a coin are only priced at the latest prices
+	snapshot := holdings()
+	if err := PriceHoldings(snapshot, time.Date(2024, 2, 1, 15, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	if value, ok := snapshot[0].USDValue(); !ok || value != 1150 {
+		t.Fatalf("eth is worth %f, %t, want its price of the day", value, ok)
+	}
+	for _, h := range snapshot[1:] {
+		if h.USDPrice != nil {
+			t.Fatalf("%s is priced at %f", h.Token.Symbol, *h.USDPrice)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package portfolio
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CoinGeckoAPIURL is the CoinGecko API holdings are priced with.
+var CoinGeckoAPIURL = "https://api.coingecko.com/api/v3"
+
+func coinGeckoGet(path string, query url.Values, result interface{}) error {
+	u := fmt.Sprintf("%s/%s?%s", strings.TrimRight(CoinGeckoAPIURL, "/"), path, query.Encode())
+	resp, err := http.Get(u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading body from coingecko: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("coingecko answered %d: %s", resp.StatusCode, string(body))
+	}
+	if err := json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("error unmarshalling body from coingecko: %w", err)
+	}
+	return nil
+}
+
+// CoinPrices returns the USD prices of the CoinGecko coins ids by id, their
+// latest prices when at is zero and the prices of the day of at otherwise.
+// Coins CoinGecko has no price of are left out.
+func CoinPrices(ids []string, at time.Time) (map[string]float64, error) {
+	prices := map[string]float64{}
+	if len(ids) == 0 {
+		return prices, nil
+	}
+	if at.IsZero() {
+		result := map[string]map[string]float64{}
+		err := coinGeckoGet("simple/price", url.Values{
+			"ids":           {strings.Join(ids, ",")},
+			"vs_currencies": {"usd"},
+		}, &result)
+		if err != nil {
+			return prices, err
+		}
+		for id, price := range result {
+			if usd, found := price["usd"]; found {
+				prices[id] = usd
+			}
+		}
+		return prices, nil
+	}
+
+	// the history of a coin is read one coin at a time
+	errs := []error{}
+	for _, id := range ids {
+		result := struct {
+			MarketData *struct {
+				CurrentPrice map[string]float64 `json:"current_price"`
+			} `json:"market_data"`
+		}{}
+		err := coinGeckoGet("coins/"+url.PathEscape(id)+"/history", url.Values{
+			"date":         {at.UTC().Format("02-01-2006")},
+			"localization": {"false"},
+		}, &result)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("price of %s: %w", id, err))
+			continue
+		}
+		if result.MarketData == nil {
+			continue
+		}
+		if usd, found := result.MarketData.CurrentPrice["usd"]; found {
+			prices[id] = usd
+		}
+	}
+	return prices, errors.Join(errs...)
+}
+
+// TokenPrices returns the latest USD prices of the tokens at addresses on
+// the chain by lower-case address. Tokens CoinGecko has no price of are
+// left out, so are the tokens of the chains CoinGecko doesn't know.
+func TokenPrices(chainID uint64, addresses []string) (map[string]float64, error) {
+	prices := map[string]float64{}
+	chain, found := coinGeckoChains[chainID]
+	if !found || len(addresses) == 0 {
+		return prices, nil
+	}
+	result := map[string]map[string]float64{}
+	err := coinGeckoGet("simple/token_price/"+chain.platform, url.Values{
+		"contract_addresses": {strings.ToLower(strings.Join(addresses, ","))},
+		"vs_currencies":      {"usd"},
+	}, &result)
+	if err != nil {
+		return prices, err
+	}
+	for addr, price := range result {
+		if usd, found := price["usd"]; found {
+			prices[strings.ToLower(addr)] = usd
+		}
+	}
+	return prices, nil
+}
+
+// PriceHoldings sets the USD price of the holdings CoinGecko has a price
+// of, as of at or the latest one when at is zero. Tokens are priced by
+// their coin when they have one and by their address otherwise, which only
+// the latest prices are known of. The holdings priced before an error are
+// kept priced.
+func PriceHoldings(holdings []Holding, at time.Time) error {
+	ids := map[string]bool{}
+	addresses := map[uint64]map[string]bool{}
+	for _, h := range holdings {
+		if h.Token.CoinGeckoID != "" {
+			ids[h.Token.CoinGeckoID] = true
+			continue
+		}
+		if !at.IsZero() || h.Token.IsNative() {
+			continue
+		}
+		if addresses[h.ChainID] == nil {
+			addresses[h.ChainID] = map[string]bool{}
+		}
+		addresses[h.ChainID][strings.ToLower(h.Token.Address)] = true
+	}
+
+	errs := []error{}
+	coins, err := CoinPrices(sortedKeys(ids), at)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	tokens := map[uint64]map[string]float64{}
+	for chainID, addrs := range addresses {
+		prices, err := TokenPrices(chainID, sortedKeys(addrs))
+		if err != nil {
+			errs = append(errs, err)
+		}
+		tokens[chainID] = prices
+	}
+
+	for i := range holdings {
+		h := &holdings[i]
+		price, found := coins[h.Token.CoinGeckoID]
+		if h.Token.CoinGeckoID == "" {
+			price, found = tokens[h.ChainID][strings.ToLower(h.Token.Address)]
+		}
+		if found {
+			h.USDPrice = &price
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
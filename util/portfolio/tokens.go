@@ -0,0 +1,132 @@
+package portfolio
+
+import (
+	"strings"
+
+	jarvisnetworks "github.com/tranvictor/jarvis/networks"
+)
+
+// NativeToken is the address standing for the native token of a network,
+// util.ETH_ADDR.
+const NativeToken = "0xeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee"
+
+// Token is a token whose balances a portfolio reads.
+type Token struct {
+	Address  string `json:"address"`
+	Symbol   string `json:"symbol"`
+	Decimals uint64 `json:"decimals"`
+	// CoinGeckoID is the coin CoinGecko prices the token as, "" when the
+	// token is priced by its address on the network instead.
+	CoinGeckoID string `json:"coingecko_id,omitempty"`
+}
+
+// IsNative tells whether t is the native token of its network.
+func (t Token) IsNative() bool {
+	return strings.EqualFold(t.Address, NativeToken)
+}
+
+// coinGeckoChain is how CoinGecko knows a chain: the coin of its native
+// token and the platform id its tokens are priced by address on.
+type coinGeckoChain struct {
+	native   string
+	platform string
+}
+
+var coinGeckoChains = map[uint64]coinGeckoChain{
+	1:      {"ethereum", "ethereum"},
+	10:     {"ethereum", "optimistic-ethereum"},
+	56:     {"binancecoin", "binance-smart-chain"},
+	137:    {"polygon-ecosystem-token", "polygon-pos"},
+	199:    {"bittorrent", "bittorrent"},
+	250:    {"fantom", "fantom"},
+	1101:   {"ethereum", "polygon-zkevm"},
+	8453:   {"ethereum", "base"},
+	42161:  {"ethereum", "arbitrum-one"},
+	43114:  {"avalanche-2", "avalanche"},
+	59144:  {"ethereum", "linea"},
+	534352: {"ethereum", "scroll"},
+}
+
+// knownTokens are the major tokens of the networks jarvis is bundled with,
+// by chain id. Holdings in other tokens are read with --tokens.
+var knownTokens = map[uint64][]Token{
+	1: {
+		{"0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", "USDC", 6, "usd-coin"},
+		{"0xdAC17F958D2ee523a2206206994597C13D831ec7", "USDT", 6, "tether"},
+		{"0x6B175474E89094C44Da98b954EedeAC495271d0F", "DAI", 18, "dai"},
+		{"0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2", "WETH", 18, "weth"},
+		{"0x2260FAC5E5542a773Aa44fBCfeDf7C193bc2C599", "WBTC", 8, "wrapped-bitcoin"},
+		{"0xdeFA4e8a7bcBA345F687a2f1456F5Edd9CE97202", "KNC", 18, "kyber-network-crystal"},
+	},
+	10: {
+		{"0x0b2C639c533813f4Aa9D7837CAf62653d097Ff85", "USDC", 6, "usd-coin"},
+		{"0x94b008aA00579c1307B0EF2c499aD98a8ce58e58", "USDT", 6, "tether"},
+		{"0xDA10009cBd5D07dd0CeCc66161FC93D7c9000da1", "DAI", 18, "dai"},
+		{"0x4200000000000000000000000000000000000006", "WETH", 18, "weth"},
+		{"0x4200000000000000000000000000000000000042", "OP", 18, "optimism"},
+	},
+	56: {
+		{"0x55d398326f99059fF775485246999027B3197955", "USDT", 18, "tether"},
+		{"0x8AC76a51cc950d9822D68b83fE1Ad97B32Cd580d", "USDC", 18, "usd-coin"},
+		{"0xe9e7CEA3DedcA5984780Bafc599bD69ADd087D56", "BUSD", 18, "binance-usd"},
+		{"0xbb4CdB9CBd36B01bD1cBaEBF2De08d9173bc095c", "WBNB", 18, "wbnb"},
+		{"0x2170Ed0880ac9A755fd29B2688956BD959F933F8", "ETH", 18, "ethereum"},
+	},
+	137: {
+		{"0x3c499c542cEF5E3811e1192ce70d8cC03d5c3359", "USDC", 6, "usd-coin"},
+		{"0x2791Bca1f2de4661ED88A30C99A7a9449Aa84174", "USDC.e", 6, "usd-coin"},
+		{"0xc2132D05D31c914a87C6611C10748AEb04B58e8F", "USDT", 6, "tether"},
+		{"0x8f3Cf7ad23Cd3CaDbD9735AFf958023239c6A063", "DAI", 18, "dai"},
+		{"0x7ceB23fD6bC0adD59E62ac25578270cFf1b9f619", "WETH", 18, "weth"},
+		{"0x1BFD67037B42Cf73acF2047067bd4F2C47D9BfD6", "WBTC", 8, "wrapped-bitcoin"},
+		{"0x0d500B1d8E8eF31E21C99d1Db9A6444d3ADf1270", "WMATIC", 18, "wmatic"},
+	},
+	250: {
+		{"0x21be370D5312f44cB42ce377BC9b8a0cEF1A4C83", "WFTM", 18, "wrapped-fantom"},
+	},
+	8453: {
+		{"0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913", "USDC", 6, "usd-coin"},
+		{"0xd9aAEc86B65D86f6A7B5B1b0c42FFA531710b6CA", "USDbC", 6, "usd-coin"},
+		{"0x50c5725949A6F0c72E6C4a641F24049A917DB0Cb", "DAI", 18, "dai"},
+		{"0x4200000000000000000000000000000000000006", "WETH", 18, "weth"},
+	},
+	42161: {
+		{"0xaf88d065e77c8cC2239327C5EDb3A432268e5831", "USDC", 6, "usd-coin"},
+		{"0xFF970A61A04b1cA14834A43f5dE4533eBDDB5CC8", "USDC.e", 6, "usd-coin"},
+		{"0xFd086bC7CD5C481DCC9C85ebE478A1C0b69FCbb9", "USDT", 6, "tether"},
+		{"0xDA10009cBd5D07dd0CeCc66161FC93D7c9000da1", "DAI", 18, "dai"},
+		{"0x82aF49447D8a07e3bd95BD0d56f35241523fBab1", "WETH", 18, "weth"},
+		{"0x2f2a2543B76A4166549F7aaB2e75Bef0aefC5B0f", "WBTC", 8, "wrapped-bitcoin"},
+		{"0x912CE59144191C1204E64559FE8253a0e49E6548", "ARB", 18, "arbitrum"},
+	},
+	43114: {
+		{"0xB97EF9Ef8734C71904D8002F8b6Bc66Dd9c48a6E", "USDC", 6, "usd-coin"},
+		{"0x9702230A8Ea53601f5cD2dc00fDBc13d4dF4A8c7", "USDT", 6, "tether"},
+		{"0x49D5c2BdFfac6CE2BFdB6640F4F80f226bc10bAB", "WETH.e", 18, "weth"},
+		{"0xB31f66AA3C1e785363F0875A1B74E27b85FD66c7", "WAVAX", 18, "wrapped-avax"},
+	},
+	59144: {
+		{"0x176211869cA2b568f2A7D4EE941E073a821EE1ff", "USDC", 6, "usd-coin"},
+		{"0xe5D7C2a44FfDDf6b295A15c148167daaAf5Cf34f", "WETH", 18, "weth"},
+	},
+	534352: {
+		{"0x06eFdBFf2a14a7c8E15944D1F4A48F9F95F663A4", "USDC", 6, "usd-coin"},
+		{"0x5300000000000000000000000000000000000004", "WETH", 18, "weth"},
+	},
+}
+
+// Native returns the native token of network.
+func Native(network jarvisnetworks.Network) Token {
+	return Token{
+		Address:     NativeToken,
+		Symbol:      network.GetNativeTokenSymbol(),
+		Decimals:    network.GetNativeTokenDecimal(),
+		CoinGeckoID: coinGeckoChains[network.GetChainID()].native,
+	}
+}
+
+// KnownTokens returns the native token of network followed by the major
+// tokens jarvis knows on it.
+func KnownTokens(network jarvisnetworks.Network) []Token {
+	return append([]Token{Native(network)}, knownTokens[network.GetChainID()]...)
+}
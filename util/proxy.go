@@ -0,0 +1,181 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/tranvictor/jarvis/networks"
+	"github.com/tranvictor/jarvis/util/reader"
+)
+
+// proxyInfos caches what DetectProxy found per (network, address) for the
+// rest of the process: the analyzer looks the same destinations up again
+// and again, and each detection takes several rpc calls.
+var (
+	proxyInfosMu sync.Mutex
+	proxyInfos   = map[string]*reader.ProxyInfo{}
+)
+
+// ProxyOf tells what kind of proxy the contract at address is and what it
+// delegates to. See reader.DetectProxy.
+func ProxyOf(address string, network networks.Network) (*reader.ProxyInfo, error) {
+	key := probeKey(network, strings.ToLower(address))
+	proxyInfosMu.Lock()
+	info, found := proxyInfos[key]
+	proxyInfosMu.Unlock()
+	if found {
+		return info, nil
+	}
+
+	r, err := EthReader(network)
+	if err != nil {
+		return nil, err
+	}
+	info, err = r.DetectProxy(-1, address)
+	if err != nil {
+		return nil, err
+	}
+	proxyInfosMu.Lock()
+	proxyInfos[key] = info
+	proxyInfosMu.Unlock()
+	return info, nil
+}
+
+// mayBeProxyABI tells whether a is the ABI of a contract that may be a
+// proxy: one with the proxy methods, one without any method of its own
+// (clones, Safe and beacon proxies and most diamonds are verified like
+// that), or one with a diamond loupe.
+func mayBeProxyABI(a *abi.ABI) bool {
+	if len(a.Methods) == 0 || IsProxyABI(a) {
+		return true
+	}
+	_, found := a.Methods["facets"]
+	return found
+}
+
+// ResolveABI returns the ABI calls to address are decoded with: its own
+// ABI or, for proxies, the ABI of what they delegate to. It is the
+// jarviscommon.ABIDatabase the analyzer looks destinations up with.
+func ResolveABI(address string, network networks.Network) (*abi.ABI, error) {
+	return ConfigToABI(address, false, "", network)
+}
+
+// ProxyABI returns the ABI calls to the proxy at address are decoded with:
+// the ABI of its implementation or, for a diamond, the ABIs of its facets
+// merged with own, its own ABI, which may be nil.
+func ProxyABI(address string, proxy *reader.ProxyInfo, own *abi.ABI, network networks.Network) (*abi.ABI, error) {
+	if proxy.Kind == reader.DiamondProxy {
+		return MergeFacetABIs(own, proxy.Facets, func(facet common.Address) (*abi.ABI, error) {
+			return GetABI(facet.Hex(), network)
+		})
+	}
+	a, err := GetABI(proxy.Implementation.Hex(), network)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"couldn't get the abi of %s, the implementation of %s (%s): %w",
+			proxy.Implementation.Hex(), address, proxy.Kind, err,
+		)
+	}
+	return a, nil
+}
+
+// MergeFacetABIs merges the ABIs of the facets of a diamond into one, with
+// own, the ABI of the diamond itself, as a base. Only the methods a facet
+// is routed to are taken from its ABI, so each selector decodes with the
+// function the diamond actually calls. Facets whose ABI lookup can't find
+// are left out; it errors only when there is nothing to merge.
+func MergeFacetABIs(
+	own *abi.ABI,
+	facets []reader.Facet,
+	lookup func(facet common.Address) (*abi.ABI, error),
+) (*abi.ABI, error) {
+	merged := &abi.ABI{
+		Methods: map[string]abi.Method{},
+		Events:  map[string]abi.Event{},
+		Errors:  map[string]abi.Error{},
+	}
+	routed := map[[4]byte]bool{}
+	errs := []error{}
+	for _, f := range facets {
+		a, err := lookup(f.Address)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("facet %s: %w", f.Address.Hex(), err))
+			continue
+		}
+		selectors := map[[4]byte]bool{}
+		for _, s := range f.Selectors {
+			selectors[s] = true
+		}
+		for _, m := range a.Methods {
+			if selector := [4]byte(m.ID); selectors[selector] && !routed[selector] {
+				routed[selector] = true
+				addMethod(merged, m)
+			}
+		}
+		addEventsAndErrors(merged, a)
+	}
+	if own == nil {
+		if len(routed) == 0 {
+			return nil, fmt.Errorf("couldn't get the abi of any facet: %w", errors.Join(errs...))
+		}
+		return merged, nil
+	}
+	merged.Constructor = own.Constructor
+	merged.Fallback = own.Fallback
+	merged.Receive = own.Receive
+	for _, m := range own.Methods {
+		if !routed[[4]byte(m.ID)] {
+			addMethod(merged, m)
+		}
+	}
+	addEventsAndErrors(merged, own)
+	return merged, nil
+}
+
+// freeName is name, or name with the first number making it unused, the
+// way abi.JSON names overloads.
+func freeName(name string, used func(string) bool) string {
+	free := name
+	for i := 0; used(free); i++ {
+		free = fmt.Sprintf("%s%d", name, i)
+	}
+	return free
+}
+
+func addMethod(a *abi.ABI, m abi.Method) {
+	m.Name = freeName(m.RawName, func(name string) bool {
+		_, found := a.Methods[name]
+		return found
+	})
+	a.Methods[m.Name] = m
+}
+
+// addEventsAndErrors adds the events and errors of from that a doesn't have
+// yet.
+func addEventsAndErrors(a *abi.ABI, from *abi.ABI) {
+	for _, e := range from.Events {
+		if _, err := a.EventByID(e.ID); err == nil {
+			continue
+		}
+		e.Name = freeName(e.RawName, func(name string) bool {
+			_, found := a.Events[name]
+			return found
+		})
+		a.Events[e.Name] = e
+	}
+	for _, e := range from.Errors {
+		if _, err := a.ErrorByID([4]byte(e.ID[:4])); err == nil {
+			continue
+		}
+		e.Name = freeName(e.Name, func(name string) bool {
+			_, found := a.Errors[name]
+			return found
+		})
+		a.Errors[e.Name] = e
+	}
+}
@@ -0,0 +1,92 @@
+package util_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/tranvictor/jarvis/util"
+	"github.com/tranvictor/jarvis/util/reader"
+)
+
+func mustABI(t *testing.T, str string) *abi.ABI {
+	t.Helper()
+	a, err := abi.JSON(strings.NewReader(str))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &a
+}
+
+func selector(a *abi.ABI, name string) [4]byte {
+	return [4]byte(a.Methods[name].ID)
+}
+
+func TestMergeFacetABIs(t *testing.T) {
+	// both facets declare deposit(uint256) but the diamond routes it to the
+	// vault facet, and withdraw is declared by the vault facet without
+	// being routed to it
+	vault := mustABI(t, `[
+		{"type":"function","name":"deposit","inputs":[{"name":"amount","type":"uint256"}],"outputs":[]},
+		{"type":"function","name":"withdraw","inputs":[{"name":"amount","type":"uint256"}],"outputs":[]},
+		{"type":"event","name":"Deposited","inputs":[{"name":"amount","type":"uint256","indexed":false}]},
+		{"type":"error","name":"TooMuch","inputs":[]}
+	]`)
+	admin := mustABI(t, `[
+		{"type":"function","name":"deposit","inputs":[{"name":"amount","type":"uint256"}],"outputs":[]},
+		{"type":"function","name":"pause","inputs":[],"outputs":[]},
+		{"type":"function","name":"pause","inputs":[{"name":"until","type":"uint64"}],"outputs":[]}
+	]`)
+	own := mustABI(t, `[{"type":"fallback","stateMutability":"payable"},{"type":"error","name":"TooMuch","inputs":[]}]`)
+	vaultAddr := common.HexToAddress("0x000000000000000000000000000000000000000a")
+	adminAddr := common.HexToAddress("0x000000000000000000000000000000000000000b")
+	missingAddr := common.HexToAddress("0x000000000000000000000000000000000000000c")
+
+	facets := []reader.Facet{
+		{Address: vaultAddr, Selectors: [][4]byte{selector(vault, "deposit")}},
+		{Address: adminAddr, Selectors: [][4]byte{selector(admin, "pause"), selector(admin, "pause0")}},
+		{Address: missingAddr, Selectors: [][4]byte{{1, 2, 3, 4}}},
+	}
+	lookup := func(facet common.Address) (*abi.ABI, error) {
+		switch facet {
+		case vaultAddr:
+			return vault, nil
+		case adminAddr:
+			return admin, nil
+		}
+		return nil, errors.New("not verified")
+	}
+
+	merged, err := util.MergeFacetABIs(own, facets, lookup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(merged.Methods) != 3 {
+		t.Fatalf("merged %d methods, want deposit and both pause", len(merged.Methods))
+	}
+	if _, found := merged.Methods["withdraw"]; found {
+		t.Fatal("withdraw isn't routed to any facet")
+	}
+	for _, name := range []string{"pause", "pause0"} {
+		m, found := merged.Methods[name]
+		if !found || m.RawName != "pause" {
+			t.Fatalf("missing overload %s", name)
+		}
+	}
+	if _, err := merged.MethodById(vault.Methods["deposit"].ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, found := merged.Events["Deposited"]; !found {
+		t.Fatal("missing event of a facet")
+	}
+	if len(merged.Errors) != 1 || merged.Fallback.Type != abi.Fallback {
+		t.Fatalf("own abi not merged: %d errors, fallback %v", len(merged.Errors), merged.Fallback)
+	}
+
+	if _, err := util.MergeFacetABIs(nil, facets[2:], lookup); err == nil {
+		t.Fatal("merged facets without any abi")
+	}
+}
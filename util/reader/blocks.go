@@ -0,0 +1,63 @@
+package reader
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// HeaderReader is what BlockAtTime reads block headers with, *EthReader in
+// practice.
+type HeaderReader interface {
+	HeaderByNumber(number int64) (*types.Header, error)
+}
+
+// BlockAtTime returns the last block mined at or before t, the latest block
+// when t is after it. The block is looked up with a binary search over the
+// timestamps of the blocks, so it takes a few dozen header reads. It errors
+// when t is before the first block.
+func BlockAtTime(r HeaderReader, t time.Time) (uint64, error) {
+	latest, err := r.HeaderByNumber(-1)
+	if err != nil {
+		return 0, fmt.Errorf("couldn't read the latest block: %w", err)
+	}
+	ts := uint64(t.Unix())
+	if t.Unix() < 0 {
+		ts = 0
+	}
+	if ts >= latest.Time {
+		return latest.Number.Uint64(), nil
+	}
+
+	timeOf := func(number uint64) (uint64, error) {
+		header, err := r.HeaderByNumber(int64(number))
+		if err != nil {
+			return 0, fmt.Errorf("couldn't read block %d: %w", number, err)
+		}
+		return header.Time, nil
+	}
+	first, err := timeOf(0)
+	if err != nil {
+		return 0, err
+	}
+	if ts < first {
+		return 0, fmt.Errorf("%s is before the first block", t.UTC().Format(time.RFC3339))
+	}
+
+	// the block at lo is mined at or before ts and the one at hi after it
+	lo, hi := uint64(0), latest.Number.Uint64()
+	for hi-lo > 1 {
+		mid := lo + (hi-lo)/2
+		midTime, err := timeOf(mid)
+		if err != nil {
+			return 0, err
+		}
+		if midTime <= ts {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo, nil
+}
@@ -0,0 +1,69 @@
+package reader
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// chain mines a block every 12 seconds from genesis, except for a 10
+// minute gap before block gapAt.
+type chain struct {
+	genesis uint64
+	latest  uint64
+	gapAt   uint64
+	reads   int
+}
+
+func (c *chain) HeaderByNumber(number int64) (*types.Header, error) {
+	c.reads++
+	if number < 0 {
+		number = int64(c.latest)
+	}
+	if uint64(number) > c.latest {
+		return nil, fmt.Errorf("block %d isn't mined yet", number)
+	}
+	ts := c.genesis + uint64(number)*12
+	if uint64(number) >= c.gapAt {
+		ts += 600
+	}
+	return &types.Header{Number: big.NewInt(number), Time: ts}, nil
+}
+
+func TestBlockAtTime(t *testing.T) {
+	c := &chain{genesis: 1600000000, latest: 1000000, gapAt: 500000}
+	at := func(ts uint64) time.Time { return time.Unix(int64(ts), 0) }
+
+	tests := []struct {
+		name string
+		ts   uint64
+		want uint64
+	}{
+		{"genesis", c.genesis, 0},
+		{"right when a block is mined", c.genesis + 120, 10},
+		{"between blocks", c.genesis + 125, 10},
+		{"in the gap", c.genesis + 500000*12 + 300, 499999},
+		{"after the gap", c.genesis + 500001*12 + 600, 500001},
+		{"after the latest block", c.genesis + 2000000*12, 1000000},
+	}
+	for _, tt := range tests {
+		c.reads = 0
+		got, err := BlockAtTime(c, at(tt.ts))
+		if err != nil {
+			t.Fatalf("%s: %s", tt.name, err)
+		}
+		if got != tt.want {
+			t.Fatalf("%s: got block %d, want %d", tt.name, got, tt.want)
+		}
+		if c.reads > 25 {
+			t.Fatalf("%s: read %d headers", tt.name, c.reads)
+		}
+	}
+
+	if _, err := BlockAtTime(c, at(c.genesis-1)); err == nil {
+		t.Fatalf("found a block before the first one")
+	}
+}
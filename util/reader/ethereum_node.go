@@ -3,6 +3,7 @@ package reader
 import (
 	"math/big"
 
+	goethereum "github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	ethereum "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -22,6 +23,9 @@ type EthereumNode interface {
 		atBlock *big.Int,
 	) (gas uint64, err error)
 	GetCode(address string) (code []byte, err error)
+	CreateAccessList(from, to string, value *big.Int, data []byte) (types.AccessList, uint64, error)
+	EstimateGasWithAccessList(from, to string, value *big.Int, data []byte, al types.AccessList) (uint64, error)
+	EstimateGasWithCode(from, to string, value *big.Int, data []byte, codeOverrides map[string][]byte) (uint64, error)
 	GetBalance(address string) (balance *big.Int, err error)
 	GetMinedNonce(address string) (nonce uint64, err error)
 	GetPendingNonce(address string) (nonce uint64, err error)
@@ -31,6 +35,8 @@ type EthereumNode interface {
 	GetGasPriceSuggestion() (*big.Int, error)
 	SuggestedGasPrice() (*big.Int, error)
 	SuggestedGasTipCap() (*big.Int, error)
+	FeeHistory(blocks uint64, percentiles []float64) (*goethereum.FeeHistory, error)
+	BlobBaseFee() (*big.Int, error)
 	ReadContractToBytes(
 		atBlock int64,
 		from string,
@@ -40,8 +46,11 @@ type EthereumNode interface {
 		args ...interface{},
 	) ([]byte, error)
 	EthCall(from string, to string, value *big.Int, data []byte, overrides *map[ethereum.Address]gethclient.OverrideAccount) ([]byte, error)
+	EthCallAt(atBlock int64, from string, to string, value *big.Int, gas uint64, data []byte) ([]byte, error)
+	TraceCall(from, to string, value *big.Int, data []byte, codeOverrides map[string][]byte) (*CallFrame, error)
 	StorageAt(atBlock int64, caddr string, slot string) ([]byte, error)
 	HeaderByNumber(number int64) (*types.Header, error)
 	GetLogs(fromBlock, toBlock int, addresses []string, topic string) ([]types.Log, error)
+	FilterLogs(q goethereum.FilterQuery) ([]types.Log, error)
 	CurrentBlock() (uint64, error)
 }
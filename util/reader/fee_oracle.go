@@ -0,0 +1,144 @@
+package reader
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+)
+
+// GasSpeed is a tier of fee suggestions: the faster, the higher the tip and
+// the more base fee increases the max fee covers.
+type GasSpeed string
+
+const (
+	GasSpeedSlow   GasSpeed = "slow"
+	GasSpeedNormal GasSpeed = "normal"
+	GasSpeedFast   GasSpeed = "fast"
+)
+
+// ParseGasSpeed reads a speed tier name. An empty name is the normal tier.
+func ParseGasSpeed(s string) (GasSpeed, error) {
+	switch GasSpeed(strings.ToLower(strings.TrimSpace(s))) {
+	case GasSpeedSlow:
+		return GasSpeedSlow, nil
+	case GasSpeedNormal, "":
+		return GasSpeedNormal, nil
+	case GasSpeedFast:
+		return GasSpeedFast, nil
+	}
+	return "", fmt.Errorf("unknown gas speed %q, use slow, normal or fast", s)
+}
+
+const (
+	// feeHistoryBlocks is how many of the latest blocks the suggestions are
+	// computed from.
+	feeHistoryBlocks = 20
+)
+
+// feeHistoryPercentiles are the reward percentiles of the slow, normal and
+// fast tips: the tip a tier suggests is the median, over the latest blocks,
+// of the tip paid by the tx at that percentile of the block.
+var feeHistoryPercentiles = []float64{10, 30, 60}
+
+// baseFeeMultipliers bound the max fee of each tier to a multiple of the
+// next base fee. The base fee rises at most 12.5% per block: 1.25 covers 1
+// full block, 1.5 about 3 and 2 about 6.
+var baseFeeMultipliers = map[GasSpeed]float64{
+	GasSpeedSlow:   1.25,
+	GasSpeedNormal: 1.5,
+	GasSpeedFast:   2,
+}
+
+// FeeSuggestion is what a tx of one speed tier should pay, in wei. On
+// chains without a base fee MaxFee is the gas price.
+type FeeSuggestion struct {
+	MaxFee *big.Int
+	TipCap *big.Int
+}
+
+// FeeSuggestions are the slow, normal and fast suggestions for the next
+// block, from the fee history of the latest blocks.
+type FeeSuggestions struct {
+	BaseFee *big.Int
+	Slow    FeeSuggestion
+	Normal  FeeSuggestion
+	Fast    FeeSuggestion
+}
+
+// Tier returns the suggestion of speed.
+func (fs FeeSuggestions) Tier(speed GasSpeed) FeeSuggestion {
+	switch speed {
+	case GasSpeedSlow:
+		return fs.Slow
+	case GasSpeedFast:
+		return fs.Fast
+	}
+	return fs.Normal
+}
+
+// SuggestFees computes the suggestions from the result of an eth_feeHistory
+// call made with feeHistoryPercentiles. Empty blocks are left out: every
+// reward of an empty block is 0 whatever the market.
+func SuggestFees(history *ethereum.FeeHistory) (FeeSuggestions, error) {
+	if history == nil || len(history.BaseFee) == 0 {
+		return FeeSuggestions{}, fmt.Errorf("the fee history has no block")
+	}
+	// the node appends the base fee of the block after the last one
+	baseFee := history.BaseFee[len(history.BaseFee)-1]
+	if baseFee == nil {
+		baseFee = big.NewInt(0)
+	}
+
+	tips := make([][]*big.Int, len(feeHistoryPercentiles))
+	for i, rewards := range history.Reward {
+		if i < len(history.GasUsedRatio) && history.GasUsedRatio[i] == 0 {
+			continue
+		}
+		if len(rewards) != len(feeHistoryPercentiles) {
+			continue
+		}
+		for p, reward := range rewards {
+			if reward != nil {
+				tips[p] = append(tips[p], reward)
+			}
+		}
+	}
+	if len(tips[0]) == 0 {
+		return FeeSuggestions{}, fmt.Errorf("the latest %d blocks are empty", len(history.Reward))
+	}
+
+	result := FeeSuggestions{BaseFee: baseFee}
+	tiers := []*FeeSuggestion{&result.Slow, &result.Normal, &result.Fast}
+	speeds := []GasSpeed{GasSpeedSlow, GasSpeedNormal, GasSpeedFast}
+	var prevTip *big.Int
+	for p, tier := range tiers {
+		tip := median(tips[p])
+		// a faster tier never tips less than a slower one
+		if prevTip != nil && tip.Cmp(prevTip) < 0 {
+			tip = prevTip
+		}
+		prevTip = tip
+		tier.TipCap = tip
+		tier.MaxFee = new(big.Int).Add(mulFloat(baseFee, baseFeeMultipliers[speeds[p]]), tip)
+	}
+	return result, nil
+}
+
+func median(values []*big.Int) *big.Int {
+	sorted := append([]*big.Int{}, values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Cmp(sorted[j]) < 0 })
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return new(big.Int).Set(sorted[mid])
+	}
+	sum := new(big.Int).Add(sorted[mid-1], sorted[mid])
+	return sum.Div(sum, big.NewInt(2))
+}
+
+func mulFloat(v *big.Int, f float64) *big.Int {
+	result, _ := new(big.Float).Mul(new(big.Float).SetInt(v), big.NewFloat(f)).Int(nil)
+	return result
+}
@@ -0,0 +1,100 @@
+package reader
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+)
+
+func gwei(n int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(n), big.NewInt(1e9))
+}
+
+func TestSuggestFees(t *testing.T) {
+	history := &ethereum.FeeHistory{
+		BaseFee: []*big.Int{gwei(9), gwei(10), gwei(11), gwei(10)},
+		// the middle block is empty and left out
+		GasUsedRatio: []float64{0.5, 0, 0.7},
+		Reward: [][]*big.Int{
+			{gwei(1), gwei(2), gwei(5)},
+			{gwei(0), gwei(0), gwei(0)},
+			{gwei(3), gwei(2), gwei(7)},
+		},
+	}
+	fees, err := SuggestFees(history)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fees.BaseFee.Cmp(gwei(10)) != 0 {
+		t.Fatalf("base fee %s, want the next block's", fees.BaseFee)
+	}
+	for _, tc := range []struct {
+		speed  GasSpeed
+		tip    *big.Int
+		maxFee *big.Int
+	}{
+		{GasSpeedSlow, gwei(2), big.NewInt(14_500_000_000)}, // 10 * 1.25 + 2
+		{GasSpeedNormal, gwei(2), gwei(17)},                 // 10 * 1.5 + 2
+		{GasSpeedFast, gwei(6), gwei(26)},                   // 10 * 2 + 6
+	} {
+		tier := fees.Tier(tc.speed)
+		if tier.TipCap.Cmp(tc.tip) != 0 || tier.MaxFee.Cmp(tc.maxFee) != 0 {
+			t.Fatalf("%s: tip %s max fee %s, want %s %s", tc.speed, tier.TipCap, tier.MaxFee, tc.tip, tc.maxFee)
+		}
+	}
+}
+
+func TestSuggestFeesTiersNeverDecrease(t *testing.T) {
+	fees, err := SuggestFees(&ethereum.FeeHistory{
+		BaseFee:      []*big.Int{gwei(1), gwei(1)},
+		GasUsedRatio: []float64{0.5},
+		Reward:       [][]*big.Int{{gwei(4), gwei(3), gwei(2)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fees.Normal.TipCap.Cmp(gwei(4)) != 0 || fees.Fast.TipCap.Cmp(gwei(4)) != 0 {
+		t.Fatalf("tips %s %s %s", fees.Slow.TipCap, fees.Normal.TipCap, fees.Fast.TipCap)
+	}
+}
+
+func TestSuggestFeesLegacyChain(t *testing.T) {
+	fees, err := SuggestFees(&ethereum.FeeHistory{
+		BaseFee:      []*big.Int{big.NewInt(0), big.NewInt(0)},
+		GasUsedRatio: []float64{0.2},
+		Reward:       [][]*big.Int{{gwei(5), gwei(6), gwei(8)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fees.Normal.MaxFee.Cmp(gwei(6)) != 0 {
+		t.Fatalf("without base fee the max fee is the gas price, got %s", fees.Normal.MaxFee)
+	}
+}
+
+func TestSuggestFeesErrors(t *testing.T) {
+	if _, err := SuggestFees(&ethereum.FeeHistory{}); err == nil {
+		t.Fatalf("expected an error for an empty history")
+	}
+	_, err := SuggestFees(&ethereum.FeeHistory{
+		BaseFee:      []*big.Int{gwei(1), gwei(1)},
+		GasUsedRatio: []float64{0},
+		Reward:       [][]*big.Int{{gwei(0), gwei(0), gwei(0)}},
+	})
+	if err == nil {
+		t.Fatalf("expected an error when every block is empty")
+	}
+}
+
+func TestParseGasSpeed(t *testing.T) {
+	for in, want := range map[string]GasSpeed{"": GasSpeedNormal, "Fast": GasSpeedFast, " slow ": GasSpeedSlow} {
+		got, err := ParseGasSpeed(in)
+		if err != nil || got != want {
+			t.Fatalf("ParseGasSpeed(%q) = %s, %v", in, got, err)
+		}
+	}
+	if _, err := ParseGasSpeed("turbo"); err == nil {
+		t.Fatalf("expected an error for an unknown speed")
+	}
+}
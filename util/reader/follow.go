@@ -0,0 +1,305 @@
+package reader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	DefaultFollowPollInterval   = 4 * time.Second
+	DefaultFollowReconnectDelay = 3 * time.Second
+)
+
+// LogSubscriber pushes the logs matching a query as blocks are mined.
+type LogSubscriber interface {
+	SubscribeLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error)
+}
+
+// LogSource is what a LogFollower catches up on missed blocks from.
+type LogSource interface {
+	LogFilterer
+	CurrentBlock() (uint64, error)
+}
+
+// NodeLogSources is a LogSource backed by several nodes. A LogFollower
+// catches up from one node at a time so the logs it reads up to a head come
+// from the node that reported that head: a lagging node answering for the
+// logs would otherwise make it skip the blocks that node hasn't seen yet.
+type NodeLogSources interface {
+	LogSource
+	LogSources() []LogSource
+}
+
+// LogSources returns the nodes of the reader as log sources, ordered by
+// name.
+func (er *EthReader) LogSources() []LogSource {
+	names := []string{}
+	for name := range er.nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	sources := []LogSource{}
+	for _, name := range names {
+		sources = append(sources, er.nodes[name])
+	}
+	return sources
+}
+
+// wsSubscriber subscribes through the first of its nodes that accepts.
+type wsSubscriber struct {
+	nodes []*OneNodeReader
+}
+
+func (s wsSubscriber) SubscribeLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	errs := []error{}
+	for _, n := range s.nodes {
+		sub, err := n.SubscribeLogs(ctx, q, ch)
+		if err == nil {
+			return sub, nil
+		}
+		errs = append(errs, wrapError(err, n.NodeName()))
+	}
+	return nil, fmt.Errorf("couldn't subscribe on any nodes: %w", errors.Join(errs...))
+}
+
+// LogSubscriber subscribes to logs through the ws(s) nodes of the reader. It
+// is nil when the reader has none.
+func (er *EthReader) LogSubscriber() LogSubscriber {
+	names := []string{}
+	for name, n := range er.nodes {
+		if IsWebSocketURL(n.NodeURL()) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	s := wsSubscriber{}
+	for _, name := range names {
+		if onr, ok := er.nodes[name].(*OneNodeReader); ok {
+			s.nodes = append(s.nodes, onr)
+		}
+	}
+	if len(s.nodes) == 0 {
+		return nil
+	}
+	return s
+}
+
+// followStop carries an error of the log handler, which ends Follow
+// instead of making it reconnect.
+type followStop struct {
+	err error
+}
+
+func (s followStop) Error() string {
+	return s.err.Error()
+}
+
+// LogFollower tails the logs matching Query. It subscribes through
+// Subscriber and falls back to polling Source every PollInterval when
+// Subscriber is nil or refuses the first subscription. After a dropped
+// subscription it catches up on the blocks mined meanwhile from Source, so
+// no log is missed or reported twice.
+type LogFollower struct {
+	Source         LogSource
+	Subscriber     LogSubscriber
+	Query          ethereum.FilterQuery
+	MaxRange       uint64
+	PollInterval   time.Duration
+	ReconnectDelay time.Duration
+	Logf           func(format string, args ...interface{})
+
+	// nextBlock and nextIndex are the position of the first log not
+	// reported yet.
+	nextBlock uint64
+	nextIndex uint
+}
+
+// Follow reports the logs from block from onwards to onLog, in order, until
+// ctx is done or onLog returns an error, which Follow returns. Logs a reorg
+// removes are reported again with Removed set, and the logs replacing them
+// are reported as new.
+func (f *LogFollower) Follow(ctx context.Context, from uint64, onLog func(types.Log) error) error {
+	f.nextBlock, f.nextIndex = from, 0
+	subscribed := false
+	for f.Subscriber != nil {
+		ok, err := f.subscribe(ctx, onLog)
+		subscribed = subscribed || ok
+		var stop followStop
+		if errors.As(err, &stop) {
+			return stop.err
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		if !subscribed {
+			f.logf("Couldn't subscribe to logs, polling instead: %s", err)
+			break
+		}
+		f.logf("Lost the log subscription, resuming from block %d: %s", f.nextBlock, err)
+		if !f.wait(ctx, f.ReconnectDelay, DefaultFollowReconnectDelay) {
+			return nil
+		}
+	}
+	return f.poll(ctx, onLog)
+}
+
+func (f *LogFollower) subscribe(ctx context.Context, onLog func(types.Log) error) (bool, error) {
+	ch := make(chan types.Log, 128)
+	sctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sub, err := f.Subscriber.SubscribeLogs(sctx, f.Query, ch)
+	if err != nil {
+		return false, err
+	}
+	defer sub.Unsubscribe()
+
+	// the logs mined before the subscription started
+	if err := f.catchUp(onLog); err != nil {
+		return true, err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return true, nil
+		case err := <-sub.Err():
+			if err == nil {
+				err = fmt.Errorf("the node closed the subscription")
+			}
+			return true, err
+		case l := <-ch:
+			if err := f.deliver(l, onLog); err != nil {
+				return true, err
+			}
+		}
+	}
+}
+
+func (f *LogFollower) poll(ctx context.Context, onLog func(types.Log) error) error {
+	for {
+		if err := f.catchUp(onLog); err != nil {
+			var stop followStop
+			if errors.As(err, &stop) {
+				return stop.err
+			}
+			f.logf("Couldn't get new logs, retrying: %s", err)
+		}
+		if !f.wait(ctx, f.PollInterval, DefaultFollowPollInterval) {
+			return nil
+		}
+	}
+}
+
+// catchUp reports the logs from the next block to the latest one. With
+// several nodes it reads from the most advanced one that answers, falling
+// back to the others.
+func (f *LogFollower) catchUp(onLog func(types.Log) error) error {
+	nodes, ok := f.Source.(NodeLogSources)
+	if !ok {
+		return f.catchUpFrom(f.Source, onLog)
+	}
+	type nodeHead struct {
+		source LogSource
+		head   uint64
+	}
+	heads := []nodeHead{}
+	errs := []error{}
+	for _, s := range nodes.LogSources() {
+		head, err := s.CurrentBlock()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		heads = append(heads, nodeHead{s, head})
+	}
+	sort.SliceStable(heads, func(i, j int) bool { return heads[i].head > heads[j].head })
+	for _, h := range heads {
+		err := f.catchUpTo(h.source, h.head, onLog)
+		if err == nil {
+			return nil
+		}
+		var stop followStop
+		if errors.As(err, &stop) {
+			return err
+		}
+		errs = append(errs, err)
+	}
+	return fmt.Errorf("couldn't catch up from any nodes: %w", errors.Join(errs...))
+}
+
+func (f *LogFollower) catchUpFrom(s LogSource, onLog func(types.Log) error) error {
+	head, err := s.CurrentBlock()
+	if err != nil {
+		return err
+	}
+	return f.catchUpTo(s, head, onLog)
+}
+
+// catchUpTo reports the logs from the next block to head, which s reported
+// as its latest block.
+func (f *LogFollower) catchUpTo(s LogSource, head uint64, onLog func(types.Log) error) error {
+	if head < f.nextBlock {
+		return nil
+	}
+	err := PageLogs(s, f.Query, f.nextBlock, head, f.MaxRange, func(from, to uint64, logs []types.Log) error {
+		for _, l := range logs {
+			if err := f.deliver(l, onLog); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if f.nextBlock <= head {
+		f.nextBlock, f.nextIndex = head+1, 0
+	}
+	return nil
+}
+
+// deliver reports l unless it was reported already. A removed log that was
+// reported moves the position back to it so its replacement is reported.
+func (f *LogFollower) deliver(l types.Log, onLog func(types.Log) error) error {
+	reported := l.BlockNumber < f.nextBlock ||
+		(l.BlockNumber == f.nextBlock && l.Index < f.nextIndex)
+	if l.Removed {
+		if !reported {
+			return nil
+		}
+		f.nextBlock, f.nextIndex = l.BlockNumber, l.Index
+	} else {
+		if reported {
+			return nil
+		}
+		f.nextBlock, f.nextIndex = l.BlockNumber, l.Index+1
+	}
+	if err := onLog(l); err != nil {
+		return followStop{err}
+	}
+	return nil
+}
+
+// wait sleeps d, or def when d is 0, and tells whether ctx is still live.
+func (f *LogFollower) wait(ctx context.Context, d, def time.Duration) bool {
+	if d == 0 {
+		d = def
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+func (f *LogFollower) logf(format string, args ...interface{}) {
+	if f.Logf != nil {
+		f.Logf(format, args...)
+	}
+}
@@ -0,0 +1,219 @@
+package reader
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// fakeChain holds the logs mined so far.
+type fakeChain struct {
+	mu   sync.Mutex
+	head uint64
+	logs []types.Log
+}
+
+func (c *fakeChain) mine(block uint64, indexes ...uint) []types.Log {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	mined := []types.Log{}
+	for _, i := range indexes {
+		mined = append(mined, types.Log{BlockNumber: block, Index: i})
+	}
+	c.logs = append(c.logs, mined...)
+	c.head = block
+	return mined
+}
+
+func (c *fakeChain) CurrentBlock() (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.head, nil
+}
+
+func (c *fakeChain) FilterLogs(q ethereum.FilterQuery) ([]types.Log, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	logs := []types.Log{}
+	for _, l := range c.logs {
+		if l.BlockNumber >= q.FromBlock.Uint64() && l.BlockNumber <= q.ToBlock.Uint64() {
+			logs = append(logs, l)
+		}
+	}
+	return logs, nil
+}
+
+type fakeSubscription struct {
+	errCh chan error
+	once  sync.Once
+}
+
+func (s *fakeSubscription) Unsubscribe()      { s.once.Do(func() { close(s.errCh) }) }
+func (s *fakeSubscription) Err() <-chan error { return s.errCh }
+
+// fakeSubscriber hands each subscription to the test through subs.
+type fakeSubscriber struct {
+	subs chan fakeSub
+}
+
+type fakeSub struct {
+	ch  chan<- types.Log
+	sub *fakeSubscription
+}
+
+func (s *fakeSubscriber) SubscribeLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	sub := &fakeSubscription{errCh: make(chan error, 1)}
+	s.subs <- fakeSub{ch, sub}
+	return sub, nil
+}
+
+type position struct {
+	block   uint64
+	index   uint
+	removed bool
+}
+
+// collect gathers the reported logs and cancels ctx after n of them.
+func collect(n int, cancel context.CancelFunc) (*[]position, func(types.Log) error) {
+	got := &[]position{}
+	return got, func(l types.Log) error {
+		*got = append(*got, position{l.BlockNumber, l.Index, l.Removed})
+		if len(*got) == n {
+			cancel()
+		}
+		return nil
+	}
+}
+
+func checkPositions(t *testing.T, got, want []position) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got logs %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got logs %v, want %v", got, want)
+		}
+	}
+}
+
+func TestFollowPollsWithoutDuplicates(t *testing.T) {
+	chain := &fakeChain{}
+	chain.mine(9, 0)
+	chain.mine(10, 0, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	got, onLog := collect(4, cancel)
+	f := &LogFollower{Source: chain, MaxRange: 100, PollInterval: time.Millisecond}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		chain.mine(12, 3, 4)
+	}()
+	if err := f.Follow(ctx, 10, onLog); err != nil {
+		t.Fatal(err)
+	}
+	checkPositions(t, *got, []position{{10, 0, false}, {10, 1, false}, {12, 3, false}, {12, 4, false}})
+}
+
+func TestFollowResumesAfterADroppedSubscription(t *testing.T) {
+	chain := &fakeChain{}
+	chain.mine(10, 0)
+	subscriber := &fakeSubscriber{subs: make(chan fakeSub)}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	got, onLog := collect(5, cancel)
+	f := &LogFollower{
+		Source:         chain,
+		Subscriber:     subscriber,
+		MaxRange:       100,
+		ReconnectDelay: time.Millisecond,
+	}
+	go func() {
+		first := <-subscriber.subs
+		for _, l := range chain.mine(11, 0) {
+			first.ch <- l
+		}
+		// mined while the connection is down
+		chain.mine(12, 0, 1)
+		first.sub.errCh <- errors.New("websocket: close 1006")
+
+		second := <-subscriber.subs
+		// the node replays what the catch up already reported
+		for _, l := range chain.mine(13, 0) {
+			second.ch <- types.Log{BlockNumber: 12, Index: 1}
+			second.ch <- l
+		}
+	}()
+	if err := f.Follow(ctx, 10, onLog); err != nil {
+		t.Fatal(err)
+	}
+	checkPositions(t, *got, []position{
+		{10, 0, false}, {11, 0, false}, {12, 0, false}, {12, 1, false}, {13, 0, false},
+	})
+}
+
+func TestFollowReportsReorgs(t *testing.T) {
+	chain := &fakeChain{}
+	subscriber := &fakeSubscriber{subs: make(chan fakeSub)}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	got, onLog := collect(3, cancel)
+	f := &LogFollower{Source: chain, Subscriber: subscriber, MaxRange: 100}
+	go func() {
+		s := <-subscriber.subs
+		s.ch <- types.Log{BlockNumber: 5, Index: 0}
+		s.ch <- types.Log{BlockNumber: 5, Index: 0, Removed: true}
+		s.ch <- types.Log{BlockNumber: 5, Index: 0}
+	}()
+	if err := f.Follow(ctx, 5, onLog); err != nil {
+		t.Fatal(err)
+	}
+	checkPositions(t, *got, []position{{5, 0, false}, {5, 0, true}, {5, 0, false}})
+}
+
+func TestFollowStopsOnHandlerErrors(t *testing.T) {
+	chain := &fakeChain{}
+	chain.mine(3, 0, 1)
+	stop := errors.New("enough")
+	f := &LogFollower{Source: chain, MaxRange: 100, PollInterval: time.Millisecond}
+	err := f.Follow(context.Background(), 0, func(l types.Log) error {
+		return stop
+	})
+	if !errors.Is(err, stop) {
+		t.Fatalf("got %v, want the handler's error", err)
+	}
+}
+
+// fakeNodes answers like EthReader: the first node to answer, here the
+// lagging one, unless the follower reads the nodes one by one.
+type fakeNodes struct {
+	*fakeChain
+	nodes []LogSource
+}
+
+func (n fakeNodes) LogSources() []LogSource { return n.nodes }
+
+func TestFollowReadsLogsFromTheNodeOfTheHead(t *testing.T) {
+	synced, lagging := &fakeChain{}, &fakeChain{}
+	synced.mine(10, 0)
+	synced.mine(11, 0)
+	lagging.mine(10, 0)
+	source := fakeNodes{fakeChain: lagging, nodes: []LogSource{lagging, synced}}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	got, onLog := collect(3, cancel)
+	f := &LogFollower{Source: source, MaxRange: 100, PollInterval: time.Millisecond}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		synced.mine(12, 0)
+	}()
+	if err := f.Follow(ctx, 10, onLog); err != nil {
+		t.Fatal(err)
+	}
+	checkPositions(t, *got, []position{{10, 0, false}, {11, 0, false}, {12, 0, false}})
+}
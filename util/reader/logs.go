@@ -0,0 +1,105 @@
+package reader
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// LogFilterer is what PageLogs queries logs with, *EthReader in practice.
+type LogFilterer interface {
+	FilterLogs(q ethereum.FilterQuery) ([]types.Log, error)
+}
+
+// regrowAfter is how many pages in a row have to succeed before PageLogs
+// tries a range that was refused again. How many logs a range holds varies
+// along the chain, so a refusal doesn't mean the range is always too big.
+const regrowAfter = 10
+
+// tooManyResultsErrors are the ways nodes say a log query asks for too much:
+// too many logs, too many blocks or too long to answer.
+var tooManyResultsErrors = []string{
+	"too many results",
+	"too many logs",
+	"query returned more than",
+	"logs matched by query exceeds",
+	"limit exceeded",
+	"block range",
+	"range is too large",
+	"range too large",
+	"response size",
+	"response is too big",
+	"deadline exceeded",
+	"timeout",
+	"-32005",
+}
+
+// IsTooManyResults tells whether err is a node refusing a log query for
+// being too big, so a smaller range could succeed.
+func IsTooManyResults(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	for _, s := range tooManyResultsErrors {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// PageLogs queries the logs matching q from block from to block to, at most
+// maxRange blocks at a time, and calls onPage with the logs of each range in
+// order. The range is halved when a node refuses it for being too big and
+// grows back as pages succeed. q.FromBlock and q.ToBlock are ignored.
+// PageLogs stops at the first error of onPage and returns it.
+func PageLogs(
+	f LogFilterer,
+	q ethereum.FilterQuery,
+	from, to, maxRange uint64,
+	onPage func(from, to uint64, logs []types.Log) error,
+) error {
+	if maxRange == 0 {
+		maxRange = 1
+	}
+	span, ceiling := maxRange, maxRange
+	succeeded := 0
+	for start := from; start <= to; {
+		end := to
+		if to-start >= span {
+			end = start + span - 1
+		}
+		q.FromBlock = new(big.Int).SetUint64(start)
+		q.ToBlock = new(big.Int).SetUint64(end)
+		logs, err := f.FilterLogs(q)
+		if err != nil {
+			if !IsTooManyResults(err) || end == start {
+				return err
+			}
+			span = (end - start + 1) / 2
+			ceiling = span
+			succeeded = 0
+			continue
+		}
+		if err := onPage(start, end, logs); err != nil {
+			return err
+		}
+		if end == to {
+			return nil
+		}
+		start = end + 1
+
+		succeeded++
+		if succeeded >= regrowAfter {
+			ceiling, succeeded = maxRange, 0
+		}
+		span *= 2
+		if span > ceiling {
+			span = ceiling
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package reader
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// rangeLimitedNode refuses queries over more than limit blocks and returns
+// one log per block otherwise.
+type rangeLimitedNode struct {
+	limit   uint64
+	queries [][2]uint64
+}
+
+func (n *rangeLimitedNode) FilterLogs(q ethereum.FilterQuery) ([]types.Log, error) {
+	from, to := q.FromBlock.Uint64(), q.ToBlock.Uint64()
+	n.queries = append(n.queries, [2]uint64{from, to})
+	if to-from+1 > n.limit {
+		return nil, fmt.Errorf("couldn't read from any nodes: node1: query returned more than 10000 results")
+	}
+	logs := []types.Log{}
+	for b := from; b <= to; b++ {
+		logs = append(logs, types.Log{BlockNumber: b})
+	}
+	return logs, nil
+}
+
+func TestPageLogsShrinksAndCoversTheRange(t *testing.T) {
+	node := &rangeLimitedNode{limit: 300}
+	next := uint64(100)
+	err := PageLogs(node, ethereum.FilterQuery{}, 100, 5099, 1000, func(from, to uint64, logs []types.Log) error {
+		if from != next {
+			t.Fatalf("page starts at %d, want %d", from, next)
+		}
+		if to-from+1 > 300 {
+			t.Fatalf("page [%d, %d] is over the node's limit", from, to)
+		}
+		for i, l := range logs {
+			if l.BlockNumber != from+uint64(i) {
+				t.Fatalf("log %d of page [%d, %d] is at block %d", i, from, to, l.BlockNumber)
+			}
+		}
+		next = to + 1
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next != 5100 {
+		t.Fatalf("paged up to %d, want 5099", next-1)
+	}
+	refused := 0
+	for _, q := range node.queries {
+		if q[1]-q[0]+1 > 300 {
+			refused++
+		}
+	}
+	// 1000 -> 500 -> 250 at first, then a retry every regrowAfter pages
+	if refused > 5 {
+		t.Fatalf("%d of %d queries were refused, the range doesn't stay small", refused, len(node.queries))
+	}
+}
+
+func TestPageLogsGrowsBackAfterABusyRange(t *testing.T) {
+	node := &rangeLimitedNode{limit: 1}
+	err := PageLogs(node, ethereum.FilterQuery{}, 0, 99, 4, func(from, to uint64, logs []types.Log) error {
+		// only the first blocks are busy
+		node.limit = 100
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := node.queries[len(node.queries)-1]
+	if last[1]-last[0]+1 != 4 {
+		t.Fatalf("last query %v, want the range to grow back to 4 blocks", last)
+	}
+}
+
+func TestPageLogsStopsOnOtherErrors(t *testing.T) {
+	failing := errors.New("connection refused")
+	f := logFilterFunc(func(q ethereum.FilterQuery) ([]types.Log, error) {
+		return nil, failing
+	})
+	err := PageLogs(f, ethereum.FilterQuery{}, 0, 100, 10, func(from, to uint64, logs []types.Log) error {
+		t.Fatal("no page should be reported")
+		return nil
+	})
+	if !errors.Is(err, failing) {
+		t.Fatalf("got %v, want the node's error", err)
+	}
+}
+
+func TestPageLogsGivesUpOnASingleBlock(t *testing.T) {
+	node := &rangeLimitedNode{limit: 0}
+	err := PageLogs(node, ethereum.FilterQuery{}, 7, 9, 8, func(from, to uint64, logs []types.Log) error {
+		return nil
+	})
+	if !IsTooManyResults(err) {
+		t.Fatalf("got %v, want the node's refusal", err)
+	}
+	if last := node.queries[len(node.queries)-1]; last != [2]uint64{7, 7} {
+		t.Fatalf("last query %v, want a single block", last)
+	}
+}
+
+type logFilterFunc func(q ethereum.FilterQuery) ([]types.Log, error)
+
+func (f logFilterFunc) FilterLogs(q ethereum.FilterQuery) ([]types.Log, error) {
+	return f(q)
+}
+
+func TestIsTooManyResults(t *testing.T) {
+	for _, tc := range []struct {
+		msg  string
+		want bool
+	}{
+		{"query returned more than 10000 results", true},
+		{"Log response size exceeded. You can make eth_getLogs requests with up to a 2K block range", true},
+		{"exceed maximum block range: 5000", true},
+		{"context deadline exceeded", true},
+		{"json: cannot unmarshal", false},
+		{"dial tcp: lookup node: no such host", false},
+	} {
+		if got := IsTooManyResults(errors.New(tc.msg)); got != tc.want {
+			t.Errorf("IsTooManyResults(%q) = %v, want %v", tc.msg, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package reader
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	jarviscommon "github.com/tranvictor/jarvis/common"
+)
+
+func TestMultiCallToleratesFailedCalls(t *testing.T) {
+	mc := NewMultiCall(nil, "0x0000000000000000000000000000000000000000")
+	if mc.Contract() != Multicall3Address {
+		t.Fatalf("batching through %s, want the usual Multicall3", mc.Contract())
+	}
+	erc20 := jarviscommon.GetERC20ABI()
+	wallet := "0x00000000000000000000000000000000000000aa"
+
+	ethBalance, tokenBalance, brokenBalance := big.NewInt(0), big.NewInt(0), big.NewInt(0)
+	var brokenErr error
+	mc.RegisterEthBalance(&ethBalance, FAIL_ON_ERROR_MC_ONE_RESULT_HANDLER, wallet)
+	mc.Register(&tokenBalance, "0x00000000000000000000000000000000000000bb", erc20, "balanceOf", common.HexToAddress(wallet))
+	mc.RegisterWithHook(&brokenBalance, func(result interface{}, err error) error {
+		brokenErr = err
+		return nil
+	}, "0x00000000000000000000000000000000000000cc", erc20, "balanceOf", common.HexToAddress(wallet))
+
+	calls, err := mc.calls()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 4 || calls[0].AllowFailure || calls[0].Target != common.HexToAddress(Multicall3Address) {
+		t.Fatalf("got calls %+v, want getBlockNumber of the multicall first", calls)
+	}
+	for _, c := range calls[1:] {
+		if !c.AllowFailure {
+			t.Fatalf("call %+v isn't allowed to fail", c)
+		}
+	}
+	if calls[1].Target != common.HexToAddress(Multicall3Address) || !strings.HasPrefix(common.Bytes2Hex(calls[1].CallData), "4d2301cc") {
+		t.Fatalf("native balance read with %+v, want getEthBalance", calls[1])
+	}
+
+	word := func(n int64) []byte { return common.BigToHash(big.NewInt(n)).Bytes() }
+	// Error(string) with "no code"
+	revert := append(crypto.Keccak256([]byte("Error(string)"))[:4], append(append(word(32), word(7)...), common.RightPadBytes([]byte("no code"), 32)...)...)
+	returned, err := mc.mcABI.Methods["aggregate3"].Outputs.Pack([]call3Result{
+		{true, word(19000000)},
+		{true, word(5)},
+		{true, word(7)},
+		{false, revert},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, errs, err := mc.unpack(returned)
+	if err != nil || block != 19000000 {
+		t.Fatalf("got block %d, %v", block, err)
+	}
+	if err := mc.handle(errs); err != nil {
+		t.Fatalf("a tolerated failure failed the batch: %s", err)
+	}
+	if ethBalance.Int64() != 5 || tokenBalance.Int64() != 7 || brokenBalance.Sign() != 0 {
+		t.Fatalf("got balances %s, %s, %s", ethBalance, tokenBalance, brokenBalance)
+	}
+	if brokenErr == nil || !strings.Contains(brokenErr.Error(), "no code") {
+		t.Fatalf("the hook got %v, want the revert reason", brokenErr)
+	}
+
+	// calls registered without a hook still fail the batch
+	returned, _ = mc.mcABI.Methods["aggregate3"].Outputs.Pack([]call3Result{
+		{true, word(19000000)},
+		{true, word(5)},
+		{false, nil},
+		{false, nil},
+	})
+	_, errs, err = mc.unpack(returned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mc.handle(errs); err == nil || !strings.Contains(err.Error(), "index 1") {
+		t.Fatalf("got %v, want the failure of the call registered without a hook", err)
+	}
+}
+
+// TestMultiCallFallsBackToMulticall3 reads a batch on a network saved with
+// a Multicall v1, on which aggregate3 reverts.
+func TestMultiCallFallsBackToMulticall3(t *testing.T) {
+	v1 := "0xeefBa1e63905eF1D7ACbA5a8513c70307C1cE441"
+	mcABI := jarviscommon.GetMultiCall3ABI()
+	word := func(n int64) []byte { return common.BigToHash(big.NewInt(n)).Bytes() }
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Method != "eth_call" {
+			t.Errorf("unexpected request %s: %v", req.Method, err)
+			return
+		}
+		var msg struct {
+			To string `json:"to"`
+		}
+		json.Unmarshal(req.Params[0], &msg)
+		w.Header().Set("Content-Type", "application/json")
+		if !strings.EqualFold(msg.To, Multicall3Address) {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"jsonrpc": "2.0", "id": req.ID,
+				"error": map[string]interface{}{"code": 3, "message": "execution reverted"},
+			})
+			return
+		}
+		returned, err := mcABI.Methods["aggregate3"].Outputs.Pack([]call3Result{
+			{true, word(19000000)},
+			{true, word(5)},
+		})
+		if err != nil {
+			t.Error(err)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"jsonrpc": "2.0", "id": req.ID, "result": fmt.Sprintf("0x%x", returned),
+		})
+	}))
+	defer node.Close()
+
+	mc := NewMultiCall(NewEthReaderGeneric(map[string]string{"node": node.URL}, nil), v1)
+	warnings := []string{}
+	mc.Logf = func(format string, args ...interface{}) {
+		warnings = append(warnings, fmt.Sprintf(format, args...))
+	}
+	balance := big.NewInt(0)
+	mc.RegisterEthBalance(&balance, FAIL_ON_ERROR_MC_ONE_RESULT_HANDLER, "0x00000000000000000000000000000000000000aa")
+
+	block, err := mc.Do(-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block != 19000000 || balance.Int64() != 5 {
+		t.Fatalf("got block %d, balance %s", block, balance)
+	}
+	if mc.Contract() != Multicall3Address || !strings.EqualFold(mc.caddrs[0], Multicall3Address) {
+		t.Fatalf("still batching through %s, reading the balance from %s", mc.Contract(), mc.caddrs[0])
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], v1) {
+		t.Fatalf("got warnings %q, want one naming %s", warnings, v1)
+	}
+}
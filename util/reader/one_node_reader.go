@@ -4,12 +4,14 @@ import (
 	"context"
 	"fmt"
 	"math/big"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/ethclient/gethclient"
@@ -40,6 +42,13 @@ func NewOneNodeReader(name, url string) *OneNodeReader {
 	}
 }
 
+// IsWebSocketURL tells whether a node is dialed over ws(s), the only
+// transport jarvis can subscribe to new logs over.
+func IsWebSocketURL(nodeURL string) bool {
+	u := strings.ToLower(strings.TrimSpace(nodeURL))
+	return strings.HasPrefix(u, "ws://") || strings.HasPrefix(u, "wss://")
+}
+
 func (onr *OneNodeReader) NodeName() string {
 	return onr.nodeName
 }
@@ -109,6 +118,86 @@ func (onr *OneNodeReader) EstimateGas(from, to string, priceGwei float64, value
 	}, atBlock)
 }
 
+// EstimateGasWithAccessList estimates the gas of a call that carries the
+// given EIP-2930 access list. A nil list estimates the plain call.
+func (onr *OneNodeReader) EstimateGasWithAccessList(from, to string, value *big.Int, data []byte, al types.AccessList) (uint64, error) {
+	toAddr := common.HexToAddress(to)
+	ethcli, err := onr.EthClient()
+	if err != nil {
+		return 0, err
+	}
+	timeout, cancel := context.WithTimeout(context.Background(), TIMEOUT)
+	defer cancel()
+	return ethcli.EstimateGas(timeout, ethereum.CallMsg{
+		From:       common.HexToAddress(from),
+		To:         &toAddr,
+		Value:      value,
+		Data:       data,
+		AccessList: al,
+	})
+}
+
+// EstimateGasWithCode estimates the gas of a call on the pending state with
+// the code of the given addresses replaced, e.g. to count a delegation its tx
+// sets before the call. Nodes without state overrides in eth_estimateGas
+// error.
+func (onr *OneNodeReader) EstimateGasWithCode(from, to string, value *big.Int, data []byte, codeOverrides map[string][]byte) (uint64, error) {
+	client, err := onr.Client()
+	if err != nil {
+		return 0, err
+	}
+	toAddr := jarviscommon.HexToAddress(to)
+	args := traceCallArgs{
+		From:  jarviscommon.HexToAddress(from),
+		To:    &toAddr,
+		Input: data,
+	}
+	if value != nil {
+		args.Value = (*hexutil.Big)(value)
+	}
+	overrides := map[common.Address]traceOverride{}
+	for addr, code := range codeOverrides {
+		overrides[jarviscommon.HexToAddress(addr)] = traceOverride{Code: code}
+	}
+
+	timeout, cancel := context.WithTimeout(context.Background(), TIMEOUT)
+	defer cancel()
+	var gas hexutil.Uint64
+	if err := client.CallContext(timeout, &gas, "eth_estimateGas", args, "pending", overrides); err != nil {
+		return 0, err
+	}
+	return uint64(gas), nil
+}
+
+// CreateAccessList asks the node for the access list of a call with
+// eth_createAccessList. The gas it returns is the gas used by the call
+// with the list attached.
+func (onr *OneNodeReader) CreateAccessList(from, to string, value *big.Int, data []byte) (types.AccessList, uint64, error) {
+	toAddr := common.HexToAddress(to)
+	gethcli, err := onr.GEthClient()
+	if err != nil {
+		return nil, 0, err
+	}
+	timeout, cancel := context.WithTimeout(context.Background(), TIMEOUT)
+	defer cancel()
+	al, gasUsed, vmErr, err := gethcli.CreateAccessList(timeout, ethereum.CallMsg{
+		From:  common.HexToAddress(from),
+		To:    &toAddr,
+		Value: value,
+		Data:  data,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	if vmErr != "" {
+		return nil, 0, fmt.Errorf("the call fails: %s", vmErr)
+	}
+	if al == nil {
+		return types.AccessList{}, gasUsed, nil
+	}
+	return *al, gasUsed, nil
+}
+
 func (onr *OneNodeReader) GetCode(address string) (code []byte, err error) {
 	addr := common.HexToAddress(address)
 	ethcli, err := onr.EthClient()
@@ -246,6 +335,31 @@ func (onr *OneNodeReader) SuggestedGasTipCap() (*big.Int, error) {
 	return ethcli.SuggestGasTipCap(timeout)
 }
 
+// FeeHistory is eth_feeHistory over the latest blocks blocks.
+func (onr *OneNodeReader) FeeHistory(blocks uint64, percentiles []float64) (*ethereum.FeeHistory, error) {
+	ethcli, err := onr.EthClient()
+	if err != nil {
+		return nil, err
+	}
+
+	timeout, cancel := context.WithTimeout(context.Background(), TIMEOUT)
+	defer cancel()
+
+	return ethcli.FeeHistory(timeout, blocks, nil, percentiles)
+}
+
+func (onr *OneNodeReader) BlobBaseFee() (*big.Int, error) {
+	ethcli, err := onr.EthClient()
+	if err != nil {
+		return nil, err
+	}
+
+	timeout, cancel := context.WithTimeout(context.Background(), TIMEOUT)
+	defer cancel()
+
+	return ethcli.BlobBaseFee(timeout)
+}
+
 func (onr *OneNodeReader) GetLogs(fromBlock, toBlock int, addresses []string, topic string) ([]types.Log, error) {
 	ethcli, err := onr.EthClient()
 	if err != nil {
@@ -270,6 +384,29 @@ func (onr *OneNodeReader) GetLogs(fromBlock, toBlock int, addresses []string, to
 	return ethcli.FilterLogs(timeout, *q)
 }
 
+// SubscribeLogs pushes the logs matching q to ch as blocks are mined. It
+// only works with ws(s) nodes.
+func (onr *OneNodeReader) SubscribeLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	if !IsWebSocketURL(onr.NodeURL()) {
+		return nil, fmt.Errorf("%s isn't a websocket node", onr.nodeName)
+	}
+	ethcli, err := onr.EthClient()
+	if err != nil {
+		return nil, err
+	}
+	return ethcli.SubscribeFilterLogs(ctx, q, ch)
+}
+
+func (onr *OneNodeReader) FilterLogs(q ethereum.FilterQuery) ([]types.Log, error) {
+	ethcli, err := onr.EthClient()
+	if err != nil {
+		return nil, err
+	}
+	timeout, cancel := context.WithTimeout(context.Background(), TIMEOUT)
+	defer cancel()
+	return ethcli.FilterLogs(timeout, q)
+}
+
 func (onr *OneNodeReader) ReadContractToBytes(atBlock int64, from string, caddr string, abi *abi.ABI, method string, args ...interface{}) ([]byte, error) {
 	ethcli, err := onr.EthClient()
 	if err != nil {
@@ -336,6 +473,25 @@ func (onr *OneNodeReader) EthCall(from string, to string, value *big.Int, data [
 	}, big.NewInt(int64(rpc.PendingBlockNumber))) // pending block number is used to call the contract on the pending block
 }
 
+// EthCallAt runs a call on the state at the end of block atBlock, with a
+// gas limit when gas isn't 0. It replays mined txs.
+func (onr *OneNodeReader) EthCallAt(atBlock int64, from string, to string, value *big.Int, gas uint64, data []byte) ([]byte, error) {
+	ethcli, err := onr.EthClient()
+	if err != nil {
+		return nil, err
+	}
+	contract := jarviscommon.HexToAddress(to)
+	timeout, cancel := context.WithTimeout(context.Background(), 4*time.Second)
+	defer cancel()
+	return ethcli.CallContract(timeout, ethereum.CallMsg{
+		From:  jarviscommon.HexToAddress(from),
+		To:    &contract,
+		Gas:   gas,
+		Value: value,
+		Data:  data,
+	}, big.NewInt(atBlock))
+}
+
 func (onr *OneNodeReader) CurrentBlock() (uint64, error) {
 	ethcli, err := onr.EthClient()
 	if err != nil {
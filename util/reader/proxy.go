@@ -0,0 +1,188 @@
+package reader
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	jarviscommon "github.com/tranvictor/jarvis/common"
+)
+
+// ProxyKind is the kind of proxy a contract is, as DetectProxy tells it.
+type ProxyKind string
+
+const (
+	NotProxy        ProxyKind = ""
+	MinimalProxy    ProxyKind = "EIP-1167 minimal proxy"
+	EIP1967Proxy    ProxyKind = "EIP-1967 proxy"
+	BeaconProxy     ProxyKind = "EIP-1967 beacon proxy"
+	ZeppelinOSProxy ProxyKind = "ZeppelinOS proxy"
+	MaticProxy      ProxyKind = "Polygon proxy"
+	SafeProxy       ProxyKind = "Safe proxy"
+	DiamondProxy    ProxyKind = "EIP-2535 diamond"
+)
+
+// Facet is a facet of a diamond and the selectors the diamond routes to it.
+type Facet struct {
+	Address   common.Address
+	Selectors [][4]byte
+}
+
+// ProxyInfo is what a contract delegates its calls to. Diamonds have no
+// single Implementation but one per facet.
+type ProxyInfo struct {
+	Kind           ProxyKind
+	Implementation common.Address
+	// Beacon is the beacon a BeaconProxy reads its implementation from.
+	Beacon common.Address
+	Facets []Facet
+}
+
+func (p *ProxyInfo) IsProxy() bool {
+	return p.Kind != NotProxy
+}
+
+// ProxyReader is what DetectProxy reads a contract with, *EthReader in
+// practice.
+type ProxyReader interface {
+	GetCode(address string) ([]byte, error)
+	StorageAt(atBlock int64, caddr string, slot string) ([]byte, error)
+	ReadContractToBytes(atBlock int64, from string, caddr string, abi *abi.ABI, method string, args ...interface{}) ([]byte, error)
+}
+
+// proxySlot is the storage slot a kind of proxy keeps its implementation
+// at.
+type proxySlot struct {
+	kind ProxyKind
+	slot common.Hash
+}
+
+func minusOne(h common.Hash) common.Hash {
+	return common.BigToHash(big.NewInt(0).Sub(h.Big(), big.NewInt(1)))
+}
+
+var (
+	// bytes32(uint256(keccak256('eip1967.proxy.implementation')) - 1)
+	eip1967ImplementationSlot = minusOne(crypto.Keccak256Hash([]byte("eip1967.proxy.implementation")))
+	// bytes32(uint256(keccak256('eip1967.proxy.beacon')) - 1)
+	eip1967BeaconSlot = minusOne(crypto.Keccak256Hash([]byte("eip1967.proxy.beacon")))
+
+	// legacySlots are checked after the EIP-1967 ones
+	legacySlots = []proxySlot{
+		// old standard: org.zeppelinos.proxy.implementation
+		{ZeppelinOSProxy, crypto.Keccak256Hash([]byte("org.zeppelinos.proxy.implementation"))},
+		// eip 1967 on Poygon
+		{MaticProxy, minusOne(crypto.Keccak256Hash([]byte("matic.network.proxy.implementation")))},
+	}
+)
+
+// DetectProxy tells what kind of proxy the contract at caddr is, from its
+// bytecode and storage: EIP-1167 clones by their code, Safe proxies by
+// their code and the singleton at slot 0, EIP-1967, beacon, ZeppelinOS and
+// Polygon proxies by their implementation slot, and EIP-2535 diamonds by
+// the facets their loupe lists. Anything else, EOAs included, is
+// NotProxy.
+func DetectProxy(r ProxyReader, atBlock int64, caddr string) (*ProxyInfo, error) {
+	code, err := r.GetCode(caddr)
+	if err != nil {
+		return nil, err
+	}
+	if len(code) == 0 {
+		return &ProxyInfo{}, nil
+	}
+	if impl, ok := jarviscommon.MinimalProxyImplementation(code); ok {
+		return &ProxyInfo{Kind: MinimalProxy, Implementation: impl}, nil
+	}
+	if jarviscommon.IsSafeProxyCode(code) {
+		singleton, err := storageAddress(r, atBlock, caddr, common.Hash{})
+		if err != nil {
+			return nil, err
+		}
+		if singleton != (common.Address{}) {
+			return &ProxyInfo{Kind: SafeProxy, Implementation: singleton}, nil
+		}
+	}
+
+	impl, err := storageAddress(r, atBlock, caddr, eip1967ImplementationSlot)
+	if err != nil {
+		return nil, err
+	}
+	if impl != (common.Address{}) {
+		return &ProxyInfo{Kind: EIP1967Proxy, Implementation: impl}, nil
+	}
+	beacon, err := storageAddress(r, atBlock, caddr, eip1967BeaconSlot)
+	if err != nil {
+		return nil, err
+	}
+	if beacon != (common.Address{}) {
+		data, err := r.ReadContractToBytes(
+			atBlock, DEFAULT_ADDRESS, beacon.Hex(),
+			jarviscommon.GetEIP1967BeaconABI(), "implementation",
+		)
+		if err != nil {
+			return nil, err
+		}
+		return &ProxyInfo{
+			Kind:           BeaconProxy,
+			Implementation: common.BytesToAddress(data),
+			Beacon:         beacon,
+		}, nil
+	}
+	for _, s := range legacySlots {
+		impl, err := storageAddress(r, atBlock, caddr, s.slot)
+		if err != nil {
+			return nil, err
+		}
+		if impl != (common.Address{}) {
+			return &ProxyInfo{Kind: s.kind, Implementation: impl}, nil
+		}
+	}
+
+	// a contract without a loupe reverts or answers garbage, neither of
+	// which is an error of the detection
+	if facets, err := diamondFacets(r, atBlock, caddr); err == nil && len(facets) > 0 {
+		return &ProxyInfo{Kind: DiamondProxy, Facets: facets}, nil
+	}
+	return &ProxyInfo{}, nil
+}
+
+func storageAddress(r ProxyReader, atBlock int64, caddr string, slot common.Hash) (common.Address, error) {
+	data, err := r.StorageAt(atBlock, caddr, slot.Hex())
+	if err != nil {
+		return common.Address{}, err
+	}
+	return common.BytesToAddress(data), nil
+}
+
+// diamondFacets lists the facets of a diamond through the facets() of its
+// loupe.
+func diamondFacets(r ProxyReader, atBlock int64, caddr string) ([]Facet, error) {
+	loupe := jarviscommon.GetDiamondLoupeABI()
+	data, err := r.ReadContractToBytes(atBlock, DEFAULT_ADDRESS, caddr, loupe, "facets")
+	if err != nil {
+		return nil, err
+	}
+	var facets []struct {
+		FacetAddress      common.Address
+		FunctionSelectors [][4]byte
+	}
+	if err := loupe.UnpackIntoInterface(&facets, "facets", data); err != nil {
+		return nil, err
+	}
+	result := []Facet{}
+	for _, f := range facets {
+		if f.FacetAddress == (common.Address{}) || len(f.FunctionSelectors) == 0 {
+			continue
+		}
+		result = append(result, Facet{Address: f.FacetAddress, Selectors: f.FunctionSelectors})
+	}
+	return result, nil
+}
+
+// DetectProxy tells what kind of proxy the contract at caddr is, see
+// DetectProxy.
+func (er *EthReader) DetectProxy(atBlock int64, caddr string) (*ProxyInfo, error) {
+	return DetectProxy(er, atBlock, caddr)
+}
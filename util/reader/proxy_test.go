@@ -0,0 +1,121 @@
+package reader
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	jarviscommon "github.com/tranvictor/jarvis/common"
+)
+
+// fakeContracts is a chain of contracts with code, storage and canned
+// answers to calls by method name.
+type fakeContracts struct {
+	code    map[string][]byte
+	storage map[string]map[common.Hash]common.Hash
+	answers map[string]map[string][]byte
+}
+
+func (f *fakeContracts) GetCode(address string) ([]byte, error) {
+	return f.code[strings.ToLower(address)], nil
+}
+
+func (f *fakeContracts) StorageAt(atBlock int64, caddr string, slot string) ([]byte, error) {
+	word := f.storage[strings.ToLower(caddr)][common.HexToHash(slot)]
+	return word.Bytes(), nil
+}
+
+func (f *fakeContracts) ReadContractToBytes(atBlock int64, from string, caddr string, abi *abi.ABI, method string, args ...interface{}) ([]byte, error) {
+	if data, found := f.answers[strings.ToLower(caddr)][method]; found {
+		return data, nil
+	}
+	return nil, errors.New("execution reverted")
+}
+
+var (
+	proxyAddr = "0x00000000000000000000000000000000000000aa"
+	implAddr  = common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	someCode  = hexutil.MustDecode("0x6080604052348015600f57600080fd5b50")
+)
+
+func contractsWith(code []byte, slot common.Hash, value common.Address) *fakeContracts {
+	return &fakeContracts{
+		code: map[string][]byte{proxyAddr: code},
+		storage: map[string]map[common.Hash]common.Hash{
+			proxyAddr: {slot: common.BytesToHash(value.Bytes())},
+		},
+		answers: map[string]map[string][]byte{},
+	}
+}
+
+func TestDetectProxy(t *testing.T) {
+	clone := hexutil.MustDecode("0x363d3d373d3d3d363d7300000000000000000000000000000000000000bb5af43d82803e903d91602b57fd5bf3")
+	safeProxy := hexutil.MustDecode("0x608060405273ffffffffffffffffffffffffffffffffffffffff600054167fa619486e0000000000000000000000000000000000000000000000000000000060003514156050578060005260206000f35b3660008037600080366000845af43d6000803e60008114156070573d6000fd5b3d6000f3")
+	beacon := common.HexToAddress("0x00000000000000000000000000000000000000cc")
+	beaconChain := contractsWith(someCode, eip1967BeaconSlot, beacon)
+	beaconChain.answers[strings.ToLower(beacon.Hex())] = map[string][]byte{
+		"implementation": common.LeftPadBytes(implAddr.Bytes(), 32),
+	}
+
+	for _, tc := range []struct {
+		name  string
+		chain *fakeContracts
+		kind  ProxyKind
+	}{
+		{"clone", contractsWith(clone, common.Hash{}, common.Address{}), MinimalProxy},
+		{"safe", contractsWith(safeProxy, common.Hash{}, implAddr), SafeProxy},
+		{"eip1967", contractsWith(someCode, eip1967ImplementationSlot, implAddr), EIP1967Proxy},
+		{"beacon", beaconChain, BeaconProxy},
+		{"zeppelinos", contractsWith(someCode, legacySlots[0].slot, implAddr), ZeppelinOSProxy},
+	} {
+		info, err := DetectProxy(tc.chain, -1, proxyAddr)
+		if err != nil {
+			t.Fatalf("%s: %s", tc.name, err)
+		}
+		if info.Kind != tc.kind || info.Implementation != implAddr {
+			t.Fatalf("%s: got %q at %s, want %q at %s", tc.name, info.Kind, info.Implementation.Hex(), tc.kind, implAddr.Hex())
+		}
+	}
+
+	info, err := DetectProxy(contractsWith(someCode, common.Hash{}, implAddr), -1, proxyAddr)
+	if err != nil || info.IsProxy() {
+		t.Fatalf("plain contract detected as %q, %v", info.Kind, err)
+	}
+	info, err = DetectProxy(contractsWith(nil, common.Hash{}, common.Address{}), -1, proxyAddr)
+	if err != nil || info.IsProxy() {
+		t.Fatalf("EOA detected as %q, %v", info.Kind, err)
+	}
+}
+
+func TestDetectDiamond(t *testing.T) {
+	type facet struct {
+		FacetAddress      common.Address
+		FunctionSelectors [][4]byte
+	}
+	facetA := common.HexToAddress("0x000000000000000000000000000000000000000a")
+	facetB := common.HexToAddress("0x000000000000000000000000000000000000000b")
+	data, err := jarviscommon.GetDiamondLoupeABI().Methods["facets"].Outputs.Pack([]facet{
+		{facetA, [][4]byte{{0xa9, 0x05, 0x9c, 0xbb}, {0x70, 0xa0, 0x82, 0x31}}},
+		{facetB, [][4]byte{{0x7a, 0x0e, 0xd6, 0x27}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain := contractsWith(someCode, common.Hash{}, common.Address{})
+	chain.answers[proxyAddr] = map[string][]byte{"facets": data}
+
+	info, err := DetectProxy(chain, -1, proxyAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Kind != DiamondProxy || len(info.Facets) != 2 {
+		t.Fatalf("got %q with %d facets, want a diamond with 2", info.Kind, len(info.Facets))
+	}
+	if info.Facets[0].Address != facetA || len(info.Facets[0].Selectors) != 2 || info.Facets[1].Selectors[0] != [4]byte{0x7a, 0x0e, 0xd6, 0x27} {
+		t.Fatalf("unexpected facets %+v", info.Facets)
+	}
+}
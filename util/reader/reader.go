@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"math/big"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -25,13 +28,22 @@ type Reader interface {
 	TxInfoFromHash(tx string) (jarviscommon.TxInfo, error)
 	RecommendedGasPrice() (float64, error)
 	GetMinedNonce(address string) (nonce uint64, err error)
+	GetPendingNonce(address string) (nonce uint64, err error)
 	GetSuggestedGasTipCap() (float64, error)
 	CheckDynamicFeeTxAvailable() (bool, error)
+	CheckBlobTxAvailable() (bool, error)
+	RecommendedBlobGasFeeCap() (*big.Int, error)
 	EstimateExactGas(from, to string, priceGwei float64, value *big.Int, data []byte) (uint64, error)
 	EstimateGas(from, to string, priceGwei, value float64, data []byte) (uint64, error)
+	CreateAccessList(from, to string, value *big.Int, data []byte) (types.AccessList, uint64, error)
+	EstimateGasWithAccessList(from, to string, value *big.Int, data []byte, al types.AccessList) (uint64, error)
+	EstimateGasWithCode(from, to string, value *big.Int, data []byte, codeOverrides map[string][]byte) (uint64, error)
 	GetBalance(address string) (balance *big.Int, err error)
+	GetCode(address string) (code []byte, err error)
 	ERC20Balance(caddr string, user string) (*big.Int, error)
 	ERC20Decimal(caddr string) (uint64, error)
+	ERC20Symbol(caddr string) (string, error)
+	ERC20Allowance(caddr string, owner string, spender string) (*big.Int, error)
 	ReadContractToBytes(atBlock int64, from string, caddr string, abi *abi.ABI, method string, args ...interface{}) ([]byte, error)
 }
 
@@ -44,6 +56,16 @@ const (
 type EthReader struct {
 	nodes map[string]EthereumNode
 	be    jarvisnetworks.BlockExplorer
+
+	// Speed is the tier of the fee suggestions RecommendedGasPrice and
+	// GetSuggestedGasTipCap return, the normal one when it is empty.
+	Speed GasSpeed
+
+	// fees caches the latest fee suggestions: a command asks for the gas
+	// price and the tip separately.
+	feesMu sync.Mutex
+	fees   *FeeSuggestions
+	feesAt time.Time
 }
 
 func NewEthReaderGeneric(nodes map[string]string, be jarvisnetworks.BlockExplorer) *EthReader {
@@ -106,6 +128,102 @@ func (er *EthReader) EstimateGas(
 	return er.EstimateExactGas(from, to, priceGwei, jarviscommon.FloatToBigInt(value, 18), data)
 }
 
+// EstimateGasWithAccessList is EstimateExactGas for a call carrying an
+// EIP-2930 access list.
+func (er *EthReader) EstimateGasWithAccessList(
+	from, to string,
+	value *big.Int,
+	data []byte,
+	al types.AccessList,
+) (uint64, error) {
+	resCh := make(chan estimateGasResult, len(er.nodes))
+	for i := range er.nodes {
+		n := er.nodes[i]
+		go func() {
+			gas, err := n.EstimateGasWithAccessList(from, to, value, data, al)
+			resCh <- estimateGasResult{
+				Gas:   gas,
+				Error: wrapError(err, n.NodeName()),
+			}
+		}()
+	}
+	errs := []error{}
+	for i := 0; i < len(er.nodes); i++ {
+		result := <-resCh
+		if result.Error == nil {
+			return result.Gas, result.Error
+		}
+		errs = append(errs, result.Error)
+	}
+	return 0, fmt.Errorf("couldn't read from any nodes: %w", errors.Join(errs...))
+}
+
+// EstimateGasWithCode is EstimateExactGas with the code of the given
+// addresses replaced for the call.
+func (er *EthReader) EstimateGasWithCode(
+	from, to string,
+	value *big.Int,
+	data []byte,
+	codeOverrides map[string][]byte,
+) (uint64, error) {
+	resCh := make(chan estimateGasResult, len(er.nodes))
+	for i := range er.nodes {
+		n := er.nodes[i]
+		go func() {
+			gas, err := n.EstimateGasWithCode(from, to, value, data, codeOverrides)
+			resCh <- estimateGasResult{
+				Gas:   gas,
+				Error: wrapError(err, n.NodeName()),
+			}
+		}()
+	}
+	errs := []error{}
+	for i := 0; i < len(er.nodes); i++ {
+		result := <-resCh
+		if result.Error == nil {
+			return result.Gas, result.Error
+		}
+		errs = append(errs, result.Error)
+	}
+	return 0, fmt.Errorf("couldn't read from any nodes: %w", errors.Join(errs...))
+}
+
+type createAccessListResponse struct {
+	AccessList types.AccessList
+	GasUsed    uint64
+	Error      error
+}
+
+// CreateAccessList returns the EIP-2930 access list of a call, as computed
+// by eth_createAccessList, and the gas the call uses with it.
+func (er *EthReader) CreateAccessList(
+	from, to string,
+	value *big.Int,
+	data []byte,
+) (types.AccessList, uint64, error) {
+	resCh := make(chan createAccessListResponse, len(er.nodes))
+	for i := range er.nodes {
+		n := er.nodes[i]
+		go func() {
+			al, gasUsed, err := n.CreateAccessList(from, to, value, data)
+			resCh <- createAccessListResponse{
+				AccessList: al,
+				GasUsed:    gasUsed,
+				Error:      wrapError(err, n.NodeName()),
+			}
+		}()
+	}
+	errs := []error{}
+	for i := 0; i < len(er.nodes); i++ {
+		result := <-resCh
+		if result.Error == nil {
+			return result.AccessList, result.GasUsed, result.Error
+		}
+		errs = append(errs, result.Error)
+	}
+	return nil, 0, fmt.Errorf("couldn't read from any nodes: %w", errors.Join(errs...))
+}
+
 type getCodeResponse struct {
 	Code  []byte
 	Error error
@@ -134,6 +252,12 @@ func (er *EthReader) GetCode(address string) (code []byte, err error) {
 	return nil, fmt.Errorf("couldn't read from any nodes: %w", errors.Join(errs...))
 }
 
+// ErrTxNotFound is returned by TxInfoFromHash when every node answered and
+// none knows the tx: it was never broadcasted or it was dropped.
+var ErrTxNotFound = errors.New("none of the nodes knows the tx")
+
+// TxInfoFromHash returns the tx of hash tx and its receipt once it is mined.
+// A tx no node knows has the status "notfound" and ErrTxNotFound.
 func (er *EthReader) TxInfoFromHash(tx string) (jarviscommon.TxInfo, error) {
 	txObj, isPending, err := er.TransactionByHash(tx)
 
@@ -151,7 +275,7 @@ func (er *EthReader) TxInfoFromHash(tx string) (jarviscommon.TxInfo, error) {
 			Tx:          nil,
 			InternalTxs: []jarviscommon.InternalTx{},
 			Receipt:     nil,
-		}, nil
+		}, fmt.Errorf("%w: %s", ErrTxNotFound, tx)
 	}
 	if isPending {
 		return jarviscommon.TxInfo{
@@ -347,6 +471,8 @@ type transactionByHashResponse struct {
 	Error     error
 }
 
+// TransactionByHash returns the tx from the first node that knows it. A tx
+// that no node knows is returned nil without an error.
 func (er *EthReader) TransactionByHash(
 	txHash string,
 ) (tx *jarviscommon.Transaction, isPending bool, err error) {
@@ -364,12 +490,18 @@ func (er *EthReader) TransactionByHash(
 	}
 
 	errs := []error{}
+	notFound := true
 	for i := 0; i < len(er.nodes); i++ {
 		result := <-resCh
 		if result.Error == nil {
 			return result.Tx, result.IsPending, result.Error
 		}
 		errs = append(errs, result.Error)
+		notFound = notFound && errors.Is(result.Error, ethereum.NotFound)
+	}
+	if notFound {
+		// every node answered and none knows the tx
+		return nil, false, nil
 	}
 	return nil, false, fmt.Errorf("couldn't read from any nodes: %w", errors.Join(errs...))
 }
@@ -432,6 +564,31 @@ func (er *EthReader) EthCall(from string, to string, value *big.Int, data []byte
 	return nil, fmt.Errorf("couldn't read from any nodes: %w", errors.Join(errs...))
 }
 
+// EthCallAt runs a call on the state at the end of block atBlock. Replaying
+// a mined tx at the block before its own reproduces its revert.
+func (er *EthReader) EthCallAt(atBlock int64, from string, to string, value *big.Int, gas uint64, data []byte) ([]byte, error) {
+	resCh := make(chan readContractToBytesResponse, len(er.nodes))
+	for i := range er.nodes {
+		n := er.nodes[i]
+		go func() {
+			data, err := n.EthCallAt(atBlock, from, to, value, gas, data)
+			resCh <- readContractToBytesResponse{
+				Data:  data,
+				Error: wrapError(err, n.NodeName()),
+			}
+		}()
+	}
+	errs := []error{}
+	for i := 0; i < len(er.nodes); i++ {
+		result := <-resCh
+		if result.Error == nil {
+			return result.Data, result.Error
+		}
+		errs = append(errs, result.Error)
+	}
+	return nil, fmt.Errorf("couldn't read from any nodes: %w", errors.Join(errs...))
+}
+
 func (er *EthReader) ImplementationOfEIP1967(
 	atBlock int64,
 	caddr string,
@@ -480,39 +637,15 @@ func (er *EthReader) ImplementationOfEIP1967(
 	return common.Address{}, fmt.Errorf("not an eip1967 proxy contract")
 }
 
+// ImplementationOf returns the implementation the proxy at caddr delegates
+// to, the zero address for contracts that aren't proxies and for diamonds,
+// which delegate to a facet per selector. See DetectProxy.
 func (er *EthReader) ImplementationOf(atBlock int64, caddr string) (common.Address, error) {
-	addr, err := er.ImplementationOfEIP1967(atBlock, caddr)
-	if err == nil {
-		return addr, nil
-	}
-
-	// old standard: org.zeppelinos.proxy.implementation
-	slotBig := crypto.Keccak256Hash([]byte("org.zeppelinos.proxy.implementation")).Big()
-
-	addrByte, err := er.StorageAt(atBlock, caddr, common.BigToHash(slotBig).Hex())
-	if err != nil {
-		return common.Address{}, err
-	}
-
-	addr = common.BytesToAddress(addrByte)
-
-	if addr.Big().Cmp(big.NewInt(0)) != 0 {
-		return addr, nil
-	}
-
-	// eip 1967 on Poygon
-	// bytes32(uint256(keccak256('matic.network.proxy.implementation')) - 1)
-	slotBig = big.NewInt(0).Sub(
-		crypto.Keccak256Hash([]byte("matic.network.proxy.implementation")).Big(),
-		big.NewInt(1),
-	)
-
-	addrByte, err = er.StorageAt(atBlock, caddr, common.BigToHash(slotBig).Hex())
+	info, err := er.DetectProxy(atBlock, caddr)
 	if err != nil {
 		return common.Address{}, err
 	}
-
-	return common.BytesToAddress(addrByte), nil
+	return info.Implementation, nil
 }
 
 func (er *EthReader) StorageAt(atBlock int64, caddr string, slot string) ([]byte, error) {
@@ -684,6 +817,8 @@ func (er *EthReader) HeaderByNumber(number int64) (*types.Header, error) {
 	return nil, fmt.Errorf("couldn't read from any nodes: %w", errors.Join(errs...))
 }
 
+// SuggestedGasSettings returns the max fee and the tip of the Speed tier
+// of the fee suggestions. The tip is 0 on networks without dynamic fee txs.
 func (er *EthReader) SuggestedGasSettings() (maxGasPriceGwei, maxTipGwei float64, err error) {
 	isDynamicFeeAvailable, err := er.CheckDynamicFeeTxAvailable()
 	if err != nil {
@@ -705,6 +840,57 @@ func (er *EthReader) SuggestedGasSettings() (maxGasPriceGwei, maxTipGwei float64
 	return maxGasPriceGwei, maxTipGwei, nil
 }
 
+type feeHistoryResponse struct {
+	History *ethereum.FeeHistory
+	Error   error
+}
+
+// FeeHistory returns the eth_feeHistory of the latest blocks blocks with
+// the rewards at percentiles.
+func (er *EthReader) FeeHistory(blocks uint64, percentiles []float64) (*ethereum.FeeHistory, error) {
+	resCh := make(chan feeHistoryResponse, len(er.nodes))
+	for i := range er.nodes {
+		n := er.nodes[i]
+		go func() {
+			history, err := n.FeeHistory(blocks, percentiles)
+			resCh <- feeHistoryResponse{
+				History: history,
+				Error:   wrapError(err, n.NodeName()),
+			}
+		}()
+	}
+	errs := []error{}
+	for i := 0; i < len(er.nodes); i++ {
+		result := <-resCh
+		if result.Error == nil {
+			return result.History, result.Error
+		}
+		errs = append(errs, result.Error)
+	}
+	return nil, fmt.Errorf("couldn't read from any nodes: %w", errors.Join(errs...))
+}
+
+// SuggestedFees returns the slow, normal and fast fee suggestions computed
+// from the fee history of the latest blocks.
+func (er *EthReader) SuggestedFees() (FeeSuggestions, error) {
+	er.feesMu.Lock()
+	defer er.feesMu.Unlock()
+
+	if er.fees != nil && time.Since(er.feesAt) < 10*time.Second {
+		return *er.fees, nil
+	}
+	history, err := er.FeeHistory(feeHistoryBlocks, feeHistoryPercentiles)
+	if err != nil {
+		return FeeSuggestions{}, err
+	}
+	fees, err := SuggestFees(history)
+	if err != nil {
+		return FeeSuggestions{}, err
+	}
+	er.fees, er.feesAt = &fees, time.Now()
+	return fees, nil
+}
+
 // CheckDynamicFeeTxAvailable use to detect if current network that connect via node url is support dynamic fee tx,
 // this is done by a trick where we check if block info contain baseFee > 0, that may not always work but should enough
 // for now.
@@ -717,14 +903,31 @@ func (er *EthReader) CheckDynamicFeeTxAvailable() (bool, error) {
 	return header.BaseFee != nil && header.BaseFee.Cmp(common.Big0) > 0, nil
 }
 
+// CheckBlobTxAvailable detects if the network accepts EIP-4844 blob txs by
+// checking that the latest header carries the excess blob gas field.
+func (er *EthReader) CheckBlobTxAvailable() (bool, error) {
+	header, err := er.HeaderByNumber(-1)
+	if err != nil {
+		return false, err
+	}
+
+	return header.ExcessBlobGas != nil, nil
+}
+
 type getSuggestedGasResponse struct {
 	Gas   *big.Int
 	Error error
 }
 
-// add 20% tip to miners compared to what returned from the node to improve UX
-// a bit more
+// GetSuggestedGasTipCap returns the tip of the Speed tier of the fee
+// suggestions. When the node has no fee history it falls back to the
+// node's eth_maxPriorityFeePerGas plus 20% to improve UX a bit more.
 func (er *EthReader) GetSuggestedGasTipCap() (float64, error) {
+	fees, feesErr := er.SuggestedFees()
+	if feesErr == nil {
+		return jarviscommon.BigToFloat(fees.Tier(er.Speed).TipCap, 9), nil
+	}
+
 	resCh := make(chan getSuggestedGasResponse, len(er.nodes))
 	for i := range er.nodes {
 		n := er.nodes[i]
@@ -737,7 +940,7 @@ func (er *EthReader) GetSuggestedGasTipCap() (float64, error) {
 		}()
 	}
 
-	errs := []error{}
+	errs := []error{fmt.Errorf("fee history: %w", feesErr)}
 	for i := 0; i < len(er.nodes); i++ {
 		result := <-resCh
 		if result.Error == nil {
@@ -748,9 +951,17 @@ func (er *EthReader) GetSuggestedGasTipCap() (float64, error) {
 	return 0, fmt.Errorf("couldn't read from any nodes: %w", errors.Join(errs...))
 }
 
-// add 50% to max gas price because the next blocks based price can be increased
-// according to ethereum protocol
+// RecommendedGasPrice returns the max fee (the gas price on networks without
+// base fee) of the Speed tier of the fee suggestions. When the node has no
+// fee history it falls back to the node's eth_gasPrice plus 50%, because the
+// next blocks based price can be increased according to ethereum protocol,
+// then to the gas oracle of the block explorer.
 func (er *EthReader) RecommendedGasPrice() (float64, error) {
+	fees, feesErr := er.SuggestedFees()
+	if feesErr == nil {
+		return jarviscommon.BigToFloat(fees.Tier(er.Speed).MaxFee, 9), nil
+	}
+
 	resCh := make(chan getSuggestedGasResponse, len(er.nodes))
 	for i := range er.nodes {
 		n := er.nodes[i]
@@ -763,7 +974,7 @@ func (er *EthReader) RecommendedGasPrice() (float64, error) {
 		}()
 	}
 
-	errs := []error{}
+	errs := []error{fmt.Errorf("fee history: %w", feesErr)}
 	for i := 0; i < len(er.nodes); i++ {
 		result := <-resCh
 		if result.Error == nil {
@@ -771,9 +982,56 @@ func (er *EthReader) RecommendedGasPrice() (float64, error) {
 		}
 		errs = append(errs, result.Error)
 	}
+	if er.be != nil {
+		gasPrice, err := er.be.RecommendedGasPrice()
+		if err == nil {
+			return gasPrice, nil
+		}
+		errs = append(errs, err)
+	}
 	return 0, fmt.Errorf("couldn't read from any nodes: %w", errors.Join(errs...))
 }
 
+// GetBlobBaseFee returns the blob base fee (in wei) of the next block.
+func (er *EthReader) GetBlobBaseFee() (*big.Int, error) {
+	resCh := make(chan getSuggestedGasResponse, len(er.nodes))
+	for i := range er.nodes {
+		n := er.nodes[i]
+		go func() {
+			fee, err := n.BlobBaseFee()
+			resCh <- getSuggestedGasResponse{
+				Gas:   fee,
+				Error: wrapError(err, n.NodeName()),
+			}
+		}()
+	}
+
+	errs := []error{}
+	for i := 0; i < len(er.nodes); i++ {
+		result := <-resCh
+		if result.Error == nil {
+			return result.Gas, result.Error
+		}
+		errs = append(errs, result.Error)
+	}
+	return nil, fmt.Errorf("couldn't read from any nodes: %w", errors.Join(errs...))
+}
+
+// double the blob base fee for max fee per blob gas, the blob base fee can
+// rise up to 12.5% per block so this leaves room for several blocks.
+// Never goes below 1 wei, which is the protocol's minimum.
+func (er *EthReader) RecommendedBlobGasFeeCap() (*big.Int, error) {
+	fee, err := er.GetBlobBaseFee()
+	if err != nil {
+		return nil, err
+	}
+	fee = new(big.Int).Mul(fee, big.NewInt(2))
+	if fee.Sign() == 0 {
+		fee.SetInt64(1)
+	}
+	return fee, nil
+}
+
 func (er *EthReader) HistoryERC20Allowance(
 	atBlock int64,
 	caddr string,
@@ -866,6 +1124,31 @@ func (er *EthReader) GetLogs(
 	return nil, fmt.Errorf("couldn't read from any nodes: %w", errors.Join(errs...))
 }
 
+// FilterLogs queries the logs matching q, which can filter on several topics
+// unlike GetLogs.
+func (er *EthReader) FilterLogs(q ethereum.FilterQuery) ([]types.Log, error) {
+	resCh := make(chan getLogsResponse, len(er.nodes))
+	for i := range er.nodes {
+		n := er.nodes[i]
+		go func() {
+			logs, err := n.FilterLogs(q)
+			resCh <- getLogsResponse{
+				Logs:  logs,
+				Error: wrapError(err, n.NodeName()),
+			}
+		}()
+	}
+	errs := []error{}
+	for i := 0; i < len(er.nodes); i++ {
+		result := <-resCh
+		if result.Error == nil {
+			return result.Logs, result.Error
+		}
+		errs = append(errs, result.Error)
+	}
+	return nil, fmt.Errorf("couldn't read from any nodes: %w", errors.Join(errs...))
+}
+
 type getBlockResponse struct {
 	Block uint64
 	Error error
@@ -1,8 +1,10 @@
 package reader
 
 import (
+	"errors"
 	"fmt"
 	"math/big"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
@@ -10,9 +12,24 @@ import (
 	jarviscommon "github.com/tranvictor/jarvis/common"
 )
 
-var DO_NOTHING_MC_ONE_RESULT_HANDLER MCOneResultHandler = func(result interface{}) error { return nil }
+// Multicall3Address is where Multicall3 is deployed on nearly every EVM
+// chain. It is used on the networks that don't configure a multicall
+// contract.
+const Multicall3Address = "0xcA11bde05977b3631167028862bE2a173976CA11"
 
-type MCOneResultHandler func(result interface{}) error
+// DO_NOTHING_MC_ONE_RESULT_HANDLER ignores the result of a call, and its
+// failure too.
+var DO_NOTHING_MC_ONE_RESULT_HANDLER MCOneResultHandler = func(result interface{}, err error) error { return nil }
+
+// FAIL_ON_ERROR_MC_ONE_RESULT_HANDLER makes the whole batch fail when the
+// call does. Calls registered without a hook get it.
+var FAIL_ON_ERROR_MC_ONE_RESULT_HANDLER MCOneResultHandler = func(result interface{}, err error) error { return err }
+
+// MCOneResultHandler is called with the result of one call of a batch once
+// the batch is done. err is why the call failed, reverted or returned what
+// couldn't be unpacked, and the result is left untouched then. Returning an
+// error fails the batch.
+type MCOneResultHandler func(result interface{}, err error) error
 
 type MultipleCall struct {
 	r        *EthReader
@@ -24,22 +41,38 @@ type MultipleCall struct {
 	methods  []string
 	argLists [][]interface{}
 	hooks    []MCOneResultHandler
+
+	// Logf, when set, warns that the configured contract isn't a
+	// Multicall3 and Multicall3Address is used instead.
+	Logf func(format string, args ...interface{})
 }
 
+// NewMultiCall batches calls through the Multicall3 at mcContract, or at
+// Multicall3Address when it is empty or the zero address.
 func NewMultiCall(r *EthReader, mcContract string) *MultipleCall {
+	if mcContract == "" || common.HexToAddress(mcContract) == (common.Address{}) {
+		mcContract = Multicall3Address
+	}
 	return &MultipleCall{
 		r,
 		mcContract,
-		jarviscommon.GetMultiCallABI(),
+		jarviscommon.GetMultiCall3ABI(),
 		[]interface{}{},
 		[]string{},
 		[]*abi.ABI{},
 		[]string{},
 		[][]interface{}{},
 		[]MCOneResultHandler{},
+		nil,
 	}
 }
 
+// Contract is the Multicall3 the calls are batched through, which also
+// answers getEthBalance for native balances.
+func (mc *MultipleCall) Contract() string {
+	return mc.contract
+}
+
 func (mc *MultipleCall) RegisterWithHook(
 	result interface{},
 	hook MCOneResultHandler,
@@ -66,7 +99,7 @@ func (mc *MultipleCall) Register(
 ) *MultipleCall {
 	return mc.RegisterWithHook(
 		result,
-		DO_NOTHING_MC_ONE_RESULT_HANDLER,
+		FAIL_ON_ERROR_MC_ONE_RESULT_HANDLER,
 		caddr,
 		abi,
 		method,
@@ -74,67 +107,170 @@ func (mc *MultipleCall) Register(
 	)
 }
 
-type multicallres struct {
-	BlockNumber *big.Int
-	ReturnData  [][]byte
+// RegisterEthBalance registers reading the native balance of account
+// through getEthBalance of the multicall contract.
+func (mc *MultipleCall) RegisterEthBalance(
+	result **big.Int,
+	hook MCOneResultHandler,
+	account string,
+) *MultipleCall {
+	return mc.RegisterWithHook(
+		result,
+		hook,
+		mc.contract,
+		mc.mcABI,
+		"getEthBalance",
+		jarviscommon.HexToAddress(account),
+	)
 }
 
-type call struct {
-	Target   common.Address
-	CallData []byte
+type call3 struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
 }
 
-func (mc *MultipleCall) callMCContract(atBlock int64) (block int64, err error) {
-	res := multicallres{}
+type call3Result struct {
+	Success    bool
+	ReturnData []byte
+}
 
-	calls := []call{}
+// calls packs the registered calls for aggregate3, after a getBlockNumber
+// call telling the block they are read at. Every registered call is allowed
+// to fail.
+func (mc *MultipleCall) calls() ([]call3, error) {
+	blockNumber, err := mc.mcABI.Pack("getBlockNumber")
+	if err != nil {
+		return nil, err
+	}
+	calls := []call3{{common.HexToAddress(mc.contract), false, blockNumber}}
 	for i, caddr := range mc.caddrs {
 		data, err := mc.abis[i].Pack(mc.methods[i], mc.argLists[i]...)
 		if err != nil {
-			return 0, err
+			return nil, fmt.Errorf("packing call index %d failed: %w", i, err)
+		}
+		calls = append(calls, call3{jarviscommon.HexToAddress(caddr), true, data})
+	}
+	return calls, nil
+}
+
+// unpack reads what aggregate3 returned into the registered results and
+// returns the block and why each call failed, if it did.
+func (mc *MultipleCall) unpack(returned []byte) (block int64, errs []error, err error) {
+	results := []call3Result{}
+	if err := mc.mcABI.UnpackIntoInterface(&results, "aggregate3", returned); err != nil {
+		return 0, nil, fmt.Errorf("unpacking aggregate3 failed: %w", err)
+	}
+	if len(results) != len(mc.results)+1 {
+		return 0, nil, fmt.Errorf("aggregate3 returned %d results for %d calls", len(results), len(mc.results)+1)
+	}
+	blockNumber := big.NewInt(0)
+	if err := mc.mcABI.UnpackIntoInterface(&blockNumber, "getBlockNumber", results[0].ReturnData); err != nil {
+		return 0, nil, fmt.Errorf("unpacking getBlockNumber failed: %w", err)
+	}
+
+	errs = make([]error, len(mc.results))
+	for i, result := range results[1:] {
+		if !result.Success {
+			errs[i] = fmt.Errorf("%s of %s reverted: %s", mc.methods[i], mc.caddrs[i], revertReason(result.ReturnData))
+			continue
+		}
+		if err := mc.abis[i].UnpackIntoInterface(mc.results[i], mc.methods[i], result.ReturnData); err != nil {
+			errs[i] = fmt.Errorf("unpacking %s of %s failed: %w", mc.methods[i], mc.caddrs[i], err)
+		}
+	}
+	return blockNumber.Int64(), errs, nil
+}
+
+func revertReason(data []byte) string {
+	if len(data) == 0 {
+		return "no reason"
+	}
+	if reason, err := abi.UnpackRevert(data); err == nil {
+		return reason
+	}
+	return fmt.Sprintf("0x%x", data)
+}
+
+// callMCContract reads the batch through mc.contract. When aggregate3
+// fails on a contract other than Multicall3Address, e.g. a Multicall v1 a
+// network was saved with, the batch is read through Multicall3Address
+// instead, which is kept for the later batches.
+func (mc *MultipleCall) callMCContract(atBlock int64) (block int64, errs []error, err error) {
+	block, errs, err = mc.aggregate3(atBlock)
+	if err == nil || strings.EqualFold(mc.contract, Multicall3Address) {
+		return block, errs, err
+	}
+	configured := mc.contract
+	mc.retarget(Multicall3Address)
+	block, errs, fallbackErr := mc.aggregate3(atBlock)
+	if fallbackErr != nil {
+		mc.retarget(configured)
+		return 0, nil, fmt.Errorf(
+			"%w (the multicall contract %s may not be a Multicall3, and %s failed too: %s)",
+			err, configured, Multicall3Address, fallbackErr,
+		)
+	}
+	mc.logf(
+		"the multicall contract %s of the network isn't a Multicall3 (%s), using %s instead. Set the network's multicall contract to a Multicall3 to stop this warning.",
+		configured, err, Multicall3Address,
+	)
+	return block, errs, nil
+}
+
+// retarget batches through contract from now on, reading the native
+// balances registered so far through it too.
+func (mc *MultipleCall) retarget(contract string) {
+	for i, caddr := range mc.caddrs {
+		if mc.abis[i] == mc.mcABI && strings.EqualFold(caddr, mc.contract) {
+			mc.caddrs[i] = contract
 		}
+	}
+	mc.contract = contract
+}
 
-		calls = append(calls, call{jarviscommon.HexToAddress(caddr), data})
+func (mc *MultipleCall) logf(format string, args ...interface{}) {
+	if mc.Logf != nil {
+		mc.Logf(format, args...)
 	}
+}
 
-	err = mc.r.ReadHistoryContractWithABI(
+func (mc *MultipleCall) aggregate3(atBlock int64) (block int64, errs []error, err error) {
+	calls, err := mc.calls()
+	if err != nil {
+		return 0, nil, err
+	}
+	returned, err := mc.r.ReadContractToBytes(
 		atBlock,
-		&res,
+		DEFAULT_ADDRESS,
 		mc.contract,
 		mc.mcABI,
-		"aggregate",
+		"aggregate3",
 		calls,
 	)
-
 	if err != nil {
-		return 0, fmt.Errorf("reading mc.aggregate failed: %w", err)
+		return 0, nil, fmt.Errorf("reading mc.aggregate3 failed: %w", err)
 	}
-
-	for i := range mc.results {
-		err = mc.abis[i].UnpackIntoInterface(
-			mc.results[i],
-			mc.methods[i],
-			res.ReturnData[i],
-		)
-		if err != nil {
-			return 0, fmt.Errorf("unpacking call index %d failed: %w", i, err)
-		}
-	}
-	return res.BlockNumber.Int64(), nil
+	return mc.unpack(returned)
 }
 
+// Do reads every registered call in one aggregate3 call at atBlock, -1
+// for the latest block, and hands the results to their hooks. A call
+// failing only fails the batch when its hook says so.
 func (mc *MultipleCall) Do(atBlock int64) (block int64, err error) {
-	block, err = mc.callMCContract(atBlock)
+	block, errs, err := mc.callMCContract(atBlock)
 	if err != nil {
 		return 0, fmt.Errorf("calling mc contract failed: %w", err)
 	}
+	return block, mc.handle(errs)
+}
 
+func (mc *MultipleCall) handle(errs []error) error {
+	failed := []error{}
 	for i, result := range mc.results {
-		err = mc.hooks[i](result)
-		if err != nil {
-			return 0, fmt.Errorf("calling hook at index %d failed: %w", i, err)
+		if err := mc.hooks[i](result, errs[i]); err != nil {
+			failed = append(failed, fmt.Errorf("calling hook at index %d failed: %w", i, err))
 		}
 	}
-
-	return block, nil
+	return errors.Join(failed...)
 }
@@ -0,0 +1,39 @@
+package reader
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestTxInfoFromHashUnknownTx makes sure a tx no node knows is an error, so
+// callers checking only the error never read its nil Tx.
+func TestTxInfoFromHashUnknownTx(t *testing.T) {
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("bad request: %s", err)
+			return
+		}
+		if req.Method != "eth_getTransactionByHash" {
+			t.Errorf("unexpected call to %s", req.Method)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": nil})
+	}))
+	defer node.Close()
+
+	r := NewEthReaderGeneric(map[string]string{"a": node.URL, "b": node.URL + "/"}, nil)
+	info, err := r.TxInfoFromHash("0x5a2f0c6e4a1b7c8d9e0f1a2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c4d5e6f")
+	if !errors.Is(err, ErrTxNotFound) {
+		t.Fatalf("got %v, want ErrTxNotFound", err)
+	}
+	if info.Status != "notfound" {
+		t.Fatalf("status = %s, want notfound", info.Status)
+	}
+}
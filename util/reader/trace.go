@@ -0,0 +1,157 @@
+package reader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	jarviscommon "github.com/tranvictor/jarvis/common"
+)
+
+// ErrTracingNotSupported is returned by TraceCall when none of the nodes
+// serves debug_traceCall.
+var ErrTracingNotSupported = errors.New("the nodes don't support debug_traceCall")
+
+// traceTimeout is longer than TIMEOUT: tracing a call replays it opcode by
+// opcode.
+const traceTimeout = 20 * time.Second
+
+// CallFrame is a call traced by the callTracer of debug_traceCall, with its
+// sub-calls and the logs it emitted. A frame with Error reverted, and so
+// did all its sub-calls.
+type CallFrame struct {
+	Type   string         `json:"type"`
+	From   common.Address `json:"from"`
+	To     common.Address `json:"to"`
+	Value  *hexutil.Big   `json:"value,omitempty"`
+	Input  hexutil.Bytes  `json:"input"`
+	Output hexutil.Bytes  `json:"output,omitempty"`
+	Error  string         `json:"error,omitempty"`
+	Calls  []CallFrame    `json:"calls,omitempty"`
+	Logs   []CallLog      `json:"logs,omitempty"`
+}
+
+// CallLog is a log emitted by a traced call.
+type CallLog struct {
+	Address common.Address `json:"address"`
+	Topics  []common.Hash  `json:"topics"`
+	Data    hexutil.Bytes  `json:"data"`
+}
+
+type traceCallArgs struct {
+	From  common.Address  `json:"from"`
+	To    *common.Address `json:"to"`
+	Value *hexutil.Big    `json:"value,omitempty"`
+	Input hexutil.Bytes   `json:"input"`
+}
+
+type traceOverride struct {
+	Code hexutil.Bytes `json:"code"`
+}
+
+type traceConfig struct {
+	Tracer         string                           `json:"tracer"`
+	TracerConfig   map[string]interface{}           `json:"tracerConfig"`
+	StateOverrides map[common.Address]traceOverride `json:"stateOverrides,omitempty"`
+}
+
+// TraceCall traces a call at the latest block with the callTracer, logs
+// included. codeOverrides replaces the code of the given addresses for the
+// call, e.g. to run a delegatecall target in the context of its caller.
+func (onr *OneNodeReader) TraceCall(from, to string, value *big.Int, data []byte, codeOverrides map[string][]byte) (*CallFrame, error) {
+	client, err := onr.Client()
+	if err != nil {
+		return nil, err
+	}
+	toAddr := jarviscommon.HexToAddress(to)
+	args := traceCallArgs{
+		From:  jarviscommon.HexToAddress(from),
+		To:    &toAddr,
+		Input: data,
+	}
+	if value != nil {
+		args.Value = (*hexutil.Big)(value)
+	}
+	config := traceConfig{
+		Tracer:       "callTracer",
+		TracerConfig: map[string]interface{}{"withLog": true},
+	}
+	if len(codeOverrides) > 0 {
+		config.StateOverrides = map[common.Address]traceOverride{}
+		for addr, code := range codeOverrides {
+			config.StateOverrides[jarviscommon.HexToAddress(addr)] = traceOverride{Code: code}
+		}
+	}
+
+	timeout, cancel := context.WithTimeout(context.Background(), traceTimeout)
+	defer cancel()
+	frame := &CallFrame{}
+	if err := client.CallContext(timeout, frame, "debug_traceCall", args, "latest", config); err != nil {
+		if isMethodUnsupported(err) {
+			return nil, fmt.Errorf("%w: %s", ErrTracingNotSupported, err)
+		}
+		return nil, err
+	}
+	return frame, nil
+}
+
+// isMethodUnsupported tells whether err is a node refusing a method it
+// doesn't serve. Providers word it differently, often without the standard
+// -32601 code.
+func isMethodUnsupported(err error) bool {
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == -32601 {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{"method not found", "not supported", "not available", "does not exist", "unsupported", "not allowed", "not whitelisted"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+type traceCallResponse struct {
+	Frame *CallFrame
+	Error error
+}
+
+// TraceCall traces a call with the callTracer on the first node that
+// serves debug_traceCall. It returns ErrTracingNotSupported when none does.
+func (er *EthReader) TraceCall(from, to string, value *big.Int, data []byte, codeOverrides map[string][]byte) (*CallFrame, error) {
+	resCh := make(chan traceCallResponse, len(er.nodes))
+	for i := range er.nodes {
+		n := er.nodes[i]
+		go func() {
+			frame, err := n.TraceCall(from, to, value, data, codeOverrides)
+			resCh <- traceCallResponse{
+				Frame: frame,
+				Error: wrapError(err, n.NodeName()),
+			}
+		}()
+	}
+	errs := []error{}
+	unsupported := 0
+	for i := 0; i < len(er.nodes); i++ {
+		result := <-resCh
+		if result.Error == nil {
+			return result.Frame, nil
+		}
+		if errors.Is(result.Error, ErrTracingNotSupported) {
+			unsupported++
+		}
+		errs = append(errs, result.Error)
+	}
+	if unsupported == len(er.nodes) {
+		return nil, ErrTracingNotSupported
+	}
+	return nil, fmt.Errorf("couldn't trace the call with any nodes: %w", errors.Join(errs...))
+}
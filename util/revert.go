@@ -0,0 +1,99 @@
+package util
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+
+	jarviscommon "github.com/tranvictor/jarvis/common"
+	"github.com/tranvictor/jarvis/networks"
+)
+
+// RevertABIs returns the ABIs the reverts of calls to addr are decoded
+// with: the custom ABI of addr if any, its own ABI and, for proxies, the
+// ABI of the implementation or the facets, where the custom errors are
+// declared.
+func RevertABIs(addr string, network networks.Network, customABIs map[string]*abi.ABI) []*abi.ABI {
+	result := []*abi.ABI{}
+	if a, found := customABIs[strings.ToLower(addr)]; found && a != nil {
+		result = append(result, a)
+	}
+	if a, err := GetABI(addr, network); err == nil {
+		result = append(result, a)
+	}
+	if proxy, err := ProxyOf(addr, network); err == nil && proxy.IsProxy() {
+		if a, err := ProxyABI(addr, proxy, nil, network); err == nil {
+			result = append(result, a)
+		}
+	}
+	return result
+}
+
+// RevertReason decodes the revert carried by err, the error of an eth_call
+// or eth_estimateGas to to. ok is false when err isn't a revert.
+func RevertReason(err error, to string, network networks.Network, customABIs map[string]*abi.ABI) (reason string, ok bool) {
+	if !jarviscommon.IsRevert(err) {
+		return "", false
+	}
+	data, _ := jarviscommon.RevertData(err)
+	return jarviscommon.DecodeRevert(data, RevertABIs(to, network, customABIs)...), true
+}
+
+// SimulateTx runs a tx with eth_call at the pending block. It returns the
+// decoded revert when the tx would revert, and an error when the
+// simulation couldn't run at all.
+func SimulateTx(
+	from, to string,
+	value *big.Int,
+	data []byte,
+	network networks.Network,
+	customABIs map[string]*abi.ABI,
+) (reverted bool, reason string, err error) {
+	r, err := EthReader(network)
+	if err != nil {
+		return false, "", err
+	}
+	_, err = r.EthCall(from, to, value, data, nil)
+	if err == nil {
+		return false, "", nil
+	}
+	if reason, ok := RevertReason(err, to, network, customABIs); ok {
+		return true, reason, nil
+	}
+	return false, "", err
+}
+
+// ReplayRevert replays a reverted mined tx on the state of the block before
+// its own and decodes why it reverted. Txs of the same block mined before
+// it aren't replayed, so a tx depending on them may not revert again.
+func ReplayRevert(txinfo *jarviscommon.TxInfo, network networks.Network, customABIs map[string]*abi.ABI) (string, error) {
+	if txinfo.Receipt == nil || txinfo.Receipt.BlockNumber == nil || txinfo.Tx == nil || txinfo.Tx.To() == nil {
+		return "", fmt.Errorf("only mined calls can be replayed")
+	}
+	from := common.Address{}
+	if txinfo.Tx.Extra.From != nil {
+		from = *txinfo.Tx.Extra.From
+	}
+	to := txinfo.Tx.To().Hex()
+	r, err := EthReader(network)
+	if err != nil {
+		return "", err
+	}
+	_, err = r.EthCallAt(
+		txinfo.Receipt.BlockNumber.Int64()-1,
+		from.Hex(), to, txinfo.Tx.Value(), txinfo.Tx.Gas(), txinfo.Tx.Data(),
+	)
+	if err == nil {
+		return "", fmt.Errorf("the tx doesn't revert when replayed, it likely depends on an earlier tx of its block")
+	}
+	if reason, ok := RevertReason(err, to, network, customABIs); ok {
+		return reason, nil
+	}
+	if strings.Contains(err.Error(), "out of gas") {
+		return fmt.Sprintf("ran out of gas (limit %d)", txinfo.Tx.Gas()), nil
+	}
+	return "", err
+}
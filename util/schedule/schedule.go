@@ -0,0 +1,339 @@
+// Package schedule holds a signed or ready-to-sign tx back until a block
+// height, a point in time or an on-chain condition is reached.
+//
+// A condition is a contract read compared to a value, written as
+//
+//	<contract>.<method>(<params>) <op> <value>
+//
+// e.g. `0xabc...def.balanceOf(0x123...456) >= 1000000` or
+// `vault.paused() == false`. The op is one of ==, !=, <, <=, >, >= and can
+// be omitted when the method returns a bool, meaning `== true`. Numbers are
+// compared as integers in the token's smallest unit; any other result is
+// compared as text, case insensitively.
+package schedule
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DefaultPollInterval is how often a trigger checks the chain.
+const DefaultPollInterval = 3 * time.Second
+
+var ops = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+// Condition is a parsed --when expression.
+type Condition struct {
+	Contract string
+	Method   string
+	// Params are the raw params of the call, parsed against the ABI of the
+	// method by the caller.
+	Params []string
+	Op     string
+	Value  string
+}
+
+// ParseCondition parses `<contract>.<method>(<params>) <op> <value>`.
+func ParseCondition(expr string) (*Condition, error) {
+	expr = strings.TrimSpace(expr)
+	open := strings.Index(expr, "(")
+	if open < 0 {
+		return nil, fmt.Errorf("%q is not a contract read, expected <contract>.<method>(<params>) <op> <value>", expr)
+	}
+	target := expr[:open]
+	dot := strings.LastIndex(target, ".")
+	if dot <= 0 || dot == len(target)-1 {
+		return nil, fmt.Errorf("%q doesn't name a contract and a method", target)
+	}
+	end, err := matchingParen(expr, open)
+	if err != nil {
+		return nil, err
+	}
+	c := &Condition{
+		Contract: strings.TrimSpace(target[:dot]),
+		Method:   strings.TrimSpace(target[dot+1:]),
+		Params:   splitParams(expr[open+1 : end]),
+	}
+
+	rest := strings.TrimSpace(expr[end+1:])
+	if rest == "" {
+		c.Op, c.Value = "==", "true"
+		return c, nil
+	}
+	for _, op := range ops {
+		if strings.HasPrefix(rest, op) {
+			c.Op = op
+			c.Value = strings.TrimSpace(rest[len(op):])
+			break
+		}
+	}
+	if c.Op == "" {
+		return nil, fmt.Errorf("%q doesn't start with one of %s", rest, strings.Join(ops, " "))
+	}
+	if c.Value == "" {
+		return nil, fmt.Errorf("nothing to compare to after %s", c.Op)
+	}
+	return c, nil
+}
+
+func (c *Condition) String() string {
+	return fmt.Sprintf("%s.%s(%s) %s %s", c.Contract, c.Method, strings.Join(c.Params, ", "), c.Op, c.Value)
+}
+
+// matchingParen returns the index of the ')' closing the '(' at open.
+func matchingParen(s string, open int) (int, error) {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+			if depth == 0 {
+				if s[i] != ')' {
+					return 0, fmt.Errorf("mismatched ']' at position %d", i)
+				}
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unclosed '(' at position %d", open)
+}
+
+// splitParams splits the params of a call at the top level commas, so arrays
+// and tuples stay whole.
+func splitParams(s string) []string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return []string{}
+	}
+	params := []string{}
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				params = append(params, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(params, strings.TrimSpace(s[start:]))
+}
+
+// Holds compares result, the first output of the method, to the value of
+// the condition.
+func (c *Condition) Holds(result interface{}) (bool, error) {
+	if got, ok := toBig(result); ok {
+		want, ok := parseNumber(c.Value)
+		if !ok {
+			return false, fmt.Errorf("%s returns a number, %q isn't one", c.Method, c.Value)
+		}
+		cmp := got.Cmp(want)
+		switch c.Op {
+		case "==":
+			return cmp == 0, nil
+		case "!=":
+			return cmp != 0, nil
+		case "<":
+			return cmp < 0, nil
+		case "<=":
+			return cmp <= 0, nil
+		case ">":
+			return cmp > 0, nil
+		case ">=":
+			return cmp >= 0, nil
+		}
+		return false, fmt.Errorf("unknown op %s", c.Op)
+	}
+
+	var got string
+	switch r := result.(type) {
+	case common.Address:
+		got = r.Hex()
+	case []byte:
+		got = "0x" + common.Bytes2Hex(r)
+	default:
+		got = fmt.Sprintf("%v", r)
+	}
+	equal := strings.EqualFold(got, strings.Trim(c.Value, `"'`))
+	switch c.Op {
+	case "==":
+		return equal, nil
+	case "!=":
+		return !equal, nil
+	}
+	return false, fmt.Errorf("%s doesn't return a number, it can only be compared with == or !=", c.Method)
+}
+
+func toBig(v interface{}) (*big.Int, bool) {
+	switch n := v.(type) {
+	case *big.Int:
+		return n, n != nil
+	case uint8:
+		return new(big.Int).SetUint64(uint64(n)), true
+	case uint16:
+		return new(big.Int).SetUint64(uint64(n)), true
+	case uint32:
+		return new(big.Int).SetUint64(uint64(n)), true
+	case uint64:
+		return new(big.Int).SetUint64(n), true
+	case int8:
+		return big.NewInt(int64(n)), true
+	case int16:
+		return big.NewInt(int64(n)), true
+	case int32:
+		return big.NewInt(int64(n)), true
+	case int64:
+		return big.NewInt(n), true
+	}
+	return nil, false
+}
+
+// parseNumber reads decimal and hex integers, and 1e18 style ones.
+func parseNumber(s string) (*big.Int, bool) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), "_", "")
+	if n, ok := new(big.Int).SetString(s, 0); ok {
+		return n, true
+	}
+	f, ok := new(big.Float).SetPrec(512).SetString(s)
+	if !ok {
+		return nil, false
+	}
+	n, acc := f.Int(nil)
+	return n, acc == big.Exact
+}
+
+// ParseTime reads an RFC3339 time or a unix timestamp in seconds.
+func ParseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if secs, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(secs, 0), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither an RFC3339 time (e.g. 2006-01-02T15:04:05Z) nor a unix timestamp", s)
+	}
+	return t, nil
+}
+
+// BlockReader is what a trigger needs from the node.
+type BlockReader interface {
+	CurrentBlock() (uint64, error)
+}
+
+// Trigger is when a tx is broadcasted: from block AtBlock, from AtTime and
+// once When holds, all of the set ones together.
+type Trigger struct {
+	AtBlock uint64
+	AtTime  time.Time
+	// When checks the condition of --when on chain.
+	When func() (bool, error)
+	// Check is run on every poll before the trigger, a non nil error calls
+	// the wait off, e.g. when the nonce of the tx got used by another one.
+	Check func() error
+
+	PollInterval time.Duration
+	Logf         func(format string, args ...interface{})
+
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+// IsSet tells whether anything is scheduled.
+func (t *Trigger) IsSet() bool {
+	return t.AtBlock > 0 || !t.AtTime.IsZero() || t.When != nil
+}
+
+func (t *Trigger) String() string {
+	parts := []string{}
+	if t.AtBlock > 0 {
+		parts = append(parts, fmt.Sprintf("block %d", t.AtBlock))
+	}
+	if !t.AtTime.IsZero() {
+		parts = append(parts, t.AtTime.Format(time.RFC3339))
+	}
+	if t.When != nil {
+		parts = append(parts, "the --when condition holds")
+	}
+	return strings.Join(parts, " and ")
+}
+
+// Due tells whether every part of the trigger is reached. The condition is
+// only read once the block and the time are reached.
+func (t *Trigger) Due(chain BlockReader) (bool, error) {
+	if !t.AtTime.IsZero() && t.clock().Before(t.AtTime) {
+		return false, nil
+	}
+	if t.AtBlock > 0 {
+		block, err := chain.CurrentBlock()
+		if err != nil {
+			return false, fmt.Errorf("couldn't get the current block: %w", err)
+		}
+		// the tx lands in the block after the head at the earliest
+		if block+1 < t.AtBlock {
+			return false, nil
+		}
+	}
+	if t.When != nil {
+		return t.When()
+	}
+	return true, nil
+}
+
+// Wait polls the chain until the trigger is due. Node errors are logged and
+// retried, only an error of Check ends the wait early.
+func (t *Trigger) Wait(chain BlockReader) error {
+	poll := t.PollInterval
+	if poll == 0 {
+		poll = DefaultPollInterval
+	}
+	logged := time.Time{}
+	for {
+		if t.Check != nil {
+			if err := t.Check(); err != nil {
+				return err
+			}
+		}
+		due, err := t.Due(chain)
+		if err != nil {
+			t.logf("%s, retrying", err)
+		} else if due {
+			return nil
+		} else if t.clock().Sub(logged) >= time.Minute {
+			t.logf("Waiting until %s...", t)
+			logged = t.clock()
+		}
+		t.pause(poll)
+	}
+}
+
+func (t *Trigger) clock() time.Time {
+	if t.now != nil {
+		return t.now()
+	}
+	return time.Now()
+}
+
+func (t *Trigger) pause(d time.Duration) {
+	if t.sleep != nil {
+		t.sleep(d)
+		return
+	}
+	time.Sleep(d)
+}
+
+func (t *Trigger) logf(format string, args ...interface{}) {
+	if t.Logf != nil {
+		t.Logf(format, args...)
+	}
+}
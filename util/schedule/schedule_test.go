@@ -0,0 +1,135 @@
+package schedule
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestParseCondition(t *testing.T) {
+	c, err := ParseCondition(`0x1d9937e170Fc2174408581265bA0B87afDA4947F.balanceOf(0xA0b86991c6218b36c1d19d4a2e9eB0cE3606eB48, [1, 2]) >= 1e18`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Contract != "0x1d9937e170Fc2174408581265bA0B87afDA4947F" || c.Method != "balanceOf" || c.Op != ">=" || c.Value != "1e18" {
+		t.Fatalf("parsed %+v", c)
+	}
+	if len(c.Params) != 2 || c.Params[1] != "[1, 2]" {
+		t.Fatalf("params %q", c.Params)
+	}
+
+	c, err = ParseCondition("my vault.paused()")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Contract != "my vault" || len(c.Params) != 0 || c.Op != "==" || c.Value != "true" {
+		t.Fatalf("a bare read means == true, got %+v", c)
+	}
+
+	for _, bad := range []string{"vault.paused", "paused()", "vault.paused( == 1", "vault.paused() ~ 1", "vault.paused() >="} {
+		if _, err := ParseCondition(bad); err == nil {
+			t.Fatalf("%q must not parse", bad)
+		}
+	}
+}
+
+func TestConditionHolds(t *testing.T) {
+	addr := common.HexToAddress("0x1d9937e170Fc2174408581265bA0B87afDA4947F")
+	for _, tc := range []struct {
+		op, value string
+		result    interface{}
+		want      bool
+	}{
+		{">=", "1e18", new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil), true},
+		{">", "1e18", new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil), false},
+		{"<", "0x10", uint8(15), true},
+		{"!=", "3", int64(3), false},
+		{"==", "false", false, true},
+		{"==", "0x1d9937e170fc2174408581265ba0b87afda4947f", addr, true},
+		{"!=", `"open"`, "open", false},
+	} {
+		c := &Condition{Method: "m", Op: tc.op, Value: tc.value}
+		got, err := c.Holds(tc.result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Fatalf("%v %s %s = %v, want %v", tc.result, tc.op, tc.value, got, tc.want)
+		}
+	}
+	if _, err := (&Condition{Method: "m", Op: "<", Value: "x"}).Holds(true); err == nil {
+		t.Fatalf("a bool can't be ordered")
+	}
+	if _, err := (&Condition{Method: "m", Op: "<", Value: "1.5"}).Holds(big.NewInt(1)); err == nil {
+		t.Fatalf("1.5 isn't an integer")
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	got, err := ParseTime("1700000000")
+	if err != nil || got.Unix() != 1700000000 {
+		t.Fatalf("got %s %v", got, err)
+	}
+	got, err = ParseTime("2023-11-14T22:13:20Z")
+	if err != nil || got.Unix() != 1700000000 {
+		t.Fatalf("got %s %v", got, err)
+	}
+	if _, err := ParseTime("tomorrow"); err == nil {
+		t.Fatalf("tomorrow must not parse")
+	}
+}
+
+type fakeChain struct {
+	block uint64
+}
+
+func (c *fakeChain) CurrentBlock() (uint64, error) {
+	c.block++
+	return c.block, nil
+}
+
+func TestTriggerWait(t *testing.T) {
+	now := time.Unix(1000, 0)
+	reads := 0
+	tr := &Trigger{
+		AtBlock: 10,
+		AtTime:  time.Unix(1030, 0),
+		When: func() (bool, error) {
+			reads++
+			return reads >= 2, nil
+		},
+		now:   func() time.Time { return now },
+		sleep: func(d time.Duration) { now = now.Add(d) },
+	}
+	chain := &fakeChain{}
+	if err := tr.Wait(chain); err != nil {
+		t.Fatal(err)
+	}
+	if now.Before(tr.AtTime) || chain.block+1 < tr.AtBlock || reads != 2 {
+		t.Fatalf("fired early: now %d, head %d, reads %d", now.Unix(), chain.block, reads)
+	}
+}
+
+func TestTriggerCheckCallsTheWaitOff(t *testing.T) {
+	now := time.Unix(0, 0)
+	checks := 0
+	used := errors.New("nonce used")
+	tr := &Trigger{
+		AtTime: time.Unix(3600, 0),
+		Check: func() error {
+			checks++
+			if checks == 3 {
+				return used
+			}
+			return nil
+		},
+		now:   func() time.Time { return now },
+		sleep: func(d time.Duration) { now = now.Add(d) },
+	}
+	if err := tr.Wait(&fakeChain{}); !errors.Is(err, used) {
+		t.Fatalf("got %v, want the check error", err)
+	}
+}
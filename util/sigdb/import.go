@@ -0,0 +1,129 @@
+package sigdb
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// DefaultUpdateURL is the signature dump jarvis sigdb update downloads.
+const DefaultUpdateURL = "https://api.4byte.sourcify.dev/signature-database/v1/export"
+
+// Parse returns the signatures data holds: an ABI, a Foundry or Hardhat
+// artifact with one, or a text dump with a signature per line. The lines
+// of a dump may carry more than the signature, like the selector or
+// whether it is a function or an event, as long as the signature is the
+// last thing on them. Gzipped data is decompressed first.
+func Parse(data []byte) ([]string, error) {
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		if data, err = io.ReadAll(gz); err != nil {
+			return nil, fmt.Errorf("couldn't decompress: %w", err)
+		}
+	}
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("[")) || bytes.HasPrefix(trimmed, []byte("{")) {
+		return parseABI(trimmed)
+	}
+
+	sigs := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if sig, err := Canonical(signatureOfLine(scanner.Text())); err == nil {
+			sigs = append(sigs, sig)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(sigs) == 0 && len(trimmed) > 0 {
+		return nil, fmt.Errorf("found no signatures")
+	}
+	return sigs, nil
+}
+
+// signatureOfLine is the signature a line of a dump ends with: from the
+// name before the first parenthesis to the last one.
+func signatureOfLine(line string) string {
+	open := strings.Index(line, "(")
+	closing := strings.LastIndex(line, ")")
+	if open <= 0 || closing < open {
+		return ""
+	}
+	start := open
+	for start > 0 && isIdentifierByte(line[start-1]) {
+		start--
+	}
+	return line[start : closing+1]
+}
+
+func isIdentifierByte(c byte) bool {
+	return c == '_' || c == '$' ||
+		('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+func parseABI(data []byte) ([]string, error) {
+	if bytes.HasPrefix(data, []byte("{")) {
+		artifact := struct {
+			ABI json.RawMessage `json:"abi"`
+		}{}
+		if err := json.Unmarshal(data, &artifact); err != nil {
+			return nil, err
+		}
+		if len(artifact.ABI) == 0 {
+			return nil, fmt.Errorf("found no abi")
+		}
+		data = artifact.ABI
+	}
+	a, err := abi.JSON(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	sigs := []string{}
+	for _, m := range a.Methods {
+		sigs = append(sigs, m.Sig)
+	}
+	for _, e := range a.Events {
+		sigs = append(sigs, e.Sig)
+	}
+	for _, e := range a.Errors {
+		sigs = append(sigs, e.Sig)
+	}
+	return sigs, nil
+}
+
+// Fetch downloads the signatures at url, see Parse for the formats.
+func Fetch(ctx context.Context, url string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading body: %w", err)
+	}
+	return Parse(data)
+}
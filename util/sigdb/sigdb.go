@@ -0,0 +1,233 @@
+// Package sigdb is a local database of function and event signatures, used
+// to decode calls and logs of contracts without an ABI.
+//
+// Signatures are kept as text, e.g. transfer(address,uint256), in
+// ~/.jarvis/sigdb/signatures.txt next to the snapshot bundled with jarvis.
+// A function is looked up by its 4-byte selector and an event by its
+// topic, the keccak256 of its signature, whose first 4 bytes index it the
+// same way. Selectors collide, so every signature of a selector is a
+// candidate, and only the ones the data decodes cleanly with are kept.
+package sigdb
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// FileName is the file under the database directory the signatures are
+// stored in, one per line after their selector.
+const FileName = "signatures.txt"
+
+// DB is the signature database of a directory. The snapshot bundled with
+// jarvis is always part of it.
+type DB struct {
+	dir string
+
+	loadOnce   sync.Once
+	loadErr    error
+	mu         sync.RWMutex
+	bySelector map[[4]byte][]string
+	known      map[string]bool
+}
+
+// DefaultDir is ~/.jarvis/sigdb.
+func DefaultDir() string {
+	u, err := user.Current()
+	if err != nil {
+		return filepath.Join(".jarvis", "sigdb")
+	}
+	return filepath.Join(u.HomeDir, ".jarvis", "sigdb")
+}
+
+// New returns the database stored under dir. With an empty dir it holds
+// the bundled snapshot and whatever is added in memory only.
+func New(dir string) *DB {
+	return &DB{dir: dir}
+}
+
+var (
+	sharedOnce sync.Once
+	shared     *DB
+)
+
+// Shared is the database under DefaultDir, loaded on first use.
+func Shared() *DB {
+	sharedOnce.Do(func() {
+		shared = New(DefaultDir())
+	})
+	return shared
+}
+
+// Path is the file the signatures added to db are stored in.
+func (db *DB) Path() string {
+	if db.dir == "" {
+		return ""
+	}
+	return filepath.Join(db.dir, FileName)
+}
+
+func (db *DB) load() error {
+	db.loadOnce.Do(func() {
+		db.bySelector = map[[4]byte][]string{}
+		db.known = map[string]bool{}
+		for _, sig := range strings.Split(snapshot, "\n") {
+			if sig = strings.TrimSpace(sig); sig != "" {
+				db.insert(Selector(sig), sig)
+			}
+		}
+		if db.dir == "" {
+			return
+		}
+		f, err := os.Open(db.Path())
+		if os.IsNotExist(err) {
+			return
+		}
+		if err != nil {
+			db.loadErr = err
+			return
+		}
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			selector, sig, ok := parseLine(scanner.Text())
+			if ok {
+				db.insert(selector, sig)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			db.loadErr = fmt.Errorf("couldn't read %s: %w", db.Path(), err)
+		}
+	})
+	return db.loadErr
+}
+
+// parseLine parses a line of the signatures file: a selector and a
+// canonical signature.
+func parseLine(line string) ([4]byte, string, bool) {
+	fields := strings.Fields(line)
+	if len(fields) != 2 {
+		return [4]byte{}, "", false
+	}
+	selector, err := hexutil.Decode(fields[0])
+	if err != nil || len(selector) != 4 {
+		return [4]byte{}, "", false
+	}
+	return [4]byte(selector), fields[1], true
+}
+
+func (db *DB) insert(selector [4]byte, sig string) bool {
+	if db.known[sig] {
+		return false
+	}
+	db.known[sig] = true
+	db.bySelector[selector] = append(db.bySelector[selector], sig)
+	return true
+}
+
+// Len is how many signatures db has.
+func (db *DB) Len() (int, error) {
+	err := db.load()
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return len(db.known), err
+}
+
+// Signatures returns the signatures with the selector, the bundled ones
+// first.
+func (db *DB) Signatures(selector [4]byte) []string {
+	db.load()
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return append([]string{}, db.bySelector[selector]...)
+}
+
+// Add adds signatures to db and stores them, skipping the ones it has
+// already and the ones that aren't valid. It returns how many were added.
+func (db *DB) Add(sigs []string) (int, error) {
+	if err := db.load(); err != nil {
+		return 0, err
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	lines := strings.Builder{}
+	added := 0
+	for _, sig := range sigs {
+		sig, err := Canonical(sig)
+		if err != nil {
+			continue
+		}
+		selector := Selector(sig)
+		if db.insert(selector, sig) {
+			added++
+			fmt.Fprintf(&lines, "%s %s\n", hexutil.Encode(selector[:]), sig)
+		}
+	}
+	if added == 0 || db.dir == "" {
+		return added, nil
+	}
+	if err := os.MkdirAll(db.dir, 0o755); err != nil {
+		return 0, err
+	}
+	f, err := os.OpenFile(db.Path(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	if _, err := f.WriteString(lines.String()); err != nil {
+		return 0, fmt.Errorf("couldn't write %s: %w", db.Path(), err)
+	}
+	return added, nil
+}
+
+// Methods returns the functions of the signatures whose selector data
+// starts with and which the rest of data decodes cleanly with, in the
+// order of Signatures.
+func (db *DB) Methods(data []byte) []abi.Method {
+	if len(data) < 4 {
+		return nil
+	}
+	methods := []abi.Method{}
+	for _, sig := range db.Signatures([4]byte(data[:4])) {
+		name, args, err := parseSignature(sig)
+		if err != nil || !decodesCleanly(args, data[4:]) {
+			continue
+		}
+		methods = append(methods, abi.NewMethod(name, name, abi.Function, "", false, false, args, nil))
+	}
+	return methods
+}
+
+// Events returns the events of the signatures hashing to the first topic
+// that the other topics and data decode cleanly with, in the order of
+// Signatures. Anonymous events can't be looked up.
+func (db *DB) Events(topics []common.Hash, data []byte) []abi.Event {
+	if len(topics) == 0 {
+		return nil
+	}
+	events := []abi.Event{}
+	for _, sig := range db.Signatures([4]byte(topics[0][:4])) {
+		if crypto.Keccak256Hash([]byte(sig)) != topics[0] {
+			continue
+		}
+		name, args, err := parseSignature(sig)
+		if err != nil {
+			continue
+		}
+		if event, ok := eventOf(name, args, topics[1:], data); ok {
+			events = append(events, event)
+		}
+	}
+	return events
+}
@@ -0,0 +1,141 @@
+package sigdb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestSnapshotIsCanonical(t *testing.T) {
+	for _, sig := range strings.Split(snapshot, "\n") {
+		if sig = strings.TrimSpace(sig); sig == "" {
+			continue
+		}
+		c, err := Canonical(sig)
+		if err != nil || c != sig {
+			t.Fatalf("%s: got %s, %v", sig, c, err)
+		}
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	for sig, want := range map[string]string{
+		"transfer(address to, uint256 amount)":                              "transfer(address,uint256)",
+		"event Transfer(address indexed from, address indexed to, uint256)": "Transfer(address,address,uint256)",
+		"swap((address, uint24)[] calldata hops,bytes)":                     "swap((address,uint24)[],bytes)",
+		"noop()": "noop()",
+	} {
+		got, err := Canonical(sig)
+		if err != nil || got != want {
+			t.Fatalf("%s: got %s, %v, want %s", sig, got, err, want)
+		}
+	}
+	for _, sig := range []string{"transfer", "transfer(address", "1x()", "f(uint)", "f((uint256)"} {
+		if _, err := Canonical(sig); err == nil {
+			t.Fatalf("%s is taken for a signature", sig)
+		}
+	}
+}
+
+func TestMethods(t *testing.T) {
+	db := New("")
+	// many_msg_babbage(bytes1) has the selector of transfer(address,uint256)
+	if _, err := db.Add([]string{"many_msg_babbage(bytes1)"}); err != nil {
+		t.Fatal(err)
+	}
+	a, _ := abi.JSON(strings.NewReader(`[{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[]}]`))
+	data, _ := a.Pack("transfer", common.HexToAddress("0xaa"), big.NewInt(7))
+
+	if sigs := db.Signatures([4]byte(data[:4])); len(sigs) != 2 {
+		t.Fatalf("got candidates %v, want transfer and many_msg_babbage", sigs)
+	}
+	methods := db.Methods(data)
+	if len(methods) != 1 || methods[0].Name != "transfer" {
+		t.Fatalf("got %v, want only transfer", methods)
+	}
+	values, err := methods[0].Inputs.UnpackValues(data[4:])
+	if err != nil || values[1].(*big.Int).Int64() != 7 {
+		t.Fatalf("decoded %v, %v", values, err)
+	}
+	// trailing bytes aren't a clean encoding of transfer
+	if methods := db.Methods(append(data, 1)); len(methods) != 0 {
+		t.Fatalf("got %v for dirty calldata", methods)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	db := New("")
+	topic := crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+	from := common.BytesToHash(common.HexToAddress("0xaa").Bytes())
+	to := common.BytesToHash(common.HexToAddress("0xbb").Bytes())
+	amount := common.BigToHash(big.NewInt(5))
+
+	// ERC20: the amount is in the data
+	events := db.Events([]common.Hash{topic, from, to}, amount.Bytes())
+	if len(events) != 1 || !events[0].Inputs[1].Indexed || events[0].Inputs[2].Indexed {
+		t.Fatalf("got %v, want Transfer with from and to indexed", events)
+	}
+	// ERC721: the token id is indexed too
+	events = db.Events([]common.Hash{topic, from, to, amount}, nil)
+	if len(events) != 1 || !events[0].Inputs[2].Indexed {
+		t.Fatalf("got %v, want Transfer with everything indexed", events)
+	}
+	// a topic that isn't an address can't be from
+	events = db.Events([]common.Hash{topic, common.HexToHash("0xff00000000000000000000000000000000000000000000000000000000000001"), to}, amount.Bytes())
+	if len(events) != 0 {
+		t.Fatalf("got %v for a topic that isn't an address", events)
+	}
+}
+
+func TestAddPersists(t *testing.T) {
+	dir := t.TempDir()
+	db := New(dir)
+	added, err := db.Add([]string{"rebalance(uint256 epoch)", "transfer(address,uint256)", "bad("})
+	if err != nil || added != 1 {
+		t.Fatalf("added %d, %v, want only rebalance", added, err)
+	}
+	if added, _ := db.Add([]string{"rebalance(uint256)"}); added != 0 {
+		t.Fatal("added rebalance twice")
+	}
+	reloaded := New(dir)
+	if sigs := reloaded.Signatures(Selector("rebalance(uint256)")); len(sigs) != 1 || sigs[0] != "rebalance(uint256)" {
+		t.Fatalf("reloaded %v", sigs)
+	}
+	data, _ := os.ReadFile(reloaded.Path())
+	if strings.Count(string(data), "\n") != 1 {
+		t.Fatalf("stored %q", data)
+	}
+}
+
+func TestParse(t *testing.T) {
+	dump := "0xa9059cbb,transfer(address,uint256)\nfunction rebalance(uint256)\n\nevent\tRebalanced(uint256,(address,uint256)[])\nnot a signature\n"
+	sigs, err := Parse([]byte(dump))
+	if err != nil || len(sigs) != 3 || sigs[2] != "Rebalanced(uint256,(address,uint256)[])" {
+		t.Fatalf("got %v, %v", sigs, err)
+	}
+
+	buf := bytes.Buffer{}
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte(dump))
+	gz.Close()
+	if sigs, err := Parse(buf.Bytes()); err != nil || len(sigs) != 3 {
+		t.Fatalf("gzipped: got %v, %v", sigs, err)
+	}
+
+	artifact := `{"abi":[{"type":"function","name":"rebalance","inputs":[{"name":"epoch","type":"uint256"}],"outputs":[]},{"type":"error","name":"TooEarly","inputs":[]}]}`
+	sigs, err = Parse([]byte(artifact))
+	if err != nil || len(sigs) != 2 {
+		t.Fatalf("artifact: got %v, %v", sigs, err)
+	}
+
+	if _, err := Parse([]byte("nothing here")); err == nil {
+		t.Fatal("parsed signatures out of nothing")
+	}
+}
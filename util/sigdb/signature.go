@@ -0,0 +1,206 @@
+package sigdb
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var identifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// Canonical returns sig the way its selector and topic are hashed from,
+// e.g. transfer(address,uint256) for "transfer(address to, uint256 amount)"
+// or "function transfer(address,uint256)".
+func Canonical(sig string) (string, error) {
+	name, args, err := parseSignature(sig)
+	if err != nil {
+		return "", err
+	}
+	return canonical(name, args), nil
+}
+
+// Selector is the 4-byte selector of a canonical signature, which is also
+// the beginning of its event topic.
+func Selector(sig string) [4]byte {
+	return [4]byte(crypto.Keccak256([]byte(sig))[:4])
+}
+
+func canonical(name string, args abi.Arguments) string {
+	types := make([]string, len(args))
+	for i, arg := range args {
+		types[i] = arg.Type.String()
+	}
+	return fmt.Sprintf("%s(%s)", name, strings.Join(types, ","))
+}
+
+// parseSignature parses a text signature into the name and the arguments
+// it declares. The arguments are named arg0, arg1... since signatures
+// don't carry names.
+func parseSignature(sig string) (string, abi.Arguments, error) {
+	sig = strings.TrimSpace(sig)
+	for _, keyword := range []string{"function ", "event ", "error "} {
+		sig = strings.TrimSpace(strings.TrimPrefix(sig, keyword))
+	}
+	open := strings.Index(sig, "(")
+	if open < 0 || !strings.HasSuffix(sig, ")") {
+		return "", nil, fmt.Errorf("%q isn't a signature like transfer(address,uint256)", sig)
+	}
+	name := sig[:open]
+	if !identifier.MatchString(name) {
+		return "", nil, fmt.Errorf("%q isn't a valid function or event name", name)
+	}
+	types, err := splitTypes(sig[open+1 : len(sig)-1])
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %w", sig, err)
+	}
+	args := abi.Arguments{}
+	for i, t := range types {
+		m, err := argumentOf(t)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s: %w", sig, err)
+		}
+		typ, err := abi.NewType(m.Type, "", m.Components)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s: %w", sig, err)
+		}
+		args = append(args, abi.Argument{Name: fmt.Sprintf("arg%d", i), Type: typ})
+	}
+	return name, args, nil
+}
+
+// splitTypes splits a list of types at the commas outside of tuples.
+func splitTypes(list string) ([]string, error) {
+	if strings.TrimSpace(list) == "" {
+		return nil, nil
+	}
+	types := []string{}
+	depth, start := 0, 0
+	for i, c := range list {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unbalanced parentheses")
+			}
+		case ',':
+			if depth == 0 {
+				types = append(types, list[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced parentheses")
+	}
+	return append(types, list[start:]), nil
+}
+
+// argumentOf is the abi argument of a type of a signature. Argument names
+// and modifiers after the type, like in "address indexed from", are
+// dropped.
+func argumentOf(t string) (abi.ArgumentMarshaling, error) {
+	t = strings.TrimSpace(t)
+	if !strings.HasPrefix(t, "(") {
+		fields := strings.Fields(t)
+		if len(fields) == 0 {
+			return abi.ArgumentMarshaling{}, fmt.Errorf("empty type")
+		}
+		return abi.ArgumentMarshaling{Type: fields[0]}, nil
+	}
+	closing := strings.LastIndex(t, ")")
+	types, err := splitTypes(t[1:closing])
+	if err != nil {
+		return abi.ArgumentMarshaling{}, err
+	}
+	components := []abi.ArgumentMarshaling{}
+	for i, component := range types {
+		c, err := argumentOf(component)
+		if err != nil {
+			return abi.ArgumentMarshaling{}, err
+		}
+		c.Name = fmt.Sprintf("field%d", i)
+		components = append(components, c)
+	}
+	suffix := strings.Fields(t[closing+1:] + " ")
+	arrays := ""
+	if len(suffix) > 0 && strings.HasPrefix(suffix[0], "[") {
+		arrays = suffix[0]
+	}
+	return abi.ArgumentMarshaling{Type: "tuple" + arrays, Components: components}, nil
+}
+
+// decodesCleanly tells whether data is exactly the encoding of args, the
+// way a contract compiled with the signature would have been called or
+// would have logged: it must decode, and encode back to the same bytes.
+func decodesCleanly(args abi.Arguments, data []byte) bool {
+	values, err := args.UnpackValues(data)
+	if err != nil {
+		return false
+	}
+	packed, err := args.Pack(values...)
+	return err == nil && bytes.Equal(packed, data)
+}
+
+// topicFits tells whether topic can hold arg indexed: any hash for the
+// reference types, which are logged hashed, and a clean encoding of a value
+// for the others.
+func topicFits(arg abi.Argument, topic common.Hash) bool {
+	switch arg.Type.T {
+	case abi.BoolTy, abi.UintTy, abi.IntTy, abi.AddressTy, abi.HashTy, abi.FixedBytesTy:
+		return decodesCleanly(abi.Arguments{{Type: arg.Type}}, topic.Bytes())
+	}
+	return true
+}
+
+// eventOf returns the event sig declares with the arguments indexed so
+// that topics and data decode cleanly, trying which arguments are indexed
+// from the first ones on, since signatures don't tell.
+func eventOf(name string, args abi.Arguments, topics []common.Hash, data []byte) (abi.Event, bool) {
+	var event abi.Event
+	found := false
+	combinations(len(args), len(topics), func(indexed []int) bool {
+		candidate := make(abi.Arguments, len(args))
+		copy(candidate, args)
+		for i, pos := range indexed {
+			if !topicFits(candidate[pos], topics[i]) {
+				return false
+			}
+			candidate[pos].Indexed = true
+		}
+		if !decodesCleanly(candidate.NonIndexed(), data) {
+			return false
+		}
+		event = abi.NewEvent(name, name, false, candidate)
+		found = true
+		return true
+	})
+	return event, found
+}
+
+// combinations calls try with every k positions out of n in increasing
+// order, the first positions first, until it returns true.
+func combinations(n, k int, try func([]int) bool) bool {
+	if k > n {
+		return false
+	}
+	var pick func(from int, picked []int) bool
+	pick = func(from int, picked []int) bool {
+		if len(picked) == k {
+			return try(picked)
+		}
+		for i := from; i <= n-(k-len(picked)); i++ {
+			if pick(i+1, append(picked, i)) {
+				return true
+			}
+		}
+		return false
+	}
+	return pick(0, make([]int, 0, k))
+}
@@ -0,0 +1,232 @@
+package sigdb
+
+// snapshot is the signatures bundled with jarvis: the functions, events
+// and errors of the standards and of the contracts txs go through the most.
+var snapshot = `
+name()
+symbol()
+decimals()
+totalSupply()
+balanceOf(address)
+allowance(address,address)
+transfer(address,uint256)
+transferFrom(address,address,uint256)
+approve(address,uint256)
+increaseAllowance(address,uint256)
+decreaseAllowance(address,uint256)
+mint(address,uint256)
+burn(uint256)
+burn(address,uint256)
+burnFrom(address,uint256)
+permit(address,address,uint256,uint256,uint8,bytes32,bytes32)
+nonces(address)
+DOMAIN_SEPARATOR()
+deposit()
+withdraw(uint256)
+Transfer(address,address,uint256)
+Approval(address,address,uint256)
+Deposit(address,uint256)
+Withdrawal(address,uint256)
+ownerOf(uint256)
+safeTransferFrom(address,address,uint256)
+safeTransferFrom(address,address,uint256,bytes)
+setApprovalForAll(address,bool)
+isApprovedForAll(address,address)
+getApproved(uint256)
+tokenURI(uint256)
+ApprovalForAll(address,address,bool)
+safeTransferFrom(address,address,uint256,uint256,bytes)
+safeBatchTransferFrom(address,address,uint256[],uint256[],bytes)
+balanceOfBatch(address[],uint256[])
+TransferSingle(address,address,address,uint256,uint256)
+TransferBatch(address,address,address,uint256[],uint256[])
+URI(string,uint256)
+supportsInterface(bytes4)
+asset()
+totalAssets()
+deposit(uint256,address)
+mint(uint256,address)
+withdraw(uint256,address,address)
+redeem(uint256,address,address)
+Deposit(address,address,uint256,uint256)
+Withdraw(address,address,address,uint256,uint256)
+owner()
+transferOwnership(address)
+renounceOwnership()
+acceptOwnership()
+pendingOwner()
+OwnershipTransferred(address,address)
+OwnershipTransferStarted(address,address)
+hasRole(bytes32,address)
+grantRole(bytes32,address)
+revokeRole(bytes32,address)
+renounceRole(bytes32,address)
+getRoleAdmin(bytes32)
+RoleGranted(bytes32,address,address)
+RoleRevoked(bytes32,address,address)
+RoleAdminChanged(bytes32,bytes32,bytes32)
+pause()
+unpause()
+paused()
+Paused(address)
+Unpaused(address)
+initialize()
+implementation()
+upgradeTo(address)
+upgradeToAndCall(address,bytes)
+changeAdmin(address)
+admin()
+Upgraded(address)
+AdminChanged(address,address)
+BeaconUpgraded(address)
+Initialized(uint8)
+Initialized(uint64)
+multicall(bytes[])
+multicall(uint256,bytes[])
+aggregate((address,bytes)[])
+tryAggregate(bool,(address,bytes)[])
+aggregate3((address,bool,bytes)[])
+aggregate3Value((address,bool,uint256,bytes)[])
+execute(bytes,bytes[],uint256)
+execute(bytes,bytes[])
+swapExactTokensForTokens(uint256,uint256,address[],address,uint256)
+swapTokensForExactTokens(uint256,uint256,address[],address,uint256)
+swapExactETHForTokens(uint256,address[],address,uint256)
+swapTokensForExactETH(uint256,uint256,address[],address,uint256)
+swapExactTokensForETH(uint256,uint256,address[],address,uint256)
+swapETHForExactTokens(uint256,address[],address,uint256)
+swapExactTokensForTokensSupportingFeeOnTransferTokens(uint256,uint256,address[],address,uint256)
+swapExactETHForTokensSupportingFeeOnTransferTokens(uint256,address[],address,uint256)
+swapExactTokensForETHSupportingFeeOnTransferTokens(uint256,uint256,address[],address,uint256)
+addLiquidity(address,address,uint256,uint256,uint256,uint256,address,uint256)
+addLiquidityETH(address,uint256,uint256,uint256,address,uint256)
+removeLiquidity(address,address,uint256,uint256,uint256,address,uint256)
+removeLiquidityETH(address,uint256,uint256,uint256,address,uint256)
+getReserves()
+swap(uint256,uint256,address,bytes)
+sync()
+skim(address)
+Swap(address,uint256,uint256,uint256,uint256,address)
+Sync(uint112,uint112)
+Mint(address,uint256,uint256)
+Burn(address,uint256,uint256,address)
+PairCreated(address,address,address,uint256)
+exactInputSingle((address,address,uint24,address,uint256,uint256,uint256,uint160))
+exactInput((bytes,address,uint256,uint256,uint256))
+exactOutputSingle((address,address,uint24,address,uint256,uint256,uint256,uint160))
+exactOutput((bytes,address,uint256,uint256,uint256))
+exactInputSingle((address,address,uint24,address,uint256,uint256,uint160))
+exactInput((bytes,address,uint256,uint256))
+unwrapWETH9(uint256,address)
+refundETH()
+sweepToken(address,uint256,address)
+swap(address,bool,int256,uint160,bytes)
+slot0()
+Swap(address,address,int256,int256,uint160,uint128,int24)
+Mint(address,address,int24,int24,uint128,uint256,uint256)
+Burn(address,int24,int24,uint128,uint256,uint256)
+Collect(address,address,int24,int24,uint128,uint128)
+PoolCreated(address,address,uint24,int24,address)
+mint((address,address,uint24,int24,int24,uint256,uint256,uint256,uint256,address,uint256))
+increaseLiquidity((uint256,uint256,uint256,uint256,uint256,uint256))
+decreaseLiquidity((uint256,uint128,uint256,uint256,uint256))
+collect((uint256,address,uint128,uint128))
+IncreaseLiquidity(uint256,uint128,uint256,uint256)
+DecreaseLiquidity(uint256,uint128,uint256,uint256)
+Collect(uint256,address,uint256,uint256)
+approve(address,address,uint160,uint48)
+permit(address,((address,uint160,uint48,uint48),address,uint256),bytes)
+transferFrom(address,address,uint160,address)
+lockdown((address,address)[])
+invalidateNonces(address,address,uint48)
+execTransaction(address,uint256,bytes,uint8,uint256,uint256,uint256,address,address,bytes)
+approveHash(bytes32)
+addOwnerWithThreshold(address,uint256)
+removeOwner(address,address,uint256)
+swapOwner(address,address,address)
+changeThreshold(uint256)
+enableModule(address)
+disableModule(address,address)
+setGuard(address)
+setFallbackHandler(address)
+getOwners()
+getThreshold()
+nonce()
+setup(address[],uint256,address,bytes,address,address,uint256,address)
+createProxyWithNonce(address,bytes,uint256)
+multiSend(bytes)
+ExecutionSuccess(bytes32,uint256)
+ExecutionFailure(bytes32,uint256)
+ApproveHash(bytes32,address)
+AddedOwner(address)
+RemovedOwner(address)
+ChangedThreshold(uint256)
+EnabledModule(address)
+DisabledModule(address)
+ProxyCreation(address,address)
+SafeReceived(address,uint256)
+submitTransaction(address,uint256,bytes)
+confirmTransaction(uint256)
+revokeConfirmation(uint256)
+executeTransaction(uint256)
+Submission(uint256)
+Confirmation(address,uint256)
+Revocation(address,uint256)
+Execution(uint256)
+ExecutionFailure(uint256)
+claim(uint256,address,uint256,bytes32[])
+Claimed(uint256,address,uint256)
+stake(uint256)
+unstake(uint256)
+getReward()
+exit()
+Staked(address,uint256)
+Withdrawn(address,uint256)
+RewardPaid(address,uint256)
+supply(address,uint256,address,uint16)
+borrow(address,uint256,uint256,uint16,address)
+repay(address,uint256,uint256,address)
+withdraw(address,uint256,address)
+flashLoan(address,address[],uint256[],uint256[],address,bytes,uint16)
+deposit(address,uint256,address,uint16)
+submit(address)
+wrap(uint256)
+unwrap(uint256)
+register(string,address,uint256,bytes32)
+setAddr(bytes32,address)
+setText(bytes32,string,string)
+commit(bytes32)
+transferAndCall(address,uint256,bytes)
+delegate(address)
+delegates(address)
+getVotes(address)
+DelegateChanged(address,address,address)
+DelegateVotesChanged(address,uint256,uint256)
+propose(address[],uint256[],bytes[],string)
+castVote(uint256,uint8)
+castVoteWithReason(uint256,uint8,string)
+queue(address[],uint256[],bytes[],bytes32)
+execute(address[],uint256[],bytes[],bytes32)
+schedule(address,uint256,bytes,bytes32,bytes32,uint256)
+CallScheduled(bytes32,uint256,address,uint256,bytes,bytes32,uint256)
+CallExecuted(bytes32,uint256,address,uint256,bytes)
+disperseEther(address[],uint256[])
+disperseToken(address,address[],uint256[])
+Error(string)
+Panic(uint256)
+OwnableUnauthorizedAccount(address)
+OwnableInvalidOwner(address)
+AccessControlUnauthorizedAccount(address,bytes32)
+ERC20InsufficientBalance(address,uint256,uint256)
+ERC20InsufficientAllowance(address,uint256,uint256)
+ERC20InvalidSender(address)
+ERC20InvalidReceiver(address)
+ERC721NonexistentToken(uint256)
+ERC721IncorrectOwner(address,uint256,address)
+EnforcedPause()
+ExpectedPause()
+ReentrancyGuardReentrantCall()
+SafeERC20FailedOperation(address)
+InvalidInitialization()
+NotInitializing()
+`
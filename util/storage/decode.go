@@ -0,0 +1,178 @@
+package storage
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// MaxListed is how many elements of an array Read decodes. Longer arrays
+// are read one element at a time.
+const MaxListed = 16
+
+// maxBytesLength caps the length of a string or bytes Read decodes, so a
+// slot that doesn't hold one can't make it read the whole storage.
+const maxBytesLength = 32 * 1024
+
+// SlotReader reads one storage slot of the contract.
+type SlotReader func(slot common.Hash) (common.Hash, error)
+
+// Value is a decoded value. Members holds the members of a struct or the
+// elements of an array.
+type Value struct {
+	Path    string  `json:"path"`
+	Type    string  `json:"type"`
+	Slot    string  `json:"slot"`
+	Offset  uint64  `json:"offset"`
+	Value   string  `json:"value"`
+	Members []Value `json:"members,omitempty"`
+}
+
+// Read reads and decodes the value at loc.
+func (l *Layout) Read(loc Location, read SlotReader) (Value, error) {
+	t := loc.Type
+	if t == nil {
+		return Value{}, fmt.Errorf("the layout has no type %s", loc.TypeID)
+	}
+	v := Value{Path: loc.Path, Type: t.Label, Slot: loc.Slot.Hex(), Offset: loc.Offset}
+
+	switch {
+	case t.Encoding == "mapping":
+		v.Value = fmt.Sprintf("read an entry with %s[<key>]", loc.Path)
+		return v, nil
+
+	case t.Encoding == "bytes":
+		word, err := read(loc.Slot)
+		if err != nil {
+			return v, err
+		}
+		data, err := readBytes(loc.Slot, word, read)
+		if err != nil {
+			return v, err
+		}
+		if t.Label == "string" {
+			v.Value = strconv.Quote(string(data))
+		} else {
+			v.Value = hexutil.Encode(data)
+		}
+		return v, nil
+
+	case t.Encoding == "dynamic_array":
+		word, err := read(loc.Slot)
+		if err != nil {
+			return v, err
+		}
+		length := word.Big()
+		v.Value = fmt.Sprintf("length %s", length)
+		if length.Cmp(big.NewInt(MaxListed)) > 0 {
+			return v, nil
+		}
+		return l.readElements(v, loc, int(length.Int64()), read)
+
+	case len(t.Members) > 0:
+		for _, m := range t.Members {
+			mloc, err := l.member(loc, m.Label)
+			if err != nil {
+				return v, err
+			}
+			mv, err := l.Read(mloc, read)
+			if err != nil {
+				return v, err
+			}
+			v.Members = append(v.Members, mv)
+		}
+		return v, nil
+
+	case t.Base != "":
+		m := staticArrayLength.FindStringSubmatch(loc.TypeID)
+		if m == nil {
+			return v, fmt.Errorf("couldn't read the length of %s", loc.TypeID)
+		}
+		length, _ := strconv.Atoi(m[1])
+		v.Value = fmt.Sprintf("length %d", length)
+		if length > MaxListed {
+			return v, nil
+		}
+		return l.readElements(v, loc, length, read)
+	}
+
+	word, err := read(loc.Slot)
+	if err != nil {
+		return v, err
+	}
+	size := t.Size()
+	if loc.Offset+size > 32 {
+		return v, fmt.Errorf("%s doesn't fit in its slot", loc.Path)
+	}
+	v.Value = FormatValue(t.Label, word[32-loc.Offset-size:32-loc.Offset])
+	return v, nil
+}
+
+func (l *Layout) readElements(v Value, loc Location, length int, read SlotReader) (Value, error) {
+	for i := 0; i < length; i++ {
+		eloc, err := l.index(loc, strconv.Itoa(i), EncodeKey)
+		if err != nil {
+			return v, err
+		}
+		ev, err := l.Read(eloc, read)
+		if err != nil {
+			return v, err
+		}
+		v.Members = append(v.Members, ev)
+	}
+	return v, nil
+}
+
+// readBytes decodes a string or bytes stored at slot, whose first word is
+// word. Up to 31 bytes are kept in the word itself with twice the length in
+// the lowest byte; longer ones store 2*length+1 in the word and the data
+// from keccak(slot) on.
+func readBytes(slot, word common.Hash, read SlotReader) ([]byte, error) {
+	if word[31]&1 == 0 {
+		length := int(word[31] / 2)
+		if length > 31 {
+			return nil, fmt.Errorf("the slot doesn't hold a short string")
+		}
+		return word[:length], nil
+	}
+	length := new(big.Int).Rsh(word.Big(), 1)
+	if length.Cmp(big.NewInt(maxBytesLength)) > 0 {
+		return nil, fmt.Errorf("the value is %s bytes long, too long to read", length)
+	}
+	n := int(length.Int64())
+	data := make([]byte, 0, n+31)
+	start := crypto.Keccak256Hash(slot.Bytes())
+	for i := 0; len(data) < n; i++ {
+		chunk, err := read(addToSlot(start, big.NewInt(int64(i))))
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, chunk.Bytes()...)
+	}
+	return data[:n], nil
+}
+
+// FormatValue formats the bytes of a value type as solidity would write it.
+func FormatValue(label string, b []byte) string {
+	switch {
+	case strings.HasPrefix(label, "address") || strings.HasPrefix(label, "contract "):
+		return common.BytesToAddress(b).Hex()
+	case label == "bool":
+		return strconv.FormatBool(new(big.Int).SetBytes(b).Sign() != 0)
+	case strings.HasPrefix(label, "uint") || strings.HasPrefix(label, "enum "):
+		return new(big.Int).SetBytes(b).String()
+	case strings.HasPrefix(label, "int"):
+		n := new(big.Int).SetBytes(b)
+		if len(b) > 0 && b[0]&0x80 != 0 {
+			n.Sub(n, new(big.Int).Lsh(big.NewInt(1), uint(8*len(b))))
+		}
+		return n.String()
+	}
+	// bytesN, user defined value types and function pointers
+	return hexutil.Encode(b)
+}
@@ -0,0 +1,143 @@
+// Package storage locates and decodes the state variables of a contract
+// from the storageLayout solc outputs for it.
+//
+// A variable is named by a path in solidity syntax: the variable, then
+// struct members and mapping keys or array indexes, e.g.
+//
+//	owner
+//	balances[0xabc...def]
+//	positions[0xabc...def][3].amount
+//
+// Mapping keys are written like jarvis params: addresses in hex, numbers in
+// decimal or 0x hex, bools as true/false and strings wrapped in " ".
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// Layout is the storageLayout of a contract.
+type Layout struct {
+	Storage []Variable       `json:"storage"`
+	Types   map[string]*Type `json:"types"`
+}
+
+// Variable is a state variable, or a member of a struct, placed at Offset
+// bytes from the right of slot Slot.
+type Variable struct {
+	Label  string `json:"label"`
+	Offset int    `json:"offset"`
+	Slot   string `json:"slot"`
+	Type   string `json:"type"`
+}
+
+// Type is a type of the layout. Encoding is inplace, mapping,
+// dynamic_array or bytes.
+type Type struct {
+	Encoding      string     `json:"encoding"`
+	Label         string     `json:"label"`
+	NumberOfBytes string     `json:"numberOfBytes"`
+	Key           string     `json:"key,omitempty"`
+	Value         string     `json:"value,omitempty"`
+	Base          string     `json:"base,omitempty"`
+	Members       []Variable `json:"members,omitempty"`
+}
+
+// Size is the number of bytes a value of t takes in storage.
+func (t *Type) Size() uint64 {
+	n, ok := new(big.Int).SetString(t.NumberOfBytes, 10)
+	if !ok || !n.IsUint64() {
+		return 32
+	}
+	return n.Uint64()
+}
+
+// ParseLayout reads a layout from data, which is either the layout itself,
+// a Foundry artifact compiled with the storageLayout output, or a solc
+// standard-JSON output or Hardhat build-info holding it. contract picks the
+// contract of a solc output, by name or by fully qualified name
+// path:Name, and can be empty when only one contract has a layout.
+func ParseLayout(data []byte, contract string) (*Layout, error) {
+	file := struct {
+		Storage       json.RawMessage                    `json:"storage"`
+		StorageLayout *Layout                            `json:"storageLayout"`
+		Output        *solcOutput                        `json:"output"`
+		Contracts     map[string]map[string]solcContract `json:"contracts"`
+	}{}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("the layout isn't JSON: %w", err)
+	}
+	switch {
+	case file.Storage != nil:
+		layout := &Layout{}
+		if err := json.Unmarshal(data, layout); err != nil {
+			return nil, fmt.Errorf("couldn't decode the layout: %w", err)
+		}
+		return checkLayout(layout)
+	case file.StorageLayout != nil:
+		return checkLayout(file.StorageLayout)
+	case file.Output != nil:
+		return file.Output.layoutOf(contract)
+	case file.Contracts != nil:
+		return (&solcOutput{Contracts: file.Contracts}).layoutOf(contract)
+	}
+	return nil, fmt.Errorf("no storage layout found, compile with the storageLayout output, e.g. forge build --extra-output storageLayout")
+}
+
+type solcContract struct {
+	StorageLayout *Layout `json:"storageLayout"`
+}
+
+type solcOutput struct {
+	Contracts map[string]map[string]solcContract `json:"contracts"`
+}
+
+func (o *solcOutput) layoutOf(contract string) (*Layout, error) {
+	found := []string{}
+	layouts := map[string]*Layout{}
+	for path, contracts := range o.Contracts {
+		for name, c := range contracts {
+			if c.StorageLayout == nil {
+				continue
+			}
+			fqn := path + ":" + name
+			if contract == "" || contract == name || contract == fqn {
+				found = append(found, fqn)
+				layouts[fqn] = c.StorageLayout
+			}
+		}
+	}
+	sort.Strings(found)
+	switch {
+	case len(found) == 1:
+		return checkLayout(layouts[found[0]])
+	case len(found) == 0 && contract != "":
+		return nil, fmt.Errorf("no storage layout of %s in the output", contract)
+	case len(found) == 0:
+		return nil, fmt.Errorf("no storage layout in the output, compile with the storageLayout output selected")
+	}
+	return nil, fmt.Errorf("the output has the layouts of %s, pick one with its fully qualified name", strings.Join(found, ", "))
+}
+
+func checkLayout(l *Layout) (*Layout, error) {
+	for _, v := range l.Storage {
+		if l.Types[v.Type] == nil {
+			return nil, fmt.Errorf("the layout has no type %s of %s", v.Type, v.Label)
+		}
+	}
+	return l, nil
+}
+
+// Variable returns the state variable named label.
+func (l *Layout) Variable(label string) (Variable, bool) {
+	for _, v := range l.Storage {
+		if v.Label == label {
+			return v, true
+		}
+	}
+	return Variable{}, false
+}
@@ -0,0 +1,286 @@
+package storage
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Location is where a value lives: Offset bytes from the right of Slot.
+type Location struct {
+	Path   string
+	Slot   common.Hash
+	Offset uint64
+	TypeID string
+	Type   *Type
+}
+
+// KeyEncoder turns a mapping key the user wrote into the bytes the slot of
+// its entry is hashed with: a 32 bytes word for value types, the raw bytes
+// for string and bytes keys.
+type KeyEncoder func(keyType *Type, key string) ([]byte, error)
+
+// staticArrayLength reads the length of t_array(<base>)<length>_storage.
+var staticArrayLength = regexp.MustCompile(`\)(\d+)_storage$`)
+
+// Locate returns the location of the state variable of v.
+func (l *Layout) Locate(v Variable) (Location, error) {
+	slot, ok := new(big.Int).SetString(v.Slot, 10)
+	if !ok {
+		return Location{}, fmt.Errorf("%s is at an invalid slot %s", v.Label, v.Slot)
+	}
+	return Location{
+		Path:   v.Label,
+		Slot:   common.BigToHash(slot),
+		Offset: uint64(v.Offset),
+		TypeID: v.Type,
+		Type:   l.Types[v.Type],
+	}, nil
+}
+
+// Resolve returns the location of the value at path. encodeKey encodes the
+// mapping keys of the path, EncodeKey when it is nil.
+func (l *Layout) Resolve(path string, encodeKey KeyEncoder) (Location, error) {
+	if encodeKey == nil {
+		encodeKey = EncodeKey
+	}
+	steps, err := splitPath(path)
+	if err != nil {
+		return Location{}, err
+	}
+	v, found := l.Variable(steps[0])
+	if !found {
+		return Location{}, fmt.Errorf("the contract has no state variable %s", steps[0])
+	}
+	loc, err := l.Locate(v)
+	if err != nil {
+		return Location{}, err
+	}
+	for _, step := range steps[1:] {
+		if strings.HasPrefix(step, "[") {
+			loc, err = l.index(loc, strings.TrimSpace(step[1:len(step)-1]), encodeKey)
+		} else {
+			loc, err = l.member(loc, step)
+		}
+		if err != nil {
+			return Location{}, err
+		}
+	}
+	return loc, nil
+}
+
+// splitPath splits a.b[key][1].c into a, b, [key], [1] and c.
+func splitPath(path string) ([]string, error) {
+	path = strings.TrimSpace(path)
+	steps := []string{}
+	start := 0
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '.':
+			if i > start {
+				steps = append(steps, path[start:i])
+			}
+			start = i + 1
+		case '[':
+			if i > start {
+				steps = append(steps, path[start:i])
+			}
+			end, err := closingBracket(path, i)
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, path[i:end+1])
+			i, start = end, end+1
+		}
+	}
+	if start < len(path) {
+		steps = append(steps, path[start:])
+	}
+	if len(steps) == 0 || strings.HasPrefix(steps[0], "[") {
+		return nil, fmt.Errorf("%q doesn't start with a variable name", path)
+	}
+	return steps, nil
+}
+
+// closingBracket finds the ] closing the [ at open, skipping quoted keys.
+func closingBracket(path string, open int) (int, error) {
+	quoted := false
+	for i := open + 1; i < len(path); i++ {
+		switch path[i] {
+		case '"':
+			quoted = !quoted
+		case ']':
+			if !quoted {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("%q has an unclosed [", path)
+}
+
+func (l *Layout) member(loc Location, name string) (Location, error) {
+	if len(loc.Type.Members) == 0 {
+		return Location{}, fmt.Errorf("%s is a %s, it has no member %s", loc.Path, loc.Type.Label, name)
+	}
+	for _, m := range loc.Type.Members {
+		if m.Label != name {
+			continue
+		}
+		offset, ok := new(big.Int).SetString(m.Slot, 10)
+		if !ok {
+			return Location{}, fmt.Errorf("%s.%s is at an invalid slot %s", loc.Path, name, m.Slot)
+		}
+		return Location{
+			Path:   loc.Path + "." + name,
+			Slot:   addToSlot(loc.Slot, offset),
+			Offset: uint64(m.Offset),
+			TypeID: m.Type,
+			Type:   l.Types[m.Type],
+		}, nil
+	}
+	return Location{}, fmt.Errorf("%s has no member %s", loc.Path, name)
+}
+
+func (l *Layout) index(loc Location, key string, encodeKey KeyEncoder) (Location, error) {
+	path := fmt.Sprintf("%s[%s]", loc.Path, key)
+	switch {
+	case loc.Type.Encoding == "mapping":
+		keyType := l.Types[loc.Type.Key]
+		if keyType == nil {
+			return Location{}, fmt.Errorf("the layout has no type %s", loc.Type.Key)
+		}
+		encoded, err := encodeKey(keyType, key)
+		if err != nil {
+			return Location{}, fmt.Errorf("invalid key %s of %s: %w", key, loc.Path, err)
+		}
+		return Location{
+			Path:   path,
+			Slot:   crypto.Keccak256Hash(encoded, loc.Slot.Bytes()),
+			TypeID: loc.Type.Value,
+			Type:   l.Types[loc.Type.Value],
+		}, nil
+
+	case loc.Type.Encoding == "dynamic_array":
+		i, err := parseIndex(key)
+		if err != nil {
+			return Location{}, err
+		}
+		// the length is at the slot, the elements from its hash on
+		start := crypto.Keccak256Hash(loc.Slot.Bytes())
+		return l.element(path, start, i, loc.Type.Base)
+
+	case loc.Type.Base != "":
+		i, err := parseIndex(key)
+		if err != nil {
+			return Location{}, err
+		}
+		if m := staticArrayLength.FindStringSubmatch(loc.TypeID); m != nil {
+			if length, _ := strconv.ParseUint(m[1], 10, 64); i >= length {
+				return Location{}, fmt.Errorf("index %d is out of %s of %d elements", i, loc.Path, length)
+			}
+		}
+		return l.element(path, loc.Slot, i, loc.Type.Base)
+	}
+	return Location{}, fmt.Errorf("%s is a %s, it can't be indexed", loc.Path, loc.Type.Label)
+}
+
+// element is the location of element i of an array of base starting at
+// slot start. Elements of 16 bytes or less are packed together.
+func (l *Layout) element(path string, start common.Hash, i uint64, base string) (Location, error) {
+	t := l.Types[base]
+	if t == nil {
+		return Location{}, fmt.Errorf("the layout has no type %s", base)
+	}
+	size := t.Size()
+	loc := Location{Path: path, TypeID: base, Type: t}
+	if perSlot := 32 / size; perSlot > 1 {
+		loc.Slot = addToSlot(start, new(big.Int).SetUint64(i/perSlot))
+		loc.Offset = i % perSlot * size
+		return loc, nil
+	}
+	slots := (size + 31) / 32
+	loc.Slot = addToSlot(start, new(big.Int).Mul(new(big.Int).SetUint64(i), new(big.Int).SetUint64(slots)))
+	return loc, nil
+}
+
+func parseIndex(key string) (uint64, error) {
+	i, err := strconv.ParseUint(key, 0, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid array index %s", key)
+	}
+	return i, nil
+}
+
+func addToSlot(slot common.Hash, n *big.Int) common.Hash {
+	sum := new(big.Int).Add(slot.Big(), n)
+	return common.BigToHash(math.U256(sum))
+}
+
+// EncodeKey encodes key as a mapping key of keyType.
+func EncodeKey(keyType *Type, key string) ([]byte, error) {
+	label := keyType.Label
+	switch {
+	case label == "string":
+		if len(key) < 2 || !strings.HasPrefix(key, `"`) || !strings.HasSuffix(key, `"`) {
+			return nil, fmt.Errorf("a string key must be wrapped in \" \"")
+		}
+		return []byte(key[1 : len(key)-1]), nil
+
+	case label == "bytes":
+		return hexBytes(key)
+
+	case strings.HasPrefix(label, "address") || strings.HasPrefix(label, "contract "):
+		if !common.IsHexAddress(key) {
+			return nil, fmt.Errorf("%s isn't an address", key)
+		}
+		return common.LeftPadBytes(common.HexToAddress(key).Bytes(), 32), nil
+
+	case label == "bool":
+		switch strings.ToLower(key) {
+		case "true":
+			return common.LeftPadBytes([]byte{1}, 32), nil
+		case "false":
+			return make([]byte, 32), nil
+		}
+		return nil, fmt.Errorf("%s isn't a bool", key)
+
+	case strings.HasPrefix(label, "bytes"):
+		b, err := hexBytes(key)
+		if err != nil {
+			return nil, err
+		}
+		if uint64(len(b)) > keyType.Size() {
+			return nil, fmt.Errorf("%s is longer than a %s", key, label)
+		}
+		return common.RightPadBytes(b, 32), nil
+
+	case strings.HasPrefix(label, "int"):
+		n, ok := math.ParseBig256(key)
+		if !ok {
+			return nil, fmt.Errorf("%s isn't a number", key)
+		}
+		return math.U256Bytes(n), nil
+	}
+
+	// uints, enums and user defined value types
+	n, ok := math.ParseBig256(key)
+	if !ok || n.Sign() < 0 {
+		return nil, fmt.Errorf("%s isn't an unsigned number", key)
+	}
+	return common.LeftPadBytes(n.Bytes(), 32), nil
+}
+
+func hexBytes(s string) ([]byte, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X"))
+	if err != nil {
+		return nil, fmt.Errorf("%s isn't hex", s)
+	}
+	return b, nil
+}
@@ -0,0 +1,216 @@
+package storage
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// testLayout is the layout of
+//
+//	contract C {
+//	    struct Pos { uint128 a; uint128 b; address who; }
+//	    address owner;
+//	    uint96 fee;
+//	    bool paused;
+//	    mapping(address => uint256) balances;
+//	    uint256[] list;
+//	    string name;
+//	    mapping(address => Pos[]) positions;
+//	    uint8[3] small;
+//	    int16 delta;
+//	    mapping(string => bool) flags;
+//	}
+const testLayout = `{
+  "storage": [
+    {"label": "owner", "offset": 0, "slot": "0", "type": "t_address"},
+    {"label": "fee", "offset": 20, "slot": "0", "type": "t_uint96"},
+    {"label": "paused", "offset": 0, "slot": "1", "type": "t_bool"},
+    {"label": "balances", "offset": 0, "slot": "2", "type": "t_mapping(t_address,t_uint256)"},
+    {"label": "list", "offset": 0, "slot": "3", "type": "t_array(t_uint256)dyn_storage"},
+    {"label": "name", "offset": 0, "slot": "4", "type": "t_string_storage"},
+    {"label": "positions", "offset": 0, "slot": "5", "type": "t_mapping(t_address,t_array(t_struct(Pos)10_storage)dyn_storage)"},
+    {"label": "small", "offset": 0, "slot": "6", "type": "t_array(t_uint8)3_storage"},
+    {"label": "delta", "offset": 0, "slot": "7", "type": "t_int16"},
+    {"label": "flags", "offset": 0, "slot": "8", "type": "t_mapping(t_string_memory_ptr,t_bool)"}
+  ],
+  "types": {
+    "t_address": {"encoding": "inplace", "label": "address", "numberOfBytes": "20"},
+    "t_uint96": {"encoding": "inplace", "label": "uint96", "numberOfBytes": "12"},
+    "t_uint128": {"encoding": "inplace", "label": "uint128", "numberOfBytes": "16"},
+    "t_uint256": {"encoding": "inplace", "label": "uint256", "numberOfBytes": "32"},
+    "t_uint8": {"encoding": "inplace", "label": "uint8", "numberOfBytes": "1"},
+    "t_int16": {"encoding": "inplace", "label": "int16", "numberOfBytes": "2"},
+    "t_bool": {"encoding": "inplace", "label": "bool", "numberOfBytes": "1"},
+    "t_string_storage": {"encoding": "bytes", "label": "string", "numberOfBytes": "32"},
+    "t_string_memory_ptr": {"encoding": "bytes", "label": "string", "numberOfBytes": "32"},
+    "t_mapping(t_address,t_uint256)": {"encoding": "mapping", "key": "t_address", "label": "mapping(address => uint256)", "numberOfBytes": "32", "value": "t_uint256"},
+    "t_mapping(t_string_memory_ptr,t_bool)": {"encoding": "mapping", "key": "t_string_memory_ptr", "label": "mapping(string => bool)", "numberOfBytes": "32", "value": "t_bool"},
+    "t_array(t_uint256)dyn_storage": {"base": "t_uint256", "encoding": "dynamic_array", "label": "uint256[]", "numberOfBytes": "32"},
+    "t_array(t_uint8)3_storage": {"base": "t_uint8", "encoding": "inplace", "label": "uint8[3]", "numberOfBytes": "32"},
+    "t_struct(Pos)10_storage": {"encoding": "inplace", "label": "struct C.Pos", "numberOfBytes": "64", "members": [
+      {"label": "a", "offset": 0, "slot": "0", "type": "t_uint128"},
+      {"label": "b", "offset": 16, "slot": "0", "type": "t_uint128"},
+      {"label": "who", "offset": 0, "slot": "1", "type": "t_address"}
+    ]},
+    "t_array(t_struct(Pos)10_storage)dyn_storage": {"base": "t_struct(Pos)10_storage", "encoding": "dynamic_array", "label": "struct C.Pos[]", "numberOfBytes": "32"},
+    "t_mapping(t_address,t_array(t_struct(Pos)10_storage)dyn_storage)": {"encoding": "mapping", "key": "t_address", "label": "mapping(address => struct C.Pos[])", "numberOfBytes": "32", "value": "t_array(t_struct(Pos)10_storage)dyn_storage"}
+  }
+}`
+
+var user = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+
+func slot(n int64) common.Hash {
+	return common.BigToHash(big.NewInt(n))
+}
+
+func plus(h common.Hash, n int64) common.Hash {
+	return common.BigToHash(new(big.Int).Add(h.Big(), big.NewInt(n)))
+}
+
+func mustLayout(t *testing.T) *Layout {
+	t.Helper()
+	l, err := ParseLayout([]byte(testLayout), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func TestResolve(t *testing.T) {
+	l := mustLayout(t)
+	userKey := common.LeftPadBytes(user.Bytes(), 32)
+	positions := crypto.Keccak256Hash(crypto.Keccak256Hash(userKey, slot(5).Bytes()).Bytes())
+	for _, tc := range []struct {
+		path   string
+		slot   common.Hash
+		offset uint64
+		typ    string
+	}{
+		{"fee", slot(0), 20, "uint96"},
+		{"balances[" + user.Hex() + "]", crypto.Keccak256Hash(userKey, slot(2).Bytes()), 0, "uint256"},
+		{"list[2]", plus(crypto.Keccak256Hash(slot(3).Bytes()), 2), 0, "uint256"},
+		// elements of Pos take 2 slots
+		{"positions[" + user.Hex() + "][1].b", plus(positions, 2), 16, "uint128"},
+		{"positions[" + user.Hex() + "][1].who", plus(positions, 3), 0, "address"},
+		{"small[2]", slot(6), 2, "uint8"},
+		{`flags["a]b"]`, crypto.Keccak256Hash([]byte("a]b"), slot(8).Bytes()), 0, "bool"},
+	} {
+		loc, err := l.Resolve(tc.path, nil)
+		if err != nil {
+			t.Fatalf("%s: %s", tc.path, err)
+		}
+		if loc.Slot != tc.slot || loc.Offset != tc.offset || loc.Type.Label != tc.typ {
+			t.Fatalf("%s is at %s+%d (%s), want %s+%d (%s)",
+				tc.path, loc.Slot.Hex(), loc.Offset, loc.Type.Label, tc.slot.Hex(), tc.offset, tc.typ)
+		}
+	}
+
+	for _, path := range []string{"missing", "small[3]", "owner[0]", "fee.x", "balances[0x12]", "list[", "[1]"} {
+		if _, err := l.Resolve(path, nil); err == nil {
+			t.Fatalf("%s resolved, want an error", path)
+		}
+	}
+}
+
+func TestRead(t *testing.T) {
+	l := mustLayout(t)
+	long := strings.Repeat("jarvis ", 6)
+	storage := map[common.Hash]common.Hash{}
+	// owner and fee share slot 0
+	storage[slot(0)] = common.BytesToHash(append(common.LeftPadBytes(big.NewInt(500).Bytes(), 12), user.Bytes()...))
+	storage[slot(1)] = slot(1)
+	storage[slot(3)] = slot(2)
+	listStart := crypto.Keccak256Hash(slot(3).Bytes())
+	storage[listStart] = slot(7)
+	storage[plus(listStart, 1)] = slot(8)
+	storage[slot(4)] = slot(int64(2*len(long) + 1))
+	nameStart := crypto.Keccak256Hash(slot(4).Bytes())
+	storage[nameStart] = common.BytesToHash([]byte(long[:32]))
+	storage[plus(nameStart, 1)] = common.BytesToHash(common.RightPadBytes([]byte(long[32:]), 32))
+	storage[slot(6)] = common.BytesToHash([]byte{3, 2, 1})
+	storage[slot(7)] = common.BytesToHash([]byte{0xff, 0xfe})
+	read := func(s common.Hash) (common.Hash, error) {
+		return storage[s], nil
+	}
+
+	for path, want := range map[string]string{
+		"owner":    user.Hex(),
+		"fee":      "500",
+		"paused":   "true",
+		"list":     "length 2",
+		"list[1]":  "8",
+		"name":     `"` + long + `"`,
+		"small[0]": "1",
+		"small[2]": "3",
+		"delta":    "-2",
+		"balances": "read an entry with balances[<key>]",
+	} {
+		loc, err := l.Resolve(path, nil)
+		if err != nil {
+			t.Fatalf("%s: %s", path, err)
+		}
+		v, err := l.Read(loc, read)
+		if err != nil {
+			t.Fatalf("%s: %s", path, err)
+		}
+		if v.Value != want {
+			t.Fatalf("%s = %s, want %s", path, v.Value, want)
+		}
+	}
+
+	loc, _ := l.Resolve("list", nil)
+	v, _ := l.Read(loc, read)
+	if len(v.Members) != 2 || v.Members[0].Value != "7" || v.Members[0].Path != "list[0]" {
+		t.Fatalf("list elements %+v, want 7 and 8", v.Members)
+	}
+}
+
+func TestReadShortString(t *testing.T) {
+	l := mustLayout(t)
+	word := common.Hash{}
+	copy(word[:], "jarvis")
+	word[31] = 2 * 6
+	loc, _ := l.Resolve("name", nil)
+	v, err := l.Read(loc, func(common.Hash) (common.Hash, error) { return word, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Value != `"jarvis"` {
+		t.Fatalf("name = %s, want \"jarvis\"", v.Value)
+	}
+}
+
+func TestParseLayoutFromOutputs(t *testing.T) {
+	var layout json.RawMessage = []byte(testLayout)
+	foundry, _ := json.Marshal(map[string]interface{}{"abi": []interface{}{}, "storageLayout": layout})
+	if l, err := ParseLayout(foundry, ""); err != nil || len(l.Storage) != 10 {
+		t.Fatalf("foundry artifact: %v", err)
+	}
+
+	buildInfo, _ := json.Marshal(map[string]interface{}{
+		"output": map[string]interface{}{
+			"contracts": map[string]interface{}{
+				"src/C.sol": map[string]interface{}{
+					"C": map[string]interface{}{"storageLayout": layout},
+					"D": map[string]interface{}{"storageLayout": map[string]interface{}{"storage": []interface{}{}, "types": nil}},
+				},
+			},
+		},
+	})
+	if _, err := ParseLayout(buildInfo, ""); err == nil {
+		t.Fatal("picked a layout out of two without a contract name")
+	}
+	for _, name := range []string{"C", "src/C.sol:C"} {
+		if l, err := ParseLayout(buildInfo, name); err != nil || len(l.Storage) != 10 {
+			t.Fatalf("build-info, %s: %v", name, err)
+		}
+	}
+	if _, err := ParseLayout([]byte(`{"abi": []}`), ""); err == nil {
+		t.Fatal("found a layout in an artifact without one")
+	}
+}
@@ -0,0 +1,33 @@
+package util
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/tranvictor/jarvis/networks"
+	"github.com/tranvictor/jarvis/util/explorers"
+	"github.com/tranvictor/jarvis/util/storage"
+)
+
+// ReadStorageLayout reads the storage layout of a contract from source: a
+// Foundry artifact, a solc output or build-info, or a layout file, either
+// local or at an http(s) URL, or the address of a contract verified on
+// Sourcify. contract picks the contract of a solc output holding several.
+func ReadStorageLayout(source, contract string, network networks.Network) (*storage.Layout, error) {
+	var data []byte
+	var err error
+	switch {
+	case isRealAddress(source):
+		data, err = explorers.SourcifyStorageLayout(network.GetChainID(), source)
+	case isHttpURL(source):
+		var str string
+		str, err = GetABIStringFromURL(source)
+		data = []byte(str)
+	default:
+		data, err = os.ReadFile(source)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read the storage layout from %s: %w", source, err)
+	}
+	return storage.ParseLayout(data, contract)
+}
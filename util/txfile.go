@@ -0,0 +1,248 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// TxFile is the on-disk form of a transaction that is built on an online
+// machine, signed on an offline one and broadcasted from an online one
+// again, so the signing key never has to touch a networked box:
+//
+//	jarvis send ... --unsigned-out tx.json   (online: nonce, fees, decoding)
+//	jarvis sign tx.json                      (offline: keystore/Ledger/Trezor)
+//	jarvis broadcast tx-signed.json          (online)
+//
+// Call is the decoded view of the calldata produced by the online machine's
+// analyzer. The offline machine has no way to fetch ABIs, so it shows Call
+// as-is; it is informational and never used to build the tx.
+//
+// Numeric fields that can exceed 2^53 are encoded as decimal strings, the
+// same way safe.TxFile does, so the file survives any JSON library and diffs
+// cleanly. SignedTx and TxHash are empty until the file has been signed.
+type TxFile struct {
+	Version  string               `json:"version,omitempty"`
+	Network  string               `json:"network"`
+	ChainID  uint64               `json:"chain_id"`
+	From     string               `json:"from"`
+	Tx       TxFileTx             `json:"tx"`
+	Call     *FunctionCallDisplay `json:"call,omitempty"`
+	SignedTx string               `json:"signed_tx,omitempty"`
+	TxHash   string               `json:"tx_hash,omitempty"`
+}
+
+// TxFileTx holds the unsigned tx fields. GasPrice is set for legacy txs,
+// MaxFeePerGas / MaxPriorityFeePerGas for dynamic-fee txs. To is empty for
+// contract creation. AccessList is only set for dynamic-fee txs built with
+// --access-list.
+type TxFileTx struct {
+	Type                 uint8  `json:"type"`
+	Nonce                uint64 `json:"nonce"`
+	To                   string `json:"to,omitempty"`
+	Value                string `json:"value"`
+	Gas                  uint64 `json:"gas"`
+	GasPrice             string `json:"gas_price,omitempty"`
+	MaxFeePerGas         string `json:"max_fee_per_gas,omitempty"`
+	MaxPriorityFeePerGas string `json:"max_priority_fee_per_gas,omitempty"`
+	Data                 string `json:"data"`
+
+	AccessList types.AccessList `json:"access_list,omitempty"`
+}
+
+// currentTxFileVersion is the format version we write. Readers accept any
+// version; this exists so future changes can be rolled out cleanly.
+const currentTxFileVersion = "jarvis-txfile/v1"
+
+// NewTxFile describes the unsigned tx to be signed by from on the given
+// network. call may be nil when the tx carries no calldata.
+func NewTxFile(
+	network string, chainID uint64, from string,
+	tx *types.Transaction, call *FunctionCallDisplay,
+) (*TxFile, error) {
+	ftx := TxFileTx{
+		Type:  tx.Type(),
+		Nonce: tx.Nonce(),
+		Value: tx.Value().String(),
+		Gas:   tx.Gas(),
+		Data:  hexutil.Encode(tx.Data()),
+	}
+	if tx.To() != nil {
+		ftx.To = tx.To().Hex()
+	}
+	switch tx.Type() {
+	case types.LegacyTxType:
+		ftx.GasPrice = tx.GasPrice().String()
+	case types.DynamicFeeTxType:
+		ftx.MaxFeePerGas = tx.GasFeeCap().String()
+		ftx.MaxPriorityFeePerGas = tx.GasTipCap().String()
+		ftx.AccessList = tx.AccessList()
+	default:
+		return nil, fmt.Errorf("tx type %d is not supported in tx files", tx.Type())
+	}
+	return &TxFile{
+		Version: currentTxFileVersion,
+		Network: network,
+		ChainID: chainID,
+		From:    common.HexToAddress(from).Hex(),
+		Tx:      ftx,
+		Call:    call,
+	}, nil
+}
+
+// Transaction rebuilds the unsigned tx described by the file.
+func (f *TxFile) Transaction() (*types.Transaction, error) {
+	value, err := parseTxFileBig(f.Tx.Value, "value")
+	if err != nil {
+		return nil, err
+	}
+	data, err := hexutil.Decode(f.Tx.Data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data: %w", err)
+	}
+	var to *common.Address
+	if f.Tx.To != "" {
+		if !common.IsHexAddress(f.Tx.To) {
+			return nil, fmt.Errorf("invalid to: %q", f.Tx.To)
+		}
+		addr := common.HexToAddress(f.Tx.To)
+		to = &addr
+	}
+
+	switch f.Tx.Type {
+	case types.LegacyTxType:
+		gasPrice, err := parseTxFileBig(f.Tx.GasPrice, "gas_price")
+		if err != nil {
+			return nil, err
+		}
+		return types.NewTx(&types.LegacyTx{
+			Nonce:    f.Tx.Nonce,
+			GasPrice: gasPrice,
+			Gas:      f.Tx.Gas,
+			To:       to,
+			Value:    value,
+			Data:     data,
+		}), nil
+	case types.DynamicFeeTxType:
+		feeCap, err := parseTxFileBig(f.Tx.MaxFeePerGas, "max_fee_per_gas")
+		if err != nil {
+			return nil, err
+		}
+		tip, err := parseTxFileBig(f.Tx.MaxPriorityFeePerGas, "max_priority_fee_per_gas")
+		if err != nil {
+			return nil, err
+		}
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID:    new(big.Int).SetUint64(f.ChainID),
+			Nonce:      f.Tx.Nonce,
+			GasTipCap:  tip,
+			GasFeeCap:  feeCap,
+			Gas:        f.Tx.Gas,
+			To:         to,
+			Value:      value,
+			Data:       data,
+			AccessList: f.Tx.AccessList,
+		}), nil
+	default:
+		return nil, fmt.Errorf("tx type %d is not supported in tx files", f.Tx.Type)
+	}
+}
+
+// SetSigned records signedTx in the file. It refuses a signed tx whose
+// content differs from the unsigned one the file describes, so a file can't
+// end up carrying a signature for something other than what it shows.
+func (f *TxFile) SetSigned(signedTx *types.Transaction) error {
+	unsigned, err := f.Transaction()
+	if err != nil {
+		return err
+	}
+	signer := types.LatestSignerForChainID(new(big.Int).SetUint64(f.ChainID))
+	if signer.Hash(unsigned) != signer.Hash(signedTx) {
+		return fmt.Errorf("signed tx doesn't match the tx described in the file")
+	}
+	raw, err := signedTx.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("couldn't encode the signed tx: %w", err)
+	}
+	f.SignedTx = hexutil.Encode(raw)
+	f.TxHash = signedTx.Hash().Hex()
+	return nil
+}
+
+// SignedTransaction decodes the signed tx recorded by SetSigned.
+func (f *TxFile) SignedTransaction() (*types.Transaction, error) {
+	if f.SignedTx == "" {
+		return nil, fmt.Errorf("the tx file is not signed yet, sign it with `jarvis sign` first")
+	}
+	tx, err := DecodeSignedTx(f.SignedTx)
+	if err != nil {
+		return nil, err
+	}
+	if f.TxHash != "" && tx.Hash().Hex() != common.HexToHash(f.TxHash).Hex() {
+		return nil, fmt.Errorf("signed_tx hashes to %s but the file says %s", tx.Hash().Hex(), f.TxHash)
+	}
+	return tx, nil
+}
+
+// DecodeSignedTx decodes a 0x-prefixed raw signed tx, in the same encoding
+// eth_sendRawTransaction takes.
+func DecodeSignedTx(rawHex string) (*types.Transaction, error) {
+	raw, err := hexutil.Decode(rawHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid raw tx hex: %w", err)
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return nil, fmt.Errorf("couldn't decode raw tx: %w", err)
+	}
+	return tx, nil
+}
+
+// WriteTxFile serialises f to path as pretty-printed JSON with a trailing
+// newline, overwriting any existing file.
+func WriteTxFile(path string, f *TxFile) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal tx file: %w", err)
+	}
+	data = append(data, '\n')
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("write tx file %s: %w", path, err)
+	}
+	return nil
+}
+
+// ReadTxFile loads a TxFile from path, verifying the basic shape.
+func ReadTxFile(path string) (*TxFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read tx file %s: %w", path, err)
+	}
+	var f TxFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse tx file %s: %w", path, err)
+	}
+	if f.ChainID == 0 {
+		return nil, fmt.Errorf("tx file %s: missing chain_id", path)
+	}
+	if !common.IsHexAddress(f.From) {
+		return nil, fmt.Errorf("tx file %s: missing or invalid from", path)
+	}
+	return &f, nil
+}
+
+func parseTxFileBig(s, field string) (*big.Int, error) {
+	if s == "" {
+		return new(big.Int), nil
+	}
+	v, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid %s: %q", field, s)
+	}
+	return v, nil
+}
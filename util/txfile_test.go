@@ -0,0 +1,120 @@
+package util_test
+
+import (
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/tranvictor/jarvis/ui"
+	"github.com/tranvictor/jarvis/util"
+)
+
+func TestTxFileRoundTripAndSign(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	to := ethcommon.HexToAddress("0x1d9937e170Fc2174408581265bA0B87afDA4947F")
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   big.NewInt(8453),
+		Nonce:     42,
+		GasTipCap: big.NewInt(1_000_000),
+		GasFeeCap: big.NewInt(2_000_000_000),
+		Gas:       60000,
+		To:        &to,
+		Value:     new(big.Int).Lsh(big.NewInt(1), 100), // > 2^53, must survive JSON
+		Data:      []byte{0xa9, 0x05, 0x9c, 0xbb},
+		AccessList: types.AccessList{
+			{Address: to, StorageKeys: []ethcommon.Hash{{0x01}}},
+		},
+	})
+	call := &util.FunctionCallDisplay{
+		Destination: ui.StyledText{Text: to.Hex() + " (USDC)", Severity: ui.SeveritySuccess},
+		Method:      "transfer",
+	}
+
+	f, err := util.NewTxFile("base", 8453, from.Hex(), tx, call)
+	if err != nil {
+		t.Fatalf("NewTxFile: %s", err)
+	}
+	path := filepath.Join(t.TempDir(), "tx.json")
+	if err := util.WriteTxFile(path, f); err != nil {
+		t.Fatalf("WriteTxFile: %s", err)
+	}
+
+	read, err := util.ReadTxFile(path)
+	if err != nil {
+		t.Fatalf("ReadTxFile: %s", err)
+	}
+	if read.Version == "" || read.Network != "base" || read.Call.Method != "transfer" {
+		t.Fatalf("unexpected file content: %+v", read)
+	}
+	if read.Call.Destination.Text != to.Hex()+" (USDC)" {
+		t.Fatalf("call destination = %q", read.Call.Destination.Text)
+	}
+	if _, err := read.SignedTransaction(); err == nil {
+		t.Fatalf("an unsigned file must not yield a signed tx")
+	}
+
+	unsigned, err := read.Transaction()
+	if err != nil {
+		t.Fatalf("Transaction: %s", err)
+	}
+	signer := types.LatestSignerForChainID(big.NewInt(8453))
+	if signer.Hash(unsigned) != signer.Hash(tx) {
+		t.Fatalf("rebuilt tx differs from the original")
+	}
+
+	signed, err := types.SignTx(unsigned, signer, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := read.SetSigned(signed); err != nil {
+		t.Fatalf("SetSigned: %s", err)
+	}
+	if err := util.WriteTxFile(path, read); err != nil {
+		t.Fatal(err)
+	}
+	signedFile, err := util.ReadTxFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := signedFile.SignedTransaction()
+	if err != nil {
+		t.Fatalf("SignedTransaction: %s", err)
+	}
+	if got.Hash() != signed.Hash() {
+		t.Fatalf("hash = %s, want %s", got.Hash(), signed.Hash())
+	}
+	sender, err := types.Sender(signer, got)
+	if err != nil || sender != from {
+		t.Fatalf("sender = %s (%v), want %s", sender, err, from)
+	}
+}
+
+func TestTxFileSetSignedRejectsDifferentTx(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	to := ethcommon.HexToAddress("0x1d9937e170Fc2174408581265bA0B87afDA4947F")
+	tx := types.NewTx(&types.LegacyTx{
+		Nonce: 1, GasPrice: big.NewInt(1), Gas: 21000, To: &to, Value: big.NewInt(1),
+	})
+	f, err := util.NewTxFile("bsc", 56, crypto.PubkeyToAddress(key.PublicKey).Hex(), tx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := types.NewTx(&types.LegacyTx{
+		Nonce: 1, GasPrice: big.NewInt(1), Gas: 21000, To: &to, Value: big.NewInt(2),
+	})
+	signed, err := types.SignTx(other, types.LatestSignerForChainID(big.NewInt(56)), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.SetSigned(signed); err == nil {
+		t.Fatalf("expected SetSigned to reject a tx with a different value")
+	}
+}
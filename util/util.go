@@ -24,6 +24,7 @@ import (
 	db "github.com/tranvictor/jarvis/db"
 	"github.com/tranvictor/jarvis/networks"
 	"github.com/tranvictor/jarvis/ui"
+	"github.com/tranvictor/jarvis/util/abistore"
 	"github.com/tranvictor/jarvis/util/addrbook"
 	"github.com/tranvictor/jarvis/util/broadcaster"
 	"github.com/tranvictor/jarvis/util/cache"
@@ -310,7 +311,7 @@ func AnalyzeMethodCallAndPrint(
 	network networks.Network,
 ) (fc *jarviscommon.FunctionCall) {
 	fc = analyzer.AnalyzeFunctionCallRecursively(
-		GetABI, value, destination, data, customABIs)
+		ResolveABI, value, destination, data, customABIs)
 	DisplayFunctionCall(u, fc)
 	return fc
 }
@@ -352,19 +353,34 @@ func AnalyzeAndPrint(
 
 	if isContract {
 		if a == nil {
-			a, err = ConfigToABI(contractAddress, forceERC20ABI, customABI, network)
+			// a delegated EOA is called through its delegate's interface
+			abiAddress := contractAddress
+			if delegate, delegated, _ := GetDelegate(contractAddress, network); delegated {
+				abiAddress = delegate
+			}
+			a, err = ConfigToABI(abiAddress, forceERC20ABI, customABI, network)
 			if err != nil {
 				u.Error("Couldn't get abi for %s: %s", contractAddress, err)
 				return nil
 			}
 		}
 		customABIs[strings.ToLower(txinfo.Tx.To().Hex())] = a
-		result = analyzer.AnalyzeOffline(&txinfo, GetABI, customABIs, true)
+		result = analyzer.AnalyzeOffline(&txinfo, ResolveABI, customABIs, true)
 	} else {
-		result = analyzer.AnalyzeOffline(&txinfo, GetABI, nil, false)
+		result = analyzer.AnalyzeOffline(&txinfo, ResolveABI, nil, false)
 	}
 
-	return DisplayTxResult(u, result, network, degenMode, tx)
+	d := buildTxDisplay(result, degenMode)
+	d.Hash = tx
+	if txinfo.Status == "reverted" {
+		reason, err := ReplayRevert(&txinfo, network, customABIs)
+		if err != nil {
+			u.Warn("Couldn't find out why the tx reverted: %s", err)
+		}
+		d.RevertReason = reason
+	}
+	printTxDisplay(u, d, network)
+	return d
 }
 
 func EthTxMonitor(network networks.Network) (*monitor.TxMonitor, error) {
@@ -383,6 +399,28 @@ func EthBroadcaster(network networks.Network) (*broadcaster.Broadcaster, error)
 	return broadcaster.NewGenericBroadcaster(nodes), nil
 }
 
+// EthRelayBroadcaster returns a broadcaster sending txs only to the private
+// relays of network. chain follows the target blocks of the txs.
+func EthRelayBroadcaster(network networks.Network, chain broadcaster.ChainReader, blocks uint64) (*broadcaster.RelayBroadcaster, error) {
+	configs := GetRelays(network)
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("no private relay is configured for %s, add one with jarvis node relay add", network.GetName())
+	}
+	relays := make([]broadcaster.Relay, 0, len(configs))
+	for name, c := range configs {
+		relay := broadcaster.Relay{Name: name, URL: c.URL, Method: c.Method, Headers: c.Headers}
+		if c.FlashbotsAuth {
+			key, err := RelayAuthKey()
+			if err != nil {
+				return nil, err
+			}
+			relay.AuthKey = key
+		}
+		relays = append(relays, relay)
+	}
+	return broadcaster.NewRelayBroadcaster(relays, chain, blocks)
+}
+
 func EthReader(network networks.Network) (*reader.EthReader, error) {
 	var result *reader.EthReader
 	var err error
@@ -590,6 +628,12 @@ func ReadCustomABIString(
 		str = pathOrAddress
 		err = nil
 	}
+	if err == nil {
+		// foundry and hardhat artifacts carry the abi under "abi"
+		if raw, aerr := abiJSON(str); aerr == nil {
+			str = string(raw)
+		}
+	}
 
 	return str, err
 }
@@ -653,8 +697,11 @@ func ReadCustomABI(addr string, pathOrAddress string, network networks.Network)
 		return a, err
 	}
 
-	cacheKey := fmt.Sprintf("%s_abi", addr)
-	cache.SetCache(cacheKey, str)
+	// the abi is kept for the commands run without it later, unless the
+	// one stored is pinned
+	if e, found := StoredABI(addr, network); !found || !e.Pinned {
+		storeABI(addr, network, abistore.FromCustom, customABISource(pathOrAddress), str, false)
+	}
 	return a, nil
 }
 
@@ -683,23 +730,35 @@ func GetABIFromString(abiStr string) (*abi.ABI, error) {
 	return &result, err
 }
 
+// GetABIStringBypassCache gets the ABI of addr from the block explorer of
+// network and stores it, unless the stored one is pinned.
 func GetABIStringBypassCache(addr string, network networks.Network) (string, error) {
-	cacheKey := fmt.Sprintf("%s_abi", addr)
-	// not found from cache, getting from etherscan or equivalent websites
+	if e, found := StoredABI(addr, network); found && e.Pinned {
+		return string(e.ABI), nil
+	}
+	abiStr, _, err := fetchABI(addr, network)
+	return abiStr, err
+}
+
+// GetDelegate returns the address an EOA currently delegates its code to
+// with EIP-7702. It is never cached since the delegation can change with
+// any tx of the EOA.
+func GetDelegate(addr string, network networks.Network) (string, bool, error) {
 	reader, err := EthReader(network)
 	if err != nil {
-		return "", err
+		return "", false, err
 	}
-	abiStr, err := reader.GetABIString(addr)
+
+	code, err := reader.GetCode(addr)
 	if err != nil {
-		return "", err
+		return "", false, err
 	}
 
-	cache.SetCache(
-		cacheKey,
-		abiStr,
-	)
-	return abiStr, nil
+	delegate, delegated := jarviscommon.DelegateOf(code)
+	if !delegated {
+		return "", false, nil
+	}
+	return delegate.Hex(), true, nil
 }
 
 func IsContract(addr string, network networks.Network) (bool, error) {
@@ -721,7 +780,9 @@ func IsContract(addr string, network networks.Network) (bool, error) {
 
 	isContract := len(code) > 0
 
-	if isContract {
+	// an EOA delegated with EIP-7702 runs code too, but it can revoke or
+	// change the delegation any time so it must not be cached as a contract.
+	if _, delegated := jarviscommon.DelegateOf(code); isContract && !delegated {
 		cache.SetCache(
 			cacheKey,
 			"true",
@@ -730,10 +791,14 @@ func IsContract(addr string, network networks.Network) (bool, error) {
 	return isContract, nil
 }
 
+// GetABIString returns the stored ABI of addr on network, getting it from
+// the block explorer when there is none.
 func GetABIString(addr string, network networks.Network) (string, error) {
-	cacheKey := fmt.Sprintf("%s_abi", strings.ToLower(addr))
-	cached, found := cache.GetCache(cacheKey)
-	if found {
+	if e, found := StoredABI(addr, network); found {
+		return string(e.ABI), nil
+	}
+	if cached, found := legacyCachedABI(addr); found {
+		storeABI(addr, network, abistore.FromCache, cache.CACHE_PATH, cached, false)
 		return cached, nil
 	}
 	return GetABIStringBypassCache(addr, network)
@@ -751,30 +816,28 @@ func ConfigToABI(
 	if customABI != "" {
 		return ReadCustomABI(address, customABI, network)
 	}
+	// an abi set by the user is taken as the whole story, proxies included
+	if e, found := StoredABI(address, network); found && e.Overrides() {
+		return GetABIFromBytes(e.ABI)
+	}
 	a, err := GetABI(address, network)
-	if err != nil {
-		return a, err
+	if err == nil && !mayBeProxyABI(a) {
+		return a, nil
 	}
 
-	if IsProxyABI(a) {
-		r, err := EthReader(network)
-		if err != nil {
-			return nil, err
-		}
-
-		impl, err := r.ImplementationOf(-1, address)
-		if err != nil {
-			fmt.Printf("getting implementation of %s failed: %s\n", address, err)
-			return nil, err
-		}
-		a, err := GetABI(impl.Hex(), network)
-		if err != nil {
-			fmt.Printf("getting abi for implementation %s of %s failed: %s\n", impl.Hex(), address, err)
+	// contracts without an abi of their own may still be clones of verified
+	// ones, so they are looked at like possible proxies too
+	proxy, perr := ProxyOf(address, network)
+	if perr != nil {
+		if err == nil {
+			fmt.Printf("getting implementation of %s failed: %s\n", address, perr)
 		}
 		return a, err
 	}
-
-	return a, nil
+	if !proxy.IsProxy() {
+		return a, err
+	}
+	return ProxyABI(address, proxy, a, network)
 }
 
 func GetGnosisMsigDeployByteCode(ctorBytes []byte) ([]byte, error) {
@@ -872,13 +935,18 @@ func GetBalances(
 	return GetHistoryBalances(-1, wallets, tokens, network)
 }
 
+// GetHistoryBalances reads the balances of tokens, ETH_ADDR for the native
+// token, of every wallet at atBlock, -1 for the latest block, in one
+// multicall. The balances of a wallet are in the order of tokens. A balance
+// that couldn't be read, e.g. of a token that isn't deployed on the network,
+// is left nil and why is in err, while the other balances are still
+// returned with the block they were read at.
 func GetHistoryBalances(
 	atBlock int64,
 	wallets []string,
 	tokens []string,
 	network networks.Network,
 ) (balances map[common.Address][]*big.Int, block int64, err error) {
-	helperABI := jarviscommon.GetMultiCallABI()
 	erc20ABI := jarviscommon.GetERC20ABI()
 
 	mc, err := NewMultiCall(network)
@@ -894,25 +962,20 @@ func GetHistoryBalances(
 			index := i
 			oneResult := big.NewInt(0)
 			balances[wAddr] = append(balances[wAddr], oneResult)
+			hook := func(r interface{}, err error) error {
+				if err != nil {
+					balances[wAddr][index] = nil
+					return fmt.Errorf("balance of %s in %s: %w", wallet, token, err)
+				}
+				balances[wAddr][index] = *r.(**big.Int)
+				return nil
+			}
 			if strings.ToLower(token) == "0xeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee" {
-				mc.RegisterWithHook(
-					&oneResult,
-					func(r interface{}) error {
-						balances[wAddr][index] = *r.(**big.Int)
-						return nil
-					},
-					network.MultiCallContract(),
-					helperABI,
-					"getEthBalance",
-					jarviscommon.HexToAddress(wallet),
-				)
+				mc.RegisterEthBalance(&oneResult, hook, wallet)
 			} else {
 				mc.RegisterWithHook(
 					&oneResult,
-					func(r interface{}) error {
-						balances[wAddr][index] = *r.(**big.Int)
-						return nil
-					},
+					hook,
 					token,
 					erc20ABI,
 					"balanceOf",
@@ -932,5 +995,9 @@ func NewMultiCall(network networks.Network) (*reader.MultipleCall, error) {
 	if err != nil {
 		return nil, err
 	}
-	return reader.NewMultiCall(r, network.MultiCallContract()), nil
+	mc := reader.NewMultiCall(r, network.MultiCallContract())
+	mc.Logf = func(format string, args ...interface{}) {
+		fmt.Fprintf(os.Stderr, "warning: "+format+"\n", args...)
+	}
+	return mc, nil
 }